@@ -0,0 +1,38 @@
+package browserk
+
+import "context"
+
+// Scan phases, executed in this order
+const (
+	PhaseCrawl  = "crawl"
+	PhaseAttack = "attack"
+	PhaseReport = "report"
+)
+
+// AllPhases in the order they are executed
+var AllPhases = []string{PhaseCrawl, PhaseAttack, PhaseReport}
+
+// AttackModule is an active check executed against captured navigation results during the attack phase
+type AttackModule interface {
+	Name() string
+	ID() string
+	Attack(bctx *Context, replayer Replayer, result *NavigationResult) error
+}
+
+// AncestryLocator is implemented by replayers with the attacked page loaded, so modules can record
+// where in the DOM an element is
+type AncestryLocator interface {
+	ElementAncestry(selector string) ([]string, error)
+}
+
+// EvidenceCapturer is implemented by replayers with the attacked page loaded, so modules can attach
+// a screenshot and the html of the element a finding was confirmed on
+type EvidenceCapturer interface {
+	ElementEvidence(selector string) (*Evidence, error)
+}
+
+// FrameProber is implemented by replayers backed by a browser, so modules can confirm whether a
+// page actually renders when framed by another origin
+type FrameProber interface {
+	Framable(ctx context.Context, url string) (bool, error)
+}
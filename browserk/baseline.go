@@ -0,0 +1,88 @@
+package browserk
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+)
+
+// BaselineEntry is an accepted finding, the vuln id and url are only kept for readability
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	VulnID      string `json:"vuln_id"`
+	URL         string `json:"url,omitempty"`
+}
+
+// Baseline of accepted findings, findings matching it are still reported but not actionable
+type Baseline struct {
+	fingerprints map[string]struct{}
+}
+
+// NewBaseline of the reports' fingerprints
+func NewBaseline(reports []*Report) *Baseline {
+	b := &Baseline{fingerprints: make(map[string]struct{}, len(reports))}
+	for _, report := range reports {
+		b.fingerprints[report.Fingerprint()] = struct{}{}
+	}
+	return b
+}
+
+// LoadBaseline from a file written by WriteBaseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*BaselineEntry, 0)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	b := &Baseline{fingerprints: make(map[string]struct{}, len(entries))}
+	for _, entry := range entries {
+		b.fingerprints[entry.Fingerprint] = struct{}{}
+	}
+	return b, nil
+}
+
+// Contains returns true if the report was accepted in the baseline
+func (b *Baseline) Contains(report *Report) bool {
+	if b == nil {
+		return false
+	}
+	_, ok := b.fingerprints[report.Fingerprint()]
+	return ok
+}
+
+// WriteBaseline of the reports to path so they are baselined in future scans
+func WriteBaseline(path string, reports []*Report) error {
+	entries := make([]*BaselineEntry, 0, len(reports))
+	for _, report := range reports {
+		entry := &BaselineEntry{Fingerprint: report.Fingerprint(), VulnID: report.VulnID}
+		if report.Evidence != nil {
+			entry.URL = report.Evidence.URL
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Fingerprint < entries[j].Fingerprint
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// ActionableReports are the reports which are not baselined
+func ActionableReports(reports []*Report) []*Report {
+	actionable := make([]*Report, 0, len(reports))
+	for _, report := range reports {
+		if !report.Baselined {
+			actionable = append(actionable, report)
+		}
+	}
+	return actionable
+}
@@ -0,0 +1,44 @@
+package browserk_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+)
+
+func TestBaselineWriteLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	accepted := &browserk.Report{VulnID: "BR-A-0002", Evidence: &browserk.Evidence{URL: "http://example.com/users/1", Parameter: "path segment 2"}}
+	path := filepath.Join(dir, "baseline.json")
+	if err := browserk.WriteBaseline(path, []*browserk.Report{accepted}); err != nil {
+		t.Fatalf("error writing baseline: %s\n", err)
+	}
+
+	baseline, err := browserk.LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("error loading baseline: %s\n", err)
+	}
+
+	same := &browserk.Report{VulnID: "BR-A-0002", Description: "changed", Evidence: &browserk.Evidence{URL: "http://example.com/users/1", Parameter: "path segment 2"}}
+	if !baseline.Contains(same) {
+		t.Fatalf("expected finding with the same fingerprint to be baselined")
+	}
+
+	other := &browserk.Report{VulnID: "BR-A-0002", Evidence: &browserk.Evidence{URL: "http://example.com/users/2", Parameter: "path segment 2"}}
+	if baseline.Contains(other) {
+		t.Fatalf("expected finding at a different url to not be baselined")
+	}
+
+	var missing *browserk.Baseline
+	if missing.Contains(same) {
+		t.Fatalf("nil baseline should not contain findings")
+	}
+}
@@ -22,6 +22,8 @@ type Browser interface {
 	GetURL() (string, error)
 	GetDOM() (string, error)
 	GetCookies() ([]*Cookie, error)
+	SetCookies(cookies []*Cookie) error
+	ClearCookies() error
 	GetBaseHref() string
 	GetStorageEvents() []*StorageEvent
 	GetConsoleEvents() []*ConsoleEvent
@@ -30,8 +32,12 @@ type Browser interface {
 	FindForms() ([]*HTMLFormElement, error)
 	FindInteractables() ([]*HTMLElement, error)
 	GetMessages() ([]*HTTPMessage, error)
+	NetworkSummary() NetworkSummary // of requests finished since the last call
 	Screenshot() (string, error)
-	RefreshDocument()                                                     // reloads the document/elements
-	ExecuteAction(ctx context.Context, act *Action) ([]byte, bool, error) // result, caused page load, err
+	RefreshDocument()                                                             // reloads the document/elements
+	ExecuteAction(ctx context.Context, act *Action) ([]byte, bool, error)         // result, caused page load, err
+	ProbeForm(ctx context.Context, form *HTMLFormElement) ([]*HTMLElement, error) // fills without submitting then resets, returns fields which appeared
+	ReplayRequest(req *Request) (*HTTPResponse, error)                            // re-issues the request from the page context
+	SetExtraHeaders(headers map[string]string) error                              // headers added to every request the browser makes
 	Close()
 }
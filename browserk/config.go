@@ -1,5 +1,7 @@
 package browserk
 
+import "time"
+
 // Credentials for logging into a target site
 type Credentials struct {
 	Username string
@@ -110,21 +112,79 @@ var DefaultFormValues = FormData{
 	IPV6:              "2001:0db8:85a3:0000:0000:8a2e:0370:7334",
 }
 
+// CircuitBreakerConfig controls pausing navigations to hosts that repeatedly return 5xx
+type CircuitBreakerConfig struct {
+	Threshold int // number of consecutive 5xx responses before the breaker trips
+	Window    int // seconds in which the consecutive 5xx responses must occur
+	CoolDown  int // seconds to pause navigations to the host before probing again
+}
+
 // Config for browserker
 type Config struct {
-	URL             string
-	AllowedHosts    []string // considered 'in scope' for testing/access
-	IgnoredHosts    []string // will access, but not report/run tests against (this is the default for non AllowedURLs)
-	ExcludedHosts   []string // will be forcibly dropped by interceptors
-	ExcludedURIs    []string // will not access (logout/signout) can be relative, or absolute (relative will be from config URL base path)
-	ExcludedForms   []string // will not submit forms that have this id or name
-	DataPath        string
-	AuthScript      string
-	AuthType        AuthType
-	Credentials     *Credentials
-	NumBrowsers     int
-	MaxDepth        int       // maximum distance of paths we will traverse
-	FormData        *FormData // config form data
-	JSPluginPath    string    // path to javascript plugins (will walk sub directories)
-	DisabledPlugins []string  // plugins we will not load
+	URL                      string
+	AllowedHosts             []string // considered 'in scope' for testing/access
+	IgnoredHosts             []string // will access, but not report/run tests against (this is the default for non AllowedURLs)
+	ExcludedHosts            []string // will be forcibly dropped by interceptors
+	ExcludedURIs             []string // will not access (logout/signout) can be relative, or absolute (relative will be from config URL base path)
+	ExcludedForms            []string // will not submit forms that have this id or name
+	DataPath                 string
+	AuthScript               string
+	AuthType                 AuthType
+	Credentials              *Credentials
+	NumBrowsers              int
+	MaxDepth                 int                   // maximum distance of paths we will traverse
+	FormData                 *FormData             // config form data
+	JSPluginPath             string                // path to javascript plugins (will walk sub directories)
+	DisabledPlugins          []string              // plugins we will not load
+	CircuitBreaker           *CircuitBreakerConfig // per host 5xx circuit breaker settings (nil to disable)
+	CPUThrottle              float64               // cpu slowdown rate applied to each tab (e.g. 4 for a 4x slowdown, 0 or 1 to disable)
+	Phases                   []string              // scan phases to run (crawl, attack, report), defaults to all
+	PayloadDir               string                // directory of custom payload lists named by category (xss.txt, sqli.txt, traversal.txt)
+	MaxActionsPerState       int                   // maximum number of elements to interact with per page state, remaining are recorded but not crawled (0 for unlimited)
+	MaxScrolls               int                   // maximum number of times to scroll to the bottom of a page to load infinite scroll content (0 to disable)
+	PostNavigationDelay      time.Duration         // time to wait after a navigation loads before extracting elements, for apps that render late (0 to disable)
+	EnableIDOR               bool                  // opt in to the intrusive IDOR attack module which requests other users' identifiers
+	MaxResponseBodyBytes     int                   // response bodies larger than this are spilled to DataPath/bodies instead of held in memory (0 for unlimited)
+	ResourceBufferSize       int                   // bytes chrome buffers for a single response body, larger bodies are not captured (0 for unlimited)
+	TotalBufferSize          int                   // bytes chrome buffers for all response bodies of a tab (0 for unlimited)
+	AuthRefresh              time.Duration         // how often the browser pool re-authenticates its shared login session (0 to never refresh)
+	SkipExtensions           []string              // links to files with these extensions are recorded but not navigated to (nil for defaults, empty for none)
+	HeadSkippedLinks         bool                  // issue a HEAD request for skipped links so their status is still recorded
+	CorrelationHeader        bool                  // add each navigation's correlation id to its requests as an X-Browserk-Nav header
+	IsolateSessions          bool                  // run each browser's tab in its own incognito browser context so workers don't share cookies
+	ColorScheme              string                // prefers-color-scheme emulated in each tab (dark, light, no-preference), empty to disable
+	IgnoreCertErrors         bool                  // have chrome skip certificate validation (certificate errors are otherwise continued past), errors are still reported as Info findings
+	InteractionStrategy      string                // which elements on a page are interacted with first: forms-first (default), links-first or mixed
+	URLNormalization         *URLNormalizerConfig  // how urls are normalized for scope checks and link dedup (nil for defaults)
+	MaxDuration              time.Duration         // total scan time after which the scan is stopped and a partial report is written (0 for unlimited)
+	Proxy                    string                // http(s) proxy url browsers are launched with, user:pass in the url is used for proxy auth
+	SocksProxy               string                // socks5 proxy url browsers are launched with instead of Proxy, chrome can't authenticate to socks5 proxies
+	BaselineFile             string                // accepted findings (see --write-baseline), matching findings are listed but not actionable
+	UserDataDir              string                // persistent chrome profiles are kept here so cookies survive across runs, empty for throw away profiles
+	FailOnSeverity           string                // exit with status 2 if actionable findings of this severity or higher exist (info, low, medium, high, critical), empty to disable
+	ConfirmClickjacking      bool                  // load framable documents in a cross origin attacker frame to confirm they render
+	TabsPerBrowser           int                   // tabs each browser hosts concurrently, each in its own browser context (0 or 1 for one tab per browser)
+	HostCookieJars           bool                  // keep a cookie jar per navigation host, reused browsers are reset to the host's jar before navigating
+	ResolveRetries           int                   // times to retry resolving a selector when its node is removed mid resolve by DOM churn (0 for the default of 3)
+	GeofenceScope            bool                  // abort in flight top frame navigations to out of scope urls, e.g. javascript redirects off the target
+	ChromePath               string                // chrome/chromium executable, empty to search the usual install locations
+	MaxBrowserHeapBytes      int64                 // browsers shared by multiple tabs are recycled once their tabs' javascript heap exceeds this (0 to disable)
+	DualAuthPass             bool                  // after crawling, crawl again without the session and report authenticated only pages that load without it
+	MaxNavigationRetries     int                   // times a failing navigation is requeued before it's permanently failed (0 for the default of 3)
+	InteractOnly             []string              // css selectors of the only elements the crawler interacts with, links are still followed (empty for all)
+	ProbeFormsNonDestructive bool                  // fill newly found forms without submitting and reset them, recording fields which appear as attack surface
+	PayloadConcurrency       int                   // payload probes attack modules replay at once for each parameter (0 or 1 to send them one at a time)
+	StopLoadingAfter         time.Duration         // page loads still running after this long are stopped and the partially loaded page is crawled (0 for the default of 15s)
+	RandomSeed               int64                 // seeds generated form values and correlation ids so scans can be reproduced (0 for a time based seed, which is logged)
+	SkipParams               []string              // names or regular expressions of parameters attack modules never mutate, token like values are always skipped
+	PrioritizeHTML           bool                  // crawl navigations to html pages before those known to return other content such as json
+	RestrictScheme           string                // only navigate to urls of this scheme: https-only or http-only, empty for both
+	IncrementalBaseline      string                // data path of a previous scan, navigations whose content is unchanged since are not attacked
+	ProtocolTrace            string                // file each tab's devtools protocol commands, responses and events are appended to as json lines, empty to disable
+	AutoDismissOverlays      bool                  // click the accept/close buttons of consent banners and newsletter modals after each page load
+	OverlaySelectors         []string              // css selectors of overlay buttons AutoDismissOverlays clicks (nil for defaults, empty for none)
+	OverlayButtonText        []string              // text of overlay buttons AutoDismissOverlays clicks, matched case insensitively (nil for defaults, empty for none)
+	MaxDOMNodes              int                   // pages whose DOM exceeds this many nodes are recorded as too large and not extracted (0 for unlimited)
+	PerHostRPS               float64               // requests per second all browsers combined send to each host, other hosts are unaffected (0 for unlimited)
+	ReportBeforeUnload       bool                  // report pages whose beforeunload handler prompted before the crawler left them as Info findings
 }
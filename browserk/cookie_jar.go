@@ -0,0 +1,74 @@
+package browserk
+
+import (
+	"net"
+	"strings"
+	"sync"
+)
+
+// CookieJar keeps the cookies accumulated by navigations to each host so a browser reused
+// across hosts can be reset to the cookies of the host it's about to navigate to
+type CookieJar struct {
+	lock  *sync.RWMutex
+	seed  []*Cookie
+	hosts map[string][]*Cookie
+}
+
+// NewCookieJar with no seeded cookies
+func NewCookieJar() *CookieJar {
+	return &CookieJar{lock: &sync.RWMutex{}, hosts: make(map[string][]*Cookie)}
+}
+
+// Seed the jar with cookies (e.g. a login session) given to every host their domain matches
+func (j *CookieJar) Seed(cookies []*Cookie) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.seed = cookies
+}
+
+// Update replaces the cookies accumulated for host with those the browser had after navigating to it
+func (j *CookieJar) Update(host string, cookies []*Cookie) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.hosts[host] = cookies
+}
+
+// Cookies for host, the seeded cookies matching its domain overridden by the cookies accumulated
+// for host. Cookies accumulated for other hosts are never returned, even if their domain matches.
+func (j *CookieJar) Cookies(host string) []*Cookie {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	cookies := make([]*Cookie, 0)
+	index := make(map[string]int)
+	add := func(c *Cookie) {
+		key := c.Name + ";" + c.Domain + ";" + c.Path
+		if i, exist := index[key]; exist {
+			cookies[i] = c
+			return
+		}
+		index[key] = len(cookies)
+		cookies = append(cookies, c)
+	}
+
+	for _, c := range j.seed {
+		if DomainMatches(host, c.Domain) {
+			add(c)
+		}
+	}
+
+	for _, c := range j.hosts[host] {
+		add(c)
+	}
+	return cookies
+}
+
+// DomainMatches returns true if a cookie with domain would be sent to host (which may include a port)
+func DomainMatches(host, domain string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
@@ -0,0 +1,58 @@
+package browserk_test
+
+import (
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+)
+
+func cookieNames(cookies []*browserk.Cookie) map[string]string {
+	names := make(map[string]string, len(cookies))
+	for _, c := range cookies {
+		names[c.Name] = c.Value
+	}
+	return names
+}
+
+func TestCookieJar(t *testing.T) {
+	jar := browserk.NewCookieJar()
+	jar.Seed([]*browserk.Cookie{
+		{Name: "login", Value: "seeded", Domain: ".example.com", Path: "/"},
+		{Name: "other", Value: "seeded", Domain: "other.com", Path: "/"},
+	})
+
+	jar.Update("a.example.com:8080", []*browserk.Cookie{
+		{Name: "login", Value: "rotated", Domain: ".example.com", Path: "/"},
+		{Name: "cart", Value: "a", Domain: ".example.com", Path: "/"},
+	})
+
+	a := cookieNames(jar.Cookies("a.example.com:8080"))
+	if len(a) != 2 || a["login"] != "rotated" || a["cart"] != "a" {
+		t.Fatalf("expected accumulated cookies to override the seed for host a got %v\n", a)
+	}
+
+	b := cookieNames(jar.Cookies("b.example.com"))
+	if len(b) != 1 || b["login"] != "seeded" {
+		t.Fatalf("expected only the seeded cookie for host b got %v\n", b)
+	}
+}
+
+func TestDomainMatches(t *testing.T) {
+	var tests = []struct {
+		host   string
+		domain string
+		match  bool
+	}{
+		{"example.com", "example.com", true},
+		{"a.example.com:8443", ".example.com", true},
+		{"notexample.com", "example.com", false},
+		{"example.com", "a.example.com", false},
+		{"example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		if browserk.DomainMatches(tt.host, tt.domain) != tt.match {
+			t.Fatalf("expected %s matching %s to be %v\n", tt.host, tt.domain, tt.match)
+		}
+	}
+}
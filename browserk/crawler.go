@@ -2,6 +2,20 @@ package browserk
 
 import "context"
 
+// Interaction strategies controlling which elements on a page the crawler prioritizes
+const (
+	FormsFirst = "forms-first" // forms, then buttons, links and other elements (default)
+	LinksFirst = "links-first" // links, then forms, buttons and other elements
+	Mixed      = "mixed"       // elements in the order they were found on the page
+)
+
+// Scheme restrictions controlling which urls the crawler navigates to
+const (
+	AnyScheme = ""           // http and https urls (default)
+	HTTPSOnly = "https-only" // https urls only, links downgrading to http are reported
+	HTTPOnly  = "http-only"  // http urls only
+)
+
 // Crawler service
 type Crawler interface {
 	Init() error
@@ -10,7 +10,20 @@ type CrawlGrapher interface {
 	AddNavigation(nav *Navigation) error
 	AddNavigations(navs []*Navigation) error
 	FailNavigation(navID []byte) error
+	RequeueNavigation(navID []byte) error
+	RecordNavigationError(navID []byte, retries int, lastError string) error // stores the failed attempt count and error
 	AddResult(result *NavigationResult) error
 	NavExists(nav *Navigation) bool
 	GetNavigation(id []byte) (*Navigation, error)
+	GetNavigationResults() ([]*NavigationResult, error)
+	Transaction(fn func(tx CrawlTx) error) error
+}
+
+// CrawlTx writes to the crawl graph which are committed together when the Transaction
+// callback returns without error, and discarded if it returns an error or panics
+type CrawlTx interface {
+	AddNavigations(navs []*Navigation) error
+	AddResult(result *NavigationResult) error
+	FailNavigation(navID []byte) error
+	RequeueNavigation(navID []byte) error
 }
@@ -21,12 +21,14 @@ type HTMLElement struct {
 	Type          HTMLElementType
 	CustomTagName string
 	Events        map[string]HTMLEventType // key: line,col => event type
+	Listeners     map[string]int           // number of bound event listeners by event type
 	Attributes    map[string]string
 	InnerText     string
 	Hidden        bool
 	NodeDepth     int
 	ID            []byte
 	Value         string // value to set if it's an input field or whatever
+	Selector      string // css selector to find the element again, not part of the hash
 }
 
 // Hash the element to (hopefully) a unique value
@@ -257,6 +259,13 @@ func ImportantAttributeValues(elementType HTMLElementType, attrs map[string]stri
 	return vals
 }
 
+// SelectOption of a <select> element
+type SelectOption struct {
+	Value    string
+	Text     string
+	Selected bool
+}
+
 // HTMLElementType tag name
 type HTMLElementType int16
 
@@ -0,0 +1,12 @@
+package browserk
+
+// ExecutionContext is a javascript execution context of a frame or worker in the browser
+type ExecutionContext struct {
+	ID        int    `json:"id"`                  // context id, unique within the frame's page or the worker
+	Origin    string `json:"origin"`              // origin of the context
+	Name      string `json:"name,omitempty"`      // human readable name of the context
+	FrameID   string `json:"frame_id,omitempty"`  // frame the context belongs to, empty for workers
+	IsDefault bool   `json:"is_default"`          // the frame's main world, not an isolated world
+	WorkerID  string `json:"worker_id,omitempty"` // target id of the worker the context belongs to, empty for frames
+	Type      string `json:"type"`                // page, iframe, worker, shared_worker or service_worker
+}
@@ -0,0 +1,11 @@
+package browserk
+
+import "time"
+
+// OutOfScopeRedirect is a top frame navigation to an out of scope url which was aborted by the
+// browser's geofence, usually caused by a javascript or http redirect
+type OutOfScopeRedirect struct {
+	URL       string    `json:"url"`        // the out of scope url navigated to
+	SourceURL string    `json:"source_url"` // the document which was loaded when the navigation was attempted
+	Time      time.Time `json:"time"`       // when the navigation was aborted
+}
@@ -1,6 +1,11 @@
 package browserk
 
 import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	"github.com/wirepair/gcd/gcdapi"
 )
 
@@ -29,6 +34,39 @@ type HTTPResponse struct {
 	FrameId   string                  `json:"frameId,omitempty"`   // Frame identifier.
 	Body      []byte                  `json:"body,omitempty"`      // Raw captured body data
 	BodyHash  []byte                  `json:"body_hash,omitempty"` // sha1 hash of body data
+	BodyFile  string                  `json:"body_file,omitempty"` // path to the body if it was spilled to disk
+}
+
+// SpillBody writes the body to a file in dir named by the body hash if it is larger than maxSize,
+// the in memory body is released and BodyFile references the file. Returns true if spilled.
+func (h *HTTPResponse) SpillBody(dir string, maxSize int) (bool, error) {
+	if maxSize <= 0 || len(h.Body) <= maxSize {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, err
+	}
+
+	name := hex.EncodeToString(h.BodyHash)
+	if name == "" {
+		name = h.RequestId
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, h.Body, 0600); err != nil {
+		return false, err
+	}
+	h.Body = nil
+	h.BodyFile = path
+	return true, nil
+}
+
+// ReadBody returns the body, reading it from disk if it was spilled
+func (h *HTTPResponse) ReadBody() ([]byte, error) {
+	if h.BodyFile == "" {
+		return h.Body, nil
+	}
+	return ioutil.ReadFile(h.BodyFile)
 }
 
 // InterceptedHTTPRequest contains all information regarding an intercepted request
@@ -52,3 +52,11 @@ func MessagesBeforeResponseTime(m []*HTTPMessage, t time.Time) []*HTTPMessage {
 	}
 	return messages
 }
+
+// NetworkSummary of the requests a navigation made which finished loading
+type NetworkSummary struct {
+	RequestCount int           // requests which finished loading
+	TotalBytes   int64         // bytes received over the network (encoded, including headers)
+	SlowestURL   string        // url of the request which took the longest to load
+	SlowestTime  time.Duration // time from sending the slowest request until it finished loading
+}
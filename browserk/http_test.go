@@ -0,0 +1,51 @@
+package browserk_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+)
+
+func TestHTTPResponseSpillBody(t *testing.T) {
+	dir, err := ioutil.TempDir("", "browserk_bodies")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	body := bytes.Repeat([]byte("A"), 4096)
+	h := sha1.New()
+	h.Write(body)
+	resp := &browserk.HTTPResponse{RequestId: "1", Body: body, BodyHash: h.Sum(nil)}
+
+	spilled, err := resp.SpillBody(dir, 8192)
+	if err != nil || spilled {
+		t.Fatalf("expected body under the cap to be kept in memory: %v", err)
+	}
+
+	spilled, err = resp.SpillBody(dir, 1024)
+	if err != nil {
+		t.Fatalf("error spilling body: %s\n", err)
+	}
+
+	if !spilled || resp.Body != nil || resp.BodyFile == "" {
+		t.Fatalf("expected body to be spilled to disk")
+	}
+
+	read, err := resp.ReadBody()
+	if err != nil {
+		t.Fatalf("error reading spilled body: %s\n", err)
+	}
+
+	if !bytes.Equal(read, body) {
+		t.Fatalf("spilled body did not match")
+	}
+
+	if _, err := resp.SpillBody(dir, 0); err != nil {
+		t.Fatalf("expected no error when unlimited: %s\n", err)
+	}
+}
@@ -2,6 +2,9 @@ package browserk
 
 import (
 	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
 	"strings"
 	"time"
 
@@ -36,8 +39,27 @@ const (
 	NavVisited
 	// NavFailed unable to complete action
 	NavFailed
+	// NavDeferred recorded but never crawled due to the per state action budget
+	NavDeferred
+	// NavSkipped recorded but not navigated to as the link is to a skipped file extension
+	NavSkipped
 )
 
+// CorrelationHeader is added to requests made during a navigation when Config.CorrelationHeader is set
+const CorrelationHeader = "X-Browserk-Nav"
+
+// NewCorrelationID for tying log statements and traffic to a single navigation
+func NewCorrelationID() string {
+	return NewCorrelationIDFrom(rand.Reader)
+}
+
+// NewCorrelationIDFrom random, for reproducible ids pass the scan's Random
+func NewCorrelationIDFrom(random io.Reader) string {
+	id := make([]byte, 8)
+	io.ReadFull(random, id)
+	return hex.EncodeToString(id)
+}
+
 // Navigation for storing the action and results of navigating
 type Navigation struct {
 	ID               []byte      `graph:"id"`            // unique id of this navigation depending on type
@@ -48,6 +70,9 @@ type Navigation struct {
 	Action           *Action     `graph:"action"`
 	Scope            Scope       `graph:"scope"`
 	Distance         int         `graph:"dist"`
+	Retries          int         `graph:"retries"`    // failed attempts, requeued until this exceeds Config.MaxNavigationRetries
+	LastError        string      `graph:"last_error"` // error of the most recent failed attempt
+	Priority         int         `graph:"priority"`   // navigations in the same state are crawled highest priority first
 }
 
 // NewNavigation type
@@ -132,6 +157,22 @@ func NewNavigationFromElement(from *Navigation, triggeredBy TriggeredBy, ele *HT
 	return n
 }
 
+// NewNavigationFromLink creates a new navigation entry from a link, links to the same
+// normalized url share an id regardless of which page or element they were found on.
+// Fragment only links and links that could not be resolved use the element's id instead.
+func NewNavigationFromLink(from *Navigation, triggeredBy TriggeredBy, ele *HTMLElement, normalizedURL string) *Navigation {
+	n := NewNavigationFromElement(from, triggeredBy, ele, ActLeftClick)
+	if normalizedURL == "" || strings.HasPrefix(ele.Attributes["href"], "#") {
+		return n
+	}
+
+	h := md5.New()
+	h.Write([]byte{byte(ActLeftClick)})
+	h.Write([]byte(normalizedURL))
+	n.ID = h.Sum(nil)
+	return n
+}
+
 // NavigationResult captures result details about a navigation
 type NavigationResult struct {
 	ID            []byte          `graph:"r_id"`
@@ -144,6 +185,9 @@ type NavigationResult struct {
 	Cookies       []*Cookie       `graph:"r_cookies"`
 	ConsoleEvents []*ConsoleEvent `graph:"r_console"`
 	StorageEvents []*StorageEvent `graph:"r_storage"`
+	Network       NetworkSummary  `graph:"r_network"`
+	Overlays      []string        `graph:"r_overlays"`
+	SkipReason    string          `graph:"r_skip_reason"`
 	CausedLoad    bool            `graph:"r_caused_load"`
 	WasError      bool            `graph:"r_was_error"`
 	Errors        []error         `graph:"r_errors"`
@@ -0,0 +1,15 @@
+package browserk
+
+// Payload categories
+const (
+	PayloadXSS       = "xss"
+	PayloadSQLi      = "sqli"
+	PayloadTraversal = "traversal"
+	PayloadSQLiError = "sqli_errors" // regular expressions matching database error messages
+	PayloadSQLiTime  = "sqli_time"   // time based payloads, {delay} is replaced with the delay in seconds
+)
+
+// PayloadProvider returns attack payloads by category
+type PayloadProvider interface {
+	Payloads(category string) []string
+}
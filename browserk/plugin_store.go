@@ -42,5 +42,7 @@ type PluginStorer interface {
 	Init() error
 	AddEvent(evt *PluginEvent)
 	IsUnique(evt *PluginEvent) Unique
+	AddReport(report *Report) error // persist a finding so scans can be compared later
+	GetReports() ([]*Report, error) // findings persisted by AddReport, ordered by fingerprint
 	Close() error
 }
@@ -0,0 +1,51 @@
+package browserk
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Random is a goroutine safe source of randomness seeded from Config.RandomSeed, so scans
+// of the same target with the same seed make the same choices
+type Random struct {
+	lock sync.Mutex
+	rand *rand.Rand
+	seed int64
+}
+
+// NewRandom seeded with seed, if seed is 0 the current time is used
+func NewRandom(seed int64) *Random {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Random{rand: rand.New(rand.NewSource(seed)), seed: seed}
+}
+
+// Seed in effect, log this to reproduce a scan
+func (r *Random) Seed() int64 {
+	return r.seed
+}
+
+// Int63 returns a non-negative pseudo-random int64
+func (r *Random) Int63() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Int63()
+}
+
+// Read fills p with pseudo-random bytes, it always returns len(p) and a nil error
+func (r *Random) Read(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Read(p)
+}
+
+// Source for key, seeded from the scan's seed and key so the values drawn for key don't depend
+// on what other goroutines drew before it
+func (r *Random) Source(key string) rand.Source {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return rand.NewSource(r.seed ^ int64(h.Sum64()))
+}
@@ -0,0 +1,37 @@
+package browserk
+
+// Rect of an element in css pixels relative to the main frame's layout viewport
+type Rect struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+	Top    float64
+	Left   float64
+	Right  float64
+	Bottom float64
+}
+
+// NewRect from the top left point and size
+func NewRect(x, y, width, height float64) Rect {
+	return Rect{
+		X:      x,
+		Y:      y,
+		Width:  width,
+		Height: height,
+		Top:    y,
+		Left:   x,
+		Right:  x + width,
+		Bottom: y + height,
+	}
+}
+
+// Empty returns true if the rect has no area
+func (r Rect) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// Intersects returns true if the two rects overlap
+func (r Rect) Intersects(o Rect) bool {
+	return r.Left < o.Right && r.Right > o.Left && r.Top < o.Bottom && r.Bottom > o.Top
+}
@@ -0,0 +1,27 @@
+package browserk_test
+
+import (
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+)
+
+func TestNewRect(t *testing.T) {
+	r := browserk.NewRect(10, 20, 100, 50)
+	expected := browserk.Rect{X: 10, Y: 20, Width: 100, Height: 50, Top: 20, Left: 10, Right: 110, Bottom: 70}
+	if r != expected {
+		t.Fatalf("expected %#v got %#v\n", expected, r)
+	}
+
+	if r.Empty() || !browserk.NewRect(0, 0, 0, 10).Empty() {
+		t.Fatalf("empty check failed\n")
+	}
+
+	if !r.Intersects(browserk.NewRect(100, 60, 10, 10)) {
+		t.Fatalf("expected rects to intersect\n")
+	}
+
+	if r.Intersects(browserk.NewRect(110, 20, 10, 10)) {
+		t.Fatalf("expected touching rects to not intersect\n")
+	}
+}
@@ -1,25 +1,94 @@
 package browserk
 
-import "io"
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strings"
+)
 
+// Severity of a finding
+type Severity int8
+
+const (
+	// Info informational finding
+	Info Severity = iota + 1
+	// Low severity
+	Low
+	// Medium severity
+	Medium
+	// High severity
+	High
+	// Critical severity
+	Critical
+)
+
+// SeverityMap for printing
+var SeverityMap = map[Severity]string{
+	Info:     "Info",
+	Low:      "Low",
+	Medium:   "Medium",
+	High:     "High",
+	Critical: "Critical",
+}
+
+// ParseSeverity by name (info, low, medium, high, critical), case insensitive
+func ParseSeverity(name string) (Severity, error) {
+	for severity, severityName := range SeverityMap {
+		if strings.EqualFold(name, severityName) {
+			return severity, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity: %s", name)
+}
+
+// Evidence of a finding
 type Evidence struct {
+	URL       string // where the issue was found
+	Parameter string // the injected parameter (if any)
+	Payload   string // the injected payload (if any)
+	Match     string // what in the response identified the issue
+
+	HTML       string   // outer html of the affected element (if any)
+	Screenshot []byte   // png of the highlighted element (if any)
+	Ancestry   []string // tag#id.class of each element from <html> down to the affected element (if any)
 }
 
+// Hash of the evidence so duplicates can be filtered
 func (e *Evidence) Hash() string {
-	return ""
+	if e == nil {
+		return ""
+	}
+	h := md5.New()
+	h.Write([]byte(e.URL))
+	h.Write([]byte(e.Parameter))
+	h.Write([]byte(e.Payload))
+	h.Write([]byte(e.Match))
+	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
 type Report struct {
 	VulnID      string
 	CWE         int
+	Severity    Severity
 	Description string
 	Remediation string
 	Response    *HTTPResponse
 	// TODO: add Navigation type as alternative for flaws that don't have http responses
-	Evidence *Evidence
+	Evidence  *Evidence
+	Baselined bool // matched an accepted finding in the baseline, listed but not actionable
+}
+
+// Fingerprint identifying the finding across scans
+func (r *Report) Fingerprint() string {
+	return r.VulnID + r.Evidence.Hash()
 }
 
 type Reporter interface {
 	Add(report *Report)
 	Print(writer io.Writer)
+	SetIncomplete(reason string)    // mark the report as partial, e.g. the scan was stopped early
+	SetBaseline(baseline *Baseline) // findings matching the baseline are marked as baselined
+	SetEvidenceDir(dir string)      // directory screenshots of findings are written to when printed
+	Findings() []*Report            // all findings, including baselined ones
 }
@@ -0,0 +1,141 @@
+package browserk
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Request is a replayable HTTP request, usually built from a captured HTTPRequest
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+// NewRequest from a captured request, returns nil if the request is not populated
+func NewRequest(captured *HTTPRequest) *Request {
+	if captured == nil || captured.Request == nil {
+		return nil
+	}
+	r := &Request{
+		Method:  captured.Request.Method,
+		URL:     captured.Request.Url,
+		Headers: make(map[string]string, len(captured.Request.Headers)),
+		Body:    captured.Request.PostData,
+	}
+
+	for k, v := range captured.Request.Headers {
+		if value, ok := v.(string); ok {
+			r.Headers[k] = value
+		}
+	}
+	return r
+}
+
+// Copy the request
+func (r *Request) Copy() *Request {
+	c := &Request{
+		Method:  r.Method,
+		URL:     r.URL,
+		Headers: make(map[string]string, len(r.Headers)),
+		Body:    r.Body,
+	}
+	for k, v := range r.Headers {
+		c.Headers[k] = v
+	}
+	return c
+}
+
+// HasFormBody returns true if the body is application/x-www-form-urlencoded
+func (r *Request) HasFormBody() bool {
+	if r.Body == "" {
+		return false
+	}
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "content-type") {
+			return strings.Contains(strings.ToLower(v), "application/x-www-form-urlencoded")
+		}
+	}
+	return false
+}
+
+// Params returns the sorted, unique names of the query and form body parameters
+func (r *Request) Params() []string {
+	unique := make(map[string]struct{})
+	if u, err := url.Parse(r.URL); err == nil {
+		for name := range u.Query() {
+			unique[name] = struct{}{}
+		}
+	}
+
+	if r.HasFormBody() {
+		if values, err := url.ParseQuery(r.Body); err == nil {
+			for name := range values {
+				unique[name] = struct{}{}
+			}
+		}
+	}
+
+	params := make([]string, 0, len(unique))
+	for name := range unique {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	return params
+}
+
+// WithParam returns a copy of the request with the query or form body parameter name set to value.
+// If the parameter does not exist it is added to the query string.
+func (r *Request) WithParam(name, value string) *Request {
+	mutated := r.Copy()
+	u, err := url.Parse(mutated.URL)
+	if err != nil {
+		return mutated
+	}
+
+	query := u.Query()
+	if _, ok := query[name]; ok {
+		query.Set(name, value)
+		u.RawQuery = query.Encode()
+		mutated.URL = u.String()
+		return mutated
+	}
+
+	if mutated.HasFormBody() {
+		if values, err := url.ParseQuery(mutated.Body); err == nil {
+			if _, ok := values[name]; ok {
+				values.Set(name, value)
+				mutated.Body = values.Encode()
+				return mutated
+			}
+		}
+	}
+
+	query.Set(name, value)
+	u.RawQuery = query.Encode()
+	mutated.URL = u.String()
+	return mutated
+}
+
+// Param returns the value of the query or form body parameter name
+func (r *Request) Param(name string) string {
+	if u, err := url.Parse(r.URL); err == nil {
+		if values, ok := u.Query()[name]; ok && len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	if r.HasFormBody() {
+		if values, err := url.ParseQuery(r.Body); err == nil {
+			return values.Get(name)
+		}
+	}
+	return ""
+}
+
+// Replayer re-issues requests and captures the response
+type Replayer interface {
+	ReplayRequest(req *Request) (*HTTPResponse, error)
+}
@@ -0,0 +1,50 @@
+package browserk_test
+
+import (
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+)
+
+func TestRequestWithParam(t *testing.T) {
+	req := &browserk.Request{
+		Method:  "GET",
+		URL:     "http://example.com/search?q=test&page=1",
+		Headers: map[string]string{"X-Test": "1"},
+	}
+
+	mutated := req.WithParam("q", "injected'")
+	if mutated.Param("q") != "injected'" {
+		t.Fatalf("expected mutated param got %s", mutated.Param("q"))
+	}
+
+	if mutated.Param("page") != "1" {
+		t.Fatalf("expected other params to be preserved got %s", mutated.Param("page"))
+	}
+
+	if req.Param("q") != "test" {
+		t.Fatalf("original request should not be modified got %s", req.Param("q"))
+	}
+
+	mutated.Headers["X-Test"] = "2"
+	if req.Headers["X-Test"] != "1" {
+		t.Fatalf("original headers should not be modified")
+	}
+
+	post := &browserk.Request{
+		Method:  "POST",
+		URL:     "http://example.com/login",
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:    "user=admin&pass=x",
+	}
+
+	params := post.Params()
+	if len(params) != 2 || params[0] != "pass" || params[1] != "user" {
+		t.Fatalf("expected body params got %v", params)
+	}
+
+	mutated = post.WithParam("user", "other")
+	if mutated.Body != "pass=x&user=other" || mutated.URL != post.URL {
+		t.Fatalf("expected body param to be mutated got %s %s", mutated.URL, mutated.Body)
+	}
+}
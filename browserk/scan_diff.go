@@ -0,0 +1,30 @@
+package browserk
+
+// ScanDiff of the findings between a previous and current scan, matched by fingerprint
+type ScanDiff struct {
+	New       []*Report // only found in the current scan
+	Resolved  []*Report // only found in the previous scan
+	Unchanged []*Report // found in both scans, taken from the current scan
+}
+
+// NewScanDiff compares the previous findings to the current findings by fingerprint
+func NewScanDiff(previous, current []*Report) *ScanDiff {
+	diff := &ScanDiff{New: make([]*Report, 0), Resolved: make([]*Report, 0), Unchanged: make([]*Report, 0)}
+
+	before := NewBaseline(previous)
+	after := NewBaseline(current)
+	for _, report := range current {
+		if before.Contains(report) {
+			diff.Unchanged = append(diff.Unchanged, report)
+		} else {
+			diff.New = append(diff.New, report)
+		}
+	}
+
+	for _, report := range previous {
+		if !after.Contains(report) {
+			diff.Resolved = append(diff.Resolved, report)
+		}
+	}
+	return diff
+}
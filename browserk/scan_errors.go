@@ -0,0 +1,65 @@
+package browserk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// NavigationError is a navigation (or the attack of its result) which failed during a scan
+type NavigationError struct {
+	URL   string // page the navigation was executed on, or the attacked result's url
+	Phase string // phase the failure occurred in (crawl, attack)
+	Err   error
+}
+
+func (e *NavigationError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Phase, e.URL, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *NavigationError) Unwrap() error {
+	return e.Err
+}
+
+// ScanErrors accumulates the navigation failures of a scan which did not stop it, safe for
+// concurrent use
+type ScanErrors struct {
+	lock   sync.RWMutex
+	errors []*NavigationError
+}
+
+// NewScanErrors accumulator
+func NewScanErrors() *ScanErrors {
+	return &ScanErrors{errors: make([]*NavigationError, 0)}
+}
+
+// Add a failure of the navigation at url during phase
+func (s *ScanErrors) Add(phase, url string, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.errors = append(s.errors, &NavigationError{URL: url, Phase: phase, Err: err})
+}
+
+// Errors in the order they occurred
+func (s *ScanErrors) Errors() []*NavigationError {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return append([]*NavigationError(nil), s.errors...)
+}
+
+// Len of the accumulated errors
+func (s *ScanErrors) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.errors)
+}
+
+func (s *ScanErrors) Error() string {
+	errs := s.Errors()
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d navigations failed: %s", len(errs), strings.Join(messages, "; "))
+}
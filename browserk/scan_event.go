@@ -0,0 +1,87 @@
+package browserk
+
+import "time"
+
+// ScanEventType of events emitted while scanning
+type ScanEventType string
+
+const (
+	// EventNavigation a navigation was crawled
+	EventNavigation ScanEventType = "navigation"
+	// EventFinding a finding was reported
+	EventFinding ScanEventType = "finding"
+	// EventError a navigation or phase failed
+	EventError ScanEventType = "error"
+	// EventProgress periodic scan progress
+	EventProgress ScanEventType = "progress"
+)
+
+// ScanEvent is a structured event for tools embedding browserker, only the field matching
+// Type is set
+type ScanEvent struct {
+	Type       ScanEventType    `json:"type"`                 // type of event
+	Time       time.Time        `json:"time"`                 // when the event occurred
+	Navigation *NavigationEvent `json:"navigation,omitempty"` // crawled navigation
+	Finding    *FindingEvent    `json:"finding,omitempty"`    // reported finding
+	Error      *ErrorEvent      `json:"error,omitempty"`      // failure details
+	Progress   *ProgressEvent   `json:"progress,omitempty"`   // scan progress
+}
+
+// NavigationEvent details of a crawled navigation
+type NavigationEvent struct {
+	URL           string `json:"url"`            // url after the navigation completed
+	Path          string `json:"path"`           // action steps taken to get to this navigation
+	Step          int    `json:"step"`           // index of this navigation in the path
+	CorrelationID string `json:"correlation_id"` // id used in logs and the correlation header
+	NewNavs       int    `json:"new_navs"`       // navigations discovered
+}
+
+// FindingEvent details of a reported finding
+type FindingEvent struct {
+	VulnID      string   `json:"vuln_id"`
+	CWE         int      `json:"cwe"`
+	Severity    string   `json:"severity"`
+	Description string   `json:"description"`
+	URL         string   `json:"url,omitempty"`
+	Parameter   string   `json:"parameter,omitempty"`
+	Payload     string   `json:"payload,omitempty"`
+	Ancestry    []string `json:"ancestry,omitempty"`
+	HTML        string   `json:"html,omitempty"`
+}
+
+// ErrorEvent details of a failure
+type ErrorEvent struct {
+	Message       string `json:"message"`
+	Error         string `json:"error,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// ProgressEvent snapshot of the scan's progress
+type ProgressEvent struct {
+	Phase          string `json:"phase"`
+	Entries        int    `json:"entries"`         // navigation paths or results queued in this phase
+	LeasedBrowsers int    `json:"leased_browsers"` // browsers currently in use
+}
+
+// EventEmitter receives scan events as they occur
+type EventEmitter interface {
+	Emit(evt *ScanEvent)
+}
+
+// NewFindingEvent from a report, leaving out the response and any screenshot
+func NewFindingEvent(report *Report) *ScanEvent {
+	finding := &FindingEvent{
+		VulnID:      report.VulnID,
+		CWE:         report.CWE,
+		Severity:    SeverityMap[report.Severity],
+		Description: report.Description,
+	}
+	if report.Evidence != nil {
+		finding.URL = report.Evidence.URL
+		finding.Parameter = report.Evidence.Parameter
+		finding.Payload = report.Evidence.Payload
+		finding.Ancestry = report.Evidence.Ancestry
+		finding.HTML = report.Evidence.HTML
+	}
+	return &ScanEvent{Type: EventFinding, Time: time.Now(), Finding: finding}
+}
@@ -0,0 +1,14 @@
+package browserk
+
+import "context"
+
+// Scheduler decides which navigation paths the engine crawls next
+type Scheduler interface {
+	Next(ctx context.Context, max int64) [][]*Navigation // next paths to crawl, each ending in an unvisited navigation
+	Requeue(nav *Navigation) error                       // return nav so it will be scheduled again
+}
+
+// ResultObserver is implemented by schedulers which prioritize using navigation results
+type ResultObserver interface {
+	Observe(result *NavigationResult)
+}
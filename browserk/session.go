@@ -0,0 +1,12 @@
+package browserk
+
+import "time"
+
+// Session of an authenticated browser which can be shared with other browsers
+type Session struct {
+	Origin         string            `json:"origin"`          // origin the storage values belong to
+	Cookies        []*Cookie         `json:"cookies"`         // all cookies in the browser
+	LocalStorage   map[string]string `json:"local_storage"`   // localStorage of the origin
+	SessionStorage map[string]string `json:"session_storage"` // sessionStorage of the origin
+	Acquired       time.Time         `json:"acquired"`        // when the session was captured
+}
@@ -0,0 +1,95 @@
+package browserk
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultStripParams are tracking and session id query parameters removed when normalizing
+var DefaultStripParams = []string{
+	"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+	"gclid", "fbclid", "msclkid",
+	"phpsessid", "jsessionid", "aspsessionid", "sid", "sessionid",
+}
+
+// URLNormalizerConfig controls how urls are normalized for scope checks and dedup
+type URLNormalizerConfig struct {
+	StripParams       []string // query parameters to remove, matched case insensitively (nil for defaults, empty for none)
+	KeepTrailingSlash bool     // don't collapse trailing slashes in the path
+	KeepFragment      bool     // don't drop the #fragment
+}
+
+// URLNormalizer normalizes urls so equivalent urls compare equal
+type URLNormalizer interface {
+	Normalize(rawURL string) string
+}
+
+// DefaultURLNormalizer lowercases the scheme and host, removes default ports, strips tracking
+// and session parameters, sorts the query, collapses trailing slashes and drops fragments
+type DefaultURLNormalizer struct {
+	strip             map[string]struct{}
+	keepTrailingSlash bool
+	keepFragment      bool
+}
+
+// NewURLNormalizer from the config, nil uses the defaults
+func NewURLNormalizer(cfg *URLNormalizerConfig) *DefaultURLNormalizer {
+	if cfg == nil {
+		cfg = &URLNormalizerConfig{}
+	}
+
+	params := cfg.StripParams
+	if params == nil {
+		params = DefaultStripParams
+	}
+
+	n := &DefaultURLNormalizer{
+		strip:             make(map[string]struct{}, len(params)),
+		keepTrailingSlash: cfg.KeepTrailingSlash,
+		keepFragment:      cfg.KeepFragment,
+	}
+	for _, param := range params {
+		n.strip[strings.ToLower(param)] = struct{}{}
+	}
+	return n
+}
+
+// Normalize the url, urls that fail to parse are returned as is
+func (n *DefaultURLNormalizer) Normalize(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+
+	if !n.keepTrailingSlash {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+	}
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+
+	if u.RawQuery != "" {
+		values := u.Query()
+		for param := range values {
+			if _, strip := n.strip[strings.ToLower(param)]; strip {
+				values.Del(param)
+			}
+		}
+		// Encode sorts by key
+		u.RawQuery = values.Encode()
+	}
+	u.ForceQuery = false
+
+	if !n.keepFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+	return u.String()
+}
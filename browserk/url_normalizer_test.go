@@ -0,0 +1,75 @@
+package browserk_test
+
+import (
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+)
+
+func TestURLNormalizer(t *testing.T) {
+	n := browserk.NewURLNormalizer(nil)
+
+	var inputs = []struct {
+		a string
+		b string
+	}{
+		{"http://example.com/?b=2&a=1", "http://example.com/?a=1&b=2"},
+		{"http://example.com/page?a=1&utm_source=mail&gclid=x", "http://example.com/page?a=1"},
+		{"http://example.com/page?PHPSESSID=abc", "http://example.com/page"},
+		{"http://example.com/page/", "http://example.com/page"},
+		{"http://example.com/page#section", "http://example.com/page"},
+		{"HTTP://Example.COM:80", "http://example.com/"},
+		{"https://example.com:443/", "https://example.com"},
+	}
+
+	for _, in := range inputs {
+		if n.Normalize(in.a) != n.Normalize(in.b) {
+			t.Fatalf("expected %s (%s) to normalize the same as %s (%s)", in.a, n.Normalize(in.a), in.b, n.Normalize(in.b))
+		}
+	}
+
+	if got := n.Normalize("http://example.com/page?b=2&a=1"); got != "http://example.com/page?a=1&b=2" {
+		t.Fatalf("expected sorted query, got %s", got)
+	}
+
+	if n.Normalize("http://example.com:8080/") == n.Normalize("http://example.com/") {
+		t.Fatalf("non default port should not be removed")
+	}
+}
+
+func TestURLNormalizerConfig(t *testing.T) {
+	n := browserk.NewURLNormalizer(&browserk.URLNormalizerConfig{
+		StripParams:       []string{},
+		KeepTrailingSlash: true,
+		KeepFragment:      true,
+	})
+
+	if got := n.Normalize("http://example.com/page/?utm_source=mail#top"); got != "http://example.com/page/?utm_source=mail#top" {
+		t.Fatalf("expected url to be kept, got %s", got)
+	}
+
+	custom := browserk.NewURLNormalizer(&browserk.URLNormalizerConfig{StripParams: []string{"Token"}})
+	if got := custom.Normalize("http://example.com/?token=1&utm_source=mail"); got != "http://example.com/?utm_source=mail" {
+		t.Fatalf("expected only custom params to be stripped, got %s", got)
+	}
+}
+
+func TestNewNavigationFromLink(t *testing.T) {
+	n := browserk.NewURLNormalizer(nil)
+	root := browserk.NewNavigation(browserk.TrigCrawler, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://example.com")})
+
+	first := &browserk.HTMLElement{Type: browserk.A, Attributes: map[string]string{"href": "/list?b=2&a=1"}}
+	second := &browserk.HTMLElement{Type: browserk.A, Attributes: map[string]string{"href": "list/?a=1&b=2", "class": "nav"}}
+
+	navA := browserk.NewNavigationFromLink(root, browserk.TrigCrawler, first, n.Normalize("http://example.com/list?b=2&a=1"))
+	navB := browserk.NewNavigationFromLink(root, browserk.TrigCrawler, second, n.Normalize("http://example.com/list/?a=1&b=2"))
+	if string(navA.ID) != string(navB.ID) {
+		t.Fatalf("expected links to the same normalized url to share an id")
+	}
+
+	frag := &browserk.HTMLElement{Type: browserk.A, Attributes: map[string]string{"href": "#top"}}
+	navC := browserk.NewNavigationFromLink(root, browserk.TrigCrawler, frag, n.Normalize("http://example.com/#top"))
+	if string(navC.ID) != string(browserk.NewNavigationFromElement(root, browserk.TrigCrawler, frag, browserk.ActLeftClick).ID) {
+		t.Fatalf("expected fragment links to use the element id")
+	}
+}
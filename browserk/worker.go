@@ -0,0 +1,18 @@
+package browserk
+
+// Worker is a web, shared or service worker started by a page, scripts it loaded and requests
+// it made are captured separately from the page's
+type Worker struct {
+	ID       string          `json:"id"`       // target id of the worker
+	Type     string          `json:"type"`     // worker, shared_worker or service_worker
+	URL      string          `json:"url"`      // url of the worker's main script
+	Scripts  []*WorkerScript `json:"scripts"`  // scripts parsed by the worker, including imported scripts
+	Messages []*HTTPMessage  `json:"messages"` // requests made by the worker
+}
+
+// WorkerScript source parsed by a worker
+type WorkerScript struct {
+	ScriptID string `json:"script_id"`
+	URL      string `json:"url,omitempty"` // empty for evaluated scripts
+	Source   string `json:"source"`
+}
@@ -3,6 +3,7 @@ package clicmds
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/signal"
@@ -14,10 +15,13 @@ import (
 	_ "net/http/pprof"
 
 	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 	"gitlab.com/browserker/browserk"
 	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/browser"
+	"gitlab.com/browserker/scanner/report"
 	"gitlab.com/browserker/store"
 )
 
@@ -48,6 +52,15 @@ func CrawlerFlags() []cli.Flag {
 			Usage: "max number of browsers to use in parallel",
 			Value: 3,
 		},
+		&cli.IntFlag{
+			Name:  "tabsperbrowser",
+			Usage: "number of tabs each browser runs in parallel, each in its own browser context",
+			Value: 1,
+		},
+		&cli.Int64Flag{
+			Name:  "maxbrowserheap",
+			Usage: "recycle browsers shared by multiple tabs once their javascript heap exceeds this many bytes (0 to disable)",
+		},
 		&cli.IntFlag{
 			Name:  "maxdepth",
 			Usage: "max depth of nav paths to traverse",
@@ -58,6 +71,46 @@ func CrawlerFlags() []cli.Flag {
 			Usage: "print summary of urls/graph actions taken",
 			Value: true,
 		},
+		&cli.StringFlag{
+			Name:  "phases",
+			Usage: "comma separated list of scan phases to run (crawl, attack, report)",
+			Value: "crawl,attack,report",
+		},
+		&cli.StringFlag{
+			Name:  "store",
+			Usage: "storage backend for the crawl and attack graphs (memory, disk)",
+			Value: store.BackendDisk,
+		},
+		&cli.StringFlag{
+			Name:  "chromepath",
+			Usage: "path to the chrome/chromium executable, searches the usual install locations if not set",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "persist-profile",
+			Usage: "directory to keep chrome profiles in so cookies persist across runs",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "fail-on",
+			Usage: "exit with status 2 if findings of this severity or higher exist (info, low, medium, high, critical)",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "write-baseline",
+			Usage: "write the scan's findings to this file to be used as the config's BaselineFile",
+			Value: "",
+		},
+		&cli.BoolFlag{
+			Name:  "json-events",
+			Usage: "write newline delimited json events to stdout, logs and summary go to stderr",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  "protocol-trace",
+			Usage: "append each tab's devtools protocol commands, responses and events to this file as json lines",
+			Value: "",
+		},
 	}
 }
 
@@ -95,19 +148,62 @@ func Crawler(cliCtx *cli.Context) error {
 			cfg.DataPath = cliCtx.String("datadir")
 		}
 	}
+
+	if len(cfg.Phases) == 0 || cliCtx.IsSet("phases") {
+		cfg.Phases = splitPhases(cliCtx.String("phases"))
+	}
+
+	if cliCtx.IsSet("tabsperbrowser") {
+		cfg.TabsPerBrowser = cliCtx.Int("tabsperbrowser")
+	}
+
+	if cliCtx.IsSet("maxbrowserheap") {
+		cfg.MaxBrowserHeapBytes = cliCtx.Int64("maxbrowserheap")
+	}
+
+	if cliCtx.IsSet("chromepath") {
+		cfg.ChromePath = cliCtx.String("chromepath")
+	}
+
+	if cliCtx.IsSet("persist-profile") {
+		cfg.UserDataDir = cliCtx.String("persist-profile")
+	}
+
+	if cliCtx.IsSet("protocol-trace") {
+		cfg.ProtocolTrace = cliCtx.String("protocol-trace")
+	}
+
+	if cliCtx.IsSet("fail-on") {
+		cfg.FailOnSeverity = cliCtx.String("fail-on")
+	}
+	if cfg.FailOnSeverity != "" {
+		if _, err := browserk.ParseSeverity(cfg.FailOnSeverity); err != nil {
+			return ExitStatus(err, nil, "")
+		}
+	}
 	os.RemoveAll(cfg.DataPath)
-	crawl := store.NewCrawlGraph(cfg.DataPath + "/crawl")
-	pluginStore := store.NewPluginStore(cfg.DataPath + "/plugin")
-	browserk := scanner.New(cfg, crawl, pluginStore)
+	crawl, pluginStore, err := store.NewGraphs(cliCtx.String("store"), cfg.DataPath)
+	if err != nil {
+		return err
+	}
+	browserk := scanner.New(cfg, crawl, pluginStore, nil)
+
+	// keep stdout for the event stream only
+	var summaryOut io.Writer = os.Stdout
+	if cliCtx.Bool("json-events") {
+		log.Logger = log.Output(os.Stderr)
+		summaryOut = os.Stderr
+		browserk.SetEventEmitter(report.NewJSONEvents(os.Stdout))
+	}
 	log.Logger.Info().Msg("Starting browserker")
 
 	scanContext := context.Background()
 	if err := browserk.Init(scanContext); err != nil {
 		log.Logger.Error().Err(err).Msg("failed to init engine")
-		return err
+		return initError(err)
 	}
 
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
@@ -121,19 +217,64 @@ func Crawler(cliCtx *cli.Context) error {
 		os.Exit(1)
 	}()
 
-	err := browserk.Start()
-	if err != nil {
+	err = browserk.Start()
+	if scanErrors, ok := failedNavigations(err); ok {
+		// the scan completed, only some of its navigations failed
+		report.PrintScanErrors(summaryOut, scanErrors)
+		err = nil
+	} else if err != nil {
 		log.Error().Err(err).Msg("browserk failure occurred")
 	}
 
 	if cliCtx.Bool("summary") {
-		printSummary(crawl)
+		printSummary(summaryOut, crawl)
 	}
 
-	return browserk.Stop()
+	if path := cliCtx.String("write-baseline"); path != "" {
+		writeBaseline(path, browserk.Findings())
+	}
+
+	if stopErr := browserk.Stop(); err == nil {
+		err = stopErr
+	}
+	return ExitStatus(err, browserk.Findings(), cfg.FailOnSeverity)
+}
+
+// failedNavigations returns the scan errors if err only reports failed navigations
+func failedNavigations(err error) (*browserk.ScanErrors, bool) {
+	scanErrors, ok := err.(*browserk.ScanErrors)
+	return scanErrors, ok
+}
+
+// initError returns a friendly exit message for errors the user can fix
+func initError(err error) error {
+	if errors.Cause(err) == browser.ErrChromeNotFound {
+		return cli.Exit(err.Error(), ExitError)
+	}
+	return err
+}
+
+func splitPhases(phases string) []string {
+	selected := make([]string, 0)
+	for _, phase := range strings.Split(phases, ",") {
+		phase = strings.TrimSpace(phase)
+		if phase != "" {
+			selected = append(selected, phase)
+		}
+	}
+	return selected
+}
+
+// writeBaseline of the scan's findings so they are accepted in future scans
+func writeBaseline(path string, findings []*browserk.Report) {
+	if err := browserk.WriteBaseline(path, findings); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("failed to write baseline")
+		return
+	}
+	log.Info().Int("findings", len(findings)).Str("path", path).Msg("wrote baseline")
 }
 
-func printSummary(crawl *store.CrawlGraph) error {
+func printSummary(w io.Writer, crawl browserk.CrawlGrapher) error {
 	results, err := crawl.GetNavigationResults()
 	if err != nil {
 		return err
@@ -142,39 +283,39 @@ func printSummary(crawl *store.CrawlGraph) error {
 	if results == nil {
 		return fmt.Errorf("No result entries found")
 	}
-	fmt.Printf("Had %d results\n", len(results))
+	fmt.Fprintf(w, "Had %d results\n", len(results))
 	for _, entry := range results {
 		if entry.Messages != nil {
 			for _, m := range entry.Messages {
 				if m.Request == nil {
 					continue
 				}
-				fmt.Printf("URL visited: (DOC %s) %s\n", m.Request.DocumentURL, m.Request.Request.Url)
+				fmt.Fprintf(w, "URL visited: (DOC %s) %s\n", m.Request.DocumentURL, m.Request.Request.Url)
 			}
 		}
 	}
 
 	entries := crawl.Find(nil, browserk.NavVisited, browserk.NavVisited, 999)
-	printEntries(entries, "visited")
+	printEntries(w, entries, "visited")
 	entries = crawl.Find(nil, browserk.NavUnvisited, browserk.NavUnvisited, 999)
-	printEntries(entries, "unvisited")
+	printEntries(w, entries, "unvisited")
 	entries = crawl.Find(nil, browserk.NavInProcess, browserk.NavInProcess, 999)
-	printEntries(entries, "in process")
+	printEntries(w, entries, "in process")
 	entries = crawl.Find(nil, browserk.NavInProcess, browserk.NavInProcess, 999)
-	printEntries(entries, "nav failed")
+	printEntries(w, entries, "nav failed")
 	return nil
 }
 
-func printEntries(entries [][]*browserk.Navigation, navType string) {
-	fmt.Printf("Had %d %s entries\n", len(entries), navType)
+func printEntries(w io.Writer, entries [][]*browserk.Navigation, navType string) {
+	fmt.Fprintf(w, "Had %d %s entries\n", len(entries), navType)
 	for _, paths := range entries {
-		fmt.Printf("%s Path: \n", navType)
+		fmt.Fprintf(w, "%s Path: \n", navType)
 		for i, path := range paths {
 			if len(paths)-1 == i {
-				fmt.Printf("%s %s\n", browserk.ActionTypeMap[path.Action.Type], path.Action)
+				fmt.Fprintf(w, "%s %s\n", browserk.ActionTypeMap[path.Action.Type], path.Action)
 				break
 			}
-			fmt.Printf("%s %s -> ", browserk.ActionTypeMap[path.Action.Type], path.Action)
+			fmt.Fprintf(w, "%s %s -> ", browserk.ActionTypeMap[path.Action.Type], path.Action)
 		}
 	}
 }
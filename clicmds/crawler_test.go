@@ -1,9 +1,11 @@
 package clicmds_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/urfave/cli/v2"
+	"gitlab.com/browserker/browserk"
 	"gitlab.com/browserker/clicmds"
 )
 
@@ -23,3 +25,43 @@ func TestCrawler(t *testing.T) {
 		t.Fatalf("err: %s\n", err)
 	}
 }
+
+func TestExitStatus(t *testing.T) {
+	findings := []*browserk.Report{
+		{VulnID: "BR-A-0002", Severity: browserk.High, Evidence: &browserk.Evidence{URL: "http://example.com/users/1"}},
+		{VulnID: "BR-B-0001", Severity: browserk.Info, Evidence: &browserk.Evidence{URL: "https://example.com/"}},
+	}
+
+	var inputs = []struct {
+		err      error
+		findings []*browserk.Report
+		failOn   string
+		expected int
+	}{
+		{nil, findings, "medium", clicmds.ExitFindings},
+		{nil, findings, "Critical", clicmds.ExitClean},
+		{nil, findings, "", clicmds.ExitClean},
+		{nil, findings[1:], "low", clicmds.ExitClean},
+		{nil, []*browserk.Report{{VulnID: "BR-A-0002", Severity: browserk.High, Baselined: true}}, "medium", clicmds.ExitClean},
+		{errors.New("failed to init engine"), nil, "medium", clicmds.ExitError},
+		{nil, findings, "severe", clicmds.ExitError},
+	}
+
+	for _, in := range inputs {
+		code := clicmds.ExitClean
+		app := cli.NewApp()
+		app.ExitErrHandler = func(c *cli.Context, err error) {
+			if exitErr, ok := err.(cli.ExitCoder); ok {
+				code = exitErr.ExitCode()
+			}
+		}
+		app.Action = func(c *cli.Context) error {
+			return clicmds.ExitStatus(in.err, in.findings, in.failOn)
+		}
+		app.Run([]string{"app"})
+
+		if code != in.expected {
+			t.Fatalf("expected exit code %d for fail on %q got %d", in.expected, in.failOn, code)
+		}
+	}
+}
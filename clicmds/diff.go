@@ -0,0 +1,34 @@
+package clicmds
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"gitlab.com/browserker/scanner/report"
+)
+
+// DiffFlags for comparing two scans
+func DiffFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "previous",
+			Usage:    "data directory of the previous scan",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "current",
+			Usage:    "data directory of the current scan",
+			Required: true,
+		},
+	}
+}
+
+// Diff prints the new, resolved and unchanged findings between two scans
+func Diff(ctx *cli.Context) error {
+	diff, err := report.Diff(ctx.String("previous"), ctx.String("current"))
+	if err != nil {
+		return cli.Exit(err.Error(), ExitError)
+	}
+	report.PrintDiff(os.Stdout, diff)
+	return nil
+}
@@ -0,0 +1,44 @@
+package clicmds
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"gitlab.com/browserker/browserk"
+)
+
+// Exit codes returned by scanning commands
+const (
+	ExitClean    = 0 // scan completed without actionable findings at or above the threshold
+	ExitError    = 1 // scan failed to run
+	ExitFindings = 2 // actionable findings at or above the threshold exist
+)
+
+// ExitStatus of a scan, err is an operational error. Baselined findings are ignored and
+// failOn (a severity name) disables failing on findings if empty.
+func ExitStatus(err error, findings []*browserk.Report, failOn string) error {
+	if err != nil {
+		return cli.Exit(err.Error(), ExitError)
+	}
+
+	if failOn == "" {
+		return nil
+	}
+
+	threshold, err := browserk.ParseSeverity(failOn)
+	if err != nil {
+		return cli.Exit(err.Error(), ExitError)
+	}
+
+	failed := 0
+	for _, finding := range browserk.ActionableReports(findings) {
+		if finding.Severity >= threshold {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("%d findings at or above %s severity", failed, browserk.SeverityMap[threshold]), ExitFindings)
+	}
+	return nil
+}
@@ -37,8 +37,15 @@ func main() {
 			Action:  clicmds.DBView,
 			Flags:   clicmds.DBViewFlags(),
 		},
+		{
+			Name:    "diff",
+			Aliases: nil,
+			Usage:   "diff the findings of two scans",
+			Action:  clicmds.Diff,
+			Flags:   clicmds.DiffFlags(),
+		},
 	}
-	fmt.Println(os.Args)
+	fmt.Fprintln(os.Stderr, os.Args)
 	err := app.Run(os.Args)
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,45 @@
+package mock
+
+import "gitlab.com/browserker/browserk"
+
+type AttackModule struct {
+	NameFn     func() string
+	NameCalled bool
+
+	IDFn     func() string
+	IDCalled bool
+
+	AttackFn     func(bctx *browserk.Context, replayer browserk.Replayer, result *browserk.NavigationResult) error
+	AttackCalled bool
+}
+
+func (a *AttackModule) Name() string {
+	a.NameCalled = true
+	return a.NameFn()
+}
+
+func (a *AttackModule) ID() string {
+	a.IDCalled = true
+	return a.IDFn()
+}
+
+func (a *AttackModule) Attack(bctx *browserk.Context, replayer browserk.Replayer, result *browserk.NavigationResult) error {
+	a.AttackCalled = true
+	return a.AttackFn(bctx, replayer, result)
+}
+
+func MakeMockAttackModule() *AttackModule {
+	a := &AttackModule{}
+	a.NameFn = func() string {
+		return "TestAttack"
+	}
+
+	a.IDFn = func() string {
+		return "BR-A-9999"
+	}
+
+	a.AttackFn = func(bctx *browserk.Context, replayer browserk.Replayer, result *browserk.NavigationResult) error {
+		return nil
+	}
+	return a
+}
@@ -0,0 +1,207 @@
+package mock
+
+import (
+	"context"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// Browser for testing engine/crawler logic without chrome
+type Browser struct {
+	IDFn func() int64
+
+	GetURLFn     func() (string, error)
+	GetURLCalled bool
+
+	GetDOMFn     func() (string, error)
+	GetDOMCalled bool
+
+	GetCookiesFn     func() ([]*browserk.Cookie, error)
+	GetCookiesCalled bool
+
+	SetCookiesFn     func(cookies []*browserk.Cookie) error
+	SetCookiesCalled bool
+
+	ClearCookiesFn     func() error
+	ClearCookiesCalled bool
+
+	NavigateFn     func(ctx context.Context, url string) error
+	NavigateCalled bool
+
+	FindElementsFn     func(querySelector string) ([]*browserk.HTMLElement, error)
+	FindElementsCalled bool
+
+	FindFormsFn     func() ([]*browserk.HTMLFormElement, error)
+	FindFormsCalled bool
+
+	FindInteractablesFn     func() ([]*browserk.HTMLElement, error)
+	FindInteractablesCalled bool
+
+	GetMessagesFn     func() ([]*browserk.HTTPMessage, error)
+	GetMessagesCalled bool
+
+	ExecuteActionFn     func(ctx context.Context, act *browserk.Action) ([]byte, bool, error)
+	ExecuteActionCalled bool
+
+	ReplayRequestFn     func(req *browserk.Request) (*browserk.HTTPResponse, error)
+	ReplayRequestCalled bool
+
+	ProbeFormFn     func(ctx context.Context, form *browserk.HTMLFormElement) ([]*browserk.HTMLElement, error)
+	ProbeFormCalled bool
+
+	SetExtraHeadersFn     func(headers map[string]string) error
+	SetExtraHeadersCalled bool
+
+	CloseCalled bool
+}
+
+func (b *Browser) ID() int64 {
+	return b.IDFn()
+}
+
+func (b *Browser) GetURL() (string, error) {
+	b.GetURLCalled = true
+	return b.GetURLFn()
+}
+
+func (b *Browser) GetDOM() (string, error) {
+	b.GetDOMCalled = true
+	return b.GetDOMFn()
+}
+
+func (b *Browser) GetCookies() ([]*browserk.Cookie, error) {
+	b.GetCookiesCalled = true
+	return b.GetCookiesFn()
+}
+
+func (b *Browser) SetCookies(cookies []*browserk.Cookie) error {
+	b.SetCookiesCalled = true
+	return b.SetCookiesFn(cookies)
+}
+
+func (b *Browser) ClearCookies() error {
+	b.ClearCookiesCalled = true
+	return b.ClearCookiesFn()
+}
+
+func (b *Browser) GetBaseHref() string {
+	return ""
+}
+
+func (b *Browser) GetStorageEvents() []*browserk.StorageEvent {
+	return nil
+}
+
+func (b *Browser) GetConsoleEvents() []*browserk.ConsoleEvent {
+	return nil
+}
+
+func (b *Browser) NetworkSummary() browserk.NetworkSummary {
+	return browserk.NetworkSummary{}
+}
+
+func (b *Browser) Navigate(ctx context.Context, url string) error {
+	b.NavigateCalled = true
+	return b.NavigateFn(ctx, url)
+}
+
+func (b *Browser) FindElements(querySelector string) ([]*browserk.HTMLElement, error) {
+	b.FindElementsCalled = true
+	return b.FindElementsFn(querySelector)
+}
+
+func (b *Browser) FindForms() ([]*browserk.HTMLFormElement, error) {
+	b.FindFormsCalled = true
+	return b.FindFormsFn()
+}
+
+func (b *Browser) FindInteractables() ([]*browserk.HTMLElement, error) {
+	b.FindInteractablesCalled = true
+	return b.FindInteractablesFn()
+}
+
+func (b *Browser) GetMessages() ([]*browserk.HTTPMessage, error) {
+	b.GetMessagesCalled = true
+	return b.GetMessagesFn()
+}
+
+func (b *Browser) Screenshot() (string, error) {
+	return "", nil
+}
+
+func (b *Browser) RefreshDocument() {
+}
+
+func (b *Browser) ExecuteAction(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+	b.ExecuteActionCalled = true
+	return b.ExecuteActionFn(ctx, act)
+}
+
+func (b *Browser) ReplayRequest(req *browserk.Request) (*browserk.HTTPResponse, error) {
+	b.ReplayRequestCalled = true
+	return b.ReplayRequestFn(req)
+}
+
+func (b *Browser) ProbeForm(ctx context.Context, form *browserk.HTMLFormElement) ([]*browserk.HTMLElement, error) {
+	b.ProbeFormCalled = true
+	return b.ProbeFormFn(ctx, form)
+}
+
+func (b *Browser) SetExtraHeaders(headers map[string]string) error {
+	b.SetExtraHeadersCalled = true
+	return b.SetExtraHeadersFn(headers)
+}
+
+func (b *Browser) Close() {
+	b.CloseCalled = true
+}
+
+func MakeMockBrowser() *Browser {
+	b := &Browser{}
+	b.IDFn = func() int64 {
+		return 1
+	}
+	b.GetURLFn = func() (string, error) {
+		return "http://localhost:8080/", nil
+	}
+	b.GetDOMFn = func() (string, error) {
+		return "", nil
+	}
+	b.GetCookiesFn = func() ([]*browserk.Cookie, error) {
+		return nil, nil
+	}
+	b.SetCookiesFn = func(cookies []*browserk.Cookie) error {
+		return nil
+	}
+	b.ClearCookiesFn = func() error {
+		return nil
+	}
+	b.NavigateFn = func(ctx context.Context, url string) error {
+		return nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		return nil, nil
+	}
+	b.FindFormsFn = func() ([]*browserk.HTMLFormElement, error) {
+		return nil, nil
+	}
+	b.FindInteractablesFn = func() ([]*browserk.HTMLElement, error) {
+		return nil, nil
+	}
+	b.GetMessagesFn = func() ([]*browserk.HTTPMessage, error) {
+		return nil, nil
+	}
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		return nil, false, nil
+	}
+	b.ReplayRequestFn = func(req *browserk.Request) (*browserk.HTTPResponse, error) {
+		return nil, nil
+	}
+	b.SetExtraHeadersFn = func(headers map[string]string) error {
+		return nil
+	}
+	b.ProbeFormFn = func(ctx context.Context, form *browserk.HTMLFormElement) ([]*browserk.HTMLElement, error) {
+		return nil, nil
+	}
+	return b
+}
@@ -0,0 +1,51 @@
+package mock
+
+import (
+	"context"
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// BrowserPool hands out mock browsers and tracks returned ports
+type BrowserPool struct {
+	lock     sync.Mutex
+	Returned []string
+
+	TakeFn     func(ctx *browserk.Context) (browserk.Browser, string, error)
+	TakeCalled int
+
+	ReturnCalled int
+}
+
+func (p *BrowserPool) Take(ctx *browserk.Context) (browserk.Browser, string, error) {
+	p.lock.Lock()
+	p.TakeCalled++
+	p.lock.Unlock()
+	return p.TakeFn(ctx)
+}
+
+func (p *BrowserPool) Return(ctx context.Context, browserPort string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.ReturnCalled++
+	p.Returned = append(p.Returned, browserPort)
+}
+
+func (p *BrowserPool) Leased() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.TakeCalled - p.ReturnCalled
+}
+
+func (p *BrowserPool) Shutdown() error {
+	return nil
+}
+
+func MakeMockBrowserPool(browser browserk.Browser) *BrowserPool {
+	p := &BrowserPool{Returned: make([]string, 0)}
+	p.TakeFn = func(ctx *browserk.Context) (browserk.Browser, string, error) {
+		return browser, "9222", nil
+	}
+	return p
+}
@@ -3,12 +3,14 @@ package mock
 import (
 	"context"
 
+	"github.com/rs/zerolog/log"
 	"gitlab.com/browserker/browserk"
 )
 
 func Context(ctx context.Context) *browserk.Context {
 	return &browserk.Context{
 		Ctx:         ctx,
+		Log:         &log.Logger,
 		Auth:        nil,
 		Scope:       nil,
 		FormHandler: nil,
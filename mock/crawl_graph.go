@@ -0,0 +1,121 @@
+package mock
+
+import (
+	"context"
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// CrawlGraph records navigation state changes made by the engine
+type CrawlGraph struct {
+	lock sync.Mutex
+
+	FindFn     func(ctx context.Context, byState, setState browserk.NavState, limit int64) [][]*browserk.Navigation
+	FindCalled bool
+
+	AddNavigationsCalled bool
+	Added                []*browserk.Navigation
+
+	FailNavigationCalled bool
+	Failed               [][]byte
+
+	RequeueNavigationCalled bool
+	Requeued                [][]byte
+
+	RecordNavigationErrorCalled bool
+	Errors                      map[string]string
+
+	AddResultCalled bool
+	Results         []*browserk.NavigationResult
+
+	TransactionCalled bool
+}
+
+func (g *CrawlGraph) Init() error {
+	return nil
+}
+
+func (g *CrawlGraph) Close() error {
+	return nil
+}
+
+func (g *CrawlGraph) Find(ctx context.Context, byState, setState browserk.NavState, limit int64) [][]*browserk.Navigation {
+	g.FindCalled = true
+	return g.FindFn(ctx, byState, setState, limit)
+}
+
+func (g *CrawlGraph) AddNavigation(nav *browserk.Navigation) error {
+	return g.AddNavigations([]*browserk.Navigation{nav})
+}
+
+func (g *CrawlGraph) AddNavigations(navs []*browserk.Navigation) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.AddNavigationsCalled = true
+	g.Added = append(g.Added, navs...)
+	return nil
+}
+
+func (g *CrawlGraph) FailNavigation(navID []byte) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.FailNavigationCalled = true
+	g.Failed = append(g.Failed, navID)
+	return nil
+}
+
+func (g *CrawlGraph) RequeueNavigation(navID []byte) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.RequeueNavigationCalled = true
+	g.Requeued = append(g.Requeued, navID)
+	return nil
+}
+
+func (g *CrawlGraph) RecordNavigationError(navID []byte, retries int, lastError string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.RecordNavigationErrorCalled = true
+	if g.Errors == nil {
+		g.Errors = make(map[string]string)
+	}
+	g.Errors[string(navID)] = lastError
+	return nil
+}
+
+func (g *CrawlGraph) AddResult(result *browserk.NavigationResult) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.AddResultCalled = true
+	g.Results = append(g.Results, result)
+	return nil
+}
+
+// Transaction calls fn with the mock graph itself, errors are not rolled back
+func (g *CrawlGraph) Transaction(fn func(tx browserk.CrawlTx) error) error {
+	g.lock.Lock()
+	g.TransactionCalled = true
+	g.lock.Unlock()
+	return fn(g)
+}
+
+func (g *CrawlGraph) NavExists(nav *browserk.Navigation) bool {
+	return false
+}
+
+func (g *CrawlGraph) GetNavigation(id []byte) (*browserk.Navigation, error) {
+	return nil, nil
+}
+
+func (g *CrawlGraph) GetNavigationResults() ([]*browserk.NavigationResult, error) {
+	return g.Results, nil
+}
+
+func MakeMockCrawlGraph() *CrawlGraph {
+	g := &CrawlGraph{}
+	g.FindFn = func(ctx context.Context, byState, setState browserk.NavState, limit int64) [][]*browserk.Navigation {
+		return nil
+	}
+	return g
+}
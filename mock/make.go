@@ -276,3 +276,34 @@ func MakeMockNavi(id []byte) *browserk.Navigation {
 		},
 	}
 }
+
+// MakeMockMessage with a populated request for replaying/attacking
+func MakeMockMessage(method, url, postData string) *browserk.HTTPMessage {
+	headers := map[string]interface{}{}
+	if postData != "" {
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+	return &browserk.HTTPMessage{
+		RequestTime: time.Now(),
+		Request: &browserk.HTTPRequest{
+			RequestId:   "1",
+			DocumentURL: url,
+			Request: &gcdapi.NetworkRequest{
+				Url:         url,
+				Method:      method,
+				Headers:     headers,
+				PostData:    postData,
+				HasPostData: postData != "",
+			},
+		},
+	}
+}
+
+// MakeMockMessagesResult for a navigation that captured messages
+func MakeMockMessagesResult(messages ...*browserk.HTTPMessage) *browserk.NavigationResult {
+	return &browserk.NavigationResult{
+		NavigationID: []byte("nav"),
+		Messages:     messages,
+		MessageCount: len(messages),
+	}
+}
@@ -13,6 +13,12 @@ type PluginStore struct {
 	AddEventFn     func(evt *browserk.PluginEvent)
 	AddEventCalled bool
 
+	AddReportFn     func(report *browserk.Report) error
+	AddReportCalled bool
+
+	GetReportsFn     func() ([]*browserk.Report, error)
+	GetReportsCalled bool
+
 	CloseFn     func() error
 	CloseCalled bool
 }
@@ -35,6 +41,18 @@ func (s *PluginStore) AddEvent(evt *browserk.PluginEvent) {
 	s.AddEventFn(evt)
 }
 
+// AddReport persists a finding
+func (s *PluginStore) AddReport(report *browserk.Report) error {
+	s.AddReportCalled = true
+	return s.AddReportFn(report)
+}
+
+// GetReports persisted by AddReport
+func (s *PluginStore) GetReports() ([]*browserk.Report, error) {
+	s.GetReportsCalled = true
+	return s.GetReportsFn()
+}
+
 // Close the plugin store
 func (s *PluginStore) Close() error {
 	s.CloseCalled = true
@@ -54,5 +72,11 @@ func MakeMockPluginStore() *PluginStore {
 	}
 	p.AddEventFn = func(evt *browserk.PluginEvent) {
 	}
+	p.AddReportFn = func(report *browserk.Report) error {
+		return nil
+	}
+	p.GetReportsFn = func() ([]*browserk.Report, error) {
+		return []*browserk.Report{}, nil
+	}
 	return p
 }
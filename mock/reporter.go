@@ -0,0 +1,59 @@
+package mock
+
+import (
+	"io"
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+type Reporter struct {
+	lock    sync.Mutex
+	Reports []*browserk.Report
+
+	Incomplete  string
+	Baseline    *browserk.Baseline
+	EvidenceDir string
+
+	AddCalled   bool
+	PrintCalled bool
+}
+
+func (r *Reporter) Add(report *browserk.Report) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.AddCalled = true
+	r.Reports = append(r.Reports, report)
+}
+
+func (r *Reporter) Print(writer io.Writer) {
+	r.PrintCalled = true
+}
+
+func (r *Reporter) SetIncomplete(reason string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Incomplete = reason
+}
+
+func (r *Reporter) SetBaseline(baseline *browserk.Baseline) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Baseline = baseline
+}
+
+func (r *Reporter) SetEvidenceDir(dir string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.EvidenceDir = dir
+}
+
+func (r *Reporter) Findings() []*browserk.Report {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*browserk.Report(nil), r.Reports...)
+}
+
+func MakeMockReporter() *Reporter {
+	return &Reporter{Reports: make([]*browserk.Report, 0)}
+}
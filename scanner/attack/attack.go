@@ -0,0 +1,73 @@
+// Package attack contains the active attack modules executed during the attack phase
+package attack
+
+import (
+	"strings"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// escapes a value for use in a double quoted css attribute selector
+var selectorEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// attackableRequests returns the in scope requests with parameters captured in result
+func attackableRequests(bctx *browserk.Context, result *browserk.NavigationResult) []*browserk.Request {
+	requests := make([]*browserk.Request, 0)
+	if result == nil {
+		return requests
+	}
+
+	for _, m := range result.Messages {
+		req := browserk.NewRequest(m.Request)
+		if req == nil || len(req.Params()) == 0 {
+			continue
+		}
+
+		if bctx.Scope != nil && bctx.Scope.Check(req.URL) != browserk.InScope {
+			continue
+		}
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+// paramSelector matches the field named param
+func paramSelector(param string) string {
+	return `[name="` + selectorEscaper.Replace(param) + `"]`
+}
+
+// paramEvidence adds the DOM path, html and a screenshot of the field named param to evidence,
+// they are left empty if the replayer does not have the page loaded or no such field exists
+func paramEvidence(bctx *browserk.Context, replayer browserk.Replayer, param string, evidence *browserk.Evidence) *browserk.Evidence {
+	evidence.Ancestry = paramAncestry(bctx, replayer, param)
+
+	capturer, ok := replayer.(browserk.EvidenceCapturer)
+	if !ok || param == "" {
+		return evidence
+	}
+
+	captured, err := capturer.ElementEvidence(paramSelector(param))
+	if err != nil {
+		bctx.Log.Debug().Err(err).Str("param", param).Msg("failed to capture evidence of parameter")
+		return evidence
+	}
+	evidence.HTML = captured.HTML
+	evidence.Screenshot = captured.Screenshot
+	return evidence
+}
+
+// paramAncestry returns the DOM path to the field named param, nil if the replayer does not have
+// the page loaded or no such field exists
+func paramAncestry(bctx *browserk.Context, replayer browserk.Replayer, param string) []string {
+	locator, ok := replayer.(browserk.AncestryLocator)
+	if !ok || param == "" {
+		return nil
+	}
+
+	ancestry, err := locator.ElementAncestry(paramSelector(param))
+	if err != nil {
+		bctx.Log.Debug().Err(err).Str("param", param).Msg("no element found for parameter")
+		return nil
+	}
+	return ancestry
+}
@@ -0,0 +1,184 @@
+package attack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// sensitiveContent matches documents with elements a framing page could trick a user into interacting with
+var sensitiveContent = regexp.MustCompile(`(?i)<(form|input|button|textarea|select)[\s>/]`)
+
+// Clickjacking checks the X-Frame-Options and Content-Security-Policy frame-ancestors headers of
+// the main document, flagging sensitive pages that can be framed by arbitrary origins
+type Clickjacking struct {
+	confirm bool
+}
+
+// NewClickjacking attack module
+func NewClickjacking() *Clickjacking {
+	return &Clickjacking{}
+}
+
+// SetConfirm loads the document in a cross origin attacker frame and only reports if it renders.
+// Without a browser the document request is replayed and its live headers checked instead.
+func (c *Clickjacking) SetConfirm(confirm bool) {
+	c.confirm = confirm
+}
+
+// Name of the attack module
+func (c *Clickjacking) Name() string {
+	return "Clickjacking"
+}
+
+// ID unique to browserker
+func (c *Clickjacking) ID() string {
+	return "BR-A-0003"
+}
+
+// Attack inspects the captured main document response, replaying it first if confirmation is enabled
+func (c *Clickjacking) Attack(bctx *browserk.Context, replayer browserk.Replayer, result *browserk.NavigationResult) error {
+	msg := mainDocument(result)
+	if msg == nil || msg.Response == nil || msg.Response.Response == nil {
+		return nil
+	}
+
+	req := browserk.NewRequest(msg.Request)
+	if req == nil {
+		return nil
+	}
+
+	if bctx.Scope != nil && bctx.Scope.Check(req.URL) != browserk.InScope {
+		return nil
+	}
+
+	if !isSuccess(msg.Response) || !isSensitive(result, msg.Response) {
+		return nil
+	}
+
+	resp := msg.Response
+	if frameProtected(resp.Response.Headers) {
+		return nil
+	}
+
+	match := "missing X-Frame-Options and Content-Security-Policy frame-ancestors"
+	if c.confirm {
+		if prober, ok := replayer.(browserk.FrameProber); ok {
+			framable, err := prober.Framable(bctx.Ctx, req.URL)
+			if err != nil {
+				bctx.Log.Debug().Err(err).Str("url", req.URL).Msg("failed to load document in attacker frame")
+				return nil
+			}
+			if !framable {
+				return nil
+			}
+			match = "rendered in a cross origin frame"
+		} else {
+			live, err := replayer.ReplayRequest(req)
+			if err != nil {
+				bctx.Log.Debug().Err(err).Str("url", req.URL).Msg("failed to replay document request")
+				return nil
+			}
+			if live == nil || live.Response == nil || frameProtected(live.Response.Headers) {
+				return nil
+			}
+			resp = live
+		}
+	}
+
+	bctx.Reporter.Add(&browserk.Report{
+		VulnID:      c.ID(),
+		CWE:         1021,
+		Severity:    browserk.Medium,
+		Description: fmt.Sprintf("%s can be framed by any origin, users may be tricked into interacting with it", req.URL),
+		Remediation: "Set Content-Security-Policy: frame-ancestors 'self' (or X-Frame-Options: DENY/SAMEORIGIN) on the response",
+		Response:    resp,
+		Evidence: &browserk.Evidence{
+			URL:   req.URL,
+			Match: match,
+		},
+	})
+	return nil
+}
+
+// mainDocument returns the message which loaded the result's end url, or the first document
+func mainDocument(result *browserk.NavigationResult) *browserk.HTTPMessage {
+	if result == nil {
+		return nil
+	}
+
+	var first *browserk.HTTPMessage
+	for _, m := range result.Messages {
+		if m.Request == nil || m.Request.Request == nil || m.Request.Type != "Document" {
+			continue
+		}
+
+		if result.EndURL != "" && m.Request.Request.Url == result.EndURL {
+			return m
+		}
+
+		if first == nil {
+			first = m
+		}
+	}
+	return first
+}
+
+// isSensitive returns true if the document has elements a user could be tricked into interacting with
+func isSensitive(result *browserk.NavigationResult, resp *browserk.HTTPResponse) bool {
+	if sensitiveContent.MatchString(result.DOM) {
+		return true
+	}
+	body, err := resp.ReadBody()
+	return err == nil && sensitiveContent.Match(body)
+}
+
+// frameProtected returns true if the headers prevent framing by arbitrary origins
+func frameProtected(headers map[string]interface{}) bool {
+	for name, value := range headers {
+		v, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(name) {
+		case "x-frame-options":
+			option := strings.ToLower(strings.TrimSpace(v))
+			if option == "deny" || option == "sameorigin" {
+				return true
+			}
+		case "content-security-policy":
+			// multiple policies are joined by new lines in the captured headers
+			for _, policy := range strings.Split(v, "\n") {
+				if sources, ok := frameAncestors(policy); ok && !allowsAnyOrigin(sources) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// frameAncestors returns the sources of the frame-ancestors directive, if present
+func frameAncestors(policy string) ([]string, bool) {
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(strings.ToLower(directive))
+		if len(fields) > 0 && fields[0] == "frame-ancestors" {
+			return fields[1:], true
+		}
+	}
+	return nil, false
+}
+
+// allowsAnyOrigin returns true if the sources contain a wildcard or bare scheme source
+func allowsAnyOrigin(sources []string) bool {
+	for _, source := range sources {
+		switch source {
+		case "*", "http:", "https:", "http://*", "https://*":
+			return true
+		}
+	}
+	return false
+}
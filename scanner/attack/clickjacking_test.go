@@ -0,0 +1,116 @@
+package attack_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/attack"
+)
+
+func documentResult(url string, headers map[string]interface{}, body string) *browserk.NavigationResult {
+	msg := mock.MakeMockMessage("GET", url, "")
+	msg.Request.Type = "Document"
+	msg.Response = &browserk.HTTPResponse{
+		Type:     "Document",
+		Response: &gcdapi.NetworkResponse{Url: url, Status: 200, Headers: headers},
+		Body:     []byte(body),
+	}
+	result := mock.MakeMockMessagesResult(msg)
+	result.EndURL = url
+	return result
+}
+
+func TestClickjacking(t *testing.T) {
+	login := `<html><form action="/login"><input type="password" name="pass"></form></html>`
+
+	var tests = []struct {
+		name    string
+		headers map[string]interface{}
+		body    string
+		found   bool
+	}{
+		{"no headers", map[string]interface{}{}, login, true},
+		{"x-frame-options deny", map[string]interface{}{"X-Frame-Options": "DENY"}, login, false},
+		{"x-frame-options sameorigin", map[string]interface{}{"x-frame-options": "sameorigin"}, login, false},
+		{"frame-ancestors self", map[string]interface{}{"Content-Security-Policy": "default-src 'self'; frame-ancestors 'self'"}, login, false},
+		{"frame-ancestors wildcard", map[string]interface{}{"Content-Security-Policy": "frame-ancestors *"}, login, true},
+		{"csp without frame-ancestors", map[string]interface{}{"Content-Security-Policy": "default-src 'self'"}, login, true},
+		{"not sensitive", map[string]interface{}{}, "<html>hello</html>", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bctx := mock.Context(context.Background())
+			reporter := mock.MakeMockReporter()
+			bctx.Reporter = reporter
+
+			result := documentResult("http://example.com/login", tt.headers, tt.body)
+			if err := attack.NewClickjacking().Attack(bctx, attack.NewHTTPReplayer(nil), result); err != nil {
+				t.Fatalf("error attacking: %s\n", err)
+			}
+
+			if !tt.found {
+				if len(reporter.Reports) != 0 {
+					t.Fatalf("expected no findings got %d\n", len(reporter.Reports))
+				}
+				return
+			}
+
+			if len(reporter.Reports) != 1 {
+				t.Fatalf("expected 1 finding got %d\n", len(reporter.Reports))
+			}
+			report := reporter.Reports[0]
+			if report.VulnID != "BR-A-0003" || report.Severity != browserk.Medium || report.CWE != 1021 {
+				t.Fatalf("unexpected finding %#v\n", report)
+			}
+		})
+	}
+}
+
+type framingReplayer struct {
+	recordingReplayer
+	framable bool
+	framed   []string
+}
+
+func (f *framingReplayer) Framable(ctx context.Context, url string) (bool, error) {
+	f.framed = append(f.framed, url)
+	return f.framable, nil
+}
+
+func TestClickjackingConfirmFramed(t *testing.T) {
+	login := `<html><form action="/login"><input type="password" name="pass"></form></html>`
+
+	for _, framable := range []bool{true, false} {
+		bctx := mock.Context(context.Background())
+		reporter := mock.MakeMockReporter()
+		bctx.Reporter = reporter
+
+		clickjacking := attack.NewClickjacking()
+		clickjacking.SetConfirm(true)
+		replayer := &framingReplayer{framable: framable}
+		result := documentResult("http://example.com/login", map[string]interface{}{}, login)
+		if err := clickjacking.Attack(bctx, replayer, result); err != nil {
+			t.Fatalf("error attacking: %s\n", err)
+		}
+
+		if len(replayer.framed) != 1 || replayer.framed[0] != "http://example.com/login" {
+			t.Fatalf("expected the document to be loaded in a frame got %v\n", replayer.framed)
+		}
+
+		if len(replayer.requests) != 0 {
+			t.Fatalf("expected no replayed requests when a browser can frame the document got %d\n", len(replayer.requests))
+		}
+
+		if framable && len(reporter.Reports) != 1 {
+			t.Fatalf("expected 1 finding for a framable document got %d\n", len(reporter.Reports))
+		}
+
+		if !framable && len(reporter.Reports) != 0 {
+			t.Fatalf("expected no findings when the frame did not render got %d\n", len(reporter.Reports))
+		}
+	}
+}
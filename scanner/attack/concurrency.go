@@ -0,0 +1,42 @@
+package attack
+
+import (
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// replayConcurrently replays requests in batches of up to concurrency at a time through replayer,
+// passing each response to check in request order. Stops once check returns true, remaining
+// batches are not sent. Returns true if check did.
+func replayConcurrently(replayer browserk.Replayer, requests []*browserk.Request, concurrency int, check func(i int, resp *browserk.HTTPResponse, err error) bool) bool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	resps := make([]*browserk.HTTPResponse, len(requests))
+	errs := make([]error, len(requests))
+	for start := 0; start < len(requests); start += concurrency {
+		end := start + concurrency
+		if end > len(requests) {
+			end = len(requests)
+		}
+
+		wg := &sync.WaitGroup{}
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				resps[i], errs[i] = replayer.ReplayRequest(requests[i])
+			}(i)
+		}
+		wg.Wait()
+
+		for i := start; i < end; i++ {
+			if check(i, resps[i], errs[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package attack
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.com/browserker/browserk"
+)
+
+var (
+	numericID = regexp.MustCompile(`^[0-9]{1,18}$`)
+	uuidID    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// identifiers used to detect generic responses that do not depend on the requested object
+const (
+	bogusNumericID = "987654321987"
+	bogusUUID      = "00000000-0000-4000-8000-000000000000"
+)
+
+// idLocation is where an identifier was found in a request, either a parameter or a path segment
+type idLocation struct {
+	param   string // parameter name, empty if a path segment
+	segment int    // index of the path segment
+	value   string
+	numeric bool
+}
+
+// name of the location for reporting
+func (l *idLocation) name() string {
+	if l.param != "" {
+		return l.param
+	}
+	return fmt.Sprintf("path segment %d", l.segment)
+}
+
+// IDOR requests adjacent and other seen identifiers in urls and parameters, flagging successful
+// responses that differ from the original as potential insecure direct object references.
+// This module is intrusive and must be explicitly enabled.
+type IDOR struct {
+	concurrency int
+	params      *ParamFilter
+}
+
+// NewIDOR attack module
+func NewIDOR() *IDOR {
+	return &IDOR{concurrency: 1}
+}
+
+// SetPayloadConcurrency replays up to concurrency candidate identifiers for a location at once
+func (i *IDOR) SetPayloadConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	i.concurrency = concurrency
+}
+
+// SetParamFilter of parameters to leave untouched
+func (i *IDOR) SetParamFilter(params *ParamFilter) {
+	i.params = params
+}
+
+// Name of the attack module
+func (i *IDOR) Name() string {
+	return "IDOR"
+}
+
+// ID unique to browserker
+func (i *IDOR) ID() string {
+	return "BR-A-0002"
+}
+
+// Attack each identifier found in the requests captured in the result
+func (i *IDOR) Attack(bctx *browserk.Context, replayer browserk.Replayer, result *browserk.NavigationResult) error {
+	requests := i.identifiableRequests(bctx, result)
+	seen := seenIdentifiers(requests)
+
+	for _, req := range requests {
+		baseline, err := replayer.ReplayRequest(req)
+		if err != nil {
+			bctx.Log.Debug().Err(err).Str("url", req.URL).Msg("failed to replay baseline request")
+			continue
+		}
+
+		if !isSuccess(baseline) {
+			continue
+		}
+
+		for _, loc := range findIdentifiers(req) {
+			if loc.param != "" && i.params.Skip(loc.param, loc.value) {
+				bctx.Log.Debug().Str("param", loc.param).Msg("skipping parameter")
+				continue
+			}
+			i.attackIdentifier(bctx, replayer, req, loc, baseline, seen)
+		}
+	}
+	return nil
+}
+
+// attackIdentifier probes candidate identifiers for loc, reports the first one exposing different content
+func (i *IDOR) attackIdentifier(bctx *browserk.Context, replayer browserk.Replayer, req *browserk.Request, loc *idLocation, baseline *browserk.HTTPResponse, seen map[string]struct{}) bool {
+	bogus := bogusUUID
+	if loc.numeric {
+		bogus = bogusNumericID
+	}
+
+	// if a non-existent identifier is also successful, responses can't be compared
+	generic, err := replayer.ReplayRequest(withIdentifier(req, loc, bogus))
+	if err != nil {
+		bctx.Log.Debug().Err(err).Str("param", loc.name()).Msg("failed to replay bogus identifier request")
+		return false
+	}
+
+	candidates := candidateIdentifiers(loc, seen)
+	probes := make([]*browserk.Request, len(candidates))
+	for idx, candidate := range candidates {
+		probes[idx] = withIdentifier(req, loc, candidate)
+	}
+
+	return replayConcurrently(replayer, probes, i.concurrency, func(idx int, resp *browserk.HTTPResponse, err error) bool {
+		if err != nil {
+			bctx.Log.Debug().Err(err).Str("param", loc.name()).Msg("failed to replay probed identifier request")
+			return false
+		}
+
+		if !isSuccess(resp) || len(resp.Body) == 0 || bytes.Equal(resp.Body, baseline.Body) {
+			return false
+		}
+
+		if isSuccess(generic) && bytes.Equal(resp.Body, generic.Body) {
+			return false
+		}
+
+		candidate := candidates[idx]
+
+		bctx.Reporter.Add(&browserk.Report{
+			VulnID:      i.ID(),
+			CWE:         639,
+			Severity:    browserk.High,
+			Description: fmt.Sprintf("Requesting identifier %s instead of %s in %s returned different content, another user's data may be exposed", candidate, loc.value, loc.name()),
+			Remediation: "Verify the authenticated user is authorized to access the requested object",
+			Response:    resp,
+			Evidence: paramEvidence(bctx, replayer, loc.param, &browserk.Evidence{
+				URL:       req.URL,
+				Parameter: loc.name(),
+				Payload:   candidate,
+				Match:     loc.value,
+			}),
+		})
+		return true
+	})
+}
+
+// identifiableRequests returns the in scope requests containing identifiers
+func (i *IDOR) identifiableRequests(bctx *browserk.Context, result *browserk.NavigationResult) []*browserk.Request {
+	requests := make([]*browserk.Request, 0)
+	if result == nil {
+		return requests
+	}
+
+	for _, m := range result.Messages {
+		req := browserk.NewRequest(m.Request)
+		if req == nil || len(findIdentifiers(req)) == 0 {
+			continue
+		}
+
+		if bctx.Scope != nil && bctx.Scope.Check(req.URL) != browserk.InScope {
+			continue
+		}
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+// findIdentifiers returns the numeric and uuid identifiers in the request's path and parameters
+func findIdentifiers(req *browserk.Request) []*idLocation {
+	locations := make([]*idLocation, 0)
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return locations
+	}
+
+	for idx, segment := range strings.Split(u.Path, "/") {
+		if numeric, ok := isIdentifier(segment); ok {
+			locations = append(locations, &idLocation{segment: idx, value: segment, numeric: numeric})
+		}
+	}
+
+	for _, param := range req.Params() {
+		value := req.Param(param)
+		if numeric, ok := isIdentifier(value); ok {
+			locations = append(locations, &idLocation{param: param, value: value, numeric: numeric})
+		}
+	}
+	return locations
+}
+
+// isIdentifier returns if the value is an identifier and if it is numeric
+func isIdentifier(value string) (bool, bool) {
+	if numericID.MatchString(value) {
+		return true, true
+	}
+	return false, uuidID.MatchString(value)
+}
+
+// seenIdentifiers across all requests, so they can be swapped between each other
+func seenIdentifiers(requests []*browserk.Request) map[string]struct{} {
+	seen := make(map[string]struct{})
+	for _, req := range requests {
+		for _, loc := range findIdentifiers(req) {
+			seen[loc.value] = struct{}{}
+		}
+	}
+	return seen
+}
+
+// candidateIdentifiers returns adjacent identifiers (n±1) for numeric ids and other seen ids of the same type
+func candidateIdentifiers(loc *idLocation, seen map[string]struct{}) []string {
+	candidates := make([]string, 0)
+	unique := map[string]struct{}{loc.value: {}}
+	add := func(candidate string) {
+		if _, exists := unique[candidate]; !exists {
+			unique[candidate] = struct{}{}
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if loc.numeric {
+		if n, err := strconv.ParseInt(loc.value, 10, 64); err == nil {
+			add(strconv.FormatInt(n+1, 10))
+			if n > 0 {
+				add(strconv.FormatInt(n-1, 10))
+			}
+		}
+	}
+
+	others := make([]string, 0, len(seen))
+	for id := range seen {
+		if numeric, _ := isIdentifier(id); numeric == loc.numeric {
+			others = append(others, id)
+		}
+	}
+	sort.Strings(others)
+	for _, id := range others {
+		add(id)
+	}
+	return candidates
+}
+
+// withIdentifier returns a copy of req with the identifier at loc replaced with value
+func withIdentifier(req *browserk.Request, loc *idLocation, value string) *browserk.Request {
+	if loc.param != "" {
+		return req.WithParam(loc.param, value)
+	}
+
+	mutated := req.Copy()
+	u, err := url.Parse(mutated.URL)
+	if err != nil {
+		return mutated
+	}
+
+	segments := strings.Split(u.Path, "/")
+	if loc.segment < len(segments) {
+		segments[loc.segment] = value
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+	mutated.URL = u.String()
+	return mutated
+}
+
+// isSuccess returns true for 2xx responses
+func isSuccess(resp *browserk.HTTPResponse) bool {
+	return resp != nil && resp.Response != nil && resp.Response.Status >= 200 && resp.Response.Status < 300
+}
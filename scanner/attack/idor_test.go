@@ -0,0 +1,73 @@
+package attack_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/attack"
+)
+
+var idorUsers = map[string]string{
+	"1":                                    "alice@example.com",
+	"2":                                    "bob@example.com",
+	"6f1c1a2e-3b4d-4c5e-8f9a-0b1c2d3e4f5a": "carol@example.com",
+}
+
+func idorServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if strings.HasPrefix(r.URL.Path, "/users/") {
+			id = strings.TrimPrefix(r.URL.Path, "/users/")
+		}
+
+		// the profile endpoint correctly checks ownership
+		if r.URL.Path == "/profile" && id != "1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		email, ok := idorUsers[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "<html>user %s %s</html>", id, email)
+	}))
+}
+
+func TestIDOR(t *testing.T) {
+	srv := idorServer()
+	defer srv.Close()
+
+	bctx := mock.Context(context.Background())
+	reporter := mock.MakeMockReporter()
+	bctx.Reporter = reporter
+
+	result := mock.MakeMockMessagesResult(
+		mock.MakeMockMessage("GET", srv.URL+"/account?id=1", ""),
+		mock.MakeMockMessage("GET", srv.URL+"/users/6f1c1a2e-3b4d-4c5e-8f9a-0b1c2d3e4f5a", ""),
+		mock.MakeMockMessage("GET", srv.URL+"/profile?id=1", ""),
+	)
+
+	if err := attack.NewIDOR().Attack(bctx, attack.NewHTTPReplayer(nil), result); err != nil {
+		t.Fatalf("error attacking: %s\n", err)
+	}
+
+	if len(reporter.Reports) != 1 {
+		t.Fatalf("expected 1 finding got %d", len(reporter.Reports))
+	}
+
+	evidence := reporter.Reports[0].Evidence
+	if evidence.Parameter != "id" || evidence.Match != "1" || evidence.Payload != "2" {
+		t.Fatalf("expected id 1 to be probed with 2, got %#v", evidence)
+	}
+
+	if !strings.Contains(reporter.Reports[0].Description, "2 instead of 1") {
+		t.Fatalf("expected original and probed identifiers in description got %s", reporter.Reports[0].Description)
+	}
+}
@@ -0,0 +1,89 @@
+package attack
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// values at least this long with at least tokenEntropy bits per character are treated as tokens
+const (
+	tokenMinLength = 16
+	tokenEntropy   = 3.5
+)
+
+// ParamFilter decides which parameters attack modules leave untouched, such as csrf tokens and
+// session ids which break the application when mutated
+type ParamFilter struct {
+	skip []*regexp.Regexp
+}
+
+// NewParamFilter skipping parameters whose name matches one of skipParams, either a name or
+// a regular expression matched against the whole name (case insensitive)
+func NewParamFilter(skipParams []string) (*ParamFilter, error) {
+	p := &ParamFilter{skip: make([]*regexp.Regexp, 0, len(skipParams))}
+	for _, skip := range skipParams {
+		if skip = strings.TrimSpace(skip); skip == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)^(?:" + skip + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid skip parameter %s: %w", skip, err)
+		}
+		p.skip = append(p.skip, re)
+	}
+	return p, nil
+}
+
+// Skip returns true if the parameter is configured to be skipped or its value looks like a
+// random token. A nil filter only skips tokens.
+func (p *ParamFilter) Skip(name, value string) bool {
+	if p != nil {
+		for _, re := range p.skip {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return isLikelyToken(value)
+}
+
+// isLikelyToken returns true for long, high entropy values mixing letters and digits without
+// whitespace. Identifiers are not tokens, they are what IDOR probes.
+func isLikelyToken(value string) bool {
+	if len(value) < tokenMinLength || uuidID.MatchString(value) {
+		return false
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, r := range value {
+		switch {
+		case unicode.IsSpace(r):
+			return false
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasLetter && hasDigit && shannonEntropy(value) >= tokenEntropy
+}
+
+// shannonEntropy of value in bits per character
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
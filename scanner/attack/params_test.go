@@ -0,0 +1,102 @@
+package attack_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/attack"
+)
+
+func TestParamFilter(t *testing.T) {
+	params, err := attack.NewParamFilter([]string{"session_id", "x-.*"})
+	if err != nil {
+		t.Fatalf("error creating filter: %s\n", err)
+	}
+
+	var tests = []struct {
+		name  string
+		value string
+		skip  bool
+	}{
+		{"session_id", "1", true},
+		{"SESSION_ID", "1", true},
+		{"X-Token", "1", true},
+		{"id", "1", false},
+		{"q", "search terms for something long 123", false},
+		{"token", "f3Kq9ZpL2xVb7RtN8mWc4HyD", true},
+		{"user", "550e8400-e29b-41d4-a716-446655440000", false},
+		{"name", "browserkerbrowserker", false},
+	}
+
+	for _, tt := range tests {
+		if skip := params.Skip(tt.name, tt.value); skip != tt.skip {
+			t.Fatalf("expected skip %v for %s=%s got %v", tt.skip, tt.name, tt.value, skip)
+		}
+	}
+
+	if _, err := attack.NewParamFilter([]string{"("}); err == nil {
+		t.Fatalf("expected invalid regular expression to fail")
+	}
+}
+
+// recordingReplayer keeps every replayed request
+type recordingReplayer struct {
+	requests []*browserk.Request
+}
+
+func (r *recordingReplayer) ReplayRequest(req *browserk.Request) (*browserk.HTTPResponse, error) {
+	r.requests = append(r.requests, req)
+	return &browserk.HTTPResponse{Body: []byte("<html>ok</html>")}, nil
+}
+
+func TestSQLiErrorSkipsParams(t *testing.T) {
+	var tests = []struct {
+		name       string
+		token      string
+		skipParams []string
+	}{
+		{"configured", "abc", []string{"csrf_.*"}},
+		{"entropy", "f3Kq9ZpL2xVb7RtN8mWc4HyD", nil},
+	}
+
+	for _, tt := range tests {
+		bctx := mock.Context(context.Background())
+		bctx.Reporter = mock.MakeMockReporter()
+
+		module, err := attack.NewSQLiError(staticPayloads{"'", "\""})
+		if err != nil {
+			t.Fatalf("error creating module: %s\n", err)
+		}
+		module.SetTimeDelay(0)
+
+		params, err := attack.NewParamFilter(tt.skipParams)
+		if err != nil {
+			t.Fatalf("error creating filter: %s\n", err)
+		}
+		module.SetParamFilter(params)
+
+		result := mock.MakeMockMessagesResult(mock.MakeMockMessage("GET", "http://example.com/product?id=1&csrf_token="+tt.token, ""))
+		replayer := &recordingReplayer{}
+		if err := module.Attack(bctx, replayer, result); err != nil {
+			t.Fatalf("error attacking: %s\n", err)
+		}
+
+		// baseline and both payloads into id
+		if len(replayer.requests) != 3 {
+			t.Fatalf("%s: expected 3 requests got %d", tt.name, len(replayer.requests))
+		}
+
+		for _, req := range replayer.requests {
+			u, err := url.Parse(req.URL)
+			if err != nil {
+				t.Fatalf("error parsing %s: %s\n", req.URL, err)
+			}
+			if u.Query().Get("csrf_token") != tt.token {
+				t.Fatalf("%s: expected csrf_token to not be injected got %s", tt.name, req.URL)
+			}
+		}
+	}
+}
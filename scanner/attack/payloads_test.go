@@ -0,0 +1,51 @@
+package attack_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/scanner/attack"
+)
+
+func TestFilePayloadProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payloads")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	custom := "<custom>\n\n\"><custom2>\r\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "xss.txt"), []byte(custom), 0644); err != nil {
+		t.Fatalf("error writing payloads: %s\n", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "ssti.txt"), []byte("{{7*7}}\n"), 0644); err != nil {
+		t.Fatalf("error writing payloads: %s\n", err)
+	}
+
+	provider := attack.NewFilePayloadProvider(dir)
+	if err := provider.Init(); err != nil {
+		t.Fatalf("error loading payloads: %s\n", err)
+	}
+
+	xss := provider.Payloads(browserk.PayloadXSS)
+	if len(xss) != 2 || xss[0] != "<custom>" || xss[1] != "\"><custom2>" {
+		t.Fatalf("expected custom xss payloads got %#v", xss)
+	}
+
+	if ssti := provider.Payloads("ssti"); len(ssti) != 1 || ssti[0] != "{{7*7}}" {
+		t.Fatalf("expected new category to be loaded got %#v", ssti)
+	}
+
+	sqli := provider.Payloads(browserk.PayloadSQLi)
+	if len(sqli) != len(attack.DefaultPayloads[browserk.PayloadSQLi]) {
+		t.Fatalf("expected default sqli payloads got %#v", sqli)
+	}
+
+	if provider.Payloads("unknown") != nil {
+		t.Fatalf("expected nil for unknown category")
+	}
+}
@@ -0,0 +1,77 @@
+package attack
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+)
+
+// HTTPReplayer replays requests directly with an http.Client, outside of the browser
+type HTTPReplayer struct {
+	client *http.Client
+}
+
+// NewHTTPReplayer using client, if client is nil http.DefaultClient is used
+func NewHTTPReplayer(client *http.Client) *HTTPReplayer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPReplayer{client: client}
+}
+
+// ReplayRequest sends the request and captures the response
+func (h *HTTPReplayer) ReplayRequest(req *browserk.Request) (*browserk.HTTPResponse, error) {
+	var body *strings.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	} else {
+		body = strings.NewReader("")
+	}
+
+	httpReq, err := http.NewRequest(req.Method, req.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range req.Headers {
+		if strings.EqualFold(k, "content-length") {
+			continue
+		}
+		if strings.EqualFold(k, "host") {
+			httpReq.Host = v
+			continue
+		}
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]interface{}, len(resp.Header))
+	for k := range resp.Header {
+		headers[k] = resp.Header.Get(k)
+	}
+
+	return &browserk.HTTPResponse{
+		Type: "Other",
+		Response: &gcdapi.NetworkResponse{
+			Url:        req.URL,
+			Status:     resp.StatusCode,
+			StatusText: http.StatusText(resp.StatusCode),
+			Headers:    headers,
+			MimeType:   resp.Header.Get("Content-Type"),
+		},
+		Body: respBody,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package attack
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// SQLiError injects SQL breaking payloads into parameters and looks for database
+// error messages in the responses
+type SQLiError struct {
+	payloads    browserk.PayloadProvider
+	signatures  []*regexp.Regexp
+	timeDelay   time.Duration
+	concurrency int
+	params      *ParamFilter
+}
+
+// NewSQLiError attack module, signatures are loaded from the payload provider's sqli_errors category
+func NewSQLiError(payloads browserk.PayloadProvider) (*SQLiError, error) {
+	s := &SQLiError{payloads: payloads, timeDelay: time.Second * 5, concurrency: 1}
+	for _, signature := range payloads.Payloads(browserk.PayloadSQLiError) {
+		re, err := regexp.Compile(signature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sql error signature %s: %w", signature, err)
+		}
+		s.signatures = append(s.signatures, re)
+	}
+	return s, nil
+}
+
+// SetTimeDelay used for confirming time based blind injections, 0 disables time based checks
+func (s *SQLiError) SetTimeDelay(delay time.Duration) {
+	s.timeDelay = delay
+}
+
+// SetPayloadConcurrency replays up to concurrency error payloads for a parameter at once, time based
+// payloads are always sent one at a time so responses aren't slowed by each other
+func (s *SQLiError) SetPayloadConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s.concurrency = concurrency
+}
+
+// SetParamFilter of parameters to leave untouched, by default only token like values are skipped
+func (s *SQLiError) SetParamFilter(params *ParamFilter) {
+	s.params = params
+}
+
+// Name of the attack module
+func (s *SQLiError) Name() string {
+	return "SQLiError"
+}
+
+// ID unique to browserker
+func (s *SQLiError) ID() string {
+	return "BR-A-0001"
+}
+
+// Attack each parameter of the requests captured in the result
+func (s *SQLiError) Attack(bctx *browserk.Context, replayer browserk.Replayer, result *browserk.NavigationResult) error {
+	for _, req := range attackableRequests(bctx, result) {
+		baseline, err := replayer.ReplayRequest(req)
+		if err != nil {
+			bctx.Log.Debug().Err(err).Str("url", req.URL).Msg("failed to replay baseline request")
+			continue
+		}
+		baselineMatch := s.match(baseline.Body)
+
+		for _, param := range req.Params() {
+			if s.params.Skip(param, req.Param(param)) {
+				bctx.Log.Debug().Str("param", param).Msg("skipping parameter")
+				continue
+			}
+
+			if s.attackErrors(bctx, replayer, req, param, baselineMatch) {
+				continue
+			}
+			s.attackTime(bctx, replayer, req, param)
+		}
+	}
+	return nil
+}
+
+// attackErrors injects sql breaking payloads looking for database errors, returns true if found
+func (s *SQLiError) attackErrors(bctx *browserk.Context, replayer browserk.Replayer, req *browserk.Request, param, baselineMatch string) bool {
+	original := req.Param(param)
+	payloads := s.payloads.Payloads(browserk.PayloadSQLi)
+	injected := make([]*browserk.Request, len(payloads))
+	for i, payload := range payloads {
+		injected[i] = req.WithParam(param, original+payload)
+	}
+
+	return replayConcurrently(replayer, injected, s.concurrency, func(i int, resp *browserk.HTTPResponse, err error) bool {
+		if err != nil {
+			bctx.Log.Debug().Err(err).Str("param", param).Msg("failed to replay injected request")
+			return false
+		}
+
+		match := s.match(resp.Body)
+		if match == "" || match == baselineMatch {
+			return false
+		}
+
+		payload := payloads[i]
+
+		bctx.Reporter.Add(&browserk.Report{
+			VulnID:      s.ID(),
+			CWE:         89,
+			Severity:    browserk.High,
+			Description: fmt.Sprintf("Database error %q returned after injecting %q into parameter %s", match, payload, param),
+			Remediation: "Use parameterized queries and do not return database errors to users",
+			Response:    resp,
+			Evidence: paramEvidence(bctx, replayer, param, &browserk.Evidence{
+				URL:       req.URL,
+				Parameter: param,
+				Payload:   payload,
+				Match:     match,
+			}),
+		})
+		return true
+	})
+}
+
+// attackTime injects time delay payloads to confirm blind injections
+func (s *SQLiError) attackTime(bctx *browserk.Context, replayer browserk.Replayer, req *browserk.Request, param string) bool {
+	if s.timeDelay <= 0 {
+		return false
+	}
+
+	for _, payload := range s.payloads.Payloads(browserk.PayloadSQLiTime) {
+		payload = DelayPayload(payload, s.timeDelay)
+		confirmed, err := ConfirmTimeDelay(replayer, req, param, payload, s.timeDelay)
+		if err != nil {
+			bctx.Log.Debug().Err(err).Str("param", param).Msg("failed to measure response time")
+			continue
+		}
+
+		if !confirmed {
+			continue
+		}
+
+		bctx.Reporter.Add(&browserk.Report{
+			VulnID:      s.ID(),
+			CWE:         89,
+			Severity:    browserk.High,
+			Description: fmt.Sprintf("Response delayed by %s after injecting %q into parameter %s", s.timeDelay, payload, param),
+			Remediation: "Use parameterized queries",
+			Evidence: paramEvidence(bctx, replayer, param, &browserk.Evidence{
+				URL:       req.URL,
+				Parameter: param,
+				Payload:   payload,
+				Match:     "time delay",
+			}),
+		})
+		return true
+	}
+	return false
+}
+
+// match returns the first matching signature in body or an empty string
+func (s *SQLiError) match(body []byte) string {
+	for _, re := range s.signatures {
+		if found := re.Find(body); found != nil {
+			return string(found)
+		}
+	}
+	return ""
+}
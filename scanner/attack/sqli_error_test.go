@@ -0,0 +1,192 @@
+package attack_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/attack"
+)
+
+func sqliServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if strings.Contains(r.Form.Get("id"), "'") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("<html>You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version</html>"))
+			return
+		}
+		w.Write([]byte("<html>product 1</html>"))
+	}))
+}
+
+func TestSQLiError(t *testing.T) {
+	srv := sqliServer()
+	defer srv.Close()
+
+	bctx := mock.Context(context.Background())
+	reporter := mock.MakeMockReporter()
+	bctx.Reporter = reporter
+
+	module, err := attack.NewSQLiError(attack.NewFilePayloadProvider(""))
+	if err != nil {
+		t.Fatalf("error creating module: %s\n", err)
+	}
+
+	result := mock.MakeMockMessagesResult(
+		mock.MakeMockMessage("GET", srv.URL+"/product?id=1&sort=asc", ""),
+		mock.MakeMockMessage("POST", srv.URL+"/product", "id=2"),
+	)
+
+	if err := module.Attack(bctx, attack.NewHTTPReplayer(nil), result); err != nil {
+		t.Fatalf("error attacking: %s\n", err)
+	}
+
+	if len(reporter.Reports) != 2 {
+		t.Fatalf("expected 2 findings got %d", len(reporter.Reports))
+	}
+
+	for _, report := range reporter.Reports {
+		if report.Severity != browserk.High {
+			t.Fatalf("expected high severity got %v", report.Severity)
+		}
+		if report.Evidence.Parameter != "id" {
+			t.Fatalf("expected id parameter got %s", report.Evidence.Parameter)
+		}
+		if !strings.Contains(report.Evidence.Payload, "'") {
+			t.Fatalf("expected quote payload got %s", report.Evidence.Payload)
+		}
+		if report.Evidence.Match != "You have an error in your SQL syntax" {
+			t.Fatalf("expected mysql signature got %s", report.Evidence.Match)
+		}
+	}
+}
+
+// locatingReplayer replays directly and reports a fixed ancestry for each selector
+type locatingReplayer struct {
+	browserk.Replayer
+	selectors []string
+}
+
+func (l *locatingReplayer) ElementAncestry(selector string) ([]string, error) {
+	l.selectors = append(l.selectors, selector)
+	return []string{"html", "body", "form#search", "input"}, nil
+}
+
+func (l *locatingReplayer) ElementEvidence(selector string) (*browserk.Evidence, error) {
+	return &browserk.Evidence{HTML: `<input name="id">`, Screenshot: []byte("png")}, nil
+}
+
+func TestSQLiErrorAttachesAncestry(t *testing.T) {
+	srv := sqliServer()
+	defer srv.Close()
+
+	bctx := mock.Context(context.Background())
+	reporter := mock.MakeMockReporter()
+	bctx.Reporter = reporter
+
+	module, err := attack.NewSQLiError(attack.NewFilePayloadProvider(""))
+	if err != nil {
+		t.Fatalf("error creating module: %s\n", err)
+	}
+
+	result := mock.MakeMockMessagesResult(mock.MakeMockMessage("GET", srv.URL+"/product?id=1", ""))
+	replayer := &locatingReplayer{Replayer: attack.NewHTTPReplayer(nil)}
+	if err := module.Attack(bctx, replayer, result); err != nil {
+		t.Fatalf("error attacking: %s\n", err)
+	}
+
+	if len(reporter.Reports) != 1 {
+		t.Fatalf("expected 1 finding got %d", len(reporter.Reports))
+	}
+
+	if len(replayer.selectors) != 1 || replayer.selectors[0] != `[name="id"]` {
+		t.Fatalf("expected ancestry of the id field to be located got %v", replayer.selectors)
+	}
+
+	if strings.Join(reporter.Reports[0].Evidence.Ancestry, " > ") != "html > body > form#search > input" {
+		t.Fatalf("expected ancestry on finding got %v", reporter.Reports[0].Evidence.Ancestry)
+	}
+
+	if reporter.Reports[0].Evidence.HTML != `<input name="id">` || string(reporter.Reports[0].Evidence.Screenshot) != "png" {
+		t.Fatalf("expected the field's html and screenshot on finding got %+v", reporter.Reports[0].Evidence)
+	}
+
+	if reporter.Reports[0].Evidence.URL == "" || reporter.Reports[0].Evidence.Parameter != "id" {
+		t.Fatalf("expected captured evidence to keep the request's url and parameter got %+v", reporter.Reports[0].Evidence)
+	}
+}
+
+// staticPayloads provides the same payloads for every category
+type staticPayloads []string
+
+func (s staticPayloads) Payloads(category string) []string {
+	if category == browserk.PayloadSQLi {
+		return s
+	}
+	return nil
+}
+
+// countingReplayer records the most requests in flight at once
+type countingReplayer struct {
+	lock        sync.Mutex
+	inFlight    int
+	maxInFlight int
+	replayed    int
+}
+
+func (c *countingReplayer) ReplayRequest(req *browserk.Request) (*browserk.HTTPResponse, error) {
+	c.lock.Lock()
+	c.inFlight++
+	c.replayed++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.lock.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	c.lock.Lock()
+	c.inFlight--
+	c.lock.Unlock()
+	return &browserk.HTTPResponse{Body: []byte("<html>product 1</html>")}, nil
+}
+
+func TestSQLiErrorPayloadConcurrency(t *testing.T) {
+	bctx := mock.Context(context.Background())
+	reporter := mock.MakeMockReporter()
+	bctx.Reporter = reporter
+
+	payloads := staticPayloads{"'", "\"", "')", "\")", "`", "';--", "\";--"}
+	module, err := attack.NewSQLiError(payloads)
+	if err != nil {
+		t.Fatalf("error creating module: %s\n", err)
+	}
+	module.SetTimeDelay(0)
+	module.SetPayloadConcurrency(3)
+
+	result := mock.MakeMockMessagesResult(mock.MakeMockMessage("GET", "http://example.com/product?id=1", ""))
+	replayer := &countingReplayer{}
+	if err := module.Attack(bctx, replayer, result); err != nil {
+		t.Fatalf("error attacking: %s\n", err)
+	}
+
+	if replayer.maxInFlight != 3 {
+		t.Fatalf("expected 3 payloads in flight at once got %d", replayer.maxInFlight)
+	}
+
+	// baseline plus every payload
+	if replayer.replayed != len(payloads)+1 {
+		t.Fatalf("expected %d requests got %d", len(payloads)+1, replayer.replayed)
+	}
+
+	if len(reporter.Reports) != 0 {
+		t.Fatalf("expected no findings got %d", len(reporter.Reports))
+	}
+}
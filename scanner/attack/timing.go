@@ -0,0 +1,77 @@
+package attack
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// TimingSamples is the number of requests sent both with and without a payload when measuring response times
+var TimingSamples = 3
+
+// delayToken is replaced in time based payloads with the number of seconds to delay
+const delayToken = "{delay}"
+
+// MeasureResponseTime replays req with and without payload appended to param and returns the
+// difference of the median response times. Requests are interleaved to reduce noise from changes
+// in server load.
+func MeasureResponseTime(replayer browserk.Replayer, req *browserk.Request, param, payload string) (time.Duration, error) {
+	injected := req.WithParam(param, req.Param(param)+payload)
+
+	baseline := make([]time.Duration, 0, TimingSamples)
+	delayed := make([]time.Duration, 0, TimingSamples)
+	for i := 0; i < TimingSamples; i++ {
+		took, err := timeRequest(replayer, req)
+		if err != nil {
+			return 0, err
+		}
+		baseline = append(baseline, took)
+
+		took, err = timeRequest(replayer, injected)
+		if err != nil {
+			return 0, err
+		}
+		delayed = append(delayed, took)
+	}
+	return median(delayed) - median(baseline), nil
+}
+
+// ConfirmTimeDelay returns true if injecting payload into param delays the response by at least 80% of expected
+func ConfirmTimeDelay(replayer browserk.Replayer, req *browserk.Request, param, payload string, expected time.Duration) (bool, error) {
+	delta, err := MeasureResponseTime(replayer, req, param, payload)
+	if err != nil {
+		return false, err
+	}
+	return delta >= expected*8/10, nil
+}
+
+// DelayPayload replaces the {delay} token in payload with the delay in seconds
+func DelayPayload(payload string, delay time.Duration) string {
+	return strings.Replace(payload, delayToken, strconv.Itoa(int(delay.Seconds())), -1)
+}
+
+func timeRequest(replayer browserk.Replayer, req *browserk.Request) (time.Duration, error) {
+	start := time.Now()
+	if _, err := replayer.ReplayRequest(req); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,49 @@
+package attack_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/scanner/attack"
+)
+
+func TestMeasureResponseTime(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("q"), "SLEEP") {
+			time.Sleep(time.Millisecond * 300)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	replayer := attack.NewHTTPReplayer(nil)
+	req := &browserk.Request{Method: "GET", URL: srv.URL + "/search?q=test", Headers: map[string]string{}}
+
+	delta, err := attack.MeasureResponseTime(replayer, req, "q", "' AND SLEEP(1)-- ")
+	if err != nil {
+		t.Fatalf("error measuring response time: %s\n", err)
+	}
+
+	if delta < time.Millisecond*250 {
+		t.Fatalf("expected delta of at least 250ms got %s", delta)
+	}
+
+	confirmed, err := attack.ConfirmTimeDelay(replayer, req, "q", "' AND BENCHMARK(1)-- ", time.Millisecond*300)
+	if err != nil {
+		t.Fatalf("error confirming time delay: %s\n", err)
+	}
+
+	if confirmed {
+		t.Fatalf("payload without delay should not be confirmed")
+	}
+}
+
+func TestDelayPayload(t *testing.T) {
+	if p := attack.DelayPayload("' AND SLEEP({delay})-- ", time.Second*5); p != "' AND SLEEP(5)-- " {
+		t.Fatalf("expected delay to be replaced got %s", p)
+	}
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"github.com/pkg/errors"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/scanner/browser"
+)
+
+// ScriptLogin navigates to loginURL and runs the login script. The script may return a promise
+// which resolves once the login completes.
+func ScriptLogin(loginURL, script string) browser.LoginFunc {
+	return func(ctx *browserk.Context, tab *browser.Tab) error {
+		if err := tab.Navigate(ctx.Ctx, loginURL); err != nil {
+			return errors.Wrap(err, "failed to navigate to login url")
+		}
+
+		if _, err := tab.EvaluatePromiseScript(script); err != nil {
+			return errors.Wrap(err, "failed to run login script")
+		}
+		return nil
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"gitlab.com/browserker/browserk"
 )
@@ -24,6 +25,18 @@ type Container struct {
 
 	consoleLock   sync.RWMutex
 	consoleEvents []*browserk.ConsoleEvent
+
+	networkLock    sync.Mutex
+	timings        map[string]*requestTiming // by request id, until both the response and loadingFinished were seen
+	networkSummary browserk.NetworkSummary
+}
+
+// requestTiming of a request, either the response or loadingFinished event may be seen first
+type requestTiming struct {
+	url      string
+	sent     float64 // monotonic seconds the request was sent
+	finished float64 // monotonic seconds the request finished loading
+	bytes    int64
 }
 
 // NewContainer for holding request/responses, storage and console events
@@ -33,9 +46,73 @@ func NewContainer() *Container {
 		messages:      make(map[string]*browserk.HTTPMessage),
 		respReady:     make(map[string]chan struct{}),
 		storageEvents: make([]*browserk.StorageEvent, 0),
+		timings:       make(map[string]*requestTiming),
+	}
+}
+
+// ResponseTiming records when the request of a received response was sent
+func (c *Container) ResponseTiming(requestID, url string, sent float64) {
+	c.networkLock.Lock()
+	defer c.networkLock.Unlock()
+
+	timing, ok := c.timings[requestID]
+	if !ok {
+		c.timings[requestID] = &requestTiming{url: url, sent: sent}
+		return
+	}
+	timing.url = url
+	timing.sent = sent
+	c.addTiming(requestID, timing)
+}
+
+// FinishedTiming records when the request finished loading and the bytes it received
+func (c *Container) FinishedTiming(requestID string, finished float64, bytes int64) {
+	c.networkLock.Lock()
+	defer c.networkLock.Unlock()
+
+	timing, ok := c.timings[requestID]
+	if !ok {
+		c.timings[requestID] = &requestTiming{finished: finished, bytes: bytes}
+		return
+	}
+	timing.finished = finished
+	timing.bytes = bytes
+	c.addTiming(requestID, timing)
+}
+
+// FailedTiming drops the timing of a request which failed to load, it never finishes loading
+func (c *Container) FailedTiming(requestID string) {
+	c.networkLock.Lock()
+	defer c.networkLock.Unlock()
+	delete(c.timings, requestID)
+}
+
+// addTiming of a request which both has a response and finished loading to the summary,
+// networkLock must be held
+func (c *Container) addTiming(requestID string, timing *requestTiming) {
+	delete(c.timings, requestID)
+
+	c.networkSummary.RequestCount++
+	c.networkSummary.TotalBytes += timing.bytes
+	elapsed := time.Duration((timing.finished - timing.sent) * float64(time.Second))
+	if c.networkSummary.SlowestURL == "" || elapsed > c.networkSummary.SlowestTime {
+		c.networkSummary.SlowestURL = timing.url
+		c.networkSummary.SlowestTime = elapsed
 	}
 }
 
+// GetNetworkSummary of requests finished since the last call and clear it, timings of requests
+// still in flight are dropped so they are not attributed to the next summary
+func (c *Container) GetNetworkSummary() browserk.NetworkSummary {
+	c.networkLock.Lock()
+	defer c.networkLock.Unlock()
+
+	summary := c.networkSummary
+	c.networkSummary = browserk.NetworkSummary{}
+	c.timings = make(map[string]*requestTiming)
+	return summary
+}
+
 // AddStorageEvent to the container
 func (c *Container) AddStorageEvent(evt *browserk.StorageEvent) {
 	c.storageLock.Lock()
@@ -0,0 +1,37 @@
+package browser_test
+
+import (
+	"testing"
+
+	"gitlab.com/browserker/scanner/browser"
+)
+
+func TestContainerNetworkSummary(t *testing.T) {
+	c := browser.NewContainer()
+
+	c.ResponseTiming("1", "http://localhost/slow", 1)
+	c.FinishedTiming("1", 3, 100)
+	c.ResponseTiming("2", "http://localhost/fast", 1)
+	c.FinishedTiming("2", 1.5, 50)
+
+	c.ResponseTiming("failed", "http://localhost/failed", 1)
+	c.FailedTiming("failed")
+	if c.PendingTimings() != 0 {
+		t.Fatalf("expected the failed request's timing to be dropped got %d pending", c.PendingTimings())
+	}
+
+	c.ResponseTiming("inflight", "http://localhost/inflight", 1)
+	summary := c.GetNetworkSummary()
+	if summary.RequestCount != 2 || summary.TotalBytes != 150 || summary.SlowestURL != "http://localhost/slow" {
+		t.Fatalf("unexpected summary %#v", summary)
+	}
+
+	if c.PendingTimings() != 0 {
+		t.Fatalf("expected in flight timings to be cleared with the summary got %d pending", c.PendingTimings())
+	}
+
+	c.FinishedTiming("inflight", 2, 10)
+	if summary := c.GetNetworkSummary(); summary.RequestCount != 0 {
+		t.Fatalf("expected a request in flight during the last summary not to be counted got %#v", summary)
+	}
+}
@@ -22,8 +22,10 @@ func ElementToHTMLElement(ele *Element) *browserk.HTMLElement {
 	b.NodeDepth = ele.Depth()
 	listeners, err := ele.GetEventListeners()
 	b.InnerText = ele.GetInnerText()
+	b.Selector, _ = ele.CSSSelector()
 
 	if err == nil {
+		b.Listeners = countListenerTypes(listeners)
 		for _, listener := range listeners {
 			eventType, ok := browserk.HTMLEventTypeMap[listener.Type]
 			if !ok {
@@ -193,6 +195,28 @@ func GCDCookieToBrowserk(gcdCookie []*gcdapi.NetworkCookie) []*browserk.Cookie {
 	return cookies
 }
 
+// BrowserkCookieToGCD browserk.Cookie -> NetworkCookieParam for setting cookies, session
+// cookies are set without an expiration
+func BrowserkCookieToGCD(cookies []*browserk.Cookie) []*gcdapi.NetworkCookieParam {
+	params := make([]*gcdapi.NetworkCookieParam, len(cookies))
+	for i, c := range cookies {
+		params[i] = &gcdapi.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+			SameSite: c.SameSite,
+			Priority: c.Priority,
+		}
+		if !c.Session {
+			params[i].Expires = c.Expires
+		}
+	}
+	return params
+}
+
 // RedirectResponseToNetworkResponse NetworkRequestWillBeSentEvent (RedirectResponse) -> NetworkResponseReceivedEvent
 func RedirectResponseToNetworkResponse(req *gcdapi.NetworkRequestWillBeSentEvent) *gcdapi.NetworkResponseReceivedEvent {
 	p := req.Params
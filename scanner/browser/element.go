@@ -1,12 +1,16 @@
 package browser
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
 	"gitlab.com/browserker/scanner/browser/keymap"
 )
 
@@ -45,6 +49,14 @@ func (e *ErrElementNotReady) Error() string {
 	return "this element is not ready"
 }
 
+// ErrElementNotEnabled when an element is still disabled after waiting for it to be enabled
+type ErrElementNotEnabled struct {
+}
+
+func (e *ErrElementNotEnabled) Error() string {
+	return "this element is not enabled"
+}
+
 // ErrInvalidDimensions when the dimensions of an element are incorrect to calculate the centroid
 type ErrInvalidDimensions struct {
 	Message string
@@ -54,12 +66,46 @@ func (e *ErrInvalidDimensions) Error() string {
 	return "invalid dimensions " + e.Message
 }
 
+// ErrInvalidScrollBlock when a scroll alignment other than start, center, end or nearest is used
+type ErrInvalidScrollBlock struct {
+	Block string
+}
+
+func (e *ErrInvalidScrollBlock) Error() string {
+	return "invalid scroll block " + e.Block + ", expected start, center, end or nearest"
+}
+
+// CDP error messages returned when a node was removed between resolving and using it
+var nodeNotFoundMessages = []string{
+	"Node with given id does not exist",
+	"Could not find node with given id",
+	"No node with given id found",
+}
+
+// NodeError normalizes CDP node not found errors to ErrInvalidElement, other errors are returned as is
+func NodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, msg := range nodeNotFoundMessages {
+		if strings.Contains(err.Error(), msg) {
+			return &ErrInvalidElement{}
+		}
+	}
+	return err
+}
+
 // Element is an abstraction over a DOM element, it can be in three modes
 // NotReady - it's data has not been returned to us by the debugger yet.
 // Ready - the debugger has given us the DOMNode reference.
 // Invalidated - The Element has been destroyed.
 // Certain actions require that the Element be populated (getting nodename/type)
 // If you need this information, wait for IsReady() to return true
+//
+// Lock ordering: the tab may lock elements while holding its own locks (dispatching DOM
+// events), so the element lock must never be held while calling into the tab. Copy what is
+// needed under the lock, release it, then make the debugger call.
 type Element struct {
 	lock           *sync.RWMutex     // for protecting read/write access to this Element
 	attributes     map[string]string // dom attributes
@@ -74,6 +120,9 @@ type Element struct {
 	ID             int               // nodeId in chrome
 	ready          bool              // has this elements data been populated by setChildNodes or GetDocument?
 	invalidated    bool              // has this node been invalidated (removed?)
+	box            []float64         // cached content quad from the box model, nil if not fetched or invalidated
+	boxGeneration  int64             // the tab's geometry generation when box was fetched
+	boxRequests    int               // number of box model requests made, for testing
 }
 
 func newElement(tab *Tab, nodeID, depth int) *Element {
@@ -110,7 +159,7 @@ func (e *Element) populateElement(node *gcdapi.DOMNode, depth int) {
 	if node.NodeType == int(NodeText) {
 		e.characterData = node.NodeValue
 	}
-
+	ready := e.ready
 	e.lock.Unlock()
 
 	for i := 0; i < len(node.Attributes); i += 2 {
@@ -118,7 +167,7 @@ func (e *Element) populateElement(node *gcdapi.DOMNode, depth int) {
 	}
 
 	// close it
-	if !e.ready {
+	if !ready {
 		close(e.readyGate)
 	}
 	e.lock.Lock()
@@ -183,18 +232,30 @@ func (e *Element) WaitForReady() error {
 	}
 }
 
+// nodeError normalizes err with NodeError, invalidating the element if the node no longer exists.
+// Must not be called while holding the element lock.
+func (e *Element) nodeError(err error) error {
+	err = NodeError(err)
+	if _, ok := err.(*ErrInvalidElement); ok {
+		e.setInvalidated(true)
+	}
+	return err
+}
+
 // GetSource returns the outer html of the element.
 func (e *Element) GetSource() (string, error) {
 	e.lock.RLock()
 	id := e.ID
+	invalidated := e.invalidated
 	e.lock.RUnlock()
 
-	if e.invalidated {
+	if invalidated {
 		return "", &ErrInvalidElement{}
 	}
 
 	outerParams := &gcdapi.DOMGetOuterHTMLParams{NodeId: id}
-	return e.tab.t.DOM.GetOuterHTMLWithParams(outerParams)
+	source, err := e.tab.t.DOM.GetOuterHTMLWithParams(outerParams)
+	return source, e.nodeError(err)
 }
 
 // IsDocument Is this Element a #document?
@@ -261,7 +322,7 @@ func (e *Element) GetEventListeners() ([]*gcdapi.DOMDebuggerEventListener, error
 
 	rro, err := e.tab.t.DOM.ResolveNodeWithParams(params)
 	if err != nil {
-		return nil, err
+		return nil, e.nodeError(err)
 	}
 	eventListeners, err := e.tab.t.DOMDebugger.GetEventListeners(rro.ObjectId, 1, false)
 	if err != nil {
@@ -270,6 +331,73 @@ func (e *Element) GetEventListeners() ([]*gcdapi.DOMDebuggerEventListener, error
 	return eventListeners, nil
 }
 
+// ListenerTypes returns the number of event listeners bound to the element by event type
+// (click, submit, keydown etc), used to prioritize which elements to interact with.
+func (e *Element) ListenerTypes() (map[string]int, error) {
+	listeners, err := e.GetEventListeners()
+	if err != nil {
+		return nil, err
+	}
+	return countListenerTypes(listeners), nil
+}
+
+// countListenerTypes by event type
+func countListenerTypes(listeners []*gcdapi.DOMDebuggerEventListener) map[string]int {
+	counts := make(map[string]int)
+	for _, listener := range listeners {
+		counts[listener.Type]++
+	}
+	return counts
+}
+
+// callFunctionOn resolves this element to a remote object and calls the functionDeclaration
+// with this bound to the element, the result is returned by value.
+func (e *Element) callFunctionOn(functionDeclaration string, args ...interface{}) (*gcdapi.RuntimeRemoteObject, error) {
+	return e.callFunction(functionDeclaration, true, args...)
+}
+
+// callFunction is callFunctionOn, returning the result by reference if returnByValue is false.
+// Callers release results returned by reference (see elementFromObjectID).
+func (e *Element) callFunction(functionDeclaration string, returnByValue bool, args ...interface{}) (*gcdapi.RuntimeRemoteObject, error) {
+	e.lock.RLock()
+	id := e.ID
+	invalidated := e.invalidated
+	e.lock.RUnlock()
+
+	if invalidated {
+		return nil, &ErrInvalidElement{}
+	}
+
+	rro, err := e.tab.t.DOM.ResolveNodeWithParams(&gcdapi.DOMResolveNodeParams{NodeId: id})
+	if err != nil {
+		return nil, e.nodeError(err)
+	}
+
+	callArgs := make([]*gcdapi.RuntimeCallArgument, len(args))
+	for i, arg := range args {
+		callArgs[i] = &gcdapi.RuntimeCallArgument{Value: arg}
+	}
+
+	params := &gcdapi.RuntimeCallFunctionOnParams{
+		FunctionDeclaration: functionDeclaration,
+		ObjectId:            rro.ObjectId,
+		Arguments:           callArgs,
+		Silent:              true,
+		ReturnByValue:       returnByValue,
+		UserGesture:         e.tab.inUserGesture(),
+		ObjectGroup:         "browserker",
+	}
+	result, exp, err := e.tab.t.Runtime.CallFunctionOnWithParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp != nil {
+		return nil, &ErrScriptEvaluation{Message: "failed to call function on element", ExceptionText: exp.Text, ExceptionDetails: exp}
+	}
+	return result, nil
+}
+
 // GetDebuggerDOMNode returns the underlying DOMNode for this element. Note this is potentially
 // unsafe to access as we give up the ability to lock.
 func (e *Element) GetDebuggerDOMNode() (*gcdapi.DOMNode, error) {
@@ -291,6 +419,7 @@ func (e *Element) updateAttribute(name, value string) {
 	defer e.lock.Unlock()
 
 	e.attributes[name] = value
+	e.box = nil
 }
 
 // removes the attribute from our attributes list.
@@ -299,6 +428,7 @@ func (e *Element) removeAttribute(name string) {
 	defer e.lock.Unlock()
 
 	delete(e.attributes, name)
+	e.box = nil
 }
 
 // updates character data
@@ -331,6 +461,7 @@ func (e *Element) addChild(child *gcdapi.DOMNode) {
 	}
 	e.node.Children = append(e.node.Children, child)
 	e.childNodeCount++
+	e.box = nil
 }
 
 // adds the children to our DOMNode
@@ -358,6 +489,7 @@ func (e *Element) removeChild(removedNodeID int) {
 		if child != nil && child.NodeId == removedNodeID {
 			e.node.Children = append(e.node.Children[:idx], e.node.Children[idx+1:]...)
 			e.childNodeCount = e.childNodeCount - 1
+			e.box = nil
 			break
 		}
 	}
@@ -383,6 +515,108 @@ func (e *Element) GetChildNodeIds() ([]int, error) {
 	return ids, nil
 }
 
+// Children returns the ready child elements of this element, including text nodes
+func (e *Element) Children() ([]*Element, error) {
+	ids, err := e.GetChildNodeIds()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, &ErrElementHasNoChildren{}
+	}
+
+	children := make([]*Element, 0, len(ids))
+	for _, id := range ids {
+		child, _ := e.tab.getElementByNodeID(id)
+		if err := child.WaitForReady(); err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	return children, nil
+}
+
+// GetParent returns the parent element of this element from the tab's known elements, waiting for
+// it to be ready. Returns ErrElementNotFound if the element has no parent (e.g. a #document).
+func (e *Element) GetParent() (*Element, error) {
+	e.lock.RLock()
+	ready, invalidated, nodeType := e.ready, e.invalidated, e.nodeType
+	parentID := 0
+	if e.node != nil {
+		parentID = e.node.ParentId
+	}
+	e.lock.RUnlock()
+
+	if !ready {
+		return nil, &ErrElementNotReady{}
+	}
+
+	if invalidated {
+		return nil, &ErrInvalidElement{}
+	}
+
+	if nodeType == int(NodeDocument) {
+		return nil, &ErrElementNotFound{Message: "element has no parent"}
+	}
+
+	// chrome doesn't always include the parent id, ask for the parent node instead
+	if parentID == 0 {
+		return e.resolveParent()
+	}
+
+	parent, _ := e.tab.getElementByNodeID(parentID)
+	if err := parent.WaitForReady(); err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
+
+// resolveParent node of this element through the runtime
+func (e *Element) resolveParent() (*Element, error) {
+	rro, err := e.callFunction("function() { return this.parentNode; }", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if rro.ObjectId == "" {
+		return nil, &ErrElementNotFound{Message: "element has no parent"}
+	}
+	return e.elementFromObjectID(rro.ObjectId)
+}
+
+// elementFromObjectID wraps the node of a remote object returned by callFunction, releasing the
+// remote object once its node has been requested
+func (e *Element) elementFromObjectID(objectID string) (*Element, error) {
+	nodeID, err := e.tab.t.DOM.RequestNode(objectID)
+	e.tab.t.Runtime.ReleaseObject(objectID)
+	if err != nil {
+		return nil, e.nodeError(err)
+	}
+	return e.tab.elementFromNodeID(nodeID)
+}
+
+// GetAncestors returns this element's parent, its parent and so on up to and including the
+// #document, nearest first
+func (e *Element) GetAncestors() ([]*Element, error) {
+	ancestors := make([]*Element, 0)
+	for current := e; ; {
+		parent, err := current.GetParent()
+		if _, noParent := err.(*ErrElementNotFound); noParent && current != e {
+			return ancestors, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors, parent)
+
+		if isDocument, _ := parent.IsDocument(); isDocument {
+			return ancestors, nil
+		}
+		current = parent
+	}
+}
+
 // GetTagName returns the tag name (input, div etc) if the element is in a ready state.
 func (e *Element) GetTagName() (string, error) {
 	e.lock.RLock()
@@ -422,6 +656,132 @@ func (e *Element) GetInnerText() string {
 	return e.tab.GetChildrensCharacterData(e)
 }
 
+// GetVisibleText of this element as rendered (element.innerText), unlike GetInnerText this
+// respects CSS visibility and excludes script/style content. Whitespace is collapsed.
+func (e *Element) GetVisibleText() (string, error) {
+	rro, err := e.callFunctionOn("function() { return this.innerText || ''; }")
+	if err != nil {
+		return "", err
+	}
+
+	text, ok := rro.Value.(string)
+	if !ok {
+		return "", nil
+	}
+	return strings.Join(strings.Fields(text), " "), nil
+}
+
+// cssSelectorFunction builds a selector from the element up to the nearest ancestor with a unique
+// id (or html/body), using nth-of-type to disambiguate siblings
+const cssSelectorFunction = `function() {
+	if (this.nodeType !== Node.ELEMENT_NODE) {
+		return '';
+	}
+	const parts = [];
+	for (let el = this; el && el.nodeType === Node.ELEMENT_NODE; el = el.parentElement) {
+		if (el.id && el.ownerDocument.querySelectorAll('#' + CSS.escape(el.id)).length === 1) {
+			parts.unshift('#' + CSS.escape(el.id));
+			break;
+		}
+		const tag = el.localName;
+		if (tag === 'html' || tag === 'body') {
+			parts.unshift(tag);
+			break;
+		}
+		let n = 1;
+		for (let sib = el.previousElementSibling; sib; sib = sib.previousElementSibling) {
+			if (sib.localName === tag) {
+				n++;
+			}
+		}
+		parts.unshift(CSS.escape(tag) + ':nth-of-type(' + n + ')');
+	}
+	return parts.join(' > ');
+}`
+
+// CSSSelector builds a reasonably unique css selector for this element, so it can be found again
+// after node ids change. Uses the id if unique, otherwise a tag and nth-of-type path up to the
+// nearest ancestor with a unique id.
+func (e *Element) CSSSelector() (string, error) {
+	rro, err := e.callFunctionOn(cssSelectorFunction)
+	if err != nil {
+		return "", err
+	}
+
+	selector, _ := rro.Value.(string)
+	if selector == "" {
+		e.lock.RLock()
+		nodeName := e.nodeName
+		e.lock.RUnlock()
+		return "", &ErrIncorrectElementType{NodeName: nodeName, ExpectedName: "element"}
+	}
+	return selector, nil
+}
+
+// ancestryPathFunction lists tag#id.class for the element and each of its ancestors, from <html> down
+const ancestryPathFunction = `function() {
+	const path = [];
+	for (let el = this; el && el.nodeType === Node.ELEMENT_NODE; el = el.parentElement) {
+		let part = el.localName;
+		if (el.id) {
+			part += '#' + el.id;
+		}
+		for (const cls of el.classList) {
+			part += '.' + cls;
+		}
+		path.unshift(part);
+	}
+	return path;
+}`
+
+// AncestryPath returns the tag, id and classes (e.g. div#main.content) of each element from <html>
+// down to and including this element, for locating findings when verifying them manually
+func (e *Element) AncestryPath() ([]string, error) {
+	rro, err := e.callFunctionOn(ancestryPathFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := rro.Value.([]interface{})
+	if len(values) == 0 {
+		e.lock.RLock()
+		nodeName := e.nodeName
+		e.lock.RUnlock()
+		return nil, &ErrIncorrectElementType{NodeName: nodeName, ExpectedName: "element"}
+	}
+
+	path := make([]string, 0, len(values))
+	for _, value := range values {
+		if part, ok := value.(string); ok {
+			path = append(path, part)
+		}
+	}
+	return path, nil
+}
+
+// parentFormFunction returns the form a control is associated with, either by its form attribute
+// or by being inside of it
+const parentFormFunction = `function() {
+	if (this.form instanceof HTMLFormElement) {
+		return this.form;
+	}
+	return this.closest ? this.closest('form') : null;
+}`
+
+// GetParentForm returns the form this element belongs to, honoring the form attribute of controls
+// outside of their form. Returns ErrElementNotFound if the element has no form.
+func (e *Element) GetParentForm() (*Element, error) {
+	rro, err := e.callFunction(parentFormFunction, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if rro.ObjectId == "" {
+		return nil, &ErrElementNotFound{Message: "no parent form"}
+	}
+	return e.elementFromObjectID(rro.ObjectId)
+}
+
 // IsEnabled returns true if the node is enabled, only makes sense for form controls.
 // Element must be in a ready state.
 func (e *Element) IsEnabled() (bool, error) {
@@ -442,6 +802,39 @@ func (e *Element) IsEnabled() (bool, error) {
 	return true, nil
 }
 
+// WaitForEnabled polls IsEnabled until the element is enabled, returning ErrElementNotEnabled
+// if it is still disabled after timeout. Used for submit buttons which are only enabled once
+// their form validates.
+func (e *Element) WaitForEnabled(timeout time.Duration) error {
+	if err := e.WaitForReady(); err != nil {
+		return err
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(50 * time.Millisecond)
+	defer poll.Stop()
+
+	for {
+		enabled, err := e.IsEnabled()
+		if err != nil {
+			return err
+		}
+
+		if enabled {
+			return nil
+		}
+
+		select {
+		case <-poll.C:
+		case <-deadline.C:
+			return &ErrElementNotEnabled{}
+		case <-e.tab.exitCh:
+			return &ErrElementNotEnabled{}
+		}
+	}
+}
+
 // IsSelected simulate WebDrivers checked propertyname check
 func (e *Element) IsSelected() (bool, error) {
 	e.lock.RLock()
@@ -458,28 +851,142 @@ func (e *Element) IsSelected() (bool, error) {
 	return false, nil
 }
 
-// GetCSSInlineStyleText returns the CSS Style Text of the element, returns the inline style first
-// and the attribute style second, or error.
-func (e *Element) GetCSSInlineStyleText() (string, string, error) {
+// sets the checked property (and reflects it to the attribute), notifying listeners if it changed.
+// A plain click Event is dispatched as a synthetic MouseEvent click would toggle the input again.
+const setCheckedFunction = `function(checked) {
+	if (this.checked === checked) {
+		return false;
+	}
+	this.checked = checked;
+	if (checked) {
+		this.setAttribute("checked", "");
+	} else {
+		this.removeAttribute("checked");
+	}
+	this.dispatchEvent(new Event("click", {bubbles: true}));
+	this.dispatchEvent(new Event("input", {bubbles: true}));
+	this.dispatchEvent(new Event("change", {bubbles: true}));
+	return true;
+}`
+
+// SetChecked checks or unchecks a checkbox or radio input without clicking it, so overlays can't
+// intercept it. Click, input and change events are dispatched if the state changed.
+func (e *Element) SetChecked(checked bool) error {
 	e.lock.RLock()
-	inline, attribute, err := e.tab.t.CSS.GetInlineStylesForNode(e.ID)
+	ready, nodeName := e.ready, e.nodeName
+	inputType := strings.ToLower(e.attributes["type"])
 	e.lock.RUnlock()
 
-	if err != nil {
-		return "", "", err
+	if !ready {
+		return &ErrElementNotReady{}
 	}
-	return inline.CssText, attribute.CssText, nil
+
+	if nodeName != "input" || (inputType != "checkbox" && inputType != "radio") {
+		return &ErrIncorrectElementType{ExpectedName: "checkbox or radio input", NodeName: nodeName}
+	}
+
+	if _, err := e.callFunctionOn(setCheckedFunction, checked); err != nil {
+		return err
+	}
+
+	if checked {
+		e.updateAttribute("checked", "")
+	} else {
+		e.removeAttribute("checked")
+	}
+	return nil
 }
 
-// GetComputedCSSStyle returns all of the computed css styles in form of name value map.
-func (e *Element) GetComputedCSSStyle() (map[string]string, error) {
+// selectOptionsFunction returns the value, text and selected state of each of a select's options
+const selectOptionsFunction = `function() {
+	return Array.from(this.options).map(function(option) {
+		return {value: option.value, text: option.text, selected: option.selected};
+	});
+}`
+
+// GetOptions returns the options of a <select> element in document order, so the values that can be
+// selected are known before selecting one. Returns ErrIncorrectElementType for other elements.
+func (e *Element) GetOptions() ([]browserk.SelectOption, error) {
 	e.lock.RLock()
-	styles, err := e.tab.t.CSS.GetComputedStyleForNode(e.ID)
+	ready, nodeName := e.ready, e.nodeName
 	e.lock.RUnlock()
 
+	if !ready {
+		return nil, &ErrElementNotReady{}
+	}
+
+	if nodeName != "select" {
+		return nil, &ErrIncorrectElementType{ExpectedName: "select", NodeName: nodeName}
+	}
+
+	rro, err := e.callFunctionOn(selectOptionsFunction)
 	if err != nil {
 		return nil, err
 	}
+
+	values, _ := rro.Value.([]interface{})
+	options := make([]browserk.SelectOption, 0, len(values))
+	for _, value := range values {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		option := browserk.SelectOption{}
+		option.Value, _ = fields["value"].(string)
+		option.Text, _ = fields["text"].(string)
+		option.Selected, _ = fields["selected"].(bool)
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// validityFunction returns the constraint validation state of a form control, null for other elements
+const validityFunction = `function() {
+	if (!this.validity || typeof this.validationMessage !== 'string') {
+		return null;
+	}
+	return {valid: this.validity.valid, message: this.validationMessage};
+}`
+
+// CheckValidity reads the form control's constraint validation state (required, pattern, min,
+// max etc), returning whether its current value is valid and the browser's validation message if
+// not. No invalid event is fired. Returns ErrIncorrectElementType for elements which aren't form
+// controls.
+func (e *Element) CheckValidity() (bool, string, error) {
+	rro, err := e.callFunctionOn(validityFunction)
+	if err != nil {
+		return false, "", err
+	}
+
+	validity, ok := rro.Value.(map[string]interface{})
+	if !ok {
+		e.lock.RLock()
+		nodeName := e.nodeName
+		e.lock.RUnlock()
+		return false, "", &ErrIncorrectElementType{NodeName: nodeName, ExpectedName: "form control"}
+	}
+
+	valid, _ := validity["valid"].(bool)
+	message, _ := validity["message"].(string)
+	return valid, message, nil
+}
+
+// GetCSSInlineStyleText returns the CSS Style Text of the element, returns the inline style first
+// and the attribute style second, or error.
+func (e *Element) GetCSSInlineStyleText() (string, string, error) {
+	inline, attribute, err := e.tab.t.CSS.GetInlineStylesForNode(e.NodeID())
+	if err != nil {
+		return "", "", e.nodeError(err)
+	}
+	return inline.CssText, attribute.CssText, nil
+}
+
+// GetComputedCSSStyle returns all of the computed css styles in form of name value map.
+func (e *Element) GetComputedCSSStyle() (map[string]string, error) {
+	styles, err := e.tab.t.CSS.GetComputedStyleForNode(e.NodeID())
+	if err != nil {
+		return nil, e.nodeError(err)
+	}
 	styleMap := make(map[string]string, len(styles))
 	for _, style := range styles {
 		styleMap[style.Name] = style.Value
@@ -487,20 +994,23 @@ func (e *Element) GetComputedCSSStyle() (map[string]string, error) {
 	return styleMap, nil
 }
 
-// GetAttributes of the node returning a map of name,value pairs.
+// GetAttributes of the node returning a copy of the name,value pairs.
 func (e *Element) GetAttributes() (map[string]string, error) {
-	e.lock.RLock()
-	attr, err := e.tab.t.DOM.GetAttributes(e.ID)
-	e.lock.RUnlock()
-
+	attr, err := e.tab.t.DOM.GetAttributes(e.NodeID())
 	if err != nil {
-		return nil, err
+		return nil, e.nodeError(err)
 	}
 	for i := 0; i < len(attr); i += 2 {
 		e.updateAttribute(attr[i], attr[i+1])
 	}
 
-	return e.attributes, nil
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	attributes := make(map[string]string, len(e.attributes))
+	for name, value := range e.attributes {
+		attributes[name] = value
+	}
+	return attributes, nil
 }
 
 // GetAttribute a single attribute by name, returns empty string if it does not exist
@@ -525,53 +1035,188 @@ func (e *Element) HasAttribute(name string) bool {
 	return exists
 }
 
+// GetAriaAttributes returns only the aria-* attributes and role of this element
+func (e *Element) GetAriaAttributes() (map[string]string, error) {
+	attr, err := e.GetAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	aria := make(map[string]string)
+	for name, value := range attr {
+		if name == "role" || strings.HasPrefix(name, "aria-") {
+			aria[name] = value
+		}
+	}
+	return aria, nil
+}
+
+// GetAccessibleName returns the computed accessible name of the element, or an empty
+// string if the element has no accessibility node (e.g. it is ignored or not rendered).
+func (e *Element) GetAccessibleName() (string, error) {
+	node, err := e.axNode()
+	if err != nil || node == nil {
+		return "", err
+	}
+	return axValueString(node.Name), nil
+}
+
+// GetRole returns the computed (explicit or implicit) role of the element, or an empty
+// string if the element has no accessibility node.
+func (e *Element) GetRole() (string, error) {
+	node, err := e.axNode()
+	if err != nil || node == nil {
+		return "", err
+	}
+	return axValueString(node.Role), nil
+}
+
+// axNode returns this element's node from the partial accessibility tree, or nil if it
+// has no accessibility representation.
+func (e *Element) axNode() (*gcdapi.AccessibilityAXNode, error) {
+	nodes, err := e.tab.t.Accessibility.GetPartialAXTree(e.NodeID(), 0, "", false)
+	if err != nil {
+		return nil, e.nodeError(err)
+	}
+
+	for _, node := range nodes {
+		if node.Ignored {
+			continue
+		}
+		return node, nil
+	}
+	return nil, nil
+}
+
+// axValueString returns the value as a string, or empty if it's not set
+func axValueString(value *gcdapi.AccessibilityAXValue) string {
+	if value == nil || value.Value == nil {
+		return ""
+	}
+	if s, ok := value.Value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", value.Value)
+}
+
 // SetAttributeValue sets an element's attribute with name to value.
 func (e *Element) SetAttributeValue(name, value string) error {
-	e.lock.Lock()
-	defer e.lock.Unlock()
+	if _, err := e.tab.t.DOM.SetAttributeValue(e.NodeID(), name, value); err != nil {
+		return e.nodeError(err)
+	}
+	e.updateAttribute(name, value)
+	return nil
+}
 
-	_, err := e.tab.t.DOM.SetAttributeValue(e.ID, name, value)
+// sets each attribute in order, stopping at the first which fails (e.g. an invalid name)
+const setAttributesFunction = `function(names, values) {
+	for (var i = 0; i < names.length; i++) {
+		try {
+			this.setAttribute(names[i], values[i]);
+		} catch (e) {
+			return {applied: i, failed: names[i], error: e.message};
+		}
+	}
+	return {applied: names.length};
+}`
+
+// SetAttributes sets all of attrs on the element in a single call, ordered by name. If an attribute
+// fails ErrAttributeNotSet names it, the attributes ordered before it are still set.
+func (e *Element) SetAttributes(attrs map[string]string) error {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = attrs[name]
+	}
+
+	rro, err := e.callFunctionOn(setAttributesFunction, names, values)
 	if err != nil {
 		return err
 	}
 
-	e.attributes[name] = value
+	result, ok := rro.Value.(map[string]interface{})
+	if !ok {
+		return &ErrAttributeNotSet{Name: strings.Join(names, ","), Message: "unexpected result setting attributes"}
+	}
 
+	applied, _ := result["applied"].(float64)
+	e.lock.Lock()
+	for _, name := range names[:int(applied)] {
+		e.attributes[name] = attrs[name]
+	}
+	e.lock.Unlock()
+
+	if failed, ok := result["failed"].(string); ok {
+		message, _ := result["error"].(string)
+		return &ErrAttributeNotSet{Name: failed, Message: message}
+	}
 	return nil
 }
 
+// empties a contenteditable element, notifying listeners (e.g. rich editors) of the change
+const clearContentEditableFunction = `function() {
+	this.textContent = "";
+	this.dispatchEvent(new Event("input", {bubbles: true}));
+}`
+
 // Clear works like WebDriver's clear(), simply sets the attribute value for input
-// or clears the value for textarea. This element must be ready so we can
-// properly read the nodeName value.
+// or clears the value for textarea. Contenteditable elements have their text removed
+// and an input event dispatched. This element must be ready so we can properly read
+// the nodeName value.
 func (e *Element) Clear() error {
-	e.lock.RLock()
-	defer e.lock.RUnlock()
-
 	var err error
 
-	if !e.ready {
+	e.lock.RLock()
+	ready, id, nodeName := e.ready, e.ID, e.nodeName
+	editable, isEditable := e.attributes["contenteditable"]
+	e.lock.RUnlock()
+
+	if !ready {
 		return &ErrElementNotReady{}
 	}
 
-	if e.nodeName == "textarea" {
-		_, err = e.tab.t.DOM.SetNodeValue(e.ID, "")
-	} else if e.nodeName == "input" {
-		_, err = e.tab.t.DOM.SetAttributeValue(e.ID, "value", "")
+	// an empty contenteditable attribute is the same as true
+	if isEditable && editable != "false" {
+		_, err = e.callFunctionOn(clearContentEditableFunction)
+		return err
+	}
+
+	if nodeName == "textarea" {
+		_, err = e.tab.t.DOM.SetNodeValue(id, "")
+	} else if nodeName == "input" {
+		_, err = e.tab.t.DOM.SetAttributeValue(id, "value", "")
 	} else {
-		err = &ErrIncorrectElementType{ExpectedName: "textarea or input", NodeName: e.nodeName}
+		err = &ErrIncorrectElementType{ExpectedName: "textarea, input or contenteditable", NodeName: nodeName}
 	}
-	return err
+
+	return e.nodeError(err)
 }
 
-// Click the center of the element.
+// Click the center of the element, inside a user gesture so handlers can use APIs which
+// require user activation (popups, clipboard, autoplay). If a fixed or sticky element (e.g. a
+// header) covers it the element is scrolled to the center of the viewport first.
 func (e *Element) Click() error {
-	x, y, err := e.getCenter()
-	if err != nil {
-		return err
-	}
+	return e.tab.withUserGesture(func() error {
+		// a single call both activates the page and uncovers the element
+		if err := e.prepareClick(); err != nil {
+			if _, err := e.tab.evaluateScript("void 0", false); err != nil {
+				return err
+			}
+		}
+
+		x, y, err := e.getCenter()
+		if err != nil {
+			return err
+		}
 
-	// click the centroid of the element.
-	return e.tab.Click(float64(x), float64(y))
+		// click the centroid of the element.
+		return e.tab.Click(float64(x), float64(y))
+	})
 }
 
 // DoubleClick the center of the element.
@@ -586,28 +1231,83 @@ func (e *Element) DoubleClick() error {
 
 // Focus on the element.
 func (e *Element) Focus() error {
-	e.lock.RLock()
-	defer e.lock.RUnlock()
-
 	params := &gcdapi.DOMFocusParams{
-		NodeId: e.ID,
+		NodeId: e.NodeID(),
 	}
 	_, err := e.tab.t.DOM.FocusWithParams(params)
+
+	return e.nodeError(err)
+}
+
+// Blur removes focus from the element, firing its blur and focusout events
+func (e *Element) Blur() error {
+	_, err := e.callFunctionOn("function() { this.blur(); }")
 	return err
 }
 
 // ScrollTo the element if needed
 func (e *Element) ScrollTo() error {
-	e.lock.RLock()
-	defer e.lock.RUnlock()
-
 	params := &gcdapi.DOMScrollIntoViewIfNeededParams{
-		NodeId: e.ID,
+		NodeId: e.NodeID(),
 	}
 	_, err := e.tab.t.DOM.ScrollIntoViewIfNeededWithParams(params)
+	e.tab.invalidateGeometry()
+
+	return e.nodeError(err)
+}
+
+// scrolls the element to the block alignment in the viewport
+const scrollIntoViewFunction = `function(block) {
+	this.scrollIntoView({block: block, inline: "nearest"});
+}`
+
+// ScrollIntoView scrolls the element to the start, center, end or nearest edge of the viewport.
+// Prefer center when a sticky header would cover elements scrolled to the start.
+func (e *Element) ScrollIntoView(block string) error {
+	switch block {
+	case "start", "center", "end", "nearest":
+	default:
+		return &ErrInvalidScrollBlock{Block: block}
+	}
+
+	_, err := e.callFunctionOn(scrollIntoViewFunction, block)
+	e.tab.invalidateGeometry()
 	return err
 }
 
+// scrolls the element to the center of the viewport if the element at its center is, or is
+// inside of, a fixed or sticky positioned element which isn't related to this one. Returns true
+// if it was scrolled.
+const prepareClickFunction = `function() {
+	var rect = this.getBoundingClientRect();
+	var top = document.elementFromPoint(rect.left + rect.width / 2, rect.top + rect.height / 2);
+	if (!top || this.contains(top) || top.contains(this)) {
+		return false;
+	}
+	for (var node = top; node && node !== document.body; node = node.parentElement) {
+		var position = window.getComputedStyle(node).position;
+		if (position === "fixed" || position === "sticky") {
+			this.scrollIntoView({block: "center", inline: "nearest"});
+			return true;
+		}
+	}
+	return false;
+}`
+
+// prepareClick scrolls the element out from under a fixed or sticky element covering its center,
+// must be called inside a user gesture so it also activates the page
+func (e *Element) prepareClick() error {
+	rro, err := e.callFunctionOn(prepareClickFunction)
+	if err != nil {
+		return err
+	}
+
+	if scrolled, _ := rro.Value.(bool); scrolled {
+		e.tab.invalidateGeometry()
+	}
+	return nil
+}
+
 // MouseOver the center of the element.
 func (e *Element) MouseOver() error {
 	x, y, err := e.getCenter()
@@ -618,23 +1318,129 @@ func (e *Element) MouseOver() error {
 	return e.tab.MoveMouse(float64(x), float64(y))
 }
 
-// Dimensions returns the dimensions of the element.
+// HoverPathTo moves the mouse from the center of this element to the center of target in a
+// straight line, dispatching steps mouseMoved events so hover menus stay open along the way.
+// The mouse is expected to already be over this element (see MouseOver).
+func (e *Element) HoverPathTo(target *Element, steps int) error {
+	if steps < 1 {
+		steps = 1
+	}
+
+	startX, startY, err := e.getCenter()
+	if err != nil {
+		return err
+	}
+
+	endX, endY, err := target.getCenter()
+	if err != nil {
+		return err
+	}
+
+	dx := float64(endX-startX) / float64(steps)
+	dy := float64(endY-startY) / float64(steps)
+	for i := 1; i <= steps; i++ {
+		if err := e.tab.MoveMouse(float64(startX)+dx*float64(i), float64(startY)+dy*float64(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// number of intermediate mouse moves DragTo makes, so drag handlers see a continuous motion
+const dragSteps = 10
+
+// DragTo presses the left button at the center of the element and releases it at the x, y
+// viewport coordinates, moving there with the button held. For range sliders, canvases and
+// other drag driven widgets.
+func (e *Element) DragTo(x, y float64) error {
+	startX, startY, err := e.getCenter()
+	if err != nil {
+		return err
+	}
+
+	if err := e.tab.Drag(float64(startX), float64(startY), x, y, dragSteps); err != nil {
+		return err
+	}
+	e.InvalidateGeometry()
+	return nil
+}
+
+// ScrollBy dispatches a mouse wheel event over the center of the element, scrolling it
+// (or the nearest scrollable ancestor) by dx, dy pixels.
+func (e *Element) ScrollBy(dx, dy float64) error {
+	x, y, err := e.getCenter()
+	if err != nil {
+		return err
+	}
+
+	return e.tab.scrollAt(float64(x), float64(y), dx, dy)
+}
+
+// Dimensions returns the dimensions of the element. The box model is cached until the DOM changes,
+// the page scrolls or is resized, emulated media changes or InvalidateGeometry is called.
 func (e *Element) Dimensions() ([]float64, error) {
-	var points []float64
-	e.lock.RLock()
+	generation := e.tab.geometry()
+	e.lock.Lock()
+	if e.box != nil && e.boxGeneration == generation {
+		points := append([]float64(nil), e.box...)
+		e.lock.Unlock()
+		return points, nil
+	}
 
 	params := &gcdapi.DOMGetBoxModelParams{
 		NodeId: e.ID,
 	}
+	e.boxRequests++
+	e.lock.Unlock()
+
 	box, err := e.tab.t.DOM.GetBoxModelWithParams(params)
+	if err != nil {
+		return nil, e.nodeError(err)
+	}
 
-	e.lock.RUnlock()
+	e.lock.Lock()
+	e.box = box.Content
+	e.boxGeneration = generation
+	e.lock.Unlock()
+	return append([]float64(nil), box.Content...), nil
+}
 
+// InvalidateGeometry clears the cached dimensions so the next Dimensions call gets the box model
+func (e *Element) InvalidateGeometry() {
+	e.lock.Lock()
+	e.box = nil
+	e.lock.Unlock()
+}
+
+// IsInViewport returns true if any part of the element intersects the visual viewport. Unlike
+// visibility checks the element's CSS (display, visibility, opacity) is not considered.
+func (e *Element) IsInViewport() (bool, error) {
+	// the page may have been scrolled by script, don't trust cached dimensions
+	e.InvalidateGeometry()
+	rect, err := e.GetRect()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	points = box.Content
-	return points, nil
+
+	// elements without a size can't intersect the viewport
+	if rect.Empty() {
+		return false, nil
+	}
+
+	_, viewport, _, err := e.tab.t.Page.GetLayoutMetrics()
+	if err != nil {
+		return false, err
+	}
+	return rect.Intersects(browserk.NewRect(viewport.OffsetX, viewport.OffsetY, viewport.ClientWidth, viewport.ClientHeight)), nil
+}
+
+// GetRect returns the bounding rectangle of the element's content box
+func (e *Element) GetRect() (browserk.Rect, error) {
+	points, err := e.Dimensions()
+	if err != nil {
+		return browserk.Rect{}, err
+	}
+	return rectFromPoints(points)
 }
 
 // gets the center of the element
@@ -668,7 +1474,7 @@ func (e *Element) SendRawKeys(keys string) error {
 	if err != nil {
 		return err
 	}
-	for _, c := range keys {
+	for _, c := range keymap.NormalizeNewlines(keys) {
 		toSend := keymap.KeyEncode(c)
 		for _, key := range toSend {
 			_, err = e.tab.t.Input.DispatchKeyEventWithParams(key)
@@ -692,12 +1498,105 @@ func (e *Element) String() string {
 	}
 	output = fmt.Sprintf("%s NodeType: %d TagName: %s characterData: %s childNodeCount: %d attributes (%d): \n%s", output, e.nodeType, e.nodeName, e.characterData, e.childNodeCount, len(e.attributes), attrs)
 	if e.nodeType == int(NodeDocument) {
+		// a detached element may no longer have its node
+		if e.node == nil {
+			return output + " (partial: node detached)\n"
+		}
 		output = fmt.Sprintf("%s FrameId: %s documentURL: %s\n", output, e.node.FrameId, e.node.DocumentURL)
 	}
 	//output = fmt.Sprintf("%s %#v", output, e.node)
 	return output
 }
 
+// CaptureEvidence highlights the element and bundles a screenshot clipped to it along with
+// its outer html and the page url, for embedding in a finding
+func (e *Element) CaptureEvidence() (*browserk.Evidence, error) {
+	source, err := e.GetSource()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.ScrollTo(); err != nil {
+		return nil, err
+	}
+
+	rect, err := e.GetRect()
+	if err != nil {
+		return nil, err
+	}
+
+	if rect.Empty() {
+		return nil, &ErrInvalidDimensions{"element has no size"}
+	}
+
+	e.lock.RLock()
+	id := e.ID
+	e.lock.RUnlock()
+
+	if _, err := e.tab.t.Overlay.Enable(); err != nil {
+		return nil, err
+	}
+	defer e.tab.t.Overlay.HideHighlight()
+
+	highlight := &gcdapi.OverlayHighlightNodeParams{
+		HighlightConfig: &gcdapi.OverlayHighlightConfig{
+			ContentColor: &gcdapi.DOMRGBA{R: 255, G: 0, B: 0, A: 0.3},
+			BorderColor:  &gcdapi.DOMRGBA{R: 255, G: 0, B: 0, A: 1},
+		},
+		NodeId: id,
+	}
+	if _, err := e.tab.t.Overlay.HighlightNodeWithParams(highlight); err != nil {
+		return nil, e.nodeError(err)
+	}
+
+	// pad the clip so the highlight border is included
+	const padding = 4
+	params := &gcdapi.PageCaptureScreenshotParams{
+		Format: "png",
+		Clip: &gcdapi.PageViewport{
+			X:      math.Max(0, rect.X-padding),
+			Y:      math.Max(0, rect.Y-padding),
+			Width:  rect.Width + padding*2,
+			Height: rect.Height + padding*2,
+			Scale:  float64(1),
+		},
+		FromSurface: true,
+	}
+	encoded, err := e.tab.t.Page.CaptureScreenshotWithParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	screenshot, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	pageURL, _ := e.tab.GetURL()
+	return &browserk.Evidence{
+		URL:        pageURL,
+		HTML:       source,
+		Screenshot: screenshot,
+	}, nil
+}
+
+// rectFromPoints returns the rectangle containing the box model points
+func rectFromPoints(points []float64) (browserk.Rect, error) {
+	if len(points) == 0 || len(points)%2 != 0 {
+		return browserk.Rect{}, &ErrInvalidDimensions{"number of points are not divisible by two"}
+	}
+
+	minX, minY := points[0], points[1]
+	maxX, maxY := points[0], points[1]
+	for i := 2; i < len(points); i += 2 {
+		minX = math.Min(minX, points[i])
+		maxX = math.Max(maxX, points[i])
+		minY = math.Min(minY, points[i+1])
+		maxY = math.Max(maxY, points[i+1])
+	}
+	return browserk.NewRect(minX, minY, maxX-minX, maxY-minY), nil
+}
+
 // finds the centroid of an arbitrary number of points.
 // Assumes points[i] = x, points[i+1] = y.
 func centroid(points []float64) (int, int, error) {
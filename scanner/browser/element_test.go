@@ -0,0 +1,1390 @@
+package browser_test
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"github.com/wirepair/gcd/gcdmessage"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/browser"
+	"golang.org/x/net/context"
+)
+
+func TestElementGetVisibleText(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/visible_text.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#container")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting container: %s\n", err)
+	}
+
+	visible, err := eles[0].GetVisibleText()
+	if err != nil {
+		t.Fatalf("error getting visible text: %s\n", err)
+	}
+
+	if visible != "Visible text more visible" {
+		t.Fatalf("expected collapsed visible text, got %q", visible)
+	}
+
+	raw := eles[0].GetInnerText()
+	if !strings.Contains(raw, "hidden text") {
+		t.Fatalf("expected raw character data to contain hidden text, got %q", raw)
+	}
+}
+
+func TestElementHoverPathTo(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/hover_menu.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	menu, err := tab.GetElementsBySelector("#menu")
+	if err != nil || len(menu) != 1 {
+		t.Fatalf("error getting menu: %s\n", err)
+	}
+
+	if err := menu[0].MouseOver(); err != nil {
+		t.Fatalf("error hovering menu: %s\n", err)
+	}
+
+	nested, err := tab.GetElementsBySelector("#nested")
+	if err != nil || len(nested) != 1 {
+		t.Fatalf("error getting nested link: %s\n", err)
+	}
+
+	if _, err := tab.InjectJS("window.moves = 0"); err != nil {
+		t.Fatalf("error resetting move count: %s\n", err)
+	}
+
+	steps := 10
+	if err := menu[0].HoverPathTo(nested[0], steps); err != nil {
+		t.Fatalf("error hovering along path: %s\n", err)
+	}
+
+	moves, err := tab.InjectJS("window.moves")
+	if err != nil {
+		t.Fatalf("error getting move count: %s\n", err)
+	}
+
+	if count, ok := moves.(float64); !ok || int(count) != steps {
+		t.Fatalf("expected %d intermediate moves got %v", steps, moves)
+	}
+
+	visible, err := tab.InjectJS("getComputedStyle(document.getElementById('submenu')).display")
+	if err != nil || visible != "block" {
+		t.Fatalf("expected submenu to remain open got %v", visible)
+	}
+}
+
+func TestNodeError(t *testing.T) {
+	cdpErr := &gcdmessage.ChromeRequestErr{Resp: &gcdmessage.ChromeErrorResponse{
+		Id:    10,
+		Error: &gcdmessage.ChromeError{Code: -32000, Message: "Node with given id does not exist"},
+	}}
+
+	if _, ok := browser.NodeError(cdpErr).(*browser.ErrInvalidElement); !ok {
+		t.Fatalf("expected ErrInvalidElement got %T", browser.NodeError(cdpErr))
+	}
+
+	other := &gcdmessage.ChromeRequestErr{Resp: &gcdmessage.ChromeErrorResponse{
+		Id:    11,
+		Error: &gcdmessage.ChromeError{Code: -32000, Message: "Cannot find context with specified id"},
+	}}
+
+	if browser.NodeError(other) != other {
+		t.Fatalf("expected unrelated errors to be returned as is")
+	}
+
+	if browser.NodeError(nil) != nil {
+		t.Fatalf("expected nil error")
+	}
+}
+
+func TestElementRemovedIsInvalid(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/visible_text.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#container")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting container: %s\n", err)
+	}
+
+	if _, err := tab.InjectJS("document.getElementById('container').remove()"); err != nil {
+		t.Fatalf("error removing element: %s\n", err)
+	}
+
+	if _, err := eles[0].Dimensions(); err == nil {
+		t.Fatalf("expected error getting dimensions of removed element")
+	} else if _, ok := err.(*browser.ErrInvalidElement); !ok {
+		t.Fatalf("expected ErrInvalidElement got %T %s", err, err)
+	}
+
+	if !eles[0].IsInvalid() {
+		t.Fatalf("expected removed element to be invalidated")
+	}
+}
+
+func TestElementGetAriaAttributes(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/aria.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#menu")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting menu: %s\n", err)
+	}
+
+	aria, err := eles[0].GetAriaAttributes()
+	if err != nil {
+		t.Fatalf("error getting aria attributes: %s\n", err)
+	}
+
+	expected := map[string]string{
+		"role":          "button",
+		"aria-label":    "Open menu",
+		"aria-expanded": "false",
+		"aria-haspopup": "true",
+	}
+	if len(aria) != len(expected) {
+		t.Fatalf("expected %d aria attributes got %d: %v", len(expected), len(aria), aria)
+	}
+
+	for name, value := range expected {
+		if aria[name] != value {
+			t.Fatalf("expected %s=%s got %s", name, value, aria[name])
+		}
+	}
+}
+
+func TestElementBlur(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/blur.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#email")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting input: %s\n", err)
+	}
+
+	if err := eles[0].Focus(); err != nil {
+		t.Fatalf("error focusing input: %s\n", err)
+	}
+
+	if err := eles[0].Blur(); err != nil {
+		t.Fatalf("error blurring input: %s\n", err)
+	}
+
+	status, err := tab.EvaluateScript("document.getElementById('status').innerText")
+	if err != nil {
+		t.Fatalf("error getting status: %s\n", err)
+	}
+
+	if status.Value != "validated a@b.c" {
+		t.Fatalf("expected blur handler to run, got %v", status.Value)
+	}
+}
+
+func TestElementCaptureEvidence(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/aria.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#menu")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting menu: %s\n", err)
+	}
+
+	evidence, err := eles[0].CaptureEvidence()
+	if err != nil {
+		t.Fatalf("error capturing evidence: %s\n", err)
+	}
+
+	if len(evidence.Screenshot) == 0 || !bytes.HasPrefix(evidence.Screenshot, []byte("\x89PNG")) {
+		t.Fatalf("expected png screenshot in evidence")
+	}
+
+	if !strings.Contains(evidence.HTML, `id="menu"`) {
+		t.Fatalf("expected element html in evidence got %s", evidence.HTML)
+	}
+
+	if !strings.HasSuffix(evidence.URL, "/aria.html") {
+		t.Fatalf("expected page url in evidence got %s", evidence.URL)
+	}
+}
+
+func TestElementChildren(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/children.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#list")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting list: %s\n", err)
+	}
+
+	children, err := eles[0].Children()
+	if err != nil {
+		t.Fatalf("error getting children: %s\n", err)
+	}
+
+	expected := []string{"li", "li", "span"}
+	if len(children) != len(expected) {
+		t.Fatalf("expected %d children got %d", len(expected), len(children))
+	}
+
+	for i, child := range children {
+		tag, err := child.GetTagName()
+		if err != nil {
+			t.Fatalf("error getting child tag name: %s\n", err)
+		}
+		if !strings.EqualFold(tag, expected[i]) {
+			t.Fatalf("expected child %d to be %s got %s", i, expected[i], tag)
+		}
+	}
+
+	empty, err := tab.GetElementsBySelector("#empty")
+	if err != nil || len(empty) != 1 {
+		t.Fatalf("error getting empty div: %s\n", err)
+	}
+
+	if _, err := empty[0].Children(); err == nil {
+		t.Fatalf("expected error for element without children")
+	} else if _, ok := err.(*browser.ErrElementHasNoChildren); !ok {
+		t.Fatalf("expected ErrElementHasNoChildren got %T %s", err, err)
+	}
+}
+
+func TestElementCSSSelector(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/children.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	for _, query := range []string{"#list li", ".plain p", "#empty"} {
+		eles, err := tab.GetElementsBySelector(query)
+		if err != nil || len(eles) == 0 {
+			t.Fatalf("error getting %s: %s\n", query, err)
+		}
+		target := eles[len(eles)-1]
+
+		selector, err := target.CSSSelector()
+		if err != nil {
+			t.Fatalf("error generating selector for %s: %s\n", query, err)
+		}
+
+		found, err := tab.QuerySelector(selector)
+		if err != nil {
+			t.Fatalf("error selecting %s: %s\n", selector, err)
+		}
+
+		if found.ID != target.ID {
+			t.Fatalf("expected %s to re-select node %d got %d", selector, target.ID, found.ID)
+		}
+	}
+}
+
+func TestElementStringDetachedNode(t *testing.T) {
+	ele := browser.NewTestElement(&gcdapi.DOMNode{
+		NodeId:      1,
+		NodeType:    int(browser.NodeDocument),
+		NodeName:    "#document",
+		DocumentURL: "http://localhost/",
+		FrameId:     "frame",
+	})
+
+	if output := ele.String(); !strings.Contains(output, "documentURL: http://localhost/") {
+		t.Fatalf("expected document url in %s", output)
+	}
+
+	ele.ClearNode()
+	output := ele.String()
+	if !strings.Contains(output, "partial") || strings.Contains(output, "documentURL") {
+		t.Fatalf("expected partial output for detached node got %s", output)
+	}
+}
+
+func TestElementWaitForEnabled(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/enable_button.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#submit")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+
+	if enabled, _ := eles[0].IsEnabled(); enabled {
+		t.Fatalf("expected button to start disabled")
+	}
+
+	if err := eles[0].WaitForEnabled(5 * time.Second); err != nil {
+		t.Fatalf("expected button to be enabled by script: %s\n", err)
+	}
+
+	never, err := tab.GetElementsBySelector("#never")
+	if err != nil || len(never) != 1 {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+
+	err = never[0].WaitForEnabled(300 * time.Millisecond)
+	if _, ok := err.(*browser.ErrElementNotEnabled); !ok {
+		t.Fatalf("expected ErrElementNotEnabled got %v", err)
+	}
+}
+
+func TestElementDimensionsCached(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/button.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#button")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+	button := eles[0]
+
+	first, err := button.Dimensions()
+	if err != nil {
+		t.Fatalf("error getting dimensions: %s\n", err)
+	}
+	second, err := button.Dimensions()
+	if err != nil {
+		t.Fatalf("error getting dimensions: %s\n", err)
+	}
+
+	if button.BoxModelRequests() != 1 || len(first) != len(second) {
+		t.Fatalf("expected one box model request got %d", button.BoxModelRequests())
+	}
+
+	button.InvalidateGeometry()
+	button.Dimensions()
+	if button.BoxModelRequests() != 2 {
+		t.Fatalf("expected box model request after invalidation got %d", button.BoxModelRequests())
+	}
+
+	// attribute changes are dispatched asynchronously
+	if err := button.SetAttributeValue("style", "width: 300px"); err != nil {
+		t.Fatalf("error setting style: %s\n", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	resized, err := button.Dimensions()
+	if err != nil {
+		t.Fatalf("error getting dimensions: %s\n", err)
+	}
+
+	if button.BoxModelRequests() != 3 || resized[2]-resized[0] == first[2]-first[0] {
+		t.Fatalf("expected style change to invalidate dimensions got %d requests, %v", button.BoxModelRequests(), resized)
+	}
+
+	// inserting another element moves the button without changing it
+	if _, err := tab.EvaluateScript(`document.body.insertBefore(document.createElement('div'), document.body.firstChild).style.height = '200px'`); err != nil {
+		t.Fatalf("error inserting element: %s\n", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	moved, err := button.Dimensions()
+	if err != nil {
+		t.Fatalf("error getting dimensions: %s\n", err)
+	}
+
+	if button.BoxModelRequests() != 4 || moved[1] == resized[1] {
+		t.Fatalf("expected any dom mutation to invalidate dimensions got %d requests, %v", button.BoxModelRequests(), moved)
+	}
+}
+
+func TestElementIsInViewport(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/below_fold.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#below")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+
+	inView, err := eles[0].IsInViewport()
+	if err != nil {
+		t.Fatalf("error checking viewport: %s\n", err)
+	}
+
+	if inView {
+		t.Fatalf("expected element below the fold to not be in the viewport")
+	}
+
+	if err := eles[0].ScrollTo(); err != nil {
+		t.Fatalf("error scrolling to element: %s\n", err)
+	}
+
+	inView, err = eles[0].IsInViewport()
+	if err != nil {
+		t.Fatalf("error checking viewport: %s\n", err)
+	}
+
+	if !inView {
+		t.Fatalf("expected element to be in the viewport after scrolling")
+	}
+}
+
+func TestElementGetRect(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/rect.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#box")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting box: %s\n", err)
+	}
+
+	rect, err := eles[0].GetRect()
+	if err != nil {
+		t.Fatalf("error getting rect: %s\n", err)
+	}
+
+	// the content box excludes the 2px border and 10px padding
+	expected := browserk.NewRect(62, 112, 200, 80)
+	if rect != expected {
+		t.Fatalf("expected %#v got %#v\n", expected, rect)
+	}
+}
+
+// run with -race, element and tab operations are interleaved while DOM events update the element
+func TestElementConcurrentAccess(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/attributes.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#attr")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting input: %s\n", err)
+	}
+	input := eles[0]
+
+	const iterations = 20
+	operations := []func(i int){
+		func(i int) { input.SetAttributeValue("data-i", fmt.Sprintf("%d", i)) },
+		func(i int) { input.GetAttributes() },
+		func(i int) { input.GetCSSInlineStyleText() },
+		func(i int) { input.GetComputedCSSStyle() },
+		func(i int) { input.Dimensions() },
+		func(i int) { _ = input.String() },
+		func(i int) {
+			tab.EvaluateScript(fmt.Sprintf("document.getElementById('attr').setAttribute('style', 'width: %dpx')", 100+i))
+		},
+		func(i int) { tab.GetElementsBySelector("input") },
+	}
+
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, op := range operations {
+			wg.Add(1)
+			go func(op func(i int)) {
+				defer wg.Done()
+				for i := 0; i < iterations; i++ {
+					op(i)
+				}
+			}(op)
+		}
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 30):
+		t.Fatalf("concurrent element and tab operations did not complete, possible deadlock")
+	}
+}
+
+func TestElementAccessibility(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/accessibility.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#submit")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+
+	role, err := eles[0].GetRole()
+	if err != nil || role != "button" {
+		t.Fatalf("expected button role got %q %v\n", role, err)
+	}
+
+	name, err := eles[0].GetAccessibleName()
+	if err != nil || name != "Submit order" {
+		t.Fatalf("expected aria-label as the name got %q %v\n", name, err)
+	}
+
+	eles, err = tab.GetElementsBySelector("#hidden")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting hidden span: %s\n", err)
+	}
+
+	role, err = eles[0].GetRole()
+	if err != nil || role != "" {
+		t.Fatalf("expected no role for hidden element got %q %v\n", role, err)
+	}
+}
+
+func TestElementListenerTypes(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/listeners.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#btn")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+
+	counts, err := eles[0].ListenerTypes()
+	if err != nil {
+		t.Fatalf("error getting listener types: %s\n", err)
+	}
+
+	// the onclick attribute and addEventListener each add a click listener
+	if counts["click"] != 2 || counts["keydown"] != 1 || counts["mouseover"] != 1 || len(counts) != 3 {
+		t.Fatalf("unexpected listener counts %v\n", counts)
+	}
+}
+
+func TestElementSetAttributes(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/rect.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#box")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting box: %s\n", err)
+	}
+	box := eles[0]
+
+	set := map[string]string{"title": "a box", "data-x": "1", "aria-label": "box"}
+	if err := box.SetAttributes(set); err != nil {
+		t.Fatalf("error setting attributes: %s\n", err)
+	}
+
+	attrs, err := box.GetAttributes()
+	if err != nil {
+		t.Fatalf("error getting attributes: %s\n", err)
+	}
+	for name, value := range set {
+		if attrs[name] != value {
+			t.Fatalf("expected %s=%s got %q\n", name, value, attrs[name])
+		}
+	}
+
+	// names are applied in order, so data-y is set before the invalid name fails
+	err = box.SetAttributes(map[string]string{"data-y": "2", "in valid": "3"})
+	if notSet, ok := err.(*browser.ErrAttributeNotSet); !ok || notSet.Name != "in valid" {
+		t.Fatalf("expected the invalid attribute to be reported got %v\n", err)
+	}
+	if box.GetAttribute("data-y") != "2" {
+		t.Fatalf("expected attributes before the failure to be set\n")
+	}
+}
+
+func TestElementClearContentEditable(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/contenteditable.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	editor, err := tab.QuerySelector("#editor")
+	if err != nil {
+		t.Fatalf("error finding editor: %s\n", err)
+	}
+
+	if err := editor.Clear(); err != nil {
+		t.Fatalf("error clearing editor: %s\n", err)
+	}
+
+	text, err := tab.EvaluateScript(`document.getElementById("editor").textContent`)
+	if err != nil {
+		t.Fatalf("error getting editor text: %s\n", err)
+	}
+
+	if text.Value != "" {
+		t.Fatalf("expected editor to be cleared got %v\n", text.Value)
+	}
+
+	input, err := tab.EvaluateScript(`document.getElementById("result").getAttribute("data-input")`)
+	if err != nil {
+		t.Fatalf("error getting result: %s\n", err)
+	}
+
+	if input.Value != "true" {
+		t.Fatalf("expected input event to be dispatched got %v\n", input.Value)
+	}
+
+	plain, err := tab.QuerySelector("#plain")
+	if err != nil {
+		t.Fatalf("error finding plain div: %s\n", err)
+	}
+
+	if _, ok := plain.Clear().(*browser.ErrIncorrectElementType); !ok {
+		t.Fatalf("expected clearing a non editable div to fail\n")
+	}
+}
+
+func TestElementAncestryPath(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/ancestry.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	ancestry, err := tab.ElementAncestry(`[name="q"]`)
+	if err != nil {
+		t.Fatalf("error getting ancestry: %s\n", err)
+	}
+
+	expected := []string{"html", "body.page", "div#main.content.wide", "form", "span", "input#query"}
+	if strings.Join(ancestry, " > ") != strings.Join(expected, " > ") {
+		t.Fatalf("expected ancestry %v got %v", expected, ancestry)
+	}
+}
+
+func TestElementGetParentForm(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/parent_form.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	for selector, formID := range map[string]string{"#inside": "search", "#associated": "comment"} {
+		ele, err := tab.QuerySelector(selector)
+		if err != nil {
+			t.Fatalf("error getting %s: %s\n", selector, err)
+		}
+
+		form, err := ele.GetParentForm()
+		if err != nil {
+			t.Fatalf("error getting parent form of %s: %s\n", selector, err)
+		}
+
+		if form.GetAttribute("id") != formID {
+			t.Fatalf("expected %s to belong to form %s got %s", selector, formID, form.GetAttribute("id"))
+		}
+	}
+
+	orphan, err := tab.QuerySelector("#orphan")
+	if err != nil {
+		t.Fatalf("error getting orphan: %s\n", err)
+	}
+
+	if _, err := orphan.GetParentForm(); err == nil {
+		t.Fatalf("expected orphan to not have a form")
+	} else if _, ok := err.(*browser.ErrElementNotFound); !ok {
+		t.Fatalf("expected ErrElementNotFound got %T %s", err, err)
+	}
+}
+
+func TestElementSetChecked(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/checkbox.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	agree, err := tab.QuerySelector("#agree")
+	if err != nil {
+		t.Fatalf("error getting checkbox: %s\n", err)
+	}
+
+	for _, checked := range []bool{true, false} {
+		if err := agree.SetChecked(checked); err != nil {
+			t.Fatalf("error setting checked to %v: %s\n", checked, err)
+		}
+
+		selected, err := agree.IsSelected()
+		if err != nil {
+			t.Fatalf("error getting selected: %s\n", err)
+		}
+
+		if selected != checked {
+			t.Fatalf("expected selected to be %v got %v", checked, selected)
+		}
+	}
+
+	var changes int
+	if err := tab.EvaluateJSON("window.changes", &changes); err != nil {
+		t.Fatalf("error getting change count: %s\n", err)
+	}
+
+	if changes != 2 {
+		t.Fatalf("expected 2 change events got %d", changes)
+	}
+
+	name, err := tab.QuerySelector("#name")
+	if err != nil {
+		t.Fatalf("error getting text input: %s\n", err)
+	}
+
+	if _, ok := name.SetChecked(true).(*browser.ErrIncorrectElementType); !ok {
+		t.Fatalf("expected ErrIncorrectElementType for text input")
+	}
+}
+
+func TestElementDragTo(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/slider.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	slider, err := tab.QuerySelector("#slider")
+	if err != nil {
+		t.Fatalf("error getting slider: %s\n", err)
+	}
+
+	rect, err := slider.GetRect()
+	if err != nil {
+		t.Fatalf("error getting slider rect: %s\n", err)
+	}
+
+	// drag the thumb to the end of the track
+	if err := slider.DragTo(rect.X+rect.Width-2, rect.Y+rect.Height/2); err != nil {
+		t.Fatalf("error dragging slider: %s\n", err)
+	}
+
+	var value float64
+	if err := tab.EvaluateJSON(`Number(document.getElementById("slider").value)`, &value); err != nil {
+		t.Fatalf("error getting slider value: %s\n", err)
+	}
+
+	if value < 90 {
+		t.Fatalf("expected slider to be dragged near its max got %v", value)
+	}
+}
+
+func TestElementScrollIntoView(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/sticky_header.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	target, err := tab.QuerySelector("#target")
+	if err != nil {
+		t.Fatalf("error getting target: %s\n", err)
+	}
+
+	if _, ok := target.ScrollIntoView("middle").(*browser.ErrInvalidScrollBlock); !ok {
+		t.Fatalf("expected ErrInvalidScrollBlock for invalid block")
+	}
+
+	// distance of the target's top from where the block alignment should put it
+	offset := map[string]string{
+		"start":  `document.getElementById("target").getBoundingClientRect().top`,
+		"center": `(function() { var r = document.getElementById("target").getBoundingClientRect(); return r.top + r.height / 2 - window.innerHeight / 2; })()`,
+		"end":    `document.getElementById("target").getBoundingClientRect().bottom - window.innerHeight`,
+	}
+
+	for _, block := range []string{"start", "center", "end"} {
+		if err := target.ScrollIntoView(block); err != nil {
+			t.Fatalf("error scrolling to %s: %s\n", block, err)
+		}
+
+		var distance float64
+		if err := tab.EvaluateJSON(offset[block], &distance); err != nil {
+			t.Fatalf("error getting target position: %s\n", err)
+		}
+
+		if math.Abs(distance) > 2 {
+			t.Fatalf("expected target to be scrolled to %s, off by %v", block, distance)
+		}
+	}
+
+	// at the start the header covers the target, click scrolls it to the center first
+	if err := target.ScrollIntoView("start"); err != nil {
+		t.Fatalf("error scrolling to start: %s\n", err)
+	}
+
+	if err := target.Click(); err != nil {
+		t.Fatalf("error clicking target: %s\n", err)
+	}
+
+	var clicked bool
+	if err := tab.EvaluateJSON("window.clicked === true", &clicked); err != nil {
+		t.Fatalf("error getting clicked: %s\n", err)
+	}
+
+	if !clicked {
+		t.Fatalf("expected click to reach the target under the sticky header")
+	}
+
+	// the sticky header check and the page activation share a single call into the page
+	trace := &traceBuffer{}
+	tab.EnableProtocolTrace(trace)
+	if err := target.Click(); err != nil {
+		t.Fatalf("error clicking target: %s\n", err)
+	}
+	tab.EnableProtocolTrace(nil)
+
+	runtimeCalls := 0
+	for _, line := range trace.Lines(t) {
+		if line.Type == browser.TraceCommand && strings.HasPrefix(line.Method, "Runtime.") {
+			runtimeCalls++
+		}
+	}
+
+	if runtimeCalls != 1 {
+		t.Fatalf("expected a click to make 1 runtime call got %d", runtimeCalls)
+	}
+}
+
+func TestElementCheckValidity(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/validation.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	zip, err := tab.QuerySelector("#zip")
+	if err != nil {
+		t.Fatalf("error getting input: %s\n", err)
+	}
+
+	if err := zip.SendKeys("abc"); err != nil {
+		t.Fatalf("error sending keys: %s\n", err)
+	}
+
+	valid, message, err := zip.CheckValidity()
+	if err != nil {
+		t.Fatalf("error checking validity: %s\n", err)
+	}
+
+	if valid || message == "" {
+		t.Fatalf("expected value not matching pattern to be invalid with a message got %v %q", valid, message)
+	}
+
+	if _, err := tab.EvaluateScript("document.getElementById('zip').value = '12345'"); err != nil {
+		t.Fatalf("error setting value: %s\n", err)
+	}
+
+	valid, message, err = zip.CheckValidity()
+	if err != nil {
+		t.Fatalf("error checking validity: %s\n", err)
+	}
+
+	if !valid || message != "" {
+		t.Fatalf("expected value matching pattern to be valid got %v %q", valid, message)
+	}
+
+	div, err := tab.QuerySelector("#notcontrol")
+	if err != nil {
+		t.Fatalf("error getting div: %s\n", err)
+	}
+
+	if _, _, err := div.CheckValidity(); err == nil {
+		t.Fatalf("expected error for element which isn't a form control")
+	}
+}
+
+func TestElementGetOptions(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/select.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	cars, err := tab.QuerySelector("#cars")
+	if err != nil {
+		t.Fatalf("error getting select: %s\n", err)
+	}
+
+	options, err := cars.GetOptions()
+	if err != nil {
+		t.Fatalf("error getting options: %s\n", err)
+	}
+
+	expected := []browserk.SelectOption{
+		{Value: "volvo", Text: "Volvo"},
+		{Value: "saab", Text: "Saab", Selected: true},
+		{Value: "", Text: "Other"},
+	}
+	if len(options) != len(expected) {
+		t.Fatalf("expected %d options got %+v", len(expected), options)
+	}
+
+	for i, option := range options {
+		if option != expected[i] {
+			t.Fatalf("expected option %d to be %+v got %+v", i, expected[i], option)
+		}
+	}
+
+	name, err := tab.QuerySelector("#name")
+	if err != nil {
+		t.Fatalf("error getting input: %s\n", err)
+	}
+
+	if _, err := name.GetOptions(); err == nil {
+		t.Fatalf("expected error for element which isn't a select")
+	}
+}
+
+func TestElementGetAncestors(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/ancestry.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	query, err := tab.QuerySelector("#query")
+	if err != nil {
+		t.Fatalf("error getting input: %s\n", err)
+	}
+
+	parent, err := query.GetParent()
+	if err != nil {
+		t.Fatalf("error getting parent: %s\n", err)
+	}
+
+	if tag, _ := parent.GetTagName(); tag != "span" {
+		t.Fatalf("expected parent to be span got %s", tag)
+	}
+
+	ancestors, err := query.GetAncestors()
+	if err != nil {
+		t.Fatalf("error getting ancestors: %s\n", err)
+	}
+
+	expected := []string{"span", "form", "div", "body", "html"}
+	if len(ancestors) != len(expected)+1 {
+		t.Fatalf("expected %d ancestors got %d", len(expected)+1, len(ancestors))
+	}
+
+	for i, tag := range expected {
+		if got, _ := ancestors[i].GetTagName(); got != tag {
+			t.Fatalf("expected ancestor %d to be %s got %s", i, tag, got)
+		}
+	}
+
+	document := ancestors[len(ancestors)-1]
+	if isDocument, _ := document.IsDocument(); !isDocument {
+		t.Fatalf("expected last ancestor to be the #document")
+	}
+
+	if _, err := document.GetParent(); err == nil {
+		t.Fatalf("expected error getting the parent of the #document")
+	}
+}
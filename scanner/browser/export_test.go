@@ -0,0 +1,55 @@
+package browser
+
+import (
+	"time"
+
+	"github.com/wirepair/gcd"
+	"github.com/wirepair/gcd/gcdapi"
+	"github.com/wirepair/gcd/gcdmessage"
+)
+
+// NewTestElement creates a ready element from node, without a tab
+func NewTestElement(node *gcdapi.DOMNode) *Element {
+	return newReadyElement(nil, node, 0)
+}
+
+// ClearNode removes the element's node reference as if it were detached
+func (e *Element) ClearNode() {
+	e.lock.Lock()
+	e.node = nil
+	e.lock.Unlock()
+}
+
+// BoxModelRequests returns how many times the element requested its box model
+func (e *Element) BoxModelRequests() int {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.boxRequests
+}
+
+// ResolveElement exposes resolveElement for testing
+func ResolveElement(retries int, resolve func() (*Element, error)) (*Element, error) {
+	return resolveElement(retries, resolve)
+}
+
+// RuntimeAlive exposes runtimeAlive for testing against targets without a browser
+func RuntimeAlive(runtime *gcdapi.Runtime, timeout time.Duration) bool {
+	return runtimeAlive(runtime, timeout)
+}
+
+// ReserveHost exposes reserve for testing
+func ReserveHost(h *HostThrottle, host string) time.Duration {
+	return h.reserve(host)
+}
+
+// PendingTimings returns how many requests the container is holding partial timings for
+func (c *Container) PendingTimings() int {
+	c.networkLock.Lock()
+	defer c.networkLock.Unlock()
+	return len(c.timings)
+}
+
+// BindDomains exposes bindDomains for testing
+func BindDomains(c *gcd.ChromeTarget, target gcdmessage.ChromeTargeter) {
+	bindDomains(c, target)
+}
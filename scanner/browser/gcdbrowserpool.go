@@ -3,6 +3,10 @@ package browser
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -59,8 +63,34 @@ type GCDBrowserPool struct {
 	leaser           LeaserService
 	startCount       int32
 	logger           zerolog.Logger
+	cfg              *browserk.Config
+	login            LoginFunc
+	refreshInterval  time.Duration
+	sessionLock      *sync.RWMutex
+	session          *browserk.Session
+	tabsPerBrowser   int
+	sharedLock       *sync.Mutex
+	shared           map[string]*sharedBrowser
+	maxHeapBytes     int64
+	traceFile        *os.File  // protocol trace file, see Config.ProtocolTrace
+	trace            io.Writer // protocol trace shared by all tabs
+	throttle         *HostThrottle
 }
 
+// sharedBrowser tracks a browser hosting multiple tabs, it is only recycled once all
+// of its tabs have been returned
+type sharedBrowser struct {
+	lock      *sync.Mutex         // serializes tab creation so targets aren't picked up by another take
+	owner     *gcd.ChromeTarget   // the browser's first tab, creates and disposes browser contexts
+	known     map[string]struct{} // target ids already connected to
+	remaining int                 // tabs not yet returned
+	tabs      []*Tab              // tabs taken from the browser, checked against the heap limit
+	recycle   bool                // exceeded the heap limit or has an unresponsive tab, remaining tabs are not handed out
+}
+
+// LoginFunc authenticates the tab, the resulting session is shared with all browsers in the pool
+type LoginFunc func(ctx *browserk.Context, tab *Tab) error
+
 // NewGCDBrowserPool number of pools, and a leaser that we can use
 func NewGCDBrowserPool(maxBrowsers int, leaser LeaserService) *GCDBrowserPool {
 	b := &GCDBrowserPool{}
@@ -68,6 +98,10 @@ func NewGCDBrowserPool(maxBrowsers int, leaser LeaserService) *GCDBrowserPool {
 	b.browserTimeout = time.Second * 45
 	b.leaser = leaser
 	b.browsers = make(chan *gcd.Gcd, b.maxBrowsers)
+	b.sessionLock = &sync.RWMutex{}
+	b.tabsPerBrowser = 1
+	b.sharedLock = &sync.Mutex{}
+	b.shared = make(map[string]*sharedBrowser)
 	return b
 }
 
@@ -76,6 +110,23 @@ func (b *GCDBrowserPool) UseDisplay(display string) {
 	b.display = fmt.Sprintf("DISPLAY=%s", display)
 }
 
+// SetConfig (to be called before Init()) for configuring tabs as they are created
+func (b *GCDBrowserPool) SetConfig(cfg *browserk.Config) {
+	b.cfg = cfg
+	if cfg != nil && cfg.TabsPerBrowser > 1 {
+		b.tabsPerBrowser = cfg.TabsPerBrowser
+	}
+	if cfg != nil {
+		b.maxHeapBytes = cfg.MaxBrowserHeapBytes
+		b.throttle = NewHostThrottle(cfg.PerHostRPS)
+	}
+}
+
+// Capacity is the number of tabs that can be taken at once
+func (b *GCDBrowserPool) Capacity() int {
+	return b.maxBrowsers * b.tabsPerBrowser
+}
+
 // Init starts the browser/Browser pool
 func (b *GCDBrowserPool) Init() error {
 	return b.Start()
@@ -86,8 +137,24 @@ func (b *GCDBrowserPool) SetAPITimeout(duration time.Duration) {
 	b.browserTimeout = duration
 }
 
-// Start the browser with a random profile directory and create Browsers
+// chromeChecker is implemented by leasers which start chrome locally
+type chromeChecker interface {
+	CheckChrome() error
+}
+
+// Start the browser with a random profile directory and create Browsers. Returns ErrChromeNotFound
+// if the leaser has no chrome to start.
 func (b *GCDBrowserPool) Start() error {
+	if checker, ok := b.leaser.(chromeChecker); ok {
+		if err := checker.CheckChrome(); err != nil {
+			return err
+		}
+	}
+
+	if err := b.openProtocolTrace(); err != nil {
+		return err
+	}
+
 	// allow 3 seconds per Browser
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(b.maxBrowsers*3))
 	defer cancel()
@@ -97,8 +164,11 @@ func (b *GCDBrowserPool) Start() error {
 		panic(fmt.Sprintf("failed to clean up browsers %s", err))
 	}
 
-	log.Info().Int("browsers", b.maxBrowsers).Msg("creating browsers")
-	b.browsers = make(chan *gcd.Gcd, b.maxBrowsers)
+	log.Info().Int("browsers", b.maxBrowsers).Int("tabs_per_browser", b.tabsPerBrowser).Msg("creating browsers")
+	b.browsers = make(chan *gcd.Gcd, b.Capacity())
+	b.sharedLock.Lock()
+	b.shared = make(map[string]*sharedBrowser)
+	b.sharedLock.Unlock()
 
 	atomic.AddInt32(&b.startCount, 1)
 	currentCount := atomic.LoadInt32(&b.startCount)
@@ -184,6 +254,9 @@ func (b *GCDBrowserPool) closeAndCreateBrowser(port string, doneCh chan struct{}
 			log.Error().Err(err).Msg("failed to return browser")
 		}
 		atomic.AddInt32(&b.acquiredBrowsers, -1)
+		b.sharedLock.Lock()
+		delete(b.shared, port)
+		b.sharedLock.Unlock()
 	}
 
 	// if we've restarted and this browser was still leased, we don't want to create another one
@@ -197,7 +270,7 @@ func (b *GCDBrowserPool) closeAndCreateBrowser(port string, doneCh chan struct{}
 	port, err := b.leaser.Acquire()
 	if err != nil {
 		log.Warn().Err(err).Msg("unable to acquire new browser")
-		b.browsers <- nil
+		b.addBrowser(nil)
 		close(doneCh)
 		return
 	}
@@ -207,51 +280,471 @@ func (b *GCDBrowserPool) closeAndCreateBrowser(port string, doneCh chan struct{}
 		newBr = nil
 	}
 
-	b.browsers <- newBr
+	if newBr != nil && b.tabsPerBrowser > 1 {
+		if err := b.share(newBr); err != nil {
+			log.Warn().Err(err).Msg("failed to connect to first tab of shared browser")
+			if err := b.leaser.Return(port); err != nil {
+				log.Error().Err(err).Msg("failed to return browser")
+			}
+			newBr = nil
+		}
+	}
+
+	b.addBrowser(newBr)
 	close(doneCh)
 }
 
+// addBrowser to the pool once for each tab it hosts
+func (b *GCDBrowserPool) addBrowser(br *gcd.Gcd) {
+	for i := 0; i < b.tabsPerBrowser; i++ {
+		b.browsers <- br
+	}
+}
+
+// share the browser between tabsPerBrowser tabs, connecting to its first tab which owns the
+// browser contexts each tab is created in
+func (b *GCDBrowserPool) share(br *gcd.Gcd) error {
+	owner, err := br.GetFirstTab()
+	if err != nil {
+		return err
+	}
+
+	b.sharedLock.Lock()
+	b.shared[br.Port()] = &sharedBrowser{
+		lock:      &sync.Mutex{},
+		owner:     owner,
+		known:     map[string]struct{}{owner.Target.Id: {}},
+		remaining: b.tabsPerBrowser,
+	}
+	b.sharedLock.Unlock()
+	return nil
+}
+
+// sharedBrowser for the port, nil if the browser isn't shared
+func (b *GCDBrowserPool) sharedBrowser(port string) *sharedBrowser {
+	b.sharedLock.Lock()
+	defer b.sharedLock.Unlock()
+	return b.shared[port]
+}
+
+// SetLogin (to be called before Warmup()) sets how the pool authenticates, re-authenticating every
+// refreshInterval (0 to never refresh)
+func (b *GCDBrowserPool) SetLogin(login LoginFunc, refreshInterval time.Duration) {
+	b.login = login
+	b.refreshInterval = refreshInterval
+}
+
+// Warmup logs in once with a single browser and shares the resulting session with every
+// browser subsequently taken from the pool, so they start pre-authenticated. If a refresh
+// interval is set, the session is refreshed in the background until ctx is done.
+func (b *GCDBrowserPool) Warmup(ctx *browserk.Context) error {
+	if b.login == nil {
+		return nil
+	}
+
+	if err := b.authenticate(ctx); err != nil {
+		return err
+	}
+
+	if b.refreshInterval > 0 {
+		go b.refreshSession(ctx)
+	}
+	return nil
+}
+
+// Session returns the shared login session, or nil if not authenticated
+func (b *GCDBrowserPool) Session() *browserk.Session {
+	b.sessionLock.RLock()
+	defer b.sessionLock.RUnlock()
+	return b.session
+}
+
+// authenticate with a fresh browser and capture the session
+func (b *GCDBrowserPool) authenticate(ctx *browserk.Context) error {
+	tab, port, err := b.take(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer b.Return(ctx.Ctx, port)
+
+	if err := b.login(ctx, tab); err != nil {
+		return errors.Wrap(err, "login failed")
+	}
+
+	session, err := tab.GetSession()
+	if err != nil {
+		return errors.Wrap(err, "failed to capture login session")
+	}
+
+	b.sessionLock.Lock()
+	b.session = session
+	b.sessionLock.Unlock()
+	log.Info().Int("cookies", len(session.Cookies)).Str("origin", session.Origin).Msg("captured login session")
+	return nil
+}
+
+// refreshSession re-authenticates every refreshInterval, browsers taken afterwards get the new session
+func (b *GCDBrowserPool) refreshSession(ctx *browserk.Context) {
+	ticker := time.NewTicker(b.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Ctx.Done():
+			return
+		case <-ticker.C:
+			if atomic.LoadInt32(&b.closing) == 1 {
+				continue
+			}
+			if err := b.authenticate(ctx); err != nil {
+				log.Warn().Err(err).Msg("failed to refresh login session, keeping previous session")
+			}
+		}
+	}
+}
+
 // Take a browser
 func (b *GCDBrowserPool) Take(ctx *browserk.Context) (browserk.Browser, string, error) {
+	gtab, port, err := b.take(ctx, false)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if session := b.Session(); session != nil {
+		if err := gtab.SetSession(session); err != nil {
+			log.Warn().Err(err).Msg("failed to apply login session to tab")
+		}
+	}
+	return gtab, port, nil
+}
+
+// TakeUnauthenticated takes a browser without applying the login session, its tab is created in a
+// new incognito browser context so no cookies or storage of authenticated tabs are shared with it
+func (b *GCDBrowserPool) TakeUnauthenticated(ctx *browserk.Context) (browserk.Browser, string, error) {
+	gtab, port, err := b.take(ctx, true)
+	if err != nil {
+		return nil, "", err
+	}
+	return gtab, port, nil
+}
+
+// take a browser and configure its tab, without applying the login session. If isolate is set
+// the tab is always created in a new browser context. Tabs which do not respond are closed and
+// their browser is returned to be recycled.
+func (b *GCDBrowserPool) take(ctx *browserk.Context, isolate bool) (*Tab, string, error) {
+	gtab, port, err := b.takeTab(ctx, isolate)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !gtab.IsAlive() {
+		log.Warn().Str("port", port).Msg("taken tab is unresponsive, recycling browser")
+		b.recycle(port)
+		gtab.Close()
+		b.Return(ctx.Ctx, port)
+		return nil, "", ErrTabUnresponsive
+	}
+	return gtab, port, nil
+}
+
+// takeTab acquires a browser and creates or connects to its tab, or creates a tab in a new browser
+// context if isolate or IsolateSessions is set
+func (b *GCDBrowserPool) takeTab(ctx *browserk.Context, isolate bool) (*Tab, string, error) {
 	var br *gcd.Gcd
 
 	if atomic.LoadInt32(&b.closing) == 1 {
 		return nil, "", ErrBrowserClosing
 	}
-	// if nil, do not return browser
-	if br = b.Acquire(ctx.Ctx); br == nil {
-		return nil, "", errors.New("browser acquisition failed during Take")
+	for {
+		// if nil, do not return browser
+		if br = b.Acquire(ctx.Ctx); br == nil {
+			return nil, "", errors.New("browser acquisition failed during Take")
+		}
+
+		if !b.recycling(br.Port()) {
+			break
+		}
+		// the browser exceeded the heap limit, give back its remaining tabs so it's recycled
+		b.Return(ctx.Ctx, br.Port())
 	}
 
 	log.Info().Int32("acquired", atomic.LoadInt32(&b.acquiredBrowsers)).Int32("errors", atomic.LoadInt32(&b.acquireErrors)).Msg("acquired browser")
+	if shared := b.sharedBrowser(br.Port()); shared != nil {
+		// tabs sharing a browser are always isolated from each other
+		shared.lock.Lock()
+		defer shared.lock.Unlock()
+		gtab, port, err := b.takeIsolated(ctx, br, shared.owner, shared.known)
+		if err == nil {
+			b.sharedLock.Lock()
+			shared.tabs = append(shared.tabs, gtab)
+			b.sharedLock.Unlock()
+		}
+		return gtab, port, err
+	}
+
 	t, err := br.GetFirstTab()
 	if err != nil {
 		b.Return(ctx.Ctx, br.Port())
 		return nil, "", fmt.Errorf("failed to aquire valid tab from browser")
 	}
+
+	if isolate || (b.cfg != nil && b.cfg.IsolateSessions) {
+		return b.takeIsolated(ctx, br, t, map[string]struct{}{t.Target.Id: {}})
+	}
 	gtab := NewTab(ctx, br, t)
+	b.configureTab(gtab)
 	return gtab, br.Port(), nil
 }
 
-// Return a browser for destruction
+// takeIsolated creates a tab in a new incognito browser context so its cookies and storage
+// are not shared, the context is disposed when the tab is closed. known are the ids of targets
+// already connected to, the new target's id is added to it.
+func (b *GCDBrowserPool) takeIsolated(ctx *browserk.Context, br *gcd.Gcd, first *gcd.ChromeTarget, known map[string]struct{}) (*Tab, string, error) {
+	contextID, err := first.TargetApi.CreateBrowserContext(true)
+	if err != nil {
+		b.Return(ctx.Ctx, br.Port())
+		return nil, "", errors.Wrap(err, "failed to create browser context")
+	}
+
+	targetID, err := first.TargetApi.CreateTarget("about:blank", 0, 0, contextID, false, false, false)
+	if err != nil {
+		b.Return(ctx.Ctx, br.Port())
+		return nil, "", errors.Wrap(err, "failed to create target in browser context")
+	}
+
+	targets, err := br.GetNewTargets(known)
+	if err != nil {
+		b.Return(ctx.Ctx, br.Port())
+		return nil, "", errors.Wrap(err, "failed to connect to target in browser context")
+	}
+
+	for _, t := range targets {
+		if t.Target.Id != targetID {
+			continue
+		}
+		known[targetID] = struct{}{}
+		gtab := NewTab(ctx, br, t)
+		gtab.setBrowserContext(first, contextID)
+		b.configureTab(gtab)
+		return gtab, br.Port(), nil
+	}
+
+	b.Return(ctx.Ctx, br.Port())
+	return nil, "", fmt.Errorf("failed to find target %s in browser context", targetID)
+}
+
+// configureTab applies config settings to a newly created tab
+func (b *GCDBrowserPool) configureTab(tab *Tab) {
+	if b.cfg == nil {
+		return
+	}
+
+	if b.cfg.GeofenceScope && tab.ctx.Scope != nil {
+		tab.SetGeofenceScope(tab.ctx.Scope)
+	}
+
+	if b.cfg.StopLoadingAfter > 0 {
+		tab.SetStopLoadingTimeout(b.cfg.StopLoadingAfter)
+	}
+
+	if b.cfg.ResolveRetries > 0 {
+		tab.SetElementResolveRetries(b.cfg.ResolveRetries)
+	}
+
+	if b.cfg.MaxResponseBodyBytes > 0 {
+		tab.SetMaxBodySize(b.cfg.MaxResponseBodyBytes, filepath.Join(b.cfg.DataPath, "bodies"))
+	}
+
+	if b.cfg.ResourceBufferSize > 0 || b.cfg.TotalBufferSize > 0 {
+		if err := tab.SetNetworkBufferSizes(bufferSize(b.cfg.ResourceBufferSize), bufferSize(b.cfg.TotalBufferSize)); err != nil {
+			log.Warn().Err(err).Msg("failed to set network buffer sizes")
+		}
+	}
+
+	if b.cfg.ColorScheme != "" {
+		if err := tab.EmulateColorScheme(b.cfg.ColorScheme); err != nil {
+			log.Warn().Err(err).Str("scheme", b.cfg.ColorScheme).Msg("failed to emulate color scheme")
+		}
+	}
+
+	if b.cfg.IgnoreCertErrors {
+		if err := tab.IgnoreCertificateErrors(true); err != nil {
+			log.Warn().Err(err).Msg("failed to ignore certificate errors")
+		}
+	}
+
+	if username, password, ok := ProxyCredentials(b.cfg); ok {
+		if err := tab.SetProxyCredentials(username, password); err != nil {
+			log.Warn().Err(err).Msg("failed to set proxy credentials")
+		}
+	}
+
+	if b.trace != nil {
+		tab.EnableProtocolTrace(b.trace)
+	}
+
+	if b.throttle != nil {
+		tab.SetHostThrottle(b.throttle)
+	}
+
+	if b.cfg.CPUThrottle > 1 {
+		if err := tab.SetCPUThrottling(b.cfg.CPUThrottle); err != nil {
+			log.Warn().Err(err).Float64("rate", b.cfg.CPUThrottle).Msg("failed to set cpu throttling")
+		}
+	}
+}
+
+// openProtocolTrace file tabs append their protocol traffic to, if enabled
+func (b *GCDBrowserPool) openProtocolTrace() error {
+	if b.cfg == nil || b.cfg.ProtocolTrace == "" || b.trace != nil {
+		return nil
+	}
+
+	f, err := os.OpenFile(b.cfg.ProtocolTrace, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open protocol trace")
+	}
+	b.traceFile = f
+	b.trace = &lockedWriter{lock: &sync.Mutex{}, w: f}
+	return nil
+}
+
+// Return a browser for destruction, a shared browser is only destroyed once all of its tabs
+// have been returned
 func (b *GCDBrowserPool) Return(ctx context.Context, browserPort string) {
 	startCount := atomic.LoadInt32(&b.startCount) // track if we've restarted so we can throw away bad browsers
+	b.checkAlive(browserPort)
+	b.checkHeap(browserPort)
+	if !b.releaseTab(browserPort) {
+		atomic.AddInt32(&b.acquiredBrowsers, -1)
+		return
+	}
 	log.Info().Msg("closing browser")
 	b.returnBrowser(ctx, browserPort, startCount)
 	return
 }
 
+// releaseTab of a shared browser, returns true if the browser has no tabs left and should be destroyed
+func (b *GCDBrowserPool) releaseTab(port string) bool {
+	b.sharedLock.Lock()
+	defer b.sharedLock.Unlock()
+
+	shared, ok := b.shared[port]
+	if !ok {
+		return true
+	}
+	shared.remaining--
+	return shared.remaining <= 0
+}
+
+// checkHeap of a shared browser's open tabs, flagging it to be recycled if their combined
+// javascript heap exceeds MaxBrowserHeapBytes. Browsers with a single tab are always recycled
+// on return.
+func (b *GCDBrowserPool) checkHeap(port string) {
+	if b.maxHeapBytes <= 0 {
+		return
+	}
+
+	shared := b.sharedBrowser(port)
+	if shared == nil {
+		return
+	}
+
+	b.sharedLock.Lock()
+	tabs := make([]*Tab, len(shared.tabs))
+	copy(tabs, shared.tabs)
+	b.sharedLock.Unlock()
+
+	heapBytes := int64(0)
+	for _, tab := range tabs {
+		used, err := tab.GetResourceUsage()
+		if err != nil {
+			continue
+		}
+		heapBytes += used
+	}
+
+	if heapBytes <= b.maxHeapBytes {
+		return
+	}
+
+	b.recycle(port)
+	log.Info().Str("port", port).Int64("heap_bytes", heapBytes).Int64("max_heap_bytes", b.maxHeapBytes).Msg("browser exceeded heap limit, recycling")
+}
+
+// checkAlive of a shared browser's open tabs, flagging it to be recycled if any of them no
+// longer respond. Tabs are probed concurrently so a return waits for at most one probe timeout.
+func (b *GCDBrowserPool) checkAlive(port string) {
+	shared := b.sharedBrowser(port)
+	if shared == nil {
+		return
+	}
+
+	b.sharedLock.Lock()
+	tabs := make([]*Tab, len(shared.tabs))
+	copy(tabs, shared.tabs)
+	b.sharedLock.Unlock()
+
+	var unresponsive int32
+	var wg sync.WaitGroup
+	for _, tab := range tabs {
+		// closed tabs were returned already
+		if tab.IsShuttingDown() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(tab *Tab) {
+			defer wg.Done()
+			if !tab.IsAlive() {
+				atomic.AddInt32(&unresponsive, 1)
+			}
+		}(tab)
+	}
+	wg.Wait()
+
+	if unresponsive > 0 {
+		b.recycle(port)
+		log.Warn().Str("port", port).Int32("unresponsive", unresponsive).Msg("browser has an unresponsive tab, recycling")
+	}
+}
+
+// recycle the shared browser once all of its tabs have been returned, no new tabs are taken from it
+func (b *GCDBrowserPool) recycle(port string) {
+	b.sharedLock.Lock()
+	defer b.sharedLock.Unlock()
+	if shared, ok := b.shared[port]; ok {
+		shared.recycle = true
+	}
+}
+
+// recycling returns true if the shared browser exceeded the heap limit or has an unresponsive tab
+func (b *GCDBrowserPool) recycling(port string) bool {
+	b.sharedLock.Lock()
+	defer b.sharedLock.Unlock()
+	shared, ok := b.shared[port]
+	return ok && shared.recycle
+}
+
 // Close all browsers and return. TODO: make this not terrible.
 func (b *GCDBrowserPool) Close(ctx context.Context) error {
 	if !atomic.CompareAndSwapInt32(&b.closing, 0, 1) {
 		return nil
 	}
+	if b.traceFile != nil {
+		defer b.traceFile.Close()
+	}
 
+	// shared browsers are in the pool once per tab
+	returned := make(map[string]struct{})
 	for {
 		br := b.Acquire(ctx)
 		if br != nil {
-			if err := b.leaser.Return(br.Port()); err != nil {
-				log.Error().Err(err).Msg("failed to return browser")
+			if _, exists := returned[br.Port()]; !exists {
+				returned[br.Port()] = struct{}{}
+				if err := b.leaser.Return(br.Port()); err != nil {
+					log.Error().Err(err).Msg("failed to return browser")
+				}
 			}
 		}
 
@@ -276,3 +769,11 @@ func (b *GCDBrowserPool) Shutdown() error {
 	_, err := b.leaser.Cleanup()
 	return err
 }
+
+// bufferSize for chrome's network buffers, 0 (unset) is unlimited
+func bufferSize(size int) int {
+	if size <= 0 {
+		return -1
+	}
+	return size
+}
@@ -0,0 +1,346 @@
+package browser_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/browser"
+)
+
+func TestPoolWarmupSharesSession(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(2, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+	target := fmt.Sprintf("http://localhost:%s/index.html", p)
+
+	var logins int32
+	pool.SetLogin(func(ctx *browserk.Context, tab *browser.Tab) error {
+		atomic.AddInt32(&logins, 1)
+		if err := tab.Navigate(ctx.Ctx, target); err != nil {
+			return err
+		}
+		_, err := tab.EvaluateScript("document.cookie = 'session=loggedin; path=/'; localStorage.setItem('token', 'secret');")
+		return err
+	}, 0)
+
+	if err := pool.Warmup(bCtx); err != nil {
+		t.Fatalf("error warming up pool: %s\n", err)
+	}
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, target); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	cookie, err := tab.EvaluateScript("document.cookie")
+	if err != nil {
+		t.Fatalf("error getting cookie: %s\n", err)
+	}
+	if !strings.Contains(cookie.Value.(string), "session=loggedin") {
+		t.Fatalf("expected session cookie, got %v", cookie.Value)
+	}
+
+	token, err := tab.EvaluateScript("localStorage.getItem('token')")
+	if err != nil {
+		t.Fatalf("error getting storage: %s\n", err)
+	}
+	if token.Value != "secret" {
+		t.Fatalf("expected token in local storage, got %v", token.Value)
+	}
+
+	if atomic.LoadInt32(&logins) != 1 {
+		t.Fatalf("expected a single login, got %d", logins)
+	}
+}
+
+func TestPoolIsolateSessions(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(2, leaser)
+	pool.SetConfig(&browserk.Config{IsolateSessions: true})
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+	target := fmt.Sprintf("http://localhost:%s/index.html", p)
+
+	tabs := make([]*browser.Tab, 2)
+	for i := range tabs {
+		b, _, err := pool.Take(bCtx)
+		if err != nil {
+			t.Fatalf("error taking browser: %s\n", err)
+		}
+		tabs[i] = b.(*browser.Tab)
+		defer tabs[i].Close()
+
+		if tabs[i].BrowserContextID() == "" {
+			t.Fatalf("expected tab to be created in its own browser context")
+		}
+
+		if err := tabs[i].Navigate(ctx, target); err != nil {
+			t.Fatalf("error navigating %s\n", err)
+		}
+	}
+
+	if _, err := tabs[0].EvaluateScript("document.cookie = 'worker=one; path=/'"); err != nil {
+		t.Fatalf("error setting cookie: %s\n", err)
+	}
+
+	cookie, err := tabs[0].EvaluateScript("document.cookie")
+	if err != nil || !strings.Contains(cookie.Value.(string), "worker=one") {
+		t.Fatalf("expected cookie to be set in first worker: %v %v", cookie, err)
+	}
+
+	cookie, err = tabs[1].EvaluateScript("document.cookie")
+	if err != nil {
+		t.Fatalf("error getting cookie: %s\n", err)
+	}
+
+	if strings.Contains(cookie.Value.(string), "worker=one") {
+		t.Fatalf("expected cookie to be invisible to second worker got %v", cookie.Value)
+	}
+}
+
+func TestPoolIgnoreCertErrors(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	pool.SetConfig(&browserk.Config{IgnoreCertErrors: true})
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	// self signed certificate
+	srv := httptest.NewTLSServer(http.FileServer(http.Dir("testdata/")))
+	defer srv.Close()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	reporter := mock.MakeMockReporter()
+	bCtx.Reporter = reporter
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	defer b.Close()
+
+	if err := b.Navigate(ctx, srv.URL+"/index.html"); err != nil {
+		t.Fatalf("expected navigation to succeed with certificate errors ignored: %s\n", err)
+	}
+
+	current, err := b.GetURL()
+	if err != nil || !strings.HasPrefix(current, srv.URL) {
+		t.Fatalf("expected to be on %s got %s (%v)", srv.URL, current, err)
+	}
+
+	found := 0
+	for _, report := range reporter.Reports {
+		if report.VulnID == browser.CertificateErrorVulnID && report.Severity == browserk.Info {
+			found++
+		}
+	}
+
+	if found != 1 {
+		t.Fatalf("expected certificate error to be reported once as an info finding got %d", found)
+	}
+}
+
+func TestPoolPersistentProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatalf("error creating profile dir: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	// each run uses its own leaser and pool with the same profile dir
+	run := func(page string) []*browserk.Cookie {
+		runLeaser := browser.NewLocalLeaser()
+		runLeaser.SetUserDataDir(dir)
+		pool := browser.NewGCDBrowserPool(1, runLeaser)
+		if err := pool.Init(); err != nil {
+			t.Fatalf("failed to init pool")
+		}
+		defer pool.Close(ctx)
+
+		b, port, err := pool.Take(bCtx)
+		if err != nil {
+			t.Fatalf("error taking browser: %s\n", err)
+		}
+		defer pool.Return(ctx, port)
+
+		if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/%s", p, page)); err != nil {
+			t.Fatalf("error navigating %s\n", err)
+		}
+
+		cookies, err := b.GetCookies()
+		if err != nil {
+			t.Fatalf("error getting cookies %s\n", err)
+		}
+		b.Close()
+		return cookies
+	}
+
+	hasCookie := func(cookies []*browserk.Cookie) bool {
+		for _, cookie := range cookies {
+			if cookie.Name == "remember_me" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !hasCookie(run("persist_cookie.html")) {
+		t.Fatalf("expected cookie to be set in first run")
+	}
+
+	if !hasCookie(run("index.html")) {
+		t.Fatalf("expected cookie from first run to be present in second run")
+	}
+}
+
+func TestPoolTabsPerBrowser(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(2, leaser)
+	pool.SetConfig(&browserk.Config{TabsPerBrowser: 2})
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	if pool.Capacity() != 4 {
+		t.Fatalf("expected capacity of 4 got %d\n", pool.Capacity())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	bCtx := mock.Context(ctx)
+
+	ports := make(map[string]int)
+	contexts := make(map[string]struct{})
+	tabs := make([]*browser.Tab, 0)
+	tabPorts := make([]string, 0)
+	for i := 0; i < pool.Capacity(); i++ {
+		b, port, err := pool.Take(bCtx)
+		if err != nil {
+			t.Fatalf("error taking tab %d: %s\n", i, err)
+		}
+		tab := b.(*browser.Tab)
+		tabs = append(tabs, tab)
+		tabPorts = append(tabPorts, port)
+		ports[port]++
+		contexts[tab.BrowserContextID()] = struct{}{}
+	}
+
+	if len(ports) != 2 {
+		t.Fatalf("expected tabs from 2 browsers got %d\n", len(ports))
+	}
+
+	if len(contexts) != 4 {
+		t.Fatalf("expected each tab in its own browser context got %d\n", len(contexts))
+	}
+
+	if pool.Leased() != 4 {
+		t.Fatalf("expected 4 leased tabs got %d\n", pool.Leased())
+	}
+
+	for i, tab := range tabs {
+		tab.Close()
+		pool.Return(ctx, tabPorts[i])
+	}
+
+	if pool.Leased() != 0 {
+		t.Fatalf("expected no leased tabs got %d\n", pool.Leased())
+	}
+}
+
+func TestPoolInitChromeNotFound(t *testing.T) {
+	missing := browser.NewLocalLeaser()
+	missing.SetChromePath("/nonexistent/chrome")
+	pool := browser.NewGCDBrowserPool(1, missing)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- pool.Init()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != browser.ErrChromeNotFound {
+			t.Fatalf("expected ErrChromeNotFound got %v\n", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected init to fail fast without chrome")
+	}
+}
+
+func TestPoolRecyclesBrowserOverHeapLimit(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	// any page's heap exceeds a single byte
+	pool.SetConfig(&browserk.Config{TabsPerBrowser: 2, MaxBrowserHeapBytes: 1})
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	bCtx := mock.Context(ctx)
+
+	b, port, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking tab: %s\n", err)
+	}
+
+	heapBytes, err := b.(*browser.Tab).GetResourceUsage()
+	if err != nil {
+		t.Fatalf("error getting resource usage: %s\n", err)
+	}
+
+	if heapBytes <= 1 {
+		t.Fatalf("expected heap usage over the limit got %d\n", heapBytes)
+	}
+
+	// the browser still has a tab to hand out, but is over the limit so it must be recycled
+	pool.Return(ctx, port)
+
+	next, nextPort, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking tab after return: %s\n", err)
+	}
+	defer pool.Return(ctx, nextPort)
+	defer next.Close()
+
+	if nextPort == port {
+		t.Fatalf("expected browser over the heap limit to be recycled, got the same browser %s\n", port)
+	}
+	b.Close()
+}
@@ -36,21 +36,60 @@ type Tab struct {
 	debug                 bool                   // for debug printing
 	nodeChange            chan *NodeChangeEvent  // for receiving node change events from tab_subscribers
 	navigationCh          chan int               // for receiving navigation complete messages while isNavigating is true
+	frameNavigatedCh      chan string            // for receiving the url the top frame navigated to
 	docUpdateCh           chan struct{}          // for receiving document update completion while isNavigating is true
 	crashedCh             chan string            // the chrome tab crashed with a reason
+	crashed               atomic.Value           // has the chrome tab crashed
 	exitCh                chan struct{}          // for when we close the tab, kill go routines
 	shutdown              atomic.Value           // have we already shut down
 	disconnectedHandler   TabDisconnectedHandler // called with reason the chrome tab was disconnected from the debugger service
 	navigationTimeout     time.Duration          // amount of time to wait before failing navigation
 	elementTimeout        time.Duration          // amount of time to wait for element readiness
+	resolveRetries        int                    // times to retry resolving a selector whose node was removed mid resolve
 	stabilityTimeout      time.Duration          // amount of time to give up waiting for stability
 	stableAfter           time.Duration          // amount of time of no activity to consider the DOM stable
+	stopLoadingAfter      time.Duration          // amount of time to wait for the load event before stopping the page load
 	lastNodeChangeTimeVal atomic.Value           // timestamp of when the last node change occurred atomic because multiple go routines will modify
 	domChangeHandler      DomChangeHandlerFunc   // allows the caller to be notified of DOM change events.
 	docWasUpdated         atomic.Value           // for tracking if an execution caused a new page load/transition
+	maxBodySize           int                    // response bodies larger than this are spilled to bodyDir (0 for unlimited)
+	bodyDir               string                 // directory to spill large response bodies to
+	browserContextID      string                 // incognito browser context the tab was created in (empty for the default)
+	contextOwner          *gcd.ChromeTarget      // target which created the browser context, used to dispose of it
+	certErrors            map[string]struct{}    // origin and error type of certificate errors already reported
+	certMutex             *sync.Mutex            // protects certErrors
+	securityState         atomic.Value           // the last visible security state of the page
+	userGestures          int32                  // number of SimulateUserGesture calls in progress
+	geometryGeneration    int64                  // incremented when the page scrolls, resizes or its DOM changes, invalidating cached element dimensions
+	geofence              atomic.Value           // scope top frame navigations are limited to, see SetGeofenceScope
+	tracer                *protocolTracer        // commands are sent through it so they can be traced, see EnableProtocolTrace
+	navigationReferrer    atomic.Value           // referrer sent by the next load url action, see SetExtraNavigationReferrer
+	throttle              *HostThrottle          // per host request rate limit shared with the pool's other tabs (nil for unlimited)
 
 	frameMutex *sync.RWMutex
 	frames     map[string]int // frames
+
+	geofenceMutex *sync.RWMutex                  // locks our out of scope redirect attempts
+	outOfScope    []*browserk.OutOfScopeRedirect // top frame navigations aborted by the geofence
+
+	unloadMutex    *sync.Mutex // locks our blocked unloads
+	blockedUnloads []string    // urls of pages whose beforeunload handler prompted before leaving them
+
+	stubMutex *sync.RWMutex   // locks our stubbed responses
+	stubs     []*responseStub // responses answering matching requests, most recently added first
+
+	contextMutex *sync.RWMutex                    // locks our execution contexts when created/destroyed
+	contexts     map[contextKey]*executionContext // javascript execution contexts of the page and its workers
+
+	workerMutex     *sync.RWMutex                       // locks our workers, their captures and pending calls
+	workers         map[string]*gcdapi.TargetTargetInfo // attached workers by session id
+	captures        map[string]*workerCapture           // scripts and requests of all workers seen, by target id
+	workerCalls     map[int64]chan *workerMessage       // calls to worker sessions waiting for a response, by message id
+	workerMessageID int64                               // id of the last message sent to a worker session
+
+	mediaMutex    *sync.Mutex       // locks our emulated media
+	mediaType     string            // emulated css media type (print, screen), empty for the default
+	mediaFeatures map[string]string // emulated media features, see SetEmulatedMediaFeatures
 }
 
 // NewTab to use
@@ -67,19 +106,42 @@ func NewTab(bctx *browserk.Context, gcdBrowser *gcd.Gcd, tab *gcd.ChromeTarget)
 
 	t.frames = make(map[string]int)
 	t.frameMutex = &sync.RWMutex{}
+	t.geofenceMutex = &sync.RWMutex{}
+	t.stubMutex = &sync.RWMutex{}
+	t.unloadMutex = &sync.Mutex{}
+	t.certErrors = make(map[string]struct{})
+	t.certMutex = &sync.Mutex{}
+	t.outOfScope = make([]*browserk.OutOfScopeRedirect, 0)
+
+	t.contexts = make(map[contextKey]*executionContext)
+	t.contextMutex = &sync.RWMutex{}
+	t.workers = make(map[string]*gcdapi.TargetTargetInfo)
+	t.captures = make(map[string]*workerCapture)
+	t.workerCalls = make(map[int64]chan *workerMessage)
+	t.workerMutex = &sync.RWMutex{}
+	t.mediaMutex = &sync.Mutex{}
+	t.mediaFeatures = make(map[string]string)
 
 	t.nodeChange = make(chan *NodeChangeEvent)
-	t.navigationCh = make(chan int, 1)  // for signaling navigation complete
+	t.navigationCh = make(chan int, 1) // for signaling navigation complete
+	t.frameNavigatedCh = make(chan string, 1)
 	t.docUpdateCh = make(chan struct{}) // wait for documentUpdate to be called during navigation
 	t.crashedCh = make(chan string)     // reason the tab crashed/was disconnected.
 	t.exitCh = make(chan struct{})
 	t.navigationTimeout = 30 * time.Second // default 30 seconds for timeout
 	t.elementTimeout = 5 * time.Second     // default 5 seconds for waiting for element.
+	t.resolveRetries = 3                   // default 3 retries when a node is removed while resolving it
 	t.stabilityTimeout = 2 * time.Second   // default 2 seconds before we give up waiting for stability
 	t.stableAfter = 300 * time.Millisecond // default 300 ms for considering the DOM stable
+	t.stopLoadingAfter = 15 * time.Second  // default 15 seconds before stopping a page that never finishes loading
 	t.domChangeHandler = nil
 	t.baseHref.Store("")
+	t.crashed.Store(false)
+	t.navigationReferrer.Store("")
 	t.disconnectedHandler = t.defaultDisconnectedHandler
+	// domains are bound before any goroutine uses them
+	t.tracer = newProtocolTracer(tab, id)
+	bindDomains(tab, t.tracer)
 	go t.listenDebuggerEvents(bctx)
 	t.subscribeBrowserEvents(bctx, true)
 	return t
@@ -96,16 +158,37 @@ func (t *Tab) defaultDisconnectedHandler(tab *Tab, reason string) {
 
 // Close the exit channel and tab
 func (t *Tab) Close() {
+	t.setShutdownState(true)
 	t.g.CloseTab(t.t)
+	if t.browserContextID != "" {
+		if _, err := t.contextOwner.TargetApi.DisposeBrowserContext(t.browserContextID); err != nil {
+			t.ctx.Log.Warn().Err(err).Msg("failed to dispose browser context")
+		}
+	}
 	close(t.exitCh)
 }
 
+// setBrowserContext the tab was created in, and the target that created it
+func (t *Tab) setBrowserContext(owner *gcd.ChromeTarget, contextID string) {
+	t.contextOwner = owner
+	t.browserContextID = contextID
+}
+
+// BrowserContextID the tab was created in, empty if the default browser context
+func (t *Tab) BrowserContextID() string {
+	return t.browserContextID
+}
+
 // ExecuteAction for this browser, calling js handler after it is called
 func (t *Tab) ExecuteAction(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
 	var err error
 	var ele *Element
 	causedLoad := false
 	// Call JSBefore hooks
+	if t.IsCrashed() {
+		return nil, false, ErrTabCrashed
+	}
+
 	t.ctx.NextJSBefore(t)
 
 	// reset doc was updated flag
@@ -124,7 +207,10 @@ func (t *Tab) ExecuteAction(ctx context.Context, act *browserk.Action) ([]byte,
 	switch act.Type {
 
 	case browserk.ActLoadURL:
-		t.Navigate(ctx, string(act.Input))
+		// only surface crashes, so the caller can requeue and replace the browser
+		if navErr := t.NavigateWithReferrer(ctx, string(act.Input), t.takeNavigationReferrer()); errors.Cause(navErr) == ErrTabCrashed {
+			return nil, false, navErr
+		}
 	case browserk.ActExecuteJS:
 		t.InjectJS(string(act.Input))
 	case browserk.ActLeftClick, browserk.ActLeftClickDown, browserk.ActLeftClickUp, browserk.ActDoubleClick:
@@ -201,16 +287,36 @@ func (t *Tab) FillForm(act *browserk.Action) error {
 	t.ctx.Log.Info().Msgf("found form we have %d child elements", len(act.Form.ChildElements))
 	form.ScrollTo()
 
+	submitButton := t.fillFields(form, act.Form)
+	if submitButton == nil {
+		return &ErrElementNotFound{}
+	}
+	// submit buttons may only be enabled once the form validates
+	if err := submitButton.WaitForEnabled(t.elementTimeout); err != nil {
+		t.ctx.Log.Warn().Err(err).Msg("submit button was not enabled, clicking anyway")
+	}
+	t.ctx.Log.Info().Msgf("Submitting form... %s", submitButton.String())
+	return submitButton.Click()
+}
+
+// fillFields of the form with their values, returning the form's submit button if it was found.
+// Matching fields belonging to a different form are skipped.
+func (t *Tab) fillFields(form *Element, htmlForm *browserk.HTMLFormElement) *Element {
 	var submitButton *Element
 	radioClicked := false
 	checkboxClicked := false
-	for _, formChild := range act.Form.ChildElements {
+	for _, formChild := range htmlForm.ChildElements {
 
 		actualElement, err := t.FindByHTMLElement(formChild)
 		if err != nil {
 			t.ctx.Log.Error().Err(err).Str("type", browserk.HTMLTypeToStrMap[formChild.Type]).Msg("failed to find")
 			continue
 		}
+
+		if parent, err := actualElement.GetParentForm(); err == nil && parent.NodeID() != form.NodeID() {
+			t.ctx.Log.Debug().Str("type", browserk.HTMLTypeToStrMap[formChild.Type]).Msg("found field belongs to another form")
+			continue
+		}
 		if formChild.Type == browserk.INPUT && formChild.Value != "" {
 			actualElement.Focus()
 			if err := actualElement.SendKeys(formChild.Value); err != nil {
@@ -234,30 +340,48 @@ func (t *Tab) FillForm(act *browserk.Action) error {
 		}
 
 		//log.Debug().Msgf("[%s] comparing %s ~ %s", browserk.HTMLTypeToStrMap[formChild.Type], string(formChild.Hash()), string(act.Form.SubmitButtonID))
-		if bytes.Compare(formChild.Hash(), act.Form.SubmitButtonID) == 0 {
-			t.ctx.Log.Info().Msgf("found submit button %#v", act.Form)
+		if bytes.Compare(formChild.Hash(), htmlForm.SubmitButtonID) == 0 {
+			t.ctx.Log.Info().Msgf("found submit button %#v", htmlForm)
 			submitButton = actualElement
 		}
 	}
-	if submitButton == nil {
-		return &ErrElementNotFound{}
-	}
-	t.ctx.Log.Info().Msgf("Submitting form... %s", submitButton.String())
-	return submitButton.Click()
+	return submitButton
 }
 
 // Navigate to the url
 func (t *Tab) Navigate(ctx context.Context, url string) error {
+	return t.NavigateWithReferrer(ctx, url, "")
+}
+
+// NavigateWithReferrer navigates to the url sending referrer as the request's Referer, for flows
+// which check where the user came from. An empty referrer sends none.
+func (t *Tab) NavigateWithReferrer(ctx context.Context, url, referrer string) error {
+	if t.IsCrashed() {
+		return ErrTabCrashed
+	}
+
 	if t.IsNavigating() {
 		return &ErrInvalidNavigation{Message: "Unable to navigate, already navigating."}
 	}
 
+	// a stopped load may have fired its load event after we stopped waiting
+	select {
+	case <-t.navigationCh:
+	default:
+	}
+
 	t.setIsNavigating(true)
 	defer t.setIsNavigating(false)
 	t.ctx.Log.Debug().Msgf("navigating to %s", url)
-	navParams := &gcdapi.PageNavigateParams{Url: url, TransitionType: "typed"}
+	navParams := &gcdapi.PageNavigateParams{Url: url, Referrer: referrer, TransitionType: "typed"}
+	if referrer != "" {
+		navParams.TransitionType = "link"
+	}
 	frameID, _, errText, err := t.t.Page.NavigateWithParams(navParams)
 	if err != nil {
+		if t.IsCrashed() {
+			return errors.Wrap(ErrTabCrashed, err.Error())
+		}
 		return err
 	}
 	t.setTopFrameID(frameID)
@@ -271,6 +395,83 @@ func (t *Tab) Navigate(ctx context.Context, url string) error {
 	return t.waitReady(ctx, t.stableAfter)
 }
 
+// SetExtraNavigationReferrer sends referrer with the next load url action's navigation, see
+// NavigateWithReferrer
+func (t *Tab) SetExtraNavigationReferrer(referrer string) {
+	t.navigationReferrer.Store(referrer)
+}
+
+// takeNavigationReferrer set for the next load url action, clearing it
+func (t *Tab) takeNavigationReferrer() string {
+	referrer, _ := t.navigationReferrer.Load().(string)
+	t.navigationReferrer.Store("")
+	return referrer
+}
+
+// WaitForNavigationAfter runs action and waits up to timeout for any navigation of the top frame
+// it triggered to load, returning the url navigated to. If no navigation occurs an empty url and
+// nil error is returned, if the navigation does not finish loading ErrNavigationTimedOut is returned.
+func (t *Tab) WaitForNavigationAfter(action func() error, timeout time.Duration) (string, error) {
+	if t.IsCrashed() {
+		return "", ErrTabCrashed
+	}
+
+	if t.IsNavigating() {
+		return "", &ErrInvalidNavigation{Message: "Unable to wait for navigation, already navigating."}
+	}
+
+	// clear any stale events before arming
+	select {
+	case <-t.frameNavigatedCh:
+	default:
+	}
+	select {
+	case <-t.navigationCh:
+	default:
+	}
+
+	t.setIsNavigating(true)
+	defer t.setIsNavigating(false)
+
+	if err := action(); err != nil {
+		return "", err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var finalURL string
+	select {
+	case <-timer.C:
+		return "", nil
+	case <-t.exitCh:
+		return "", ErrTabClosing
+	case reason := <-t.crashedCh:
+		return "", errors.Wrap(ErrTabCrashed, reason)
+	case finalURL = <-t.frameNavigatedCh:
+	}
+
+	select {
+	case <-timer.C:
+		return finalURL, ErrNavigationTimedOut
+	case <-t.exitCh:
+		return finalURL, ErrTabClosing
+	case reason := <-t.crashedCh:
+		return finalURL, errors.Wrap(ErrTabCrashed, reason)
+	case <-t.navigationCh:
+	}
+	t.lastNodeChangeTimeVal.Store(time.Now())
+	return finalURL, nil
+}
+
+// IsCrashed returns true if the chrome target crashed, the tab is no longer usable
+func (t *Tab) IsCrashed() bool {
+	if crashed, ok := t.crashed.Load().(bool); ok {
+		return crashed
+	}
+	return false
+}
+
 // IsShuttingDown answers if we are shutting down or not
 func (t *Tab) IsShuttingDown() bool {
 	if flag, ok := t.shutdown.Load().(bool); ok {
@@ -288,6 +489,67 @@ func (t *Tab) ID() int64 {
 	return t.id
 }
 
+// GetResourceUsage returns the javascript heap size in use by the tab, for deciding when a
+// long lived browser has bloated and should be recycled
+func (t *Tab) GetResourceUsage() (heapBytes int64, err error) {
+	if t.IsShuttingDown() {
+		return 0, ErrTabClosing
+	}
+
+	used, _, err := t.t.Runtime.GetHeapUsage()
+	if err != nil {
+		return 0, err
+	}
+
+	if documents, nodes, listeners, err := t.t.Memory.GetDOMCounters(); err == nil {
+		t.ctx.Log.Debug().Int("documents", documents).Int("nodes", nodes).Int("listeners", listeners).Float64("heap_bytes", used).Msg("tab resource usage")
+	}
+	return int64(used), nil
+}
+
+// DOMNodeCount returns the number of DOM nodes alive in the tab's renderer, so pages with
+// pathologically large DOMs can be detected before they are extracted
+func (t *Tab) DOMNodeCount() (int, error) {
+	if t.IsShuttingDown() {
+		return 0, ErrTabClosing
+	}
+
+	_, nodes, _, err := t.t.Memory.GetDOMCounters()
+	return nodes, err
+}
+
+// IsAlive evaluates a trivial expression in the tab, returning false if it errors or chrome
+// does not respond within aliveTimeout
+func (t *Tab) IsAlive() bool {
+	if t.IsCrashed() || t.IsShuttingDown() {
+		return false
+	}
+	return runtimeAlive(t.t.Runtime, aliveTimeout)
+}
+
+// runtimeAlive returns true if the runtime evaluates "1" within timeout
+func runtimeAlive(runtime *gcdapi.Runtime, timeout time.Duration) bool {
+	aliveCh := make(chan bool, 1)
+	go func() {
+		_, exception, err := runtime.EvaluateWithParams(&gcdapi.RuntimeEvaluateParams{
+			Expression:    "1",
+			ReturnByValue: true,
+			Silent:        true,
+		})
+		aliveCh <- err == nil && exception == nil
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case alive := <-aliveCh:
+		return alive
+	case <-timer.C:
+		return false
+	}
+}
+
 // FindByHTMLElement returns a gcd Element for interacting
 func (t *Tab) FindByHTMLElement(toFind browserk.ActHTMLElement) (*Element, error) {
 	if toFind == nil {
@@ -308,6 +570,13 @@ func (t *Tab) FindByHTMLElement(toFind browserk.ActHTMLElement) (*Element, error
 			}
 		}
 	} else {
+		// try the recorded selector first, it survives node ids changing
+		if h, ok := toFind.(*browserk.HTMLElement); ok && h.Selector != "" {
+			if found, err := t.QuerySelector(h.Selector); err == nil && bytes.Compare(ElementToHTMLElement(found).Hash(), toFind.Hash()) == 0 {
+				return found, nil
+			}
+		}
+
 		for _, found := range foundElements {
 			h := ElementToHTMLElement(found)
 			t.ctx.Log.Debug().Msgf("[%s] comparing %s ~ %s (%#v) vs (%#v)", browserk.HTMLTypeToStrMap[h.Type], string(h.Hash()), string(toFind.Hash()), h.Attributes, toFind.AllAttributes())
@@ -334,6 +603,67 @@ func (t *Tab) FindElements(querySelector string) ([]*browserk.HTMLElement, error
 	return bElements, nil
 }
 
+// ElementAncestry returns the AncestryPath of the first element matching selector
+func (t *Tab) ElementAncestry(selector string) ([]string, error) {
+	elements, err := t.GetElementsBySelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elements) == 0 {
+		return nil, &ErrElementNotFound{Message: selector}
+	}
+	return elements[0].AncestryPath()
+}
+
+// ElementEvidence captures the evidence (see Element.CaptureEvidence) of the first element matching selector
+func (t *Tab) ElementEvidence(selector string) (*browserk.Evidence, error) {
+	elements, err := t.GetElementsBySelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elements) == 0 {
+		return nil, &ErrElementNotFound{Message: selector}
+	}
+	return elements[0].CaptureEvidence()
+}
+
+// returns true if the element is inside a fixed or sticky positioned container or a modal dialog
+const inOverlayFunction = `function() {
+	for (var node = this; node && node !== document.body && node !== document.documentElement; node = node.parentElement) {
+		var role = node.getAttribute("role");
+		if (node.tagName === "DIALOG" || node.getAttribute("aria-modal") === "true" || role === "dialog" || role === "alertdialog") {
+			return true;
+		}
+		var position = window.getComputedStyle(node).position;
+		if (position === "fixed" || position === "sticky") {
+			return true;
+		}
+	}
+	return false;
+}`
+
+// InOverlay returns true if the first element matching selector is inside a fixed or sticky
+// positioned container or a modal dialog, such as a consent banner or newsletter modal
+func (t *Tab) InOverlay(selector string) (bool, error) {
+	elements, err := t.GetElementsBySelector(selector)
+	if err != nil {
+		return false, err
+	}
+
+	if len(elements) == 0 {
+		return false, &ErrElementNotFound{Message: selector}
+	}
+
+	rro, err := elements[0].callFunctionOn(inOverlayFunction)
+	if err != nil {
+		return false, err
+	}
+	in, _ := rro.Value.(bool)
+	return in, nil
+}
+
 // FindInteractables returns elements that have a static/dynamic bound event listener
 func (t *Tab) FindInteractables() ([]*browserk.HTMLElement, error) {
 	cElements := make([]*browserk.HTMLElement, 0)
@@ -426,11 +756,22 @@ func (t *Tab) waitReady(ctx context.Context, stableAfter time.Duration) error {
 	defer ticker.Stop()
 
 	navTimer := time.After(45 * time.Second)
+	var stopTimer <-chan time.Time
+	if t.stopLoadingAfter > 0 {
+		stopTimer = time.After(t.stopLoadingAfter)
+	}
+
 	// wait navigation to complete.
 	t.ctx.Log.Info().Msg("waiting for nav to complete")
 	select {
 	case <-navTimer:
 		return ErrNavigationTimedOut
+	case <-stopTimer:
+		// long polling or streaming requests can hold the load event forever, work with what loaded
+		t.ctx.Log.Info().Msg("load did not finish, stopping")
+		if err := t.StopLoading(); err != nil {
+			return errors.Wrap(err, "failed to stop loading")
+		}
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-t.exitCh:
@@ -453,6 +794,11 @@ func (t *Tab) waitReady(ctx context.Context, stableAfter time.Duration) error {
 		case <-t.exitCh:
 			return ErrTabClosing
 		case <-stableTimer:
+			// the DOM settled but requests never finished, stop them so the page can be extracted
+			if changeTime, ok := t.lastNodeChangeTimeVal.Load().(time.Time); ok && time.Now().Sub(changeTime) >= stableAfter && t.stopLoadingAfter > 0 {
+				t.ctx.Log.Info().Int32("requests", t.container.OpenRequestCount()).Msg("requests did not finish, stopping")
+				return t.StopLoading()
+			}
 			t.ctx.Log.Info().Msg("stability timed out")
 			return ErrTimedOut
 		case <-ticker.C:
@@ -468,6 +814,62 @@ func (t *Tab) waitReady(ctx context.Context, stableAfter time.Duration) error {
 	}
 }
 
+// StopLoading aborts the page's in progress loads, as if the user pressed stop
+func (t *Tab) StopLoading() error {
+	if t.IsCrashed() {
+		return ErrTabCrashed
+	}
+
+	_, err := t.t.Page.StopLoading()
+	return err
+}
+
+// SetStopLoadingTimeout to wait for a navigation's load event before stopping the page load and
+// continuing with what has loaded, default is 15 seconds. 0 waits up to the navigation timeout.
+func (t *Tab) SetStopLoadingTimeout(timeout time.Duration) {
+	t.stopLoadingAfter = timeout
+}
+
+// SetMaxBodySize of captured responses, larger bodies are written to dir instead of being held in memory
+func (t *Tab) SetMaxBodySize(maxSize int, dir string) {
+	t.maxBodySize = maxSize
+	t.bodyDir = dir
+}
+
+// SetNetworkBufferSizes limits how many bytes chrome buffers for a single response body and for
+// all response bodies of the tab, bodies which don't fit can't be captured. -1 for unlimited.
+func (t *Tab) SetNetworkBufferSizes(resourceSize, totalSize int) error {
+	_, err := t.t.Network.EnableWithParams(&gcdapi.NetworkEnableParams{
+		MaxPostDataSize:       maximumPostDataSize,
+		MaxResourceBufferSize: resourceSize,
+		MaxTotalBufferSize:    totalSize,
+	})
+	return err
+}
+
+// recordBlockedUnload of a page whose beforeunload handler prompted before leaving it
+func (t *Tab) recordBlockedUnload(pageURL string) {
+	t.ctx.Log.Info().Str("url", pageURL).Msg("page attempted to block navigation with beforeunload")
+	t.unloadMutex.Lock()
+	t.blockedUnloads = append(t.blockedUnloads, pageURL)
+	t.unloadMutex.Unlock()
+}
+
+// BlockedUnloads returns the urls of pages whose beforeunload handler prompted before leaving them
+// since the last call. The prompts are always accepted so navigation continues.
+func (t *Tab) BlockedUnloads() []string {
+	t.unloadMutex.Lock()
+	defer t.unloadMutex.Unlock()
+	blocked := t.blockedUnloads
+	t.blockedUnloads = nil
+	return blocked
+}
+
+// SetHostThrottle limits the rate of requests the tab sends to each host, nil for unlimited
+func (t *Tab) SetHostThrottle(throttle *HostThrottle) {
+	t.throttle = throttle
+}
+
 // SetNavigationTimeout to wait in seconds for navigations before giving up, default is 30 seconds
 func (t *Tab) SetNavigationTimeout(timeout time.Duration) {
 	t.navigationTimeout = timeout
@@ -478,6 +880,12 @@ func (t *Tab) SetElementWaitTimeout(timeout time.Duration) {
 	t.elementTimeout = timeout
 }
 
+// SetElementResolveRetries for QuerySelector and WaitForSelector when the matched node is removed
+// before it's resolved, as happens on heavily animated pages. Default is 3.
+func (t *Tab) SetElementResolveRetries(retries int) {
+	t.resolveRetries = retries
+}
+
 // SetStabilityTimeout to wait for WaitStable() to return, default is 2 seconds.
 func (t *Tab) SetStabilityTimeout(timeout time.Duration) {
 	t.stabilityTimeout = timeout
@@ -565,6 +973,37 @@ func (t *Tab) GetCookies() ([]*browserk.Cookie, error) {
 	return GCDCookieToBrowserk(cookies), nil
 }
 
+// GetCookiesForURL returns the cookies chrome would send with a request to u, domain, path and
+// secure matching is handled by chrome
+func (t *Tab) GetCookiesForURL(u string) ([]*gcdapi.NetworkCookie, error) {
+	return t.t.Network.GetCookies([]string{u})
+}
+
+// SetCookies in the browser, replacing any with the same name, domain and path
+func (t *Tab) SetCookies(cookies []*browserk.Cookie) error {
+	if len(cookies) == 0 {
+		return nil
+	}
+	_, err := t.t.Network.SetCookies(BrowserkCookieToGCD(cookies))
+	return err
+}
+
+// ClearCookies removes all cookies from the browser
+func (t *Tab) ClearCookies() error {
+	_, err := t.t.Network.ClearBrowserCookies()
+	return err
+}
+
+// SetExtraHeaders to be sent with every request the tab makes, replacing any previously set
+func (t *Tab) SetExtraHeaders(headers map[string]string) error {
+	extra := make(map[string]interface{}, len(headers))
+	for k, v := range headers {
+		extra[k] = v
+	}
+	_, err := t.t.Network.SetExtraHTTPHeaders(extra)
+	return err
+}
+
 // GetStorageEvents and clear the container
 func (t *Tab) GetStorageEvents() []*browserk.StorageEvent {
 	return t.container.GetStorageEvents()
@@ -575,6 +1014,11 @@ func (t *Tab) GetConsoleEvents() []*browserk.ConsoleEvent {
 	return t.container.GetConsoleEvents()
 }
 
+// NetworkSummary of the requests which finished loading since the last call, and clear it
+func (t *Tab) NetworkSummary() browserk.NetworkSummary {
+	return t.container.GetNetworkSummary()
+}
+
 // EvaluateScript in the global context.
 func (t *Tab) EvaluateScript(scriptSource string) (*gcdapi.RuntimeRemoteObject, error) {
 	return t.evaluateScript(scriptSource, false)
@@ -585,16 +1029,50 @@ func (t *Tab) EvaluatePromiseScript(scriptSource string) (*gcdapi.RuntimeRemoteO
 	return t.evaluateScript(scriptSource, true)
 }
 
+// EvaluateJSON evaluates expr in the global context and unmarshals its value into out, the
+// same as json.Unmarshal. The value must be serializable, exceptions are returned as
+// ErrScriptEvaluation.
+func (t *Tab) EvaluateJSON(expr string, out interface{}) error {
+	params := &gcdapi.RuntimeEvaluateParams{
+		Expression:    expr,
+		ObjectGroup:   "browserker",
+		Silent:        true,
+		ReturnByValue: true,
+		UserGesture:   t.inUserGesture(),
+		Timeout:       1000,
+	}
+	r, exp, err := t.t.Runtime.EvaluateWithParams(params)
+	if err != nil {
+		return err
+	}
+
+	if exp != nil {
+		return &ErrScriptEvaluation{Message: "failed to evaluate expression", ExceptionText: exp.Text, ExceptionDetails: exp}
+	}
+
+	value, err := json.Marshal(r.Value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, out)
+}
+
 // evaluateScript in the global context.
 func (t *Tab) evaluateScript(scriptSource string, awaitPromise bool) (*gcdapi.RuntimeRemoteObject, error) {
+	return t.evaluateScriptInContext(scriptSource, 0, awaitPromise)
+}
+
+// evaluateScriptInContext evaluates in the execution context, 0 for the main frame's context.
+func (t *Tab) evaluateScriptInContext(scriptSource string, contextID int, awaitPromise bool) (*gcdapi.RuntimeRemoteObject, error) {
 	params := &gcdapi.RuntimeEvaluateParams{
 		Expression:            scriptSource,
+		ContextId:             contextID,
 		ObjectGroup:           "browserker",
 		IncludeCommandLineAPI: false,
 		Silent:                true,
 		ReturnByValue:         true,
 		GeneratePreview:       false,
-		UserGesture:           false,
+		UserGesture:           t.inUserGesture(),
 		AwaitPromise:          awaitPromise,
 		ThrowOnSideEffect:     false,
 		Timeout:               1000,
@@ -771,6 +1249,86 @@ func (t *Tab) getDocumentElementByID(docNodeID int, attributeID string) (*Elemen
 	return ele, ready, nil
 }
 
+// QuerySelector returns the first element matching selector in the top level document
+func (t *Tab) QuerySelector(selector string) (*Element, error) {
+	return resolveElement(t.resolveRetries, func() (*Element, error) {
+		return t.querySelector(selector)
+	})
+}
+
+// querySelector queries the top document and wraps the matched node, returning ErrInvalidElement
+// if the node was removed before it could be resolved
+func (t *Tab) querySelector(selector string) (*Element, error) {
+	docNode, ok := t.getElement(t.getTopNodeID())
+	if !ok {
+		return nil, &ErrElementNotFound{Message: "top document not found"}
+	}
+
+	nodeID, err := t.t.DOM.QuerySelector(docNode.ID, selector)
+	if err != nil {
+		return nil, NodeError(err)
+	}
+
+	if nodeID == 0 {
+		return nil, &ErrElementNotFound{Message: fmt.Sprintf("no element matching %s", selector)}
+	}
+	return t.elementFromNodeID(nodeID)
+}
+
+// elementFromNodeID wraps the node, describing it so its data is pushed to us and waiting until
+// it is ready. Returns ErrInvalidElement if the node was removed.
+func (t *Tab) elementFromNodeID(nodeID int) (*Element, error) {
+	ele, _ := t.getElementByNodeID(nodeID)
+	if _, err := t.t.DOM.DescribeNode(nodeID, 0, "", 0, false); err != nil {
+		return nil, ele.nodeError(err)
+	}
+
+	if err := ele.WaitForReady(); err != nil {
+		return nil, err
+	}
+	return ele, nil
+}
+
+// resolveElement calls resolve, retrying up to retries times if the node was removed while it was
+// being resolved. Returns ErrElementNotFound once the retries are exhausted.
+func resolveElement(retries int, resolve func() (*Element, error)) (*Element, error) {
+	for attempt := 0; ; attempt++ {
+		ele, err := resolve()
+		if _, removed := err.(*ErrInvalidElement); !removed {
+			return ele, err
+		}
+
+		if attempt >= retries {
+			return nil, &ErrElementNotFound{Message: fmt.Sprintf("node removed while resolving after %d attempts", attempt+1)}
+		}
+	}
+}
+
+// WaitForSelector polls for an element matching selector in the top document until it's found
+// or timeout expires
+func (t *Tab) WaitForSelector(selector string, timeout time.Duration) (*Element, error) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		ele, err := t.QuerySelector(selector)
+		if _, notFound := err.(*ErrElementNotFound); !notFound {
+			return ele, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return nil, err
+		case <-t.exitCh:
+			return nil, err
+		}
+	}
+}
+
 // GetElementsBySelector all elements that match a selector from the top level document
 // also searches sub frames
 func (t *Tab) GetElementsBySelector(selector string) ([]*Element, error) {
@@ -913,6 +1471,19 @@ func (t *Tab) GetDOM() (string, error) {
 	return html, err
 }
 
+// CaptureDOMSnapshot of the flattened DOM (including iframes), computed styles and layout in a
+// single call so the page can be analyzed without keeping the browser around
+func (t *Tab) CaptureDOMSnapshot() (*DOMSnapshotCaptureSnapshotResult, error) {
+	documents, strs, err := t.t.DOMSnapshot.CaptureSnapshotWithParams(&gcdapi.DOMSnapshotCaptureSnapshotParams{
+		ComputedStyles:  snapshotComputedStyles,
+		IncludeDOMRects: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DOMSnapshotCaptureSnapshotResult{Documents: documents, Strings: strs}, nil
+}
+
 // GetPageSource returns the document's source, as visible, if docID is 0, returns top document source.
 func (t *Tab) GetPageSource(docNodeID int) (string, error) {
 	if docNodeID == 0 {
@@ -1042,6 +1613,8 @@ func (t *Tab) listenDebuggerEvents(ctx *browserk.Context) {
 		select {
 		case nodeChangeEvent := <-t.nodeChange:
 			t.lastNodeChangeTimeVal.Store(time.Now())
+			// any mutation can move elements, not just the ones it changed
+			t.invalidateGeometry()
 			t.handleNodeChange(nodeChangeEvent)
 			// if the caller registered a dom change listener, call it
 			if t.domChangeHandler != nil {
@@ -1117,6 +1690,12 @@ func (t *Tab) handleNodeChange(change *NodeChangeEvent) {
 				ele.removeAttribute(change.Name)
 			}
 		}
+	case InlineStyleInvalidatedEvent:
+		for _, nodeID := range change.NodeIDs {
+			if ele, ok := t.getElement(nodeID); ok {
+				ele.InvalidateGeometry()
+			}
+		}
 	case CharacterDataModifiedEvent:
 		if ele, ok := t.getElement(change.NodeID); ok {
 			if err := ele.WaitForReady(); err == nil {
@@ -1236,46 +1815,21 @@ func (t *Tab) subscribeBrowserEvents(ctx *browserk.Context, intercept bool) {
 	t.t.Security.Enable()
 	t.t.Console.Enable()
 	t.t.Debugger.Enable(-1)
+	t.t.Runtime.Enable()
 
 	t.t.Network.EnableWithParams(&gcdapi.NetworkEnableParams{
-		MaxPostDataSize:       -1,
-		MaxResourceBufferSize: -1,
-		MaxTotalBufferSize:    -1,
+		MaxPostDataSize:       maximumPostDataSize,
+		MaxResourceBufferSize: maximumResourceBufferSize,
+		MaxTotalBufferSize:    maximumTotalBufferSize,
 	})
 
-	t.t.Security.SetOverrideCertificateErrors(true)
-
-	t.t.Subscribe("Security.certificateError", func(target *gcd.ChromeTarget, payload []byte) {
-		resp := &gcdapi.SecurityCertificateErrorEvent{}
-		err := json.Unmarshal(payload, resp)
-		if err != nil {
-			return
-		}
-		t.ctx.Log.Info().Str("type", resp.Params.ErrorType).Msg("handling certificate error")
-		p := &gcdapi.SecurityHandleCertificateErrorParams{
-			EventId: resp.Params.EventId,
-			Action:  "continue",
-		}
-
-		t.t.Security.HandleCertificateErrorWithParams(p)
-		t.ctx.Log.Info().Msg("certificate error handled")
-	})
+	t.subscribeSecurityEvents()
 
 	// network related events
 	t.subscribeNetworkEvents(ctx)
 	if intercept {
-		patterns := []*gcdapi.FetchRequestPattern{
-			{
-				UrlPattern:   "*",
-				RequestStage: "Request",
-			},
-			{
-				UrlPattern:   "*",
-				RequestStage: "Response",
-			},
-		}
 		t.t.Fetch.EnableWithParams(&gcdapi.FetchEnableParams{
-			Patterns:           patterns,
+			Patterns:           interceptPatterns,
 			HandleAuthRequests: false,
 		})
 		t.subscribeInterception(ctx)
@@ -1288,17 +1842,25 @@ func (t *Tab) subscribeBrowserEvents(ctx *browserk.Context, intercept bool) {
 	t.subscribeLoadEvent()
 	t.subscribeFrameLoadingEvent()
 	t.subscribeFrameFinishedEvent()
+	t.subscribeFrameNavigated()
+	t.subscribeFrameResized()
 
 	// DOM update related events
 	t.subscribeDocumentUpdated()
 	t.subscribeSetChildNodes()
 	t.subscribeAttributeModified()
 	t.subscribeAttributeRemoved()
+	t.subscribeInlineStyleInvalidated()
 	t.subscribeCharacterDataModified()
 	t.subscribeChildNodeCountUpdated()
 	t.subscribeChildNodeInserted()
 	t.subscribeChildNodeRemoved()
 
+	// javascript execution contexts and workers
+	t.subscribeExecutionContexts()
+	t.subscribeWorkers()
+	t.t.TargetApi.SetAutoAttach(true, false, false)
+
 	// events
 	t.subscribeStorageEvents()
 	t.subscribeConsoleEvents()
@@ -3,6 +3,7 @@ package browser_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"gitlab.com/browserker/browserk"
 	"gitlab.com/browserker/mock"
@@ -62,3 +63,209 @@ func TestActionClick(t *testing.T) {
 		t.Fatalf("expected 3 console log events, got %d\n", len(evts))
 	}
 }
+
+func TestSelectAllGetSelectedText(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/contenteditable.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	selected, err := tab.GetSelectedText()
+	if err != nil {
+		t.Fatalf("error getting selected text: %s\n", err)
+	}
+	if selected != "" {
+		t.Fatalf("expected no selection got %s", selected)
+	}
+
+	eles, err := tab.GetElementsBySelector("#editor")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting editor: %s\n", err)
+	}
+
+	if err := eles[0].Focus(); err != nil {
+		t.Fatalf("error focusing editor: %s\n", err)
+	}
+
+	if err := tab.SelectAll(); err != nil {
+		t.Fatalf("error selecting all: %s\n", err)
+	}
+
+	selected, err = tab.GetSelectedText()
+	if err != nil {
+		t.Fatalf("error getting selected text: %s\n", err)
+	}
+	if selected != "rich text content" {
+		t.Fatalf("expected editor text to be selected got %s", selected)
+	}
+}
+
+func TestWaitForNavigationAfter(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/nav_link.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	static, err := tab.GetElementsBySelector("#static")
+	if err != nil || len(static) != 1 {
+		t.Fatalf("error getting static element: %s\n", err)
+	}
+
+	finalURL, err := tab.WaitForNavigationAfter(static[0].Click, time.Millisecond*500)
+	if err != nil || finalURL != "" {
+		t.Fatalf("expected no navigation got %s %v", finalURL, err)
+	}
+
+	link, err := tab.GetElementsBySelector("#next")
+	if err != nil || len(link) != 1 {
+		t.Fatalf("error getting link: %s\n", err)
+	}
+
+	finalURL, err = tab.WaitForNavigationAfter(link[0].Click, time.Second*10)
+	if err != nil {
+		t.Fatalf("error waiting for navigation: %s\n", err)
+	}
+
+	expected := fmt.Sprintf("http://localhost:%s/redirect_target.html", p)
+	if finalURL != expected {
+		t.Fatalf("expected navigation to %s got %s", expected, finalURL)
+	}
+}
+
+func TestTabSimulateUserGesture(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := tab.Navigate(ctx, fmt.Sprintf("http://localhost:%s/clipboard.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	copied, err := tab.EvaluateScript("copyText()")
+	if err != nil {
+		t.Fatalf("error copying: %s\n", err)
+	}
+	if copied.Value == true {
+		t.Fatalf("expected clipboard write to be refused without a user gesture")
+	}
+
+	err = tab.SimulateUserGesture(func() error {
+		copied, err = tab.EvaluateScript("copyText()")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error copying in user gesture: %s\n", err)
+	}
+	if copied.Value != true {
+		t.Fatalf("expected clipboard write to succeed inside user gesture got %v", copied.Value)
+	}
+}
+
+func TestSendKeysNewlines(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/newlines.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	textarea, err := tab.QuerySelector("#body")
+	if err != nil {
+		t.Fatalf("error finding textarea: %s\n", err)
+	}
+
+	if err := textarea.SendKeys("first line\nsecond line"); err != nil {
+		t.Fatalf("error sending keys: %s\n", err)
+	}
+
+	lines, err := tab.EvaluateScript(`document.getElementById("body").value.split("\n").length`)
+	if err != nil {
+		t.Fatalf("error getting textarea value: %s\n", err)
+	}
+
+	if fmt.Sprintf("%v", lines.Value) != "2" {
+		t.Fatalf("expected two lines in textarea got %v\n", lines.Value)
+	}
+
+	// enter in a single line input submits its form instead
+	input, err := tab.QuerySelector("#subject")
+	if err != nil {
+		t.Fatalf("error finding input: %s\n", err)
+	}
+
+	if err := input.SendKeys("subject\n"); err != nil {
+		t.Fatalf("error sending keys: %s\n", err)
+	}
+
+	submitted := ""
+	deadline := time.Now().Add(5 * time.Second)
+	for submitted == "" && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		if ele, err := tab.QuerySelector("#result"); err == nil {
+			submitted = ele.GetAttribute("data-submitted")
+		}
+	}
+
+	if submitted != "true" {
+		t.Fatalf("expected enter to submit the form")
+	}
+}
@@ -0,0 +1,127 @@
+package browser
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+)
+
+// ErrNoFrameContext when the frame has no javascript execution context (yet)
+type ErrNoFrameContext struct {
+	FrameID string
+}
+
+func (e *ErrNoFrameContext) Error() string {
+	return fmt.Sprintf("no execution context for frame %s", e.FrameID)
+}
+
+// contextKey identifies an execution context, context ids are only unique within the page
+// or worker session that reported them
+type contextKey struct {
+	sessionID string // worker session, empty for the page's contexts
+	id        int
+}
+
+// executionContext reported by the page or one of its workers
+type executionContext struct {
+	description *gcdapi.RuntimeExecutionContextDescription
+	worker      *gcdapi.TargetTargetInfo // nil for the page's contexts
+}
+
+// EvaluateOnFrame evaluates the expression in the frame's default execution context, so
+// variables defined by the frame's scripts (including same origin iframes) are visible.
+func (t *Tab) EvaluateOnFrame(frameID, expr string) (*gcdapi.RuntimeRemoteObject, error) {
+	contextID, ok := t.frameContextID(frameID)
+	if !ok {
+		return nil, &ErrNoFrameContext{FrameID: frameID}
+	}
+	return t.evaluateScriptInContext(expr, contextID, false)
+}
+
+// ExecutionContexts of the page's frames and workers, ordered by worker then id
+func (t *Tab) ExecutionContexts() []browserk.ExecutionContext {
+	topFrameID := t.getTopFrameID()
+
+	t.contextMutex.RLock()
+	contexts := make([]browserk.ExecutionContext, 0, len(t.contexts))
+	for key, context := range t.contexts {
+		c := browserk.ExecutionContext{
+			ID:        key.id,
+			Origin:    context.description.Origin,
+			Name:      context.description.Name,
+			FrameID:   contextFrameID(context.description),
+			IsDefault: isDefaultContext(context.description),
+		}
+
+		switch {
+		case context.worker != nil:
+			c.WorkerID = context.worker.TargetId
+			c.Type = context.worker.Type
+		case c.FrameID == topFrameID:
+			c.Type = "page"
+		default:
+			c.Type = "iframe"
+		}
+		contexts = append(contexts, c)
+	}
+	t.contextMutex.RUnlock()
+
+	sort.Slice(contexts, func(i, j int) bool {
+		if contexts[i].WorkerID != contexts[j].WorkerID {
+			return contexts[i].WorkerID < contexts[j].WorkerID
+		}
+		return contexts[i].ID < contexts[j].ID
+	})
+	return contexts
+}
+
+// frameContextID returns the id of the frame's default execution context
+func (t *Tab) frameContextID(frameID string) (int, bool) {
+	t.contextMutex.RLock()
+	defer t.contextMutex.RUnlock()
+
+	for key, context := range t.contexts {
+		if key.sessionID == "" && contextFrameID(context.description) == frameID && isDefaultContext(context.description) {
+			return key.id, true
+		}
+	}
+	return 0, false
+}
+
+// addExecutionContext reported by the page (sessionID is empty) or a worker
+func (t *Tab) addExecutionContext(sessionID string, worker *gcdapi.TargetTargetInfo, context *gcdapi.RuntimeExecutionContextDescription) {
+	t.contextMutex.Lock()
+	t.contexts[contextKey{sessionID: sessionID, id: context.Id}] = &executionContext{description: context, worker: worker}
+	t.contextMutex.Unlock()
+}
+
+func (t *Tab) removeExecutionContext(sessionID string, contextID int) {
+	t.contextMutex.Lock()
+	delete(t.contexts, contextKey{sessionID: sessionID, id: contextID})
+	t.contextMutex.Unlock()
+}
+
+// clearExecutionContexts of the page (sessionID is empty) or a worker
+func (t *Tab) clearExecutionContexts(sessionID string) {
+	t.contextMutex.Lock()
+	for key := range t.contexts {
+		if key.sessionID == sessionID {
+			delete(t.contexts, key)
+		}
+	}
+	t.contextMutex.Unlock()
+}
+
+// contextFrameID from the context's aux data, empty for contexts not associated with a frame
+func contextFrameID(context *gcdapi.RuntimeExecutionContextDescription) string {
+	frameID, _ := context.AuxData["frameId"].(string)
+	return frameID
+}
+
+// isDefaultContext is true for the frame's main world, not an isolated world created by an extension or devtools
+func isDefaultContext(context *gcdapi.RuntimeExecutionContextDescription) bool {
+	isDefault, _ := context.AuxData["isDefault"].(bool)
+	return isDefault
+}
@@ -0,0 +1,134 @@
+package browser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wirepair/gcd/gcdapi"
+)
+
+// SetCPUThrottling slows down the tab's CPU by rate (1 is no throttle, 4 is a 4x slowdown)
+func (t *Tab) SetCPUThrottling(rate float64) error {
+	if rate < 1 {
+		return &ErrInvalidEmulation{Message: fmt.Sprintf("cpu throttling rate must be >= 1, got %f", rate)}
+	}
+	_, err := t.t.Emulation.SetCPUThrottlingRate(rate)
+	return err
+}
+
+// MediaTypeFeature is the SetEmulatedMediaFeatures key for emulating a css media type (print,
+// screen) instead of a media feature
+const MediaTypeFeature = "media"
+
+// emulatedMediaValues are the values allowed for each emulated media feature, empty removes
+// the override
+var emulatedMediaValues = map[string][]string{
+	MediaTypeFeature:         {"", "print", "screen"},
+	"prefers-color-scheme":   {"", "dark", "light", "no-preference"},
+	"prefers-reduced-motion": {"", "reduce", "no-preference"},
+	"prefers-contrast":       {"", "more", "less", "no-preference"},
+}
+
+// EmulateColorScheme overrides the prefers-color-scheme media feature with dark, light or
+// no-preference. An empty scheme removes the override.
+func (t *Tab) EmulateColorScheme(scheme string) error {
+	return t.SetEmulatedMediaFeatures(map[string]string{"prefers-color-scheme": scheme})
+}
+
+// EmulateReducedMotion overrides the prefers-reduced-motion media feature, false removes the override
+func (t *Tab) EmulateReducedMotion(reduce bool) error {
+	value := ""
+	if reduce {
+		value = "reduce"
+	}
+	return t.SetEmulatedMediaFeatures(map[string]string{"prefers-reduced-motion": value})
+}
+
+// SetEmulatedMediaFeatures overrides the media features prefers-color-scheme,
+// prefers-reduced-motion and prefers-contrast, and the css media type with the "media" key (e.g.
+// print). Features not given keep their current override, an empty value removes it.
+func (t *Tab) SetEmulatedMediaFeatures(features map[string]string) error {
+	for name, value := range features {
+		allowed, ok := emulatedMediaValues[name]
+		if !ok {
+			return &ErrInvalidEmulation{Message: fmt.Sprintf("unsupported media feature %s", name)}
+		}
+
+		if !containsString(allowed, value) {
+			return &ErrInvalidEmulation{Message: fmt.Sprintf("%s must be one of %s, got %s", name, strings.Join(allowed[1:], ", "), value)}
+		}
+	}
+
+	t.mediaMutex.Lock()
+	defer t.mediaMutex.Unlock()
+
+	for name, value := range features {
+		switch {
+		case name == MediaTypeFeature:
+			t.mediaType = value
+		case value == "":
+			delete(t.mediaFeatures, name)
+		default:
+			t.mediaFeatures[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(emulatedMediaValues))
+	for name := range emulatedMediaValues {
+		if name != MediaTypeFeature {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// every feature is sent so removed overrides are reset
+	emulated := make([]*gcdapi.EmulationMediaFeature, 0, len(names))
+	for _, name := range names {
+		emulated = append(emulated, &gcdapi.EmulationMediaFeature{Name: name, Value: t.mediaFeatures[name]})
+	}
+	_, err := t.t.Emulation.SetEmulatedMedia(t.mediaType, emulated)
+	// media queries can change the layout
+	t.invalidateGeometry()
+	return err
+}
+
+// SetPageVisibility emulates the page being foregrounded and focused so timers and animations
+// are not paused in headless mode. Setting visible to false disables the focus emulation and
+// lets chrome decide again.
+func (t *Tab) SetPageVisibility(visible bool) error {
+	if _, err := t.t.Emulation.SetFocusEmulationEnabled(visible); err != nil {
+		return err
+	}
+
+	if !visible {
+		return nil
+	}
+
+	if _, err := t.t.Page.SetWebLifecycleState("active"); err != nil {
+		return err
+	}
+
+	_, err := t.t.Page.BringToFront()
+	return err
+}
+
+// Focus the tab, see SetPageVisibility
+func (t *Tab) Focus() error {
+	return t.SetPageVisibility(true)
+}
+
+// Blur the tab, see SetPageVisibility
+func (t *Tab) Blur() error {
+	return t.SetPageVisibility(false)
+}
+
+// containsString returns true if value is one of values
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
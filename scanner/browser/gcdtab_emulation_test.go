@@ -0,0 +1,219 @@
+package browser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/browser"
+	"golang.org/x/net/context"
+)
+
+const busyLoop = "(function() { let s = performance.now(); let x = 0; for (let i = 0; i < 5000000; i++) { x += i; } return performance.now() - s; })()"
+
+func TestSetCPUThrottling(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/index.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if err := tab.SetCPUThrottling(0.5); err == nil {
+		t.Fatalf("expected error for rate < 1")
+	}
+
+	unthrottled, err := tab.EvaluateScript(busyLoop)
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if err := tab.SetCPUThrottling(20); err != nil {
+		t.Fatalf("error setting cpu throttling: %s\n", err)
+	}
+
+	throttled, err := tab.EvaluateScript(busyLoop)
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if throttled.Value.(float64) < unthrottled.Value.(float64)*2 {
+		t.Fatalf("expected throttled loop (%v) to be slower than unthrottled (%v)", throttled.Value, unthrottled.Value)
+	}
+}
+
+func TestSetPageVisibility(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/index.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if err := tab.SetPageVisibility(true); err != nil {
+		t.Fatalf("error setting page visibility: %s\n", err)
+	}
+
+	state, err := tab.EvaluateScript("document.visibilityState")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if state.Value != "visible" {
+		t.Fatalf("expected visible got %v", state.Value)
+	}
+
+	focused, err := tab.EvaluateScript("document.hasFocus()")
+	if err != nil {
+		t.Fatalf("error evaluating script: %s\n", err)
+	}
+
+	if focused.Value != true {
+		t.Fatalf("expected document to have focus got %v", focused.Value)
+	}
+
+	if err := tab.Blur(); err != nil {
+		t.Fatalf("error blurring tab: %s\n", err)
+	}
+}
+
+func TestEmulateColorScheme(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/index.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if err := tab.EmulateColorScheme("purple"); err == nil {
+		t.Fatalf("expected error for invalid color scheme")
+	}
+
+	for _, scheme := range []string{"dark", "light"} {
+		if err := tab.EmulateColorScheme(scheme); err != nil {
+			t.Fatalf("error emulating color scheme: %s\n", err)
+		}
+
+		dark, err := tab.EvaluateScript("window.matchMedia('(prefers-color-scheme: dark)').matches")
+		if err != nil {
+			t.Fatalf("error evaluating media query: %s\n", err)
+		}
+
+		if dark.Value != (scheme == "dark") {
+			t.Fatalf("expected dark media query to be %v for %s got %v", scheme == "dark", scheme, dark.Value)
+		}
+	}
+}
+
+func TestSetEmulatedMediaFeatures(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/index.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if err := tab.SetEmulatedMediaFeatures(map[string]string{"prefers-reduced-transparency": "reduce"}); err == nil {
+		t.Fatalf("expected error for unsupported media feature")
+	}
+
+	if err := tab.SetEmulatedMediaFeatures(map[string]string{"prefers-contrast": "loud"}); err == nil {
+		t.Fatalf("expected error for invalid prefers-contrast value")
+	}
+
+	matches := func(query string) bool {
+		var matched bool
+		if err := tab.EvaluateJSON(fmt.Sprintf("window.matchMedia('%s').matches", query), &matched); err != nil {
+			t.Fatalf("error evaluating media query %s: %s\n", query, err)
+		}
+		return matched
+	}
+
+	if err := tab.EmulateColorScheme("dark"); err != nil {
+		t.Fatalf("error emulating color scheme: %s\n", err)
+	}
+
+	if err := tab.EmulateReducedMotion(true); err != nil {
+		t.Fatalf("error emulating reduced motion: %s\n", err)
+	}
+
+	if !matches("(prefers-reduced-motion: reduce)") {
+		t.Fatalf("expected prefers-reduced-motion: reduce to match")
+	}
+
+	if !matches("(prefers-color-scheme: dark)") {
+		t.Fatalf("expected color scheme override to be kept when setting other features")
+	}
+
+	if err := tab.SetEmulatedMediaFeatures(map[string]string{"prefers-contrast": "more", browser.MediaTypeFeature: "print"}); err != nil {
+		t.Fatalf("error emulating media features: %s\n", err)
+	}
+
+	if !matches("(prefers-contrast: more)") || !matches("print") {
+		t.Fatalf("expected prefers-contrast: more and print media to match")
+	}
+
+	if err := tab.SetEmulatedMediaFeatures(map[string]string{"prefers-reduced-motion": "", browser.MediaTypeFeature: ""}); err != nil {
+		t.Fatalf("error removing media features: %s\n", err)
+	}
+
+	if matches("(prefers-reduced-motion: reduce)") || matches("print") {
+		t.Fatalf("expected reduced motion and print overrides to be removed")
+	}
+}
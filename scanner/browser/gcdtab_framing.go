@@ -0,0 +1,51 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/wirepair/gcd/gcdapi"
+)
+
+// framingPage is the attacker page targets are framed by, loaded as a data url so its origin is
+// opaque and never matches the target's
+const framingPage = `<html><body><iframe id="target" src="%s" width="800" height="600"></iframe></body></html>`
+
+// Framable loads url in an iframe of an attacker page with an opaque origin, returning true if
+// the frame rendered the target instead of chrome's blocked frame error page. The tab is
+// navigated back to the page it was on afterwards.
+func (t *Tab) Framable(ctx context.Context, url string) (bool, error) {
+	previous, _ := t.GetURL()
+
+	page := fmt.Sprintf(framingPage, html.EscapeString(url))
+	if err := t.Navigate(ctx, "data:text/html;base64,"+base64.StdEncoding.EncodeToString([]byte(page))); err != nil {
+		return false, err
+	}
+
+	tree, err := t.t.Page.GetFrameTree()
+	if previous != "" {
+		if navErr := t.Navigate(ctx, previous); navErr != nil {
+			t.ctx.Log.Warn().Err(navErr).Str("url", previous).Msg("failed to return to page after framing")
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if tree == nil || len(tree.ChildFrames) == 0 || tree.ChildFrames[0].Frame == nil {
+		return false, nil
+	}
+	return frameRendered(tree.ChildFrames[0].Frame), nil
+}
+
+// frameRendered returns true if the frame committed a document rather than an error page, frames
+// blocked by X-Frame-Options or frame-ancestors commit chrome's error page instead
+func frameRendered(frame *gcdapi.PageFrame) bool {
+	if frame.UnreachableUrl != "" || strings.HasPrefix(frame.Url, "chrome-error:") {
+		return false
+	}
+	return frame.Url != "" && frame.Url != "about:blank"
+}
@@ -0,0 +1,74 @@
+package browser_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/browser"
+)
+
+func TestTabFramable(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/open", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><form><input type="password"></form></html>`))
+	})
+	mux.HandleFunc("/deny", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Write([]byte(`<html><form><input type="password"></form></html>`))
+	})
+	mux.HandleFunc("/ancestors", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "frame-ancestors 'self'")
+		w.Write([]byte(`<html><form><input type="password"></form></html>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	defer b.Close()
+	tab := b.(*browser.Tab)
+
+	if err := tab.Navigate(ctx, srv.URL+"/open"); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	var tests = []struct {
+		path     string
+		framable bool
+	}{
+		{"/open", true},
+		{"/deny", false},
+		{"/ancestors", false},
+	}
+
+	for _, tt := range tests {
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+		framable, err := tab.Framable(timeoutCtx, srv.URL+tt.path)
+		cancel()
+		if err != nil {
+			t.Fatalf("error framing %s: %s\n", tt.path, err)
+		}
+
+		if framable != tt.framable {
+			t.Fatalf("expected %s framable to be %v", tt.path, tt.framable)
+		}
+
+		if url, _ := tab.GetURL(); url != srv.URL+"/open" {
+			t.Fatalf("expected tab to return to the page it was on got %s", url)
+		}
+	}
+}
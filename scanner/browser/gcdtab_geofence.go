@@ -0,0 +1,66 @@
+package browser
+
+import (
+	"time"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+)
+
+// geofence holds the scope top frame navigations are limited to, wrapped so atomic.Value always
+// stores the same concrete type
+type geofence struct {
+	scope browserk.ScopeService
+}
+
+// SetGeofenceScope aborts top frame navigations to urls outside of scope while the request is
+// in flight, recording each as an out of scope redirect attempt. A nil scope disables the geofence.
+func (t *Tab) SetGeofenceScope(scope browserk.ScopeService) {
+	t.geofence.Store(&geofence{scope: scope})
+}
+
+// OutOfScopeRedirects returns the top frame navigations aborted by the geofence
+func (t *Tab) OutOfScopeRedirects() []*browserk.OutOfScopeRedirect {
+	t.geofenceMutex.RLock()
+	defer t.geofenceMutex.RUnlock()
+
+	redirects := make([]*browserk.OutOfScopeRedirect, len(t.outOfScope))
+	copy(redirects, t.outOfScope)
+	return redirects
+}
+
+// blockOutOfScope aborts the paused request if it's a top frame navigation to an out of scope url,
+// returning true if it was aborted
+func (t *Tab) blockOutOfScope(message *gcdapi.FetchRequestPausedEvent) bool {
+	fence, ok := t.geofence.Load().(*geofence)
+	if !ok || fence.scope == nil {
+		return false
+	}
+
+	p := message.Params
+	if p.ResourceType != "Document" || p.Request == nil || !t.isTopFrame(p.FrameId) {
+		return false
+	}
+
+	if fence.scope.Check(p.Request.Url) == browserk.InScope {
+		return false
+	}
+
+	sourceURL, _ := t.GetURL()
+	t.geofenceMutex.Lock()
+	t.outOfScope = append(t.outOfScope, &browserk.OutOfScopeRedirect{URL: p.Request.Url, SourceURL: sourceURL, Time: time.Now()})
+	t.geofenceMutex.Unlock()
+
+	t.ctx.Log.Warn().Str("url", p.Request.Url).Str("source_url", sourceURL).Msg("aborted out of scope navigation")
+	if _, err := t.t.Fetch.FailRequest(p.RequestId, "BlockedByClient"); err != nil {
+		t.ctx.Log.Warn().Err(err).Str("url", p.Request.Url).Msg("failed to abort out of scope navigation")
+	}
+	return true
+}
+
+// isTopFrame returns true if frameID is the tab's top level frame, which shares the target's id
+// before the first navigation sets it
+func (t *Tab) isTopFrame(frameID string) bool {
+	topFrameID := t.getTopFrameID()
+	return frameID == topFrameID || (topFrameID == "" && frameID == t.t.Target.Id)
+}
@@ -0,0 +1,56 @@
+package browser_test
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/browser"
+	"golang.org/x/net/context"
+)
+
+func TestTabGeofenceScope(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	target, _ := url.Parse(fmt.Sprintf("http://localhost:%s/", p))
+	tab.SetGeofenceScope(scanner.NewScopeService(target))
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/geofence.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	// the page redirects to 127.0.0.1 which is a different, out of scope, host
+	deadline := time.Now().Add(5 * time.Second)
+	for len(tab.OutOfScopeRedirects()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	redirects := tab.OutOfScopeRedirects()
+	if len(redirects) != 1 || !strings.HasPrefix(redirects[0].URL, "http://127.0.0.1:") {
+		t.Fatalf("expected the out of scope redirect to be recorded got %#v\n", redirects)
+	}
+
+	current, err := tab.GetURL()
+	if err != nil || !strings.Contains(current, "localhost") {
+		t.Fatalf("expected to remain on the in scope page got %s %v\n", current, err)
+	}
+}
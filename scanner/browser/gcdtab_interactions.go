@@ -1,6 +1,45 @@
 package browser
 
-import "github.com/wirepair/gcd/gcdapi"
+import (
+	"sync/atomic"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"github.com/wirepair/gcd/gcdmessage"
+	"gitlab.com/browserker/scanner/browser/keymap"
+)
+
+// keyEventParams adds editing commands to key events, which headless chrome will not
+// otherwise execute for keyboard shortcuts
+type keyEventParams struct {
+	gcdapi.InputDispatchKeyEventParams
+	Commands []string `json:"commands,omitempty"`
+}
+
+// SimulateUserGesture runs fn as if the user had interacted with the page. The page is given
+// transient user activation first, and scripts evaluated by fn are run as user gestures, so
+// APIs gated on activation (popups, clipboard writes, autoplay) are allowed.
+func (t *Tab) SimulateUserGesture(fn func() error) error {
+	return t.withUserGesture(func() error {
+		// an empty evaluation with userGesture set activates the page
+		if _, err := t.evaluateScript("void 0", false); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+// withUserGesture marks calls into the page made by fn as user gestures, the first of them
+// activates the page
+func (t *Tab) withUserGesture(fn func() error) error {
+	atomic.AddInt32(&t.userGestures, 1)
+	defer atomic.AddInt32(&t.userGestures, -1)
+	return fn()
+}
+
+// inUserGesture returns true while a SimulateUserGesture call is in progress
+func (t *Tab) inUserGesture() bool {
+	return atomic.LoadInt32(&t.userGestures) > 0
+}
 
 // Click the x, y coords one time
 func (t *Tab) Click(x, y float64) error {
@@ -51,18 +90,108 @@ func (t *Tab) MoveMouse(x, y float64) error {
 	return err
 }
 
+// Drag presses the left button at fromX, fromY, moves to toX, toY in steps moves with the button
+// held and releases it there. The button is released even if a move fails.
+func (t *Tab) Drag(fromX, fromY, toX, toY float64, steps int) error {
+	if steps < 1 {
+		steps = 1
+	}
+
+	if err := t.dispatchLeftButton("mousePressed", fromX, fromY); err != nil {
+		return err
+	}
+
+	var moveErr error
+	dx := (toX - fromX) / float64(steps)
+	dy := (toY - fromY) / float64(steps)
+	for i := 1; i <= steps && moveErr == nil; i++ {
+		moveErr = t.dispatchLeftButton("mouseMoved", fromX+dx*float64(i), fromY+dy*float64(i))
+	}
+
+	if err := t.dispatchLeftButton("mouseReleased", toX, toY); err != nil {
+		return err
+	}
+	return moveErr
+}
+
+// dispatchLeftButton mouse event of theType at x, y while the left button is (or was) held down
+func (t *Tab) dispatchLeftButton(theType string, x, y float64) error {
+	params := &gcdapi.InputDispatchMouseEventParams{TheType: theType,
+		X:       x,
+		Y:       y,
+		Button:  "left",
+		Buttons: 1,
+	}
+	if theType != "mouseMoved" {
+		params.ClickCount = 1
+	}
+
+	_, err := t.t.Input.DispatchMouseEventWithParams(params)
+	return err
+}
+
+// ScrollBy dispatches a mouse wheel event in the center of the viewport, scrolling the page
+// by dx, dy pixels.
+func (t *Tab) ScrollBy(dx, dy float64) error {
+	layout, _, _, err := t.t.Page.GetLayoutMetrics()
+	if err != nil {
+		return err
+	}
+	return t.scrollAt(float64(layout.ClientWidth)/2, float64(layout.ClientHeight)/2, dx, dy)
+}
+
+// scrollAt dispatches a mouse wheel event at the x, y coords, scrolling whatever is under them
+func (t *Tab) scrollAt(x, y, dx, dy float64) error {
+	mouseWheelParams := &gcdapi.InputDispatchMouseEventParams{TheType: "mouseWheel",
+		X:      x,
+		Y:      y,
+		DeltaX: dx,
+		DeltaY: dy,
+	}
+
+	_, err := t.t.Input.DispatchMouseEventWithParams(mouseWheelParams)
+	t.invalidateGeometry()
+	return err
+}
+
+// invalidateGeometry of all elements, after scrolling their cached dimensions are stale
+func (t *Tab) invalidateGeometry() {
+	atomic.AddInt64(&t.geometryGeneration, 1)
+}
+
+// geometry generation elements compare against when using their cached dimensions
+func (t *Tab) geometry() int64 {
+	return atomic.LoadInt64(&t.geometryGeneration)
+}
+
+// GetScrollHeight returns the height of the document content
+func (t *Tab) GetScrollHeight() (float64, error) {
+	_, _, contentSize, err := t.t.Page.GetLayoutMetrics()
+	if err != nil {
+		return 0, err
+	}
+	return contentSize.Height, nil
+}
+
 // SendKeys to whatever is focused, best called from Element.SendKeys which will
 // try to focus on the element first. Use \n for Enter, \b for backspace or \t for Tab.
+// Enter is sent as a real key press, so it inserts a line break in a textarea and submits
+// the form of a single line input.
 func (t *Tab) SendKeys(text string) error {
 	inputParams := &gcdapi.InputDispatchKeyEventParams{TheType: "char"}
 
 	// loop over input, looking for system keys and handling them
-	for _, inputchar := range text {
+	for _, inputchar := range keymap.NormalizeNewlines(text) {
 		input := string(inputchar)
 
 		// check system keys
 		switch input {
-		case "\r", "\n", "\t", "\b":
+		case "\r", "\n":
+			if err := t.pressEnter(); err != nil {
+				return err
+			}
+			continue
+		case "\t", "\b":
 			if err := t.pressSystemKey(input); err != nil {
 				return err
 			}
@@ -92,11 +221,6 @@ func (t *Tab) pressSystemKey(systemKey string) error {
 		inputParams.Text = "\t"
 		inputParams.WindowsVirtualKeyCode = 9
 		inputParams.NativeVirtualKeyCode = 9
-	case "\r", "\n":
-		inputParams.UnmodifiedText = "\r"
-		inputParams.Text = "\r"
-		inputParams.WindowsVirtualKeyCode = 13
-		inputParams.NativeVirtualKeyCode = 13
 	}
 
 	if _, err := t.t.Input.DispatchKeyEventWithParams(inputParams); err != nil {
@@ -114,3 +238,56 @@ func (t *Tab) pressSystemKey(systemKey string) error {
 	}
 	return nil
 }
+
+// pressEnter dispatches the keyDown, char and keyUp events of the Enter key
+func (t *Tab) pressEnter() error {
+	for _, key := range keymap.KeyEncode('\r') {
+		if _, err := t.t.Input.DispatchKeyEventWithParams(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SelectAll presses Ctrl+A in whatever is focused, selecting all of its text
+func (t *Tab) SelectAll() error {
+	params := &keyEventParams{
+		InputDispatchKeyEventParams: gcdapi.InputDispatchKeyEventParams{
+			TheType:               "rawKeyDown",
+			Modifiers:             int(keymap.ModifierCtrl),
+			Key:                   "a",
+			Code:                  "KeyA",
+			WindowsVirtualKeyCode: 65,
+			NativeVirtualKeyCode:  65,
+		},
+		Commands: []string{"selectAll"},
+	}
+	if err := t.dispatchKeyEvent(params); err != nil {
+		return err
+	}
+
+	params.TheType = "keyUp"
+	params.Commands = nil
+	return t.dispatchKeyEvent(params)
+}
+
+// GetSelectedText returns the text currently selected in the page
+func (t *Tab) GetSelectedText() (string, error) {
+	r, err := t.EvaluateScript("window.getSelection().toString()")
+	if err != nil {
+		return "", err
+	}
+
+	if r == nil {
+		return "", nil
+	}
+	selected, _ := r.Value.(string)
+	return selected, nil
+}
+
+// dispatchKeyEvent with editing commands
+func (t *Tab) dispatchKeyEvent(params *keyEventParams) error {
+	target := t.targeter()
+	_, err := gcdmessage.SendDefaultRequest(target, target.GetSendCh(), &gcdmessage.ParamRequest{Id: target.GetId(), Method: "Input.dispatchKeyEvent", Params: params})
+	return err
+}
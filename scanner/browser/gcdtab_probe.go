@@ -0,0 +1,80 @@
+package browser
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// fields of a form which can be filled
+const formFieldsSelector = "input, select, textarea"
+
+// ProbeForm fills the form's fields without submitting it, returning the fields which appeared
+// as a result (e.g. dynamically added inputs). The form is then reset with form.reset(), so its
+// client side behavior is exercised without a network submit.
+func (t *Tab) ProbeForm(ctx context.Context, htmlForm *browserk.HTMLFormElement) ([]*browserk.HTMLElement, error) {
+	if htmlForm == nil {
+		return nil, &ErrInvalidElement{}
+	}
+
+	form, err := t.FindByHTMLElement(htmlForm)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := t.formFields(form)
+	if err != nil {
+		return nil, err
+	}
+
+	form.ScrollTo()
+	t.fillFields(form, htmlForm)
+
+	// give validation and dynamic field handlers a moment to run
+	timer := time.NewTimer(time.Millisecond * 200)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	after, err := t.formFields(form)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]struct{}, len(before))
+	for _, field := range before {
+		known[string(field.Hash())] = struct{}{}
+	}
+
+	discovered := make([]*browserk.HTMLElement, 0)
+	for _, field := range after {
+		if _, ok := known[string(field.Hash())]; !ok {
+			discovered = append(discovered, field)
+		}
+	}
+
+	if _, err := form.callFunctionOn("function() { this.reset(); }"); err != nil {
+		return discovered, err
+	}
+	return discovered, nil
+}
+
+// formFields currently in the form
+func (t *Tab) formFields(form *Element) ([]*browserk.HTMLElement, error) {
+	elements, err := t.GetDocumentElementsBySelector(form.NodeID(), formFieldsSelector)
+	if err != nil {
+		return nil, NodeError(err)
+	}
+
+	fields := make([]*browserk.HTMLElement, 0, len(elements))
+	for _, ele := range elements {
+		if err := ele.WaitForReady(); err != nil {
+			continue
+		}
+		fields = append(fields, ElementToHTMLElement(ele))
+	}
+	return fields, nil
+}
@@ -0,0 +1,137 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+)
+
+// headers the browser will not allow fetch() to set
+var forbiddenFetchHeaders = map[string]struct{}{
+	"accept-charset":    {},
+	"accept-encoding":   {},
+	"connection":        {},
+	"content-length":    {},
+	"cookie":            {},
+	"cookie2":           {},
+	"date":              {},
+	"dnt":               {},
+	"expect":            {},
+	"host":              {},
+	"keep-alive":        {},
+	"origin":            {},
+	"referer":           {},
+	"te":                {},
+	"trailer":           {},
+	"transfer-encoding": {},
+	"upgrade":           {},
+	"user-agent":        {},
+	"via":               {},
+}
+
+const replayScript = `(async function(url, opts) {
+	const resp = await fetch(url, opts);
+	const headers = {};
+	resp.headers.forEach(function(v, k) { headers[k] = v; });
+	const body = await resp.text();
+	return {url: resp.url, status: resp.status, statusText: resp.statusText, headers: headers, body: body};
+})(%s, %s)`
+
+type replayOptions struct {
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers"`
+	Body        string            `json:"body,omitempty"`
+	Credentials string            `json:"credentials"`
+	Redirect    string            `json:"redirect"`
+}
+
+type replayResult struct {
+	URL        string                 `json:"url"`
+	Status     int                    `json:"status"`
+	StatusText string                 `json:"statusText"`
+	Headers    map[string]interface{} `json:"headers"`
+	Body       string                 `json:"body"`
+}
+
+// ReplayRequest issues the request with fetch() from the current page context so cookies
+// and the page's origin are preserved, returning the captured response
+func (t *Tab) ReplayRequest(req *browserk.Request) (*browserk.HTTPResponse, error) {
+	opts := &replayOptions{
+		Method:      req.Method,
+		Headers:     make(map[string]string, len(req.Headers)),
+		Credentials: "include",
+		Redirect:    "follow",
+	}
+	if opts.Method == "" {
+		opts.Method = "GET"
+	}
+
+	if opts.Method != "GET" && opts.Method != "HEAD" {
+		opts.Body = req.Body
+	}
+
+	for k, v := range req.Headers {
+		lower := strings.ToLower(k)
+		if _, forbidden := forbiddenFetchHeaders[lower]; forbidden || strings.HasPrefix(lower, "sec-") || strings.HasPrefix(lower, ":") {
+			continue
+		}
+		opts.Headers[k] = v
+	}
+
+	encodedURL, err := json.Marshal(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedOpts, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &gcdapi.RuntimeEvaluateParams{
+		Expression:    fmt.Sprintf(replayScript, encodedURL, encodedOpts),
+		ObjectGroup:   "browserker",
+		Silent:        true,
+		ReturnByValue: true,
+		AwaitPromise:  true,
+	}
+
+	rro, exp, err := t.t.Runtime.EvaluateWithParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp != nil {
+		return nil, &ErrScriptEvaluation{Message: "failed to replay request", ExceptionText: exp.Text, ExceptionDetails: exp}
+	}
+
+	raw, err := json.Marshal(rro.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &replayResult{}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return nil, err
+	}
+
+	mimeType := ""
+	if contentType, ok := result.Headers["content-type"].(string); ok {
+		mimeType = contentType
+	}
+
+	return &browserk.HTTPResponse{
+		Type: "Fetch",
+		Response: &gcdapi.NetworkResponse{
+			Url:        result.URL,
+			Status:     result.Status,
+			StatusText: result.StatusText,
+			Headers:    result.Headers,
+			MimeType:   mimeType,
+		},
+		Body: []byte(result.Body),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package browser_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/browser"
+	"golang.org/x/net/context"
+)
+
+func TestReplayRequest(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+
+	seen := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "" {
+			seen <- q
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>" + r.URL.Query().Get("q") + "</body></html>"))
+	}))
+	defer srv.Close()
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+
+	if err := b.Navigate(ctx, srv.URL+"/?q=original"); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+	<-seen
+
+	req := &browserk.Request{Method: "GET", URL: srv.URL + "/?q=original", Headers: map[string]string{}}
+	resp, err := b.ReplayRequest(req.WithParam("q", "mutated"))
+	if err != nil {
+		t.Fatalf("error replaying request: %s\n", err)
+	}
+
+	if got := <-seen; got != "mutated" {
+		t.Fatalf("expected server to see mutated value got %s", got)
+	}
+
+	if resp.Response.Status != 200 {
+		t.Fatalf("expected 200 got %d", resp.Response.Status)
+	}
+
+	if string(resp.Body) != "<html><body>mutated</body></html>" {
+		t.Fatalf("unexpected body %s", string(resp.Body))
+	}
+}
@@ -0,0 +1,116 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/wirepair/gcd"
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+)
+
+// CertificateErrorVulnID is reported for pages served with an invalid certificate
+const CertificateErrorVulnID = "BR-B-0001"
+
+// IgnoreCertificateErrors has chrome skip validating certificates (self signed, expired, wrong
+// host) instead of raising certificate errors for us to continue past. Certificate errors are
+// reported as Info findings regardless.
+func (t *Tab) IgnoreCertificateErrors(ignore bool) error {
+	_, err := t.t.Security.SetIgnoreCertificateErrors(ignore)
+	return err
+}
+
+// subscribeSecurityEvents reports certificate errors and continues the request
+func (t *Tab) subscribeSecurityEvents() {
+	// overriding lets us decide on each certificateError event
+	t.t.Security.SetOverrideCertificateErrors(true)
+
+	t.subscribe("Security.certificateError", func(target *gcd.ChromeTarget, payload []byte) {
+		resp := &gcdapi.SecurityCertificateErrorEvent{}
+		err := json.Unmarshal(payload, resp)
+		if err != nil {
+			return
+		}
+		t.reportCertificateError(resp.Params.RequestURL, resp.Params.ErrorType, nil)
+
+		t.ctx.Log.Info().Str("type", resp.Params.ErrorType).Msg("handling certificate error")
+		p := &gcdapi.SecurityHandleCertificateErrorParams{
+			EventId: resp.Params.EventId,
+			Action:  "continue",
+		}
+
+		t.t.Security.HandleCertificateErrorWithParams(p)
+		t.ctx.Log.Info().Msg("certificate error handled")
+	})
+
+	// when ignored chrome does not fire certificateError, but the page's security state has the error
+	t.subscribe("Security.visibleSecurityStateChanged", func(target *gcd.ChromeTarget, payload []byte) {
+		resp := &gcdapi.SecurityVisibleSecurityStateChangedEvent{}
+		if err := json.Unmarshal(payload, resp); err != nil {
+			return
+		}
+		state := resp.Params.VisibleSecurityState
+		if state == nil {
+			return
+		}
+		t.securityState.Store(state)
+
+		cert := state.CertificateSecurityState
+		if cert == nil || cert.CertificateNetworkError == "" {
+			return
+		}
+		pageURL, _ := t.GetURL()
+		t.reportCertificateError(pageURL, cert.CertificateNetworkError, cert)
+	})
+}
+
+// GetSecurityState of the current page as last reported by Chrome, including the certificate
+// details (subject, issuer, validity) and the TLS protocol and cipher for https pages
+func (t *Tab) GetSecurityState() (*gcdapi.SecurityVisibleSecurityState, error) {
+	state, ok := t.securityState.Load().(*gcdapi.SecurityVisibleSecurityState)
+	if !ok || state == nil {
+		return nil, ErrNoSecurityState
+	}
+	return state, nil
+}
+
+// reportCertificateError as an Info finding, once per origin and error type. cert adds the
+// issuer and expiry to the description if known.
+func (t *Tab) reportCertificateError(requestURL, errorType string, cert *gcdapi.SecurityCertificateSecurityState) {
+	if t.ctx == nil || t.ctx.Reporter == nil {
+		return
+	}
+
+	origin := requestURL
+	if u, err := url.Parse(requestURL); err == nil && u.Host != "" {
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	t.certMutex.Lock()
+	_, reported := t.certErrors[origin+" "+errorType]
+	t.certErrors[origin+" "+errorType] = struct{}{}
+	t.certMutex.Unlock()
+	if reported {
+		return
+	}
+
+	description := fmt.Sprintf("The certificate for %s is invalid: %s", origin, errorType)
+	if cert != nil {
+		expires := time.Unix(int64(cert.ValidTo), 0).UTC()
+		description += fmt.Sprintf(" (subject: %s, issuer: %s, expires: %s, protocol: %s)", cert.SubjectName, cert.Issuer, expires.Format(time.RFC3339), cert.Protocol)
+	}
+
+	t.ctx.Reporter.Add(&browserk.Report{
+		VulnID:      CertificateErrorVulnID,
+		CWE:         295,
+		Severity:    browserk.Info,
+		Description: description,
+		Remediation: "Serve the site with a valid certificate issued by a trusted certificate authority for the host name",
+		Evidence: &browserk.Evidence{
+			URL:   origin,
+			Match: errorType,
+		},
+	})
+}
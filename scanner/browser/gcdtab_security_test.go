@@ -0,0 +1,56 @@
+package browser_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/browser"
+)
+
+func TestTabGetSecurityState(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	pool.SetConfig(&browserk.Config{IgnoreCertErrors: true})
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	srv := httptest.NewTLSServer(http.FileServer(http.Dir("testdata/")))
+	defer srv.Close()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	defer b.Close()
+	tab := b.(*browser.Tab)
+
+	if err := tab.Navigate(ctx, srv.URL+"/index.html"); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	// the state is sent asynchronously after the page loads
+	var cert *gcdapi.SecurityCertificateSecurityState
+	for i := 0; i < 20 && cert == nil; i++ {
+		if state, err := tab.GetSecurityState(); err == nil {
+			cert = state.CertificateSecurityState
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if cert == nil {
+		t.Fatalf("expected security state with certificate details")
+	}
+
+	if cert.Protocol == "" || cert.Issuer == "" || cert.ValidTo == 0 || len(cert.Certificate) == 0 {
+		t.Fatalf("expected protocol and certificate details got %#v", cert)
+	}
+}
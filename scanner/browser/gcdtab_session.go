@@ -0,0 +1,98 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/browserker/browserk"
+)
+
+const getStorageScript = `JSON.stringify({
+	origin: location.origin,
+	local: Object.assign({}, window.localStorage),
+	session: Object.assign({}, window.sessionStorage)
+})`
+
+// injects storage values that are not already set, only when the document is from the session's origin
+const setStorageScript = `(function(origin, local, session) {
+	if (location.origin !== origin) {
+		return;
+	}
+	try {
+		for (const k in local) {
+			if (window.localStorage.getItem(k) === null) {
+				window.localStorage.setItem(k, local[k]);
+			}
+		}
+		for (const k in session) {
+			if (window.sessionStorage.getItem(k) === null) {
+				window.sessionStorage.setItem(k, session[k]);
+			}
+		}
+	} catch (e) {}
+})(%s, %s, %s);`
+
+type storageSnapshot struct {
+	Origin  string            `json:"origin"`
+	Local   map[string]string `json:"local"`
+	Session map[string]string `json:"session"`
+}
+
+// GetSession captures all cookies and the current origin's local and session storage
+func (t *Tab) GetSession() (*browserk.Session, error) {
+	cookies, err := t.t.Network.GetAllCookies()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get cookies")
+	}
+
+	r, err := t.EvaluateScript(getStorageScript)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get storage")
+	}
+
+	snapshot := &storageSnapshot{}
+	if r != nil {
+		if raw, ok := r.Value.(string); ok {
+			if err := json.Unmarshal([]byte(raw), snapshot); err != nil {
+				return nil, errors.Wrap(err, "failed to decode storage")
+			}
+		}
+	}
+
+	return &browserk.Session{
+		Origin:         snapshot.Origin,
+		Cookies:        GCDCookieToBrowserk(cookies),
+		LocalStorage:   snapshot.Local,
+		SessionStorage: snapshot.Session,
+		Acquired:       time.Now(),
+	}, nil
+}
+
+// SetSession sets the session's cookies in the browser and injects its storage values
+// into every document subsequently loaded from the session's origin
+func (t *Tab) SetSession(session *browserk.Session) error {
+	if session == nil {
+		return nil
+	}
+
+	if len(session.Cookies) > 0 {
+		if _, err := t.t.Network.SetCookies(BrowserkCookieToGCD(session.Cookies)); err != nil {
+			return errors.Wrap(err, "failed to set cookies")
+		}
+	}
+
+	if session.Origin == "" || (len(session.LocalStorage) == 0 && len(session.SessionStorage) == 0) {
+		return nil
+	}
+
+	origin, _ := json.Marshal(session.Origin)
+	local, _ := json.Marshal(session.LocalStorage)
+	sess, _ := json.Marshal(session.SessionStorage)
+	source := fmt.Sprintf(setStorageScript, origin, local, sess)
+	if _, err := t.t.Page.AddScriptToEvaluateOnNewDocument(source, ""); err != nil {
+		return errors.Wrap(err, "failed to inject storage")
+	}
+	return nil
+}
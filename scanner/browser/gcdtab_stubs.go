@@ -0,0 +1,85 @@
+package browser
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"github.com/wirepair/gcd/gcdapi"
+)
+
+// responseStub answers requests matching pattern without them reaching the server
+type responseStub struct {
+	pattern *regexp.Regexp
+	status  int
+	headers []*gcdapi.FetchHeaderEntry
+	body    []byte
+}
+
+// StubResponse answers requests whose url matches urlPattern with status, headers and body
+// instead of sending them to the server. The pattern is matched against the full url, * matches
+// any number of characters (e.g. "*/api/user*"). Later stubs take precedence over earlier ones.
+func (t *Tab) StubResponse(urlPattern string, status int, headers map[string]string, body []byte) {
+	stub := &responseStub{
+		pattern: wildcardPattern(urlPattern),
+		status:  status,
+		headers: make([]*gcdapi.FetchHeaderEntry, 0, len(headers)),
+		body:    body,
+	}
+	for name, value := range headers {
+		stub.headers = append(stub.headers, &gcdapi.FetchHeaderEntry{Name: name, Value: value})
+	}
+
+	t.stubMutex.Lock()
+	t.stubs = append([]*responseStub{stub}, t.stubs...)
+	t.stubMutex.Unlock()
+}
+
+// ClearStubbedResponses so requests are sent to the server again
+func (t *Tab) ClearStubbedResponses() {
+	t.stubMutex.Lock()
+	t.stubs = nil
+	t.stubMutex.Unlock()
+}
+
+// fulfillStub answers the paused request with the first matching stub, returning true if it did
+func (t *Tab) fulfillStub(message *gcdapi.FetchRequestPausedEvent) bool {
+	p := message.Params
+	if p.Request == nil {
+		return false
+	}
+
+	t.stubMutex.RLock()
+	var stub *responseStub
+	for _, s := range t.stubs {
+		if s.pattern.MatchString(p.Request.Url) {
+			stub = s
+			break
+		}
+	}
+	t.stubMutex.RUnlock()
+
+	if stub == nil {
+		return false
+	}
+
+	_, err := t.t.Fetch.FulfillRequestWithParams(&gcdapi.FetchFulfillRequestParams{
+		RequestId:       p.RequestId,
+		ResponseCode:    stub.status,
+		ResponseHeaders: stub.headers,
+		Body:            base64.StdEncoding.EncodeToString(stub.body),
+	})
+	if err != nil {
+		t.ctx.Log.Warn().Err(err).Str("url", p.Request.Url).Msg("failed to fulfill stubbed response")
+	}
+	return true
+}
+
+// wildcardPattern compiles a url pattern where * matches any number of characters
+func wildcardPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
@@ -13,7 +13,8 @@ import (
 )
 
 func (t *Tab) subscribeTargetCrashed() {
-	t.t.Subscribe("Inspector.targetCrashed", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Inspector.targetCrashed", func(target *gcd.ChromeTarget, payload []byte) {
+		t.crashed.Store(true)
 		select {
 		case t.crashedCh <- "crashed":
 		case <-t.exitCh:
@@ -24,7 +25,7 @@ func (t *Tab) subscribeTargetCrashed() {
 }
 
 func (t *Tab) subscribeTargetDetached() {
-	t.t.Subscribe("Inspector.detached", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Inspector.detached", func(target *gcd.ChromeTarget, payload []byte) {
 		header := &gcdapi.InspectorDetachedEvent{}
 		err := json.Unmarshal(payload, header)
 		reason := "detached"
@@ -44,7 +45,7 @@ func (t *Tab) subscribeTargetDetached() {
 
 // our default loadFiredEvent handler, returns a response to resp channel to navigate once complete.
 func (t *Tab) subscribeLoadEvent() {
-	t.t.Subscribe("Page.loadEventFired", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Page.loadEventFired", func(target *gcd.ChromeTarget, payload []byte) {
 		t.ctx.Log.Info().Msg("loadFiredEvent")
 		if t.IsNavigating() {
 			select {
@@ -57,7 +58,7 @@ func (t *Tab) subscribeLoadEvent() {
 }
 
 func (t *Tab) subscribeFrameLoadingEvent() {
-	t.t.Subscribe("Page.frameStartedLoading", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Page.frameStartedLoading", func(target *gcd.ChromeTarget, payload []byte) {
 		t.ctx.Log.Info().Msg("frame loading")
 		if t.IsNavigating() {
 			return
@@ -73,7 +74,7 @@ func (t *Tab) subscribeFrameLoadingEvent() {
 }
 
 func (t *Tab) subscribeFrameFinishedEvent() {
-	t.t.Subscribe("Page.frameStoppedLoading", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Page.frameStoppedLoading", func(target *gcd.ChromeTarget, payload []byte) {
 		if t.IsNavigating() {
 			return
 		}
@@ -86,9 +87,37 @@ func (t *Tab) subscribeFrameFinishedEvent() {
 	})
 }
 
+// invalidates cached element dimensions when the viewport is resized
+func (t *Tab) subscribeFrameResized() {
+	t.subscribe("Page.frameResized", func(target *gcd.ChromeTarget, payload []byte) {
+		t.invalidateGeometry()
+	})
+}
+
+// signals the url the top frame navigated to, replacing any unread url
+func (t *Tab) subscribeFrameNavigated() {
+	t.subscribe("Page.frameNavigated", func(target *gcd.ChromeTarget, payload []byte) {
+		header := &gcdapi.PageFrameNavigatedEvent{}
+		err := json.Unmarshal(payload, header)
+		if err != nil || header.Params.Frame == nil || header.Params.Frame.ParentId != "" {
+			return
+		}
+
+		select {
+		case <-t.frameNavigatedCh:
+		default:
+		}
+
+		select {
+		case t.frameNavigatedCh <- header.Params.Frame.Url:
+		default:
+		}
+	})
+}
+
 func (t *Tab) subscribeSetChildNodes() {
 	// new nodes
-	t.t.Subscribe("DOM.setChildNodes", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.setChildNodes", func(target *gcd.ChromeTarget, payload []byte) {
 		header := &gcdapi.DOMSetChildNodesEvent{}
 		err := json.Unmarshal(payload, header)
 		if err == nil {
@@ -100,7 +129,7 @@ func (t *Tab) subscribeSetChildNodes() {
 }
 
 func (t *Tab) subscribeAttributeModified() {
-	t.t.Subscribe("DOM.attributeModified", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.attributeModified", func(target *gcd.ChromeTarget, payload []byte) {
 		header := &gcdapi.DOMAttributeModifiedEvent{}
 		err := json.Unmarshal(payload, header)
 		if err == nil {
@@ -111,7 +140,7 @@ func (t *Tab) subscribeAttributeModified() {
 }
 
 func (t *Tab) subscribeAttributeRemoved() {
-	t.t.Subscribe("DOM.attributeRemoved", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.attributeRemoved", func(target *gcd.ChromeTarget, payload []byte) {
 		header := &gcdapi.DOMAttributeRemovedEvent{}
 		err := json.Unmarshal(payload, header)
 		if err == nil {
@@ -120,8 +149,19 @@ func (t *Tab) subscribeAttributeRemoved() {
 		}
 	})
 }
+func (t *Tab) subscribeInlineStyleInvalidated() {
+	t.subscribe("DOM.inlineStyleInvalidated", func(target *gcd.ChromeTarget, payload []byte) {
+		header := &gcdapi.DOMInlineStyleInvalidatedEvent{}
+		err := json.Unmarshal(payload, header)
+		if err == nil {
+			event := header.Params
+			t.dispatchNodeChange(&NodeChangeEvent{EventType: InlineStyleInvalidatedEvent, NodeIDs: event.NodeIds})
+		}
+	})
+}
+
 func (t *Tab) subscribeCharacterDataModified() {
-	t.t.Subscribe("DOM.characterDataModified", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.characterDataModified", func(target *gcd.ChromeTarget, payload []byte) {
 		header := &gcdapi.DOMCharacterDataModifiedEvent{}
 		err := json.Unmarshal(payload, header)
 		if err == nil {
@@ -131,7 +171,7 @@ func (t *Tab) subscribeCharacterDataModified() {
 	})
 }
 func (t *Tab) subscribeChildNodeCountUpdated() {
-	t.t.Subscribe("DOM.childNodeCountUpdated", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.childNodeCountUpdated", func(target *gcd.ChromeTarget, payload []byte) {
 		header := &gcdapi.DOMChildNodeCountUpdatedEvent{}
 		err := json.Unmarshal(payload, header)
 		if err == nil {
@@ -141,7 +181,7 @@ func (t *Tab) subscribeChildNodeCountUpdated() {
 	})
 }
 func (t *Tab) subscribeChildNodeInserted() {
-	t.t.Subscribe("DOM.childNodeInserted", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.childNodeInserted", func(target *gcd.ChromeTarget, payload []byte) {
 		//t.ctx.Log.Printf("childNodeInserted: %s\n", string(payload))
 		header := &gcdapi.DOMChildNodeInsertedEvent{}
 		err := json.Unmarshal(payload, header)
@@ -152,7 +192,7 @@ func (t *Tab) subscribeChildNodeInserted() {
 	})
 }
 func (t *Tab) subscribeChildNodeRemoved() {
-	t.t.Subscribe("DOM.childNodeRemoved", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.childNodeRemoved", func(target *gcd.ChromeTarget, payload []byte) {
 		header := &gcdapi.DOMChildNodeRemovedEvent{}
 		err := json.Unmarshal(payload, header)
 		if err == nil {
@@ -174,7 +214,7 @@ func (t *Tab) dispatchNodeChange(evt *NodeChangeEvent) {
 
 func (t *Tab) subscribeDocumentUpdated() {
 	// node ids are no longer valid
-	t.t.Subscribe("DOM.documentUpdated", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("DOM.documentUpdated", func(target *gcd.ChromeTarget, payload []byte) {
 		select {
 		case t.nodeChange <- &NodeChangeEvent{EventType: DocumentUpdatedEvent}:
 		case <-t.exitCh:
@@ -185,7 +225,7 @@ func (t *Tab) subscribeDocumentUpdated() {
 }
 
 func (t *Tab) subscribeStorageEvents() {
-	t.t.Subscribe("Storage.domStorageItemsCleared", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Storage.domStorageItemsCleared", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.DOMStorageDomStorageItemsClearedEvent{}
 		if err := json.Unmarshal(payload, message); err == nil {
 			p := message.Params
@@ -199,7 +239,7 @@ func (t *Tab) subscribeStorageEvents() {
 
 		}
 	})
-	t.t.Subscribe("Storage.domStorageItemRemoved", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Storage.domStorageItemRemoved", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.DOMStorageDomStorageItemRemovedEvent{}
 		if err := json.Unmarshal(payload, message); err == nil {
 			p := message.Params
@@ -213,7 +253,7 @@ func (t *Tab) subscribeStorageEvents() {
 			t.container.AddStorageEvent(evt)
 		}
 	})
-	t.t.Subscribe("Storage.domStorageItemAdded", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Storage.domStorageItemAdded", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.DOMStorageDomStorageItemAddedEvent{}
 		if err := json.Unmarshal(payload, message); err == nil {
 			p := message.Params
@@ -230,7 +270,7 @@ func (t *Tab) subscribeStorageEvents() {
 			t.container.AddStorageEvent(evt)
 		}
 	})
-	t.t.Subscribe("Storage.domStorageItemUpdated", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Storage.domStorageItemUpdated", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.DOMStorageDomStorageItemUpdatedEvent{}
 		if err := json.Unmarshal(payload, message); err == nil {
 			p := message.Params
@@ -251,7 +291,7 @@ func (t *Tab) subscribeStorageEvents() {
 }
 
 func (t *Tab) subscribeConsoleEvents() {
-	t.t.Subscribe("Console.messageAdded", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Console.messageAdded", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.ConsoleMessageAddedEvent{}
 		if err := json.Unmarshal(payload, message); err == nil {
 			p := message.Params
@@ -271,24 +311,84 @@ func (t *Tab) subscribeConsoleEvents() {
 	})
 }
 
+// tracks javascript execution contexts so scripts can be evaluated in a specific frame
+func (t *Tab) subscribeExecutionContexts() {
+	t.subscribe("Runtime.executionContextCreated", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.RuntimeExecutionContextCreatedEvent{}
+		if err := json.Unmarshal(payload, message); err == nil && message.Params.Context != nil {
+			t.addExecutionContext("", nil, message.Params.Context)
+		}
+	})
+
+	t.subscribe("Runtime.executionContextDestroyed", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.RuntimeExecutionContextDestroyedEvent{}
+		if err := json.Unmarshal(payload, message); err == nil {
+			t.removeExecutionContext("", message.Params.ExecutionContextId)
+		}
+	})
+
+	t.subscribe("Runtime.executionContextsCleared", func(target *gcd.ChromeTarget, payload []byte) {
+		t.clearExecutionContexts("")
+	})
+}
+
+// tracks workers auto attached to the page, their events arrive wrapped in receivedMessageFromTarget
+func (t *Tab) subscribeWorkers() {
+	t.subscribe("Target.attachedToTarget", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.TargetAttachedToTargetEvent{}
+		if err := json.Unmarshal(payload, message); err == nil && message.Params.TargetInfo != nil {
+			if isWorkerType(message.Params.TargetInfo.Type) {
+				t.attachWorker(message.Params.SessionId, message.Params.TargetInfo)
+			}
+		}
+	})
+
+	t.subscribe("Target.detachedFromTarget", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.TargetDetachedFromTargetEvent{}
+		if err := json.Unmarshal(payload, message); err == nil {
+			t.detachWorker(message.Params.SessionId)
+		}
+	})
+
+	t.subscribe("Target.receivedMessageFromTarget", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.TargetReceivedMessageFromTargetEvent{}
+		if err := json.Unmarshal(payload, message); err == nil {
+			t.handleWorkerMessage(message.Params.SessionId, message.Params.Message)
+		}
+	})
+}
+
 func (t *Tab) subscribeDialogEvents() {
-	t.t.Subscribe("Page.javascriptDialogOpening", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Page.javascriptDialogOpening", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.PageJavascriptDialogOpeningEvent{}
-		if err := json.Unmarshal(payload, message); err == nil {
-			t.t.Page.HandleJavaScriptDialog(true, "browserk")
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
+		}
+
+		// always leave pages trying to block navigation, so the crawler never hangs on them
+		if message.Params.Type == "beforeunload" {
+			t.recordBlockedUnload(message.Params.Url)
+			t.t.Page.HandleJavaScriptDialog(true, "")
+			return
 		}
+		t.t.Page.HandleJavaScriptDialog(true, "browserk")
 	})
 }
 
 // TODO: Need to account for redirects since they use the same requestIDs and don't seem to allow retrieving their bodies
 // HOWEVER it does appear we can intercept them???
 func (t *Tab) subscribeNetworkEvents(ctx *browserk.Context) {
-	t.t.Subscribe("network.loadingFailed", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Network.loadingFailed", func(target *gcd.ChromeTarget, payload []byte) {
 		t.ctx.Log.Info().Msgf("failed: %s\n", string(payload))
 		t.container.DecRequest()
+		message := &gcdapi.NetworkLoadingFailedEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			return
+		}
+		t.container.FailedTiming(message.Params.RequestId)
 	})
 
-	t.t.Subscribe("Network.requestWillBeSent", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Network.requestWillBeSent", func(target *gcd.ChromeTarget, payload []byte) {
 		t.container.IncRequest()
 		message := &gcdapi.NetworkRequestWillBeSentEvent{}
 		if err := json.Unmarshal(payload, message); err != nil {
@@ -316,7 +416,7 @@ func (t *Tab) subscribeNetworkEvents(ctx *browserk.Context) {
 		t.ctx.Log.Debug().Int32("pending", t.container.OpenRequestCount()).Str("url", message.Params.Request.Url).Str("request_id", message.Params.RequestId).Msg("added request")
 	})
 
-	t.t.Subscribe("Network.requestServedFromCache", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Network.requestServedFromCache", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.NetworkRequestServedFromCacheEvent{}
 		if err := json.Unmarshal(payload, message); err != nil {
 			return
@@ -324,13 +424,18 @@ func (t *Tab) subscribeNetworkEvents(ctx *browserk.Context) {
 		//t.ctx.Log.Info().Int32("pending", t.container.OpenRequestCount()).Str("request_id", message.Params.RequestId).Msg("served from cache")
 	})
 
-	t.t.Subscribe("Network.responseReceived", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Network.responseReceived", func(target *gcd.ChromeTarget, payload []byte) {
 
 		message := &gcdapi.NetworkResponseReceivedEvent{}
 		if err := json.Unmarshal(payload, message); err != nil {
 			return
 		}
 		p := message.Params
+		sent := p.Timestamp
+		if p.Response.Timing != nil {
+			sent = p.Response.Timing.RequestTime
+		}
+		t.container.ResponseTiming(p.RequestId, p.Response.Url, sent)
 		//t.ctx.Log.Info().Int32("pending", t.container.OpenRequestCount()).Str("url", p.Response.Url).Str("request_id", message.Params.RequestId).Msg("waiting")
 
 		timeoutCtx, cancel := context.WithTimeout(ctx.Ctx, time.Second*10)
@@ -351,27 +456,33 @@ func (t *Tab) subscribeNetworkEvents(ctx *browserk.Context) {
 		}
 
 		resp := GCDResponseToBrowserk(message, body)
+		body = nil
+
+		// oversized bodies go to disk before anything holds on to them, plugins read them back with ReadBody
+		if _, err := resp.SpillBody(t.bodyDir, t.maxBodySize); err != nil {
+			t.ctx.Log.Warn().Str("url", message.Params.Response.Url).Err(err).Msg("failed to spill body to disk")
+		}
 
 		// Plugin Dispatch
 		t.ctx.PluginServicer.DispatchEvent(browserk.HTTPResponsePluginEvent(t.ctx, resp.Response.Url, nil, resp))
-
 		t.container.AddResponse(resp)
 		t.ctx.Log.Debug().Int32("pending", t.container.OpenRequestCount()).Str("url", p.Response.Url).Str("request_id", message.Params.RequestId).Msg("added")
 	})
 
-	t.t.Subscribe("Network.loadingFinished", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Network.loadingFinished", func(target *gcd.ChromeTarget, payload []byte) {
 		t.container.DecRequest()
 		message := &gcdapi.NetworkLoadingFinishedEvent{}
 		if err := json.Unmarshal(payload, message); err != nil {
 			return
 		}
 		//t.ctx.Log.Info().Int32("pending", t.container.OpenRequestCount()).Str("request_id", message.Params.RequestId).Msg("finished")
+		t.container.FinishedTiming(message.Params.RequestId, message.Params.Timestamp, int64(message.Params.EncodedDataLength))
 		t.container.BodyReady(message.Params.RequestId)
 	})
 }
 
 func (t *Tab) subscribeInterception(ctx *browserk.Context) {
-	t.t.Subscribe("Fetch.requestPaused", func(target *gcd.ChromeTarget, payload []byte) {
+	t.subscribe("Fetch.requestPaused", func(target *gcd.ChromeTarget, payload []byte) {
 		message := &gcdapi.FetchRequestPausedEvent{}
 		if err := json.Unmarshal(payload, message); err != nil {
 			t.ctx.Log.Fatal().Err(err).Msg("critical error Fetch.requestPaused event was unable to decode")
@@ -389,6 +500,17 @@ func (t *Tab) subscribeInterception(ctx *browserk.Context) {
 
 func (t *Tab) interceptedRequest(ctx *browserk.Context, message *gcdapi.FetchRequestPausedEvent) {
 	// we are in a request paused event
+	if t.blockOutOfScope(message) || t.fulfillStub(message) {
+		return
+	}
+
+	if message.Params.Request != nil {
+		if err := t.throttle.Wait(ctx.Ctx, message.Params.Request.Url); err != nil {
+			t.t.Fetch.FailRequest(message.Params.RequestId, "Aborted")
+			return
+		}
+	}
+
 	modified := GCDFetchRequestToIntercepted(message, t.container)
 	ctx.NextReq(t, modified)
 
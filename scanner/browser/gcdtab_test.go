@@ -2,12 +2,21 @@ package browser_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/pkg/errors"
+	"github.com/wirepair/gcd/gcdapi"
+	"github.com/wirepair/gcd/gcdmessage"
 	"gitlab.com/browserker/browserk"
 	"gitlab.com/browserker/mock"
 	"gitlab.com/browserker/scanner/browser"
@@ -158,3 +167,774 @@ func TestBaseHref(t *testing.T) {
 	eles, _ := b.FindElements("base")
 	spew.Dump(eles)
 }
+
+func TestTabCrashed(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	b, port, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	// chrome://crash forces the renderer to crash, firing Inspector.targetCrashed
+	err = tab.Navigate(ctx, "chrome://crash")
+	if errors.Cause(err) != browser.ErrTabCrashed {
+		t.Fatalf("expected ErrTabCrashed got %v\n", err)
+	}
+
+	if !tab.IsCrashed() {
+		t.Fatalf("expected tab to be flagged as crashed")
+	}
+
+	act := &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://example.com")}
+	if _, _, err := tab.ExecuteAction(ctx, act); errors.Cause(err) != browser.ErrTabCrashed {
+		t.Fatalf("expected crashed tab to refuse actions got %v\n", err)
+	}
+
+	// returning the browser replaces it with a new one
+	tab.Close()
+	pool.Return(ctx, port)
+	replacement, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking replacement browser: %s\n", err)
+	}
+
+	if err := replacement.Navigate(ctx, "http://example.com"); err != nil {
+		t.Fatalf("replacement browser failed to navigate: %s\n", err)
+	}
+}
+
+func TestMaxBodySizeSpills(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	large := strings.Repeat("A", 2*1024*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>" + large + "</body></html>"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "browserk_bodies")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+	tab.SetMaxBodySize(1024, dir)
+
+	if err := b.Navigate(ctx, srv.URL); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	msgs, _ := b.GetMessages()
+	found := false
+	for _, m := range msgs {
+		if m.Response == nil || m.Response.Response.Url != srv.URL+"/" {
+			continue
+		}
+		found = true
+		if m.Response.Body != nil || m.Response.BodyFile == "" {
+			t.Fatalf("expected large body to be spilled to disk")
+		}
+
+		body, err := m.Response.ReadBody()
+		if err != nil || !strings.Contains(string(body), large) {
+			t.Fatalf("expected spilled body to be readable: %v", err)
+		}
+	}
+
+	if !found {
+		t.Fatalf("did not capture response")
+	}
+}
+
+func TestTabCaptureDOMSnapshot(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := tab.Navigate(ctx, "http://localhost:"+p+"/snapshot.html"); err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	snapshot, err := tab.CaptureDOMSnapshot()
+	if err != nil {
+		t.Fatalf("error capturing snapshot: %s\n", err)
+	}
+
+	if len(snapshot.Documents) < 2 {
+		t.Fatalf("expected top document and iframe document, got %d", len(snapshot.Documents))
+	}
+
+	found := make(map[string]bool)
+	for _, doc := range snapshot.Documents {
+		if doc.Nodes == nil {
+			continue
+		}
+		for _, idx := range doc.Nodes.NodeValue {
+			found[strings.TrimSpace(snapshot.String(idx))] = true
+		}
+	}
+
+	for _, expected := range []string{"snapshot visible text", "snapshot hidden text", "snapshot framed text"} {
+		if !found[expected] {
+			t.Fatalf("expected snapshot to contain %q", expected)
+		}
+	}
+
+	if snapshot.Documents[0].Layout == nil || len(snapshot.Documents[0].Layout.Styles) == 0 {
+		t.Fatalf("expected layout with computed styles")
+	}
+}
+
+func TestTabEvaluateOnFrame(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := tab.Navigate(ctx, "http://localhost:"+p+"/frame_eval.html"); err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	eles, err := tab.GetElementsBySelector("#evalframe")
+	if err != nil || len(eles) != 1 {
+		t.Fatalf("error getting iframe: %s\n", err)
+	}
+
+	node, err := eles[0].GetDebuggerDOMNode()
+	if err != nil || node.FrameId == "" {
+		t.Fatalf("error getting iframe's frame id: %s\n", err)
+	}
+
+	main, err := tab.EvaluateScript("typeof frameSecret")
+	if err != nil {
+		t.Fatalf("error evaluating in main frame: %s\n", err)
+	}
+	if main.Value != "undefined" {
+		t.Fatalf("expected frameSecret to be undefined in the main frame got %v", main.Value)
+	}
+
+	framed, err := tab.EvaluateOnFrame(node.FrameId, "frameSecret")
+	if err != nil {
+		t.Fatalf("error evaluating in iframe: %s\n", err)
+	}
+	if framed.Value != "inner" {
+		t.Fatalf("expected frameSecret from the iframe got %v", framed.Value)
+	}
+
+	if _, err := tab.EvaluateOnFrame("unknown", "1"); err == nil {
+		t.Fatalf("expected error evaluating in an unknown frame")
+	}
+}
+
+func TestTabExecutionContexts(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := tab.Navigate(ctx, "http://localhost:"+p+"/worker.html"); err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	// workers are attached asynchronously
+	var contexts []browserk.ExecutionContext
+	for i := 0; i < 50; i++ {
+		contexts = tab.ExecutionContexts()
+		if hasContextType(contexts, "worker") {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if !hasContextType(contexts, "page") {
+		t.Fatalf("expected the page's execution context got %#v", contexts)
+	}
+
+	if !hasContextType(contexts, "worker") {
+		t.Fatalf("expected the worker's execution context got %#v", contexts)
+	}
+}
+
+func hasContextType(contexts []browserk.ExecutionContext, contextType string) bool {
+	for _, c := range contexts {
+		if c.Type == contextType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTabWorkersServiceWorker(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := tab.Navigate(ctx, "http://localhost:"+p+"/service_worker.html"); err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	// service workers are registered and attached asynchronously
+	var worker *browserk.Worker
+	for i := 0; i < 50 && worker == nil; i++ {
+		for _, w := range tab.Workers() {
+			if w.Type == "service_worker" && hasWorkerScript(w, "sw-secret-token") {
+				worker = w
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if worker == nil {
+		t.Fatalf("expected service worker script to be captured got %#v", tab.Workers())
+	}
+
+	if !strings.HasSuffix(worker.URL, "/service_worker.js") {
+		t.Fatalf("expected service worker url got %s", worker.URL)
+	}
+}
+
+func hasWorkerScript(worker *browserk.Worker, contains string) bool {
+	for _, script := range worker.Scripts {
+		if strings.Contains(script.Source, contains) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResolveElementRetriesRemovedNode(t *testing.T) {
+	attempts := 0
+	expected := browser.NewTestElement(&gcdapi.DOMNode{NodeId: 5, NodeName: "BUTTON"})
+	ele, err := browser.ResolveElement(2, func() (*browser.Element, error) {
+		attempts++
+		if attempts == 1 {
+			// the node was removed by an animation between being queried and resolved
+			return nil, browser.NodeError(errors.New("Could not find node with given id"))
+		}
+		return expected, nil
+	})
+	if err != nil || ele != expected || attempts != 2 {
+		t.Fatalf("expected element on the second attempt got %v %v after %d attempts\n", ele, err, attempts)
+	}
+
+	attempts = 0
+	_, err = browser.ResolveElement(2, func() (*browser.Element, error) {
+		attempts++
+		return nil, &browser.ErrInvalidElement{}
+	})
+	if _, ok := err.(*browser.ErrElementNotFound); !ok || attempts != 3 {
+		t.Fatalf("expected ErrElementNotFound after 3 attempts got %v after %d\n", err, attempts)
+	}
+
+	attempts = 0
+	_, err = browser.ResolveElement(2, func() (*browser.Element, error) {
+		attempts++
+		return nil, &browser.ErrElementNotFound{Message: "no element matching #missing"}
+	})
+	if err == nil || attempts != 1 {
+		t.Fatalf("expected missing elements not to be retried got %v after %d attempts\n", err, attempts)
+	}
+}
+
+func TestTabStubResponse(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	// the endpoint doesn't exist in testdata, only the stub can answer it
+	tab.StubResponse("*/api/user.json", 200, map[string]string{"Content-Type": "application/json"}, []byte(`{"name":"stubbed"}`))
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/stub.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if _, err := tab.EvaluateScript("load()"); err != nil {
+		t.Fatalf("error calling load: %s\n", err)
+	}
+
+	name := ""
+	deadline := time.Now().Add(5 * time.Second)
+	for name == "" && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		if ele, err := tab.QuerySelector("#result"); err == nil {
+			name = ele.GetAttribute("data-name")
+		}
+	}
+
+	if name != "stubbed" {
+		t.Fatalf("expected the page to receive the stubbed body got %q\n", name)
+	}
+}
+
+func TestTabProbeForm(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	target := fmt.Sprintf("http://localhost:%s/probe_form.html", p)
+	if err := b.Navigate(ctx, target); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	forms, err := b.FindForms()
+	if err != nil || len(forms) != 1 {
+		t.Fatalf("expected 1 form got %d (%v)\n", len(forms), err)
+	}
+
+	for _, child := range forms[0].ChildElements {
+		if child.GetAttribute("name") == "email" {
+			child.Value = "test@example.com"
+		}
+	}
+
+	discovered, err := b.ProbeForm(ctx, forms[0])
+	if err != nil {
+		t.Fatalf("error probing form: %s\n", err)
+	}
+
+	if len(discovered) != 1 || discovered[0].GetAttribute("name") != "confirm_email" {
+		t.Fatalf("expected confirm_email field to be discovered got %#v\n", discovered)
+	}
+
+	value, err := tab.EvaluateScript(`document.getElementById("email").value`)
+	if err != nil {
+		t.Fatalf("error getting email value: %s\n", err)
+	}
+
+	if value.Value != "" {
+		t.Fatalf("expected form to be reset got %v\n", value.Value)
+	}
+
+	current, err := b.GetURL()
+	if err != nil {
+		t.Fatalf("error getting url: %s\n", err)
+	}
+
+	if current != target {
+		t.Fatalf("expected probing not to navigate got %s\n", current)
+	}
+}
+
+func TestTabEvaluateJSON(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/rect.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	state := struct {
+		Title string   `json:"title"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+		User  struct {
+			Admin bool `json:"admin"`
+		} `json:"user"`
+	}{}
+
+	expr := `({title: document.title, count: 2, tags: ["a", "b"], user: {admin: true}})`
+	if err := tab.EvaluateJSON(expr, &state); err != nil {
+		t.Fatalf("error evaluating expression: %s\n", err)
+	}
+
+	if state.Title == "" || state.Count != 2 || len(state.Tags) != 2 || state.Tags[1] != "b" || !state.User.Admin {
+		t.Fatalf("expected expression to be unmarshaled got %#v\n", state)
+	}
+
+	if _, ok := tab.EvaluateJSON("notDefined.x", &state).(*browser.ErrScriptEvaluation); !ok {
+		t.Fatalf("expected exception to be returned as ErrScriptEvaluation\n")
+	}
+}
+
+// unresponsiveTarget accepts requests but never replies, like a hung renderer
+type unresponsiveTarget struct {
+	sendCh chan *gcdmessage.Message
+	doneCh chan struct{}
+}
+
+func (u *unresponsiveTarget) GetId() int64                        { return 1 }
+func (u *unresponsiveTarget) GetApiTimeout() time.Duration        { return 30 * time.Second }
+func (u *unresponsiveTarget) GetSendCh() chan *gcdmessage.Message { return u.sendCh }
+func (u *unresponsiveTarget) GetDoneCh() chan struct{}            { return u.doneCh }
+
+func TestTabIsAliveUnresponsive(t *testing.T) {
+	target := &unresponsiveTarget{
+		sendCh: make(chan *gcdmessage.Message, 1),
+		doneCh: make(chan struct{}),
+	}
+	defer close(target.doneCh)
+
+	start := time.Now()
+	if browser.RuntimeAlive(gcdapi.NewRuntime(target), 100*time.Millisecond) {
+		t.Fatalf("expected unresponsive target to not be alive")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected IsAlive to give up after its timeout, took %s", elapsed)
+	}
+}
+
+func TestTabStopLoading(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	// /hang never finishes, holding the page's load event
+	release := make(chan struct{})
+	defer close(release)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hang", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	mux.Handle("/", http.FileServer(http.Dir("testdata/")))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+	tab.SetStopLoadingTimeout(2 * time.Second)
+
+	start := time.Now()
+	if err := b.Navigate(ctx, srv.URL+"/never_loads.html"); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 15*time.Second {
+		t.Fatalf("expected navigation to stop loading after 2s, took %s", elapsed)
+	}
+
+	links, err := b.FindElements("#link")
+	if err != nil || len(links) != 1 {
+		t.Fatalf("expected to extract link from partially loaded page: %v %s\n", links, err)
+	}
+}
+
+func TestTabGetCookiesForURL(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/path_cookie.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	var tests = []struct {
+		path   string
+		scoped bool
+	}{
+		{"/scoped", true},
+		{"/scoped/page.html", true},
+		{"/other/page.html", false},
+		{"/scopedother", false},
+	}
+
+	for _, tt := range tests {
+		cookies, err := tab.GetCookiesForURL(fmt.Sprintf("http://localhost:%s%s", p, tt.path))
+		if err != nil {
+			t.Fatalf("error getting cookies for %s: %s\n", tt.path, err)
+		}
+
+		names := make(map[string]struct{}, len(cookies))
+		for _, cookie := range cookies {
+			names[cookie.Name] = struct{}{}
+		}
+
+		if _, ok := names["everywhere"]; !ok {
+			t.Fatalf("expected root path cookie for %s got %v", tt.path, names)
+		}
+
+		if _, ok := names["scoped"]; ok != tt.scoped {
+			t.Fatalf("expected scoped cookie %v for %s got %v", tt.scoped, tt.path, names)
+		}
+	}
+}
+
+func TestTabNetworkSummary(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+	tab.NetworkSummary()
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/network_summary.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	var bodyBytes int64
+	for _, name := range []string{"network_summary.html", "network_summary.css", "network_summary.js"} {
+		info, err := os.Stat(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("error reading fixture %s: %s\n", name, err)
+		}
+		bodyBytes += info.Size()
+	}
+
+	summary := tab.NetworkSummary()
+	if summary.RequestCount != 3 {
+		t.Fatalf("expected 3 requests got %d", summary.RequestCount)
+	}
+
+	// headers are included in the bytes received
+	if summary.TotalBytes < bodyBytes {
+		t.Fatalf("expected at least %d bytes got %d", bodyBytes, summary.TotalBytes)
+	}
+
+	if summary.SlowestURL == "" {
+		t.Fatalf("expected slowest request to be set got %+v", summary)
+	}
+
+	if again := tab.NetworkSummary(); again.RequestCount != 0 {
+		t.Fatalf("expected summary to be cleared got %+v", again)
+	}
+}
+
+func TestTabNavigateWithReferrer(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	referrers := make(chan string, 2)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/deeplink", func(w http.ResponseWriter, r *http.Request) {
+		referrers <- r.Header.Get("Referer")
+		w.Write([]byte("<html><body>deep link</body></html>"))
+	})
+	mux.Handle("/", http.FileServer(http.Dir("testdata/")))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	referrer := srv.URL + "/index.html"
+	if err := tab.NavigateWithReferrer(ctx, srv.URL+"/deeplink", referrer); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if got := <-referrers; got != referrer {
+		t.Fatalf("expected referrer %s got %s", referrer, got)
+	}
+
+	// load url actions send the extra referrer once
+	tab.SetExtraNavigationReferrer(referrer)
+	if _, _, err := tab.ExecuteAction(ctx, browserk.NewLoadURLAction(srv.URL+"/deeplink?action")); err != nil {
+		t.Fatalf("error executing action %s\n", err)
+	}
+
+	if got := <-referrers; got != referrer {
+		t.Fatalf("expected load url action to send referrer %s got %s", referrer, got)
+	}
+
+	if err := b.Navigate(ctx, srv.URL+"/deeplink?plain"); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	if got := <-referrers; got != "" {
+		t.Fatalf("expected no referrer after it was used got %s", got)
+	}
+}
+
+func TestTabLeavesBeforeUnloadPage(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	editorURL := fmt.Sprintf("http://localhost:%s/beforeunload.html", p)
+	if err := b.Navigate(ctx, editorURL); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	// beforeunload only prompts once the user has interacted with the page
+	edit, err := tab.QuerySelector("#edit")
+	if err != nil {
+		t.Fatalf("error getting button: %s\n", err)
+	}
+
+	if err := edit.Click(); err != nil {
+		t.Fatalf("error clicking button: %s\n", err)
+	}
+
+	navCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	indexURL := fmt.Sprintf("http://localhost:%s/index.html", p)
+	if err := b.Navigate(navCtx, indexURL); err != nil {
+		t.Fatalf("error navigating away from page with beforeunload %s\n", err)
+	}
+
+	if current, _ := b.GetURL(); current != indexURL {
+		t.Fatalf("expected to leave page with beforeunload got %s", current)
+	}
+
+	blocked := tab.BlockedUnloads()
+	if len(blocked) != 1 || blocked[0] != editorURL {
+		t.Fatalf("expected page to be recorded as blocking navigation got %v", blocked)
+	}
+
+	if again := tab.BlockedUnloads(); len(again) != 0 {
+		t.Fatalf("expected blocked unloads to be cleared got %v", again)
+	}
+}
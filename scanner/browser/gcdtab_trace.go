@@ -0,0 +1,229 @@
+package browser
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wirepair/gcd"
+	"github.com/wirepair/gcd/gcdapi"
+	"github.com/wirepair/gcd/gcdmessage"
+)
+
+// Protocol trace line types
+const (
+	TraceCommand  = "command"
+	TraceResponse = "response"
+	TraceEvent    = "event"
+)
+
+// TraceLine is written as a line of json for each devtools protocol message of a traced tab
+type TraceLine struct {
+	Time   time.Time       `json:"time"`
+	TabID  int64           `json:"tab_id"`
+	Type   string          `json:"type"`
+	ID     int64           `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// protocolTracer sits between the tab's domains and its chrome target, writing every command
+// sent and the response it gets back once tracing is enabled
+type protocolTracer struct {
+	target *gcd.ChromeTarget
+	tabID  int64
+	sendCh chan *gcdmessage.Message
+	lock   *sync.Mutex
+	w      atomic.Value // traceWriter, tracing is disabled if its writer is nil
+}
+
+// traceWriter wraps the trace's writer so atomic.Value always stores the same concrete type
+type traceWriter struct {
+	w io.Writer
+}
+
+func newProtocolTracer(target *gcd.ChromeTarget, tabID int64) *protocolTracer {
+	p := &protocolTracer{
+		target: target,
+		tabID:  tabID,
+		sendCh: make(chan *gcdmessage.Message),
+		lock:   &sync.Mutex{},
+	}
+	p.w.Store(traceWriter{})
+	go p.listen()
+	return p
+}
+
+// writer trace lines are written to, nil if tracing is disabled
+func (p *protocolTracer) writer() io.Writer {
+	return p.w.Load().(traceWriter).w
+}
+
+// GetId of the chrome target
+func (p *protocolTracer) GetId() int64 {
+	return p.target.GetId()
+}
+
+// GetApiTimeout of the chrome target
+func (p *protocolTracer) GetApiTimeout() time.Duration {
+	return p.target.GetApiTimeout()
+}
+
+// GetSendCh returns our channel so commands pass through the tracer
+func (p *protocolTracer) GetSendCh() chan *gcdmessage.Message {
+	return p.sendCh
+}
+
+// GetDoneCh of the chrome target
+func (p *protocolTracer) GetDoneCh() chan struct{} {
+	return p.target.GetDoneCh()
+}
+
+// listen for commands, tracing and forwarding them to the chrome target until it is closed
+func (p *protocolTracer) listen() {
+	for {
+		select {
+		case msg := <-p.sendCh:
+			p.forward(msg)
+		case <-p.target.GetDoneCh():
+			return
+		}
+	}
+}
+
+// forward a command to the chrome target, tracing its response before handing it back
+func (p *protocolTracer) forward(msg *gcdmessage.Message) {
+	if p.writer() == nil {
+		select {
+		case p.target.GetSendCh() <- msg:
+		case <-p.target.GetDoneCh():
+		}
+		return
+	}
+
+	request := &gcdmessage.ChromeRequest{}
+	json.Unmarshal(msg.Data, request)
+	p.write(&TraceLine{Type: TraceCommand, ID: msg.Id, Method: request.Method, Data: msg.Data})
+
+	replyCh := msg.ReplyCh
+	tracedCh := make(chan *gcdmessage.Message, 1)
+	msg.ReplyCh = tracedCh
+	go func() {
+		select {
+		case resp := <-tracedCh:
+			p.write(&TraceLine{Type: TraceResponse, ID: msg.Id, Method: request.Method, Data: resp.Data})
+			replyCh <- resp // buffered by the sender
+		case <-p.target.GetDoneCh():
+		}
+	}()
+
+	select {
+	case p.target.GetSendCh() <- msg:
+	case <-p.target.GetDoneCh():
+	}
+}
+
+// write a trace line, lines of concurrent commands and events are never interleaved
+func (p *protocolTracer) write(line *TraceLine) {
+	w := p.writer()
+	if w == nil {
+		return
+	}
+
+	line.Time = time.Now()
+	line.TabID = p.tabID
+	if len(line.Data) > 0 && !json.Valid(line.Data) {
+		line.Data = nil
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	p.lock.Lock()
+	w.Write(append(data, '\n'))
+	p.lock.Unlock()
+}
+
+// EnableProtocolTrace writes each devtools protocol command the tab sends, its response and each
+// event the tab receives to w as a line of json (see TraceLine). A nil w disables tracing.
+func (t *Tab) EnableProtocolTrace(w io.Writer) {
+	t.tracer.w.Store(traceWriter{w: w})
+}
+
+// targeter commands should be sent through so they are traced
+func (t *Tab) targeter() gcdmessage.ChromeTargeter {
+	return t.tracer
+}
+
+// subscribe to a devtools protocol event, tracing it before it is handled
+func (t *Tab) subscribe(method string, callback func(*gcd.ChromeTarget, []byte)) {
+	t.t.Subscribe(method, func(target *gcd.ChromeTarget, payload []byte) {
+		t.tracer.write(&TraceLine{Type: TraceEvent, Method: method, Data: payload})
+		callback(target, payload)
+	})
+}
+
+// bindDomains of the chrome target to send their commands through target, must be called before
+// the domains are used by any other goroutine
+func bindDomains(c *gcd.ChromeTarget, target gcdmessage.ChromeTargeter) {
+	c.Accessibility = gcdapi.NewAccessibility(target)
+	c.Animation = gcdapi.NewAnimation(target)
+	c.ApplicationCache = gcdapi.NewApplicationCache(target)
+	c.Audits = gcdapi.NewAudits(target)
+	c.Browser = gcdapi.NewBrowser(target)
+	c.BackgroundService = gcdapi.NewBackgroundService(target)
+	c.CacheStorage = gcdapi.NewCacheStorage(target)
+	c.Cast = gcdapi.NewCast(target)
+	c.Console = gcdapi.NewConsole(target)
+	c.CSS = gcdapi.NewCSS(target)
+	c.Database = gcdapi.NewDatabase(target)
+	c.Debugger = gcdapi.NewDebugger(target)
+	c.DeviceOrientation = gcdapi.NewDeviceOrientation(target)
+	c.DOMDebugger = gcdapi.NewDOMDebugger(target)
+	c.DOM = gcdapi.NewDOM(target)
+	c.DOMSnapshot = gcdapi.NewDOMSnapshot(target)
+	c.DOMStorage = gcdapi.NewDOMStorage(target)
+	c.Emulation = gcdapi.NewEmulation(target)
+	c.HeapProfiler = gcdapi.NewHeapProfiler(target)
+	c.IndexedDB = gcdapi.NewIndexedDB(target)
+	c.Input = gcdapi.NewInput(target)
+	c.Inspector = gcdapi.NewInspector(target)
+	c.IO = gcdapi.NewIO(target)
+	c.LayerTree = gcdapi.NewLayerTree(target)
+	c.Memory = gcdapi.NewMemory(target)
+	c.Log = gcdapi.NewLog(target)
+	c.Network = gcdapi.NewNetwork(target)
+	c.Overlay = gcdapi.NewOverlay(target)
+	c.Page = gcdapi.NewPage(target)
+	c.Profiler = gcdapi.NewProfiler(target)
+	c.Runtime = gcdapi.NewRuntime(target)
+	c.Schema = gcdapi.NewSchema(target)
+	c.Security = gcdapi.NewSecurity(target)
+	c.SystemInfo = gcdapi.NewSystemInfo(target)
+	c.ServiceWorker = gcdapi.NewServiceWorker(target)
+	c.Storage = gcdapi.NewStorage(target)
+	c.TargetApi = gcdapi.NewTarget(target)
+	c.Tracing = gcdapi.NewTracing(target)
+	c.Tethering = gcdapi.NewTethering(target)
+	c.HeadlessExperimental = gcdapi.NewHeadlessExperimental(target)
+	c.Performance = gcdapi.NewPerformance(target)
+	c.Fetch = gcdapi.NewFetch(target)
+	c.Media = gcdapi.NewMedia(target)
+	c.WebAudio = gcdapi.NewWebAudio(target)
+	c.WebAuthn = gcdapi.NewWebAuthn(target)
+}
+
+// lockedWriter serializes writes of tabs sharing a protocol trace
+type lockedWriter struct {
+	lock *sync.Mutex
+	w    io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,131 @@
+package browser_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wirepair/gcd"
+	"github.com/wirepair/gcd/gcdmessage"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/browser"
+	"golang.org/x/net/context"
+)
+
+// traceBuffer is read while the tab may still be writing to it
+type traceBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *traceBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *traceBuffer) Lines(t *testing.T) []*browser.TraceLine {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	lines := make([]*browser.TraceLine, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := &browser.TraceLine{}
+		if err := json.Unmarshal(scanner.Bytes(), line); err != nil {
+			t.Fatalf("trace line is not json: %s %s\n", err, scanner.Text())
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestTabEnableProtocolTrace(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	p, srv := testServer()
+	defer srv.Shutdown(ctx)
+
+	b, _, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	tab := b.(*browser.Tab)
+
+	trace := &traceBuffer{}
+	tab.EnableProtocolTrace(trace)
+
+	if err := b.Navigate(ctx, fmt.Sprintf("http://localhost:%s/index.html", p)); err != nil {
+		t.Fatalf("error navigating %s\n", err)
+	}
+
+	commands := make(map[int64]string)
+	responses := make(map[int64]string)
+	events := make(map[string]bool)
+	for _, line := range trace.Lines(t) {
+		switch line.Type {
+		case browser.TraceCommand:
+			commands[line.ID] = line.Method
+		case browser.TraceResponse:
+			responses[line.ID] = line.Method
+		case browser.TraceEvent:
+			events[line.Method] = true
+		}
+	}
+
+	navigateID := int64(-1)
+	for id, method := range commands {
+		if method == "Page.navigate" {
+			navigateID = id
+		}
+	}
+	if navigateID == -1 {
+		t.Fatalf("expected Page.navigate command in trace, got %v\n", commands)
+	}
+
+	if responses[navigateID] != "Page.navigate" {
+		t.Fatalf("expected response to Page.navigate command %d in trace, got %v\n", navigateID, responses)
+	}
+
+	for _, method := range []string{"Page.frameNavigated", "Page.loadEventFired", "Network.requestWillBeSent"} {
+		if !events[method] {
+			t.Fatalf("expected %s event in trace, got %v\n", method, events)
+		}
+	}
+}
+
+// unusedTargeter is only bound to domains, commands are never sent through it
+type unusedTargeter struct{}
+
+func (unusedTargeter) GetId() int64                        { return 0 }
+func (unusedTargeter) GetApiTimeout() time.Duration        { return time.Second }
+func (unusedTargeter) GetSendCh() chan *gcdmessage.Message { return nil }
+func (unusedTargeter) GetDoneCh() chan struct{}            { return nil }
+
+func TestBindDomainsComplete(t *testing.T) {
+	target := &gcd.ChromeTarget{}
+	browser.BindDomains(target, unusedTargeter{})
+
+	v := reflect.ValueOf(target).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() != reflect.Ptr || field.Type.Elem().PkgPath() != "github.com/wirepair/gcd/gcdapi" {
+			continue
+		}
+
+		if v.Field(i).IsNil() {
+			t.Fatalf("expected the %s domain to be bound so its commands are traced", field.Name)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package browser
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+)
+
+// workerMessage sent to or received from a worker session, in non-flat mode messages are
+// wrapped in Target.sendMessageToTarget and Target.receivedMessageFromTarget
+type workerMessage struct {
+	ID     int64           `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// workerCapture of a worker's scripts and requests, kept after the worker is detached
+type workerCapture struct {
+	worker    *browserk.Worker
+	requests  map[string]*browserk.HTTPMessage                // requests waiting to finish loading, by request id
+	responses map[string]*gcdapi.NetworkResponseReceivedEvent // responses waiting for their body, by request id
+}
+
+// isWorkerType returns true for target types which run scripts off the page
+func isWorkerType(targetType string) bool {
+	switch targetType {
+	case "worker", "shared_worker", "service_worker":
+		return true
+	}
+	return false
+}
+
+// Workers started by the page with their captured scripts and requests, ordered by id
+func (t *Tab) Workers() []*browserk.Worker {
+	t.workerMutex.RLock()
+	workers := make([]*browserk.Worker, 0, len(t.captures))
+	for _, capture := range t.captures {
+		worker := *capture.worker
+		worker.Scripts = append([]*browserk.WorkerScript(nil), capture.worker.Scripts...)
+		worker.Messages = append([]*browserk.HTTPMessage(nil), capture.worker.Messages...)
+		workers = append(workers, &worker)
+	}
+	t.workerMutex.RUnlock()
+
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].ID < workers[j].ID
+	})
+	return workers
+}
+
+// attachWorker tracks the worker session and enables the domains we listen to
+func (t *Tab) attachWorker(sessionID string, info *gcdapi.TargetTargetInfo) {
+	t.workerMutex.Lock()
+	t.workers[sessionID] = info
+	if _, exists := t.captures[info.TargetId]; !exists {
+		t.captures[info.TargetId] = &workerCapture{
+			worker:    &browserk.Worker{ID: info.TargetId, Type: info.Type, URL: info.Url},
+			requests:  make(map[string]*browserk.HTTPMessage),
+			responses: make(map[string]*gcdapi.NetworkResponseReceivedEvent),
+		}
+	}
+	t.workerMutex.Unlock()
+
+	for _, method := range []string{"Runtime.enable", "Debugger.enable", "Network.enable"} {
+		if err := t.sendToWorker(sessionID, method, nil); err != nil {
+			t.ctx.Log.Warn().Err(err).Str("url", info.Url).Str("method", method).Msg("failed to enable domain for worker")
+		}
+	}
+}
+
+// detachWorker removes the worker session and its execution contexts, its capture is kept
+func (t *Tab) detachWorker(sessionID string) {
+	t.workerMutex.Lock()
+	delete(t.workers, sessionID)
+	t.workerMutex.Unlock()
+	t.clearExecutionContexts(sessionID)
+}
+
+// worker for the session, nil if not attached
+func (t *Tab) worker(sessionID string) *gcdapi.TargetTargetInfo {
+	t.workerMutex.RLock()
+	defer t.workerMutex.RUnlock()
+	return t.workers[sessionID]
+}
+
+// sendToWorker sends the method to the worker session without waiting for a response
+func (t *Tab) sendToWorker(sessionID, method string, params interface{}) error {
+	id := atomic.AddInt64(&t.workerMessageID, 1)
+	return t.writeWorkerMessage(sessionID, &workerMessage{ID: id, Method: method}, params)
+}
+
+// callWorker sends the method to the worker session and decodes its result into result
+func (t *Tab) callWorker(sessionID, method string, params, result interface{}) error {
+	id := atomic.AddInt64(&t.workerMessageID, 1)
+	responseCh := make(chan *workerMessage, 1)
+
+	t.workerMutex.Lock()
+	t.workerCalls[id] = responseCh
+	t.workerMutex.Unlock()
+
+	defer func() {
+		t.workerMutex.Lock()
+		delete(t.workerCalls, id)
+		t.workerMutex.Unlock()
+	}()
+
+	if err := t.writeWorkerMessage(sessionID, &workerMessage{ID: id, Method: method}, params); err != nil {
+		return err
+	}
+
+	timeout := time.NewTimer(t.elementTimeout)
+	defer timeout.Stop()
+
+	select {
+	case response := <-responseCh:
+		if response.Error != nil {
+			return errors.New(response.Error.Message)
+		}
+		return json.Unmarshal(response.Result, result)
+	case <-timeout.C:
+		return ErrTimedOut
+	case <-t.exitCh:
+		return ErrTabClosing
+	}
+}
+
+// writeWorkerMessage encodes params into msg and sends it to the worker session
+func (t *Tab) writeWorkerMessage(sessionID string, msg *workerMessage, params interface{}) error {
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = encoded
+	}
+
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = t.t.TargetApi.SendMessageToTarget(string(encoded), sessionID, "")
+	return err
+}
+
+// handleWorkerMessage dispatches responses and events received from a worker session
+func (t *Tab) handleWorkerMessage(sessionID, message string) {
+	worker := t.worker(sessionID)
+	if worker == nil {
+		return
+	}
+
+	msg := &workerMessage{}
+	if err := json.Unmarshal([]byte(message), msg); err != nil {
+		return
+	}
+
+	if msg.Method == "" {
+		t.workerMutex.RLock()
+		responseCh, ok := t.workerCalls[msg.ID]
+		t.workerMutex.RUnlock()
+		if ok {
+			responseCh <- msg
+		}
+		return
+	}
+
+	switch msg.Method {
+	case "Runtime.executionContextCreated":
+		params := &struct {
+			Context *gcdapi.RuntimeExecutionContextDescription `json:"context"`
+		}{}
+		if err := json.Unmarshal(msg.Params, params); err == nil && params.Context != nil {
+			t.addExecutionContext(sessionID, worker, params.Context)
+		}
+	case "Runtime.executionContextDestroyed":
+		params := &struct {
+			ExecutionContextID int `json:"executionContextId"`
+		}{}
+		if err := json.Unmarshal(msg.Params, params); err == nil {
+			t.removeExecutionContext(sessionID, params.ExecutionContextID)
+		}
+	case "Runtime.executionContextsCleared":
+		t.clearExecutionContexts(sessionID)
+	case "Debugger.scriptParsed":
+		t.workerScriptParsed(sessionID, worker, []byte(message))
+	case "Network.requestWillBeSent":
+		t.workerRequest(worker, []byte(message))
+	case "Network.responseReceived":
+		t.workerResponse(worker, []byte(message))
+	case "Network.loadingFinished":
+		t.workerLoadingFinished(sessionID, worker, []byte(message))
+	case "Network.loadingFailed":
+		t.workerLoadingFailed(worker, []byte(message))
+	}
+}
+
+// workerScriptParsed gets the source of the parsed script and adds it to the worker's capture
+func (t *Tab) workerScriptParsed(sessionID string, worker *gcdapi.TargetTargetInfo, message []byte) {
+	event := &gcdapi.DebuggerScriptParsedEvent{}
+	if err := json.Unmarshal(message, event); err != nil {
+		return
+	}
+
+	result := &struct {
+		ScriptSource string `json:"scriptSource"`
+	}{}
+	params := map[string]string{"scriptId": event.Params.ScriptId}
+	if err := t.callWorker(sessionID, "Debugger.getScriptSource", params, result); err != nil {
+		t.ctx.Log.Warn().Err(err).Str("url", event.Params.Url).Msg("failed to get worker script source")
+		return
+	}
+
+	t.workerMutex.Lock()
+	defer t.workerMutex.Unlock()
+	if capture, ok := t.captures[worker.TargetId]; ok {
+		capture.worker.Scripts = append(capture.worker.Scripts, &browserk.WorkerScript{
+			ScriptID: event.Params.ScriptId,
+			URL:      event.Params.Url,
+			Source:   result.ScriptSource,
+		})
+	}
+}
+
+// workerRequest starts a message for the request, it's completed once the response is received
+func (t *Tab) workerRequest(worker *gcdapi.TargetTargetInfo, message []byte) {
+	event := &gcdapi.NetworkRequestWillBeSentEvent{}
+	if err := json.Unmarshal(message, event); err != nil {
+		return
+	}
+
+	t.workerMutex.Lock()
+	defer t.workerMutex.Unlock()
+	if capture, ok := t.captures[worker.TargetId]; ok {
+		capture.requests[event.Params.RequestId] = &browserk.HTTPMessage{
+			RequestTime: time.Now(),
+			Request:     GCDRequestToBrowserk(event),
+		}
+	}
+}
+
+// workerResponse is held until loading finishes and its body is available
+func (t *Tab) workerResponse(worker *gcdapi.TargetTargetInfo, message []byte) {
+	event := &gcdapi.NetworkResponseReceivedEvent{}
+	if err := json.Unmarshal(message, event); err != nil {
+		return
+	}
+
+	t.workerMutex.Lock()
+	defer t.workerMutex.Unlock()
+	if capture, ok := t.captures[worker.TargetId]; ok {
+		capture.responses[event.Params.RequestId] = event
+	}
+}
+
+// workerLoadingFinished gets the body and adds the completed message to the worker's capture
+func (t *Tab) workerLoadingFinished(sessionID string, worker *gcdapi.TargetTargetInfo, message []byte) {
+	event := &gcdapi.NetworkLoadingFinishedEvent{}
+	if err := json.Unmarshal(message, event); err != nil {
+		return
+	}
+	requestID := event.Params.RequestId
+
+	result := &struct {
+		Body          string `json:"body"`
+		Base64Encoded bool   `json:"base64Encoded"`
+	}{}
+	params := map[string]string{"requestId": requestID}
+	if err := t.callWorker(sessionID, "Network.getResponseBody", params, result); err != nil {
+		t.ctx.Log.Debug().Err(err).Str("request_id", requestID).Msg("failed to get worker response body")
+	}
+
+	body := []byte(result.Body)
+	if result.Base64Encoded {
+		body, _ = base64.StdEncoding.DecodeString(result.Body)
+	}
+
+	t.workerMutex.Lock()
+	defer t.workerMutex.Unlock()
+	capture, ok := t.captures[worker.TargetId]
+	if !ok {
+		return
+	}
+
+	msg, ok := capture.requests[requestID]
+	if !ok {
+		return
+	}
+	delete(capture.requests, requestID)
+
+	if response, ok := capture.responses[requestID]; ok {
+		delete(capture.responses, requestID)
+		msg.ResponseTime = time.Now()
+		msg.Response = GCDResponseToBrowserk(response, body)
+	}
+	capture.worker.Messages = append(capture.worker.Messages, msg)
+}
+
+// workerLoadingFailed adds the request without a response to the worker's capture
+func (t *Tab) workerLoadingFailed(worker *gcdapi.TargetTargetInfo, message []byte) {
+	event := &gcdapi.NetworkLoadingFailedEvent{}
+	if err := json.Unmarshal(message, event); err != nil {
+		return
+	}
+
+	t.workerMutex.Lock()
+	defer t.workerMutex.Unlock()
+	capture, ok := t.captures[worker.TargetId]
+	if !ok {
+		return
+	}
+
+	if msg, ok := capture.requests[event.Params.RequestId]; ok {
+		delete(capture.requests, event.Params.RequestId)
+		delete(capture.responses, event.Params.RequestId)
+		capture.worker.Messages = append(capture.worker.Messages, msg)
+	}
+}
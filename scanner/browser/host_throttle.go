@@ -0,0 +1,95 @@
+package browser
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HostThrottle limits the rate of requests sent to each host (and port), it is shared by all
+// tabs of a pool so one host is never flooded while requests to other hosts proceed in parallel
+type HostThrottle struct {
+	rate    float64 // requests allowed per second per host
+	lock    *sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+// tokenBucket of a host, tokens go negative as requests queue up waiting for their turn
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewHostThrottle allowing rps requests per second to each host, returns nil if rps is not
+// positive (unlimited)
+func NewHostThrottle(rps float64) *HostThrottle {
+	if rps <= 0 {
+		return nil
+	}
+	return &HostThrottle{
+		rate:    rps,
+		lock:    &sync.Mutex{},
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// SetClock overrides the time source (for testing)
+func (h *HostThrottle) SetClock(now func() time.Time) {
+	h.now = now
+}
+
+// reserve a request to host, returning how long to wait before sending it
+func (h *HostThrottle) reserve(host string) time.Duration {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	now := h.now()
+	bucket, ok := h.buckets[host]
+	if !ok {
+		bucket = &tokenBucket{tokens: 1, last: now}
+		h.buckets[host] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * h.rate
+	if bucket.tokens > 1 {
+		bucket.tokens = 1
+	}
+	bucket.last = now
+	bucket.tokens--
+
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / h.rate * float64(time.Second))
+}
+
+// Wait until a request to the host of requestURL is allowed, or ctx is done. A nil throttle
+// never waits.
+func (h *HostThrottle) Wait(ctx context.Context, requestURL string) error {
+	if h == nil {
+		return nil
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	wait := h.reserve(u.Host)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,87 @@
+package browser_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/scanner/browser"
+)
+
+func TestHostThrottle(t *testing.T) {
+	if browser.NewHostThrottle(0) != nil {
+		t.Fatalf("expected no throttle when unlimited")
+	}
+
+	var unlimited *browser.HostThrottle
+	if err := unlimited.Wait(context.Background(), "http://example.com/"); err != nil {
+		t.Fatalf("expected nil throttle to never wait: %s", err)
+	}
+
+	throttle := browser.NewHostThrottle(20)
+	origins := []string{"http://localhost:8080/", "http://localhost:8081/"}
+	sent := make(map[string][]time.Duration)
+	lock := &sync.Mutex{}
+
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	for _, origin := range origins {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(origin string) {
+				defer wg.Done()
+				if err := throttle.Wait(context.Background(), origin+"page"); err != nil {
+					t.Errorf("error waiting: %s", err)
+				}
+				lock.Lock()
+				sent[origin] = append(sent[origin], time.Since(start))
+				lock.Unlock()
+			}(origin)
+		}
+	}
+	wg.Wait()
+
+	// 5 requests at 20 per second take 200ms for each origin, not 450ms for both
+	for _, origin := range origins {
+		last := sent[origin][len(sent[origin])-1]
+		if last < 180*time.Millisecond {
+			t.Fatalf("expected requests to %s to be throttled, last sent after %s", origin, last)
+		}
+		if last > 400*time.Millisecond {
+			t.Fatalf("expected %s to be throttled independently of other origins, last sent after %s", origin, last)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	throttle.Wait(ctx, origins[0])
+	if err := throttle.Wait(ctx, origins[0]); err == nil {
+		t.Fatalf("expected waiting to stop when the context is done")
+	}
+}
+
+func TestHostThrottleRefills(t *testing.T) {
+	now := time.Now()
+	throttle := browser.NewHostThrottle(2)
+	throttle.SetClock(func() time.Time { return now })
+
+	if wait := browser.ReserveHost(throttle, "example.com"); wait != 0 {
+		t.Fatalf("expected first request to be sent immediately got %s", wait)
+	}
+
+	if wait := browser.ReserveHost(throttle, "example.com"); wait != 500*time.Millisecond {
+		t.Fatalf("expected second request to wait 500ms got %s", wait)
+	}
+
+	if wait := browser.ReserveHost(throttle, "other.example.com"); wait != 0 {
+		t.Fatalf("expected other hosts to be unaffected got %s", wait)
+	}
+
+	// idle hosts don't bank more than a single request
+	now = now.Add(10 * time.Second)
+	browser.ReserveHost(throttle, "example.com")
+	if wait := browser.ReserveHost(throttle, "example.com"); wait != 500*time.Millisecond {
+		t.Fatalf("expected idle host to not burst got %s", wait)
+	}
+}
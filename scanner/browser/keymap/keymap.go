@@ -2,6 +2,7 @@ package keymap
 
 import (
 	"runtime"
+	"strings"
 	"unicode"
 
 	"github.com/wirepair/gcd/gcdapi"
@@ -101,6 +102,11 @@ func KeyEncodeUnidentified(r rune) []*gcdapi.InputDispatchKeyEventParams {
 	return []*gcdapi.InputDispatchKeyEventParams{&keyDown, &keyUp}
 }
 
+// NormalizeNewlines replaces \r\n line endings with \n so each line break is a single Enter
+func NormalizeNewlines(s string) string {
+	return strings.Replace(s, "\r\n", "\n", -1)
+}
+
 // KeyEncode encodes a keyDown, char, and keyUp sequence for the specified rune.
 func KeyEncode(r rune) []*gcdapi.InputDispatchKeyEventParams {
 	// force \n -> \r
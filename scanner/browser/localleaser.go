@@ -1,6 +1,8 @@
 package browser
 
 import (
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"time"
@@ -17,6 +19,16 @@ type LocalLeaser struct {
 	browserTimeout time.Duration
 	tmp            string
 	chromeLocation string
+	flags          []string // additional flags browsers are started with
+	userDataDir    string   // persistent profiles are kept under this directory, empty for throw away profiles
+	profiles       map[string]*persistentProfile
+}
+
+// persistentProfile a browser was started with, browsers are numbered so each concurrent
+// browser keeps using the same profile across runs
+type persistentProfile struct {
+	slot   int
+	exited chan struct{}
 }
 
 // NewLocalLeaser for browsers
@@ -25,22 +37,79 @@ func NewLocalLeaser() *LocalLeaser {
 		browserLock:    sync.RWMutex{},
 		browserTimeout: time.Second * 30,
 		browsers:       make(map[string]*gcd.Gcd),
+		profiles:       make(map[string]*persistentProfile),
 	}
 	s.chromeLocation, s.tmp = FindChrome()
 	log.Info().Msgf("FOUND CHROME %s and TMP: %s", s.chromeLocation, s.tmp)
 	return s
 }
 
+// SetChromePath of the chrome/chromium executable, overriding the one found in the usual locations
+func (s *LocalLeaser) SetChromePath(path string) {
+	s.browserLock.Lock()
+	s.chromeLocation = path
+	s.browserLock.Unlock()
+}
+
+// CheckChrome returns ErrChromeNotFound if there is no usable chrome executable to start
+func (s *LocalLeaser) CheckChrome() error {
+	s.browserLock.RLock()
+	defer s.browserLock.RUnlock()
+	return CheckChrome(s.chromeLocation)
+}
+
+// AddFlags browsers are started with, in addition to the default startup flags
+func (s *LocalLeaser) AddFlags(flags ...string) {
+	s.browserLock.Lock()
+	s.flags = append(s.flags, flags...)
+	s.browserLock.Unlock()
+}
+
+// SetUserDataDir keeps browser profiles under dir instead of deleting them on exit, so cookies
+// and storage persist across runs
+func (s *LocalLeaser) SetUserDataDir(dir string) {
+	s.browserLock.Lock()
+	s.userDataDir = dir
+	s.browserLock.Unlock()
+}
+
 // Acquire a new browser
 func (s *LocalLeaser) Acquire() (string, error) {
 	b := gcd.NewChromeDebugger()
-	b.DeleteProfileOnExit()
-
-	profileDir := randProfile(s.tmp)
 	port := randPort()
-	log.Info().Msgf("chrome temp %s path: %s", s.tmp, profileDir)
+
+	s.browserLock.Lock()
+	var profile *persistentProfile
+	profileDir := ""
+	if s.userDataDir != "" {
+		profile = &persistentProfile{slot: s.freeSlot(), exited: make(chan struct{})}
+		profileDir = filepath.Join(s.userDataDir, "browser"+strconv.Itoa(profile.slot))
+		s.profiles[port] = profile
+	}
+	flags := s.flags
+	chromeLocation := s.chromeLocation
+	s.browserLock.Unlock()
+
+	if profile != nil {
+		if err := os.MkdirAll(profileDir, 0700); err != nil {
+			s.releaseProfile(port)
+			return "", errors.Wrap(err, "failed to create persistent profile")
+		}
+		once := &sync.Once{}
+		b.SetTerminationHandler(func(reason string) {
+			once.Do(func() { close(profile.exited) })
+		})
+		log.Info().Msgf("chrome persistent profile path: %s", profileDir)
+	} else {
+		b.DeleteProfileOnExit()
+		profileDir = randProfile(s.tmp)
+		log.Info().Msgf("chrome temp %s path: %s", s.tmp, profileDir)
+	}
+
 	b.AddFlags(startupFlags)
-	if err := b.StartProcess(s.chromeLocation, profileDir, port); err != nil {
+	b.AddFlags(flags)
+	if err := b.StartProcess(chromeLocation, profileDir, port); err != nil {
+		s.releaseProfile(port)
 		return "", err
 	}
 	s.browserLock.Lock()
@@ -50,6 +119,28 @@ func (s *LocalLeaser) Acquire() (string, error) {
 	return string(port), nil
 }
 
+// freeSlot returns the lowest profile slot not used by a running browser, lock must be held
+func (s *LocalLeaser) freeSlot() int {
+	used := make(map[int]struct{}, len(s.profiles))
+	for _, profile := range s.profiles {
+		used[profile.slot] = struct{}{}
+	}
+
+	slot := 0
+	for ; ; slot++ {
+		if _, ok := used[slot]; !ok {
+			return slot
+		}
+	}
+}
+
+// releaseProfile so its slot can be used by another browser
+func (s *LocalLeaser) releaseProfile(port string) {
+	s.browserLock.Lock()
+	delete(s.profiles, port)
+	s.browserLock.Unlock()
+}
+
 // Count how many browsers
 func (s *LocalLeaser) Count() (string, error) {
 	s.browserLock.RLock()
@@ -58,20 +149,46 @@ func (s *LocalLeaser) Count() (string, error) {
 	return strconv.Itoa(count), nil
 }
 
-// Return (and kill) the browser
+// Return (and kill) the browser, browsers with persistent profiles are closed gracefully
 func (s *LocalLeaser) Return(port string) error {
 	s.browserLock.Lock()
-	defer s.browserLock.Unlock()
+	b, ok := s.browsers[port]
+	profile, persistent := s.profiles[port]
+	s.browserLock.Unlock()
+
+	if !ok {
+		return errors.New("not found")
+	}
 
-	if b, ok := s.browsers[port]; ok {
+	if !persistent || !closeGracefully(b, profile) {
 		if err := b.ExitProcess(); err != nil {
 			return err
 		}
-		delete(s.browsers, port)
-		return nil
 	}
 
-	return errors.New("not found")
+	s.browserLock.Lock()
+	delete(s.browsers, port)
+	delete(s.profiles, port)
+	s.browserLock.Unlock()
+	return nil
+}
+
+// closeGracefully asks chrome to exit so the profile (cookies) is written to disk before the
+// profile is used again, returns false if chrome did not exit in time
+func closeGracefully(b *gcd.Gcd, profile *persistentProfile) bool {
+	target, err := b.GetFirstTab()
+	if err != nil {
+		return false
+	}
+	target.Browser.Close()
+
+	select {
+	case <-profile.exited:
+		return true
+	case <-time.After(5 * time.Second):
+		log.Warn().Msg("browser did not exit in time, profile may not have been saved")
+		return false
+	}
 }
 
 // Cleanup all old browser processes, hope you weren't running chrome!
@@ -1,18 +1,70 @@
 package browser
 
-import "runtime"
+import (
+	"os"
+	"os/exec"
+	"runtime"
+)
 
-// FindChrome on the FS
-func FindChrome() (string, string) {
+// chromeCandidates are the usual install locations (absolute paths) or executable names
+// (looked up in PATH) of chrome/chromium for the OS
+func chromeCandidates() []string {
 	switch runtime.GOOS {
 	case "windows":
-		return "C:\\Program Files (x86)\\Google\\Chrome\\Application\\chrome.exe", "C:\\Temp\\gcd\\"
+		return []string{
+			"C:\\Program Files (x86)\\Google\\Chrome\\Application\\chrome.exe",
+			"C:\\Program Files\\Google\\Chrome\\Application\\chrome.exe",
+			"chrome.exe",
+		}
 	case "darwin":
-		return "/Applications/Google Chrome.app/Contents/MacOS/Google Chrome", "/tmp/gcd/"
+		return []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		}
 	case "linux":
-		return "/usr/bin/chromium-browser", "/tmp/gcd/"
+		return []string{
+			"/usr/bin/chromium-browser",
+			"/usr/bin/chromium",
+			"/usr/bin/google-chrome",
+			"/usr/bin/google-chrome-stable",
+			"/snap/bin/chromium",
+			"chromium-browser",
+			"chromium",
+			"google-chrome",
+		}
+	}
+	return []string{}
+}
+
+// FindChrome on the FS, returns an empty location if no usable chrome was found
+func FindChrome() (string, string) {
+	tmp := "tmp"
+	switch runtime.GOOS {
+	case "windows":
+		tmp = "C:\\Temp\\gcd\\"
+	case "darwin", "linux":
+		tmp = "/tmp/gcd/"
+	}
+
+	for _, candidate := range chromeCandidates() {
+		if location, err := exec.LookPath(candidate); err == nil {
+			return location, tmp
+		}
+	}
+	return "", tmp
+}
+
+// CheckChrome returns ErrChromeNotFound if location is not an executable file
+func CheckChrome(location string) error {
+	if location == "" {
+		return ErrChromeNotFound
+	}
+
+	info, err := os.Stat(location)
+	if err != nil || info.IsDir() || (runtime.GOOS != "windows" && info.Mode()&0111 == 0) {
+		return ErrChromeNotFound
 	}
-	return "", "tmp"
+	return nil
 }
 
 // FindKill based on OS
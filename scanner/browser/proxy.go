@@ -0,0 +1,102 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/wirepair/gcd"
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+)
+
+// proxyURL returns the configured http or socks5 proxy, nil if neither is set
+func proxyURL(cfg *browserk.Config) (*url.URL, error) {
+	if cfg == nil || (cfg.Proxy == "" && cfg.SocksProxy == "") {
+		return nil, nil
+	}
+
+	if cfg.Proxy != "" && cfg.SocksProxy != "" {
+		return nil, fmt.Errorf("only one of Proxy or SocksProxy can be set")
+	}
+
+	raw, schemes := cfg.Proxy, []string{"http", "https"}
+	if cfg.SocksProxy != "" {
+		raw, schemes = cfg.SocksProxy, []string{"socks5"}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url %s: %w", raw, err)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("proxy url %s is missing a host", raw)
+	}
+
+	// chrome never raises Fetch.authRequired for socks proxies so credentials can't be answered
+	if u.Scheme == "socks5" && u.User != nil {
+		return nil, fmt.Errorf("socks5 proxy %s can not use credentials, chrome does not support socks5 auth", u.Host)
+	}
+
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("proxy url %s must use one of the schemes %v", raw, schemes)
+}
+
+// ProxyFlags returns the chrome flags to send all browser traffic through the configured
+// proxy. Credentials are never passed on the command line, see ProxyCredentials.
+func ProxyFlags(cfg *browserk.Config) ([]string, error) {
+	u, err := proxyURL(cfg)
+	if err != nil || u == nil {
+		return nil, err
+	}
+
+	flags := []string{"--proxy-server=" + u.Scheme + "://" + u.Host}
+	if u.Scheme == "socks5" {
+		// chrome may still resolve host names locally, force them through the proxy
+		flags = append(flags, "--host-resolver-rules=MAP * ~NOTFOUND , EXCLUDE "+u.Hostname())
+	}
+	return flags, nil
+}
+
+// ProxyCredentials from the configured http(s) proxy url, answered when the proxy issues an auth
+// challenge
+func ProxyCredentials(cfg *browserk.Config) (string, string, bool) {
+	u, err := proxyURL(cfg)
+	if err != nil || u == nil || u.User == nil {
+		return "", "", false
+	}
+	password, _ := u.User.Password()
+	return u.User.Username(), password, true
+}
+
+// SetProxyCredentials answers proxy auth challenges with username and password, other auth
+// challenges are left to chrome's default behavior
+func (t *Tab) SetProxyCredentials(username, password string) error {
+	t.subscribe("Fetch.authRequired", func(target *gcd.ChromeTarget, payload []byte) {
+		message := &gcdapi.FetchAuthRequiredEvent{}
+		if err := json.Unmarshal(payload, message); err != nil {
+			t.ctx.Log.Error().Err(err).Msg("failed to decode Fetch.authRequired event")
+			return
+		}
+
+		response := &gcdapi.FetchAuthChallengeResponse{Response: "Default"}
+		if message.Params.AuthChallenge != nil && message.Params.AuthChallenge.Source == "Proxy" {
+			response = &gcdapi.FetchAuthChallengeResponse{Response: "ProvideCredentials", Username: username, Password: password}
+		}
+
+		if _, err := t.t.Fetch.ContinueWithAuth(message.Params.RequestId, response); err != nil {
+			t.ctx.Log.Warn().Err(err).Msg("failed to respond to auth challenge")
+		}
+	})
+
+	_, err := t.t.Fetch.EnableWithParams(&gcdapi.FetchEnableParams{
+		Patterns:           interceptPatterns,
+		HandleAuthRequests: true,
+	})
+	return err
+}
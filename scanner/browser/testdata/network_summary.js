@@ -0,0 +1 @@
+window.networkSummaryLoaded = true;
@@ -0,0 +1,4 @@
+var serviceWorkerSecret = "sw-secret-token";
+self.addEventListener("install", function(e) {
+	e.waitUntil(fetch("index.html"));
+});
@@ -0,0 +1,4 @@
+var workerSecret = "worker";
+onmessage = function(e) {
+	postMessage(e.data + " pong");
+};
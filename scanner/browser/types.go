@@ -1,6 +1,8 @@
 package browser
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/wirepair/gcd"
 	"github.com/wirepair/gcd/gcdapi"
@@ -8,12 +10,31 @@ import (
 )
 
 // https://chromium.googlesource.com/chromium/src/+/master/third_party/WebKit/Source/core/inspector/InspectorNetworkAgent.cpp#96
+// default buffer sizes, overridden by Config.TotalBufferSize and Config.ResourceBufferSize
 const maximumTotalBufferSize = -1
 
 const maximumResourceBufferSize = -1
 
 const maximumPostDataSize = -1
 
+// how long IsAlive waits for chrome to evaluate an expression
+const aliveTimeout = 2 * time.Second
+
+// requests and responses paused by the Fetch domain
+var interceptPatterns = []*gcdapi.FetchRequestPattern{
+	{
+		UrlPattern:   "*",
+		RequestStage: "Request",
+	},
+	{
+		UrlPattern:   "*",
+		RequestStage: "Response",
+	},
+}
+
+// computed styles captured for each node by CaptureDOMSnapshot
+var snapshotComputedStyles = []string{"display", "visibility", "opacity", "position", "z-index"}
+
 // GcdResponseFunc internal response function type
 type GcdResponseFunc func(target *gcd.ChromeTarget, payload []byte)
 
@@ -36,6 +57,21 @@ type DomChangeHandlerFunc func(tab *Tab, change *NodeChangeEvent)
 // ConditionalFunc function to iteratively call until returns without error
 type ConditionalFunc func(tab *Tab) bool
 
+// DOMSnapshotCaptureSnapshotResult flattened documents, computed styles and layout captured
+// in one call, string properties are indexes into Strings
+type DOMSnapshotCaptureSnapshotResult struct {
+	Documents []*gcdapi.DOMSnapshotDocumentSnapshot
+	Strings   []string
+}
+
+// String at the index of the shared string table, empty if index is -1 or out of range
+func (r *DOMSnapshotCaptureSnapshotResult) String(index int) string {
+	if index < 0 || index >= len(r.Strings) {
+		return ""
+	}
+	return r.Strings[index]
+}
+
 // revive:exported
 var (
 	ErrNavigationTimedOut = errors.New("navigation timed out")
@@ -44,6 +80,9 @@ var (
 	ErrTimedOut           = errors.New("request timed out")
 	ErrNavigating         = errors.New("error in navigation")
 	ErrBrowserClosing     = errors.New("unable to load, as closing down")
+	ErrTabUnresponsive    = errors.New("tab did not respond")
+	ErrNoSecurityState    = errors.New("no security state captured for page")
+	ErrChromeNotFound     = errors.New("chrome not found, install Google Chrome or Chromium or set the path to its executable with --chromepath")
 )
 
 // ErrElementNotFound when we are unable to find an element/nodeID
@@ -84,6 +123,17 @@ func (e *ErrScriptEvaluation) Error() string {
 	return e.Message + " " + e.ExceptionText
 }
 
+// ErrAttributeNotSet when setting one of a batch of attributes failed, attributes ordered before
+// it were still set
+type ErrAttributeNotSet struct {
+	Name    string
+	Message string
+}
+
+func (e *ErrAttributeNotSet) Error() string {
+	return "failed to set attribute " + e.Name + ": " + e.Message
+}
+
 // ErrTimeout when Tab.Navigate has timed out
 type ErrTimeout struct {
 	Message string
@@ -93,6 +143,15 @@ func (e *ErrTimeout) Error() string {
 	return "Timed out " + e.Message
 }
 
+// ErrInvalidEmulation when emulation settings are invalid
+type ErrInvalidEmulation struct {
+	Message string
+}
+
+func (e *ErrInvalidEmulation) Error() string {
+	return "Invalid emulation setting: " + e.Message
+}
+
 // NodeType are standard browser node types
 type NodeType uint8
 
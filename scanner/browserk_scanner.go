@@ -3,13 +3,19 @@ package scanner
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
 	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/scanner/attack"
 	"gitlab.com/browserker/scanner/auth"
 	"gitlab.com/browserker/scanner/browser"
 	"gitlab.com/browserker/scanner/crawler"
@@ -22,36 +28,118 @@ type Browserk struct {
 	cfg          *browserk.Config
 	pluginStore  browserk.PluginStorer
 	crawlGraph   browserk.CrawlGrapher
+	scheduler    browserk.Scheduler
 	reporter     browserk.Reporter
 	browsers     browserk.BrowserPool
 	formHandler  browserk.FormHandler
+	breaker      *CircuitBreaker
 	navCh        chan []*browserk.Navigation
 	readyCh      chan struct{}
 	stateMonitor *time.Ticker
 	mainContext  *browserk.Context
+	attacks      []browserk.AttackModule
+	payloads     browserk.PayloadProvider
+	history      *crawler.InteractionHistory
+	events       browserk.EventEmitter
+	reportOut    io.Writer
+	normalizer   browserk.URLNormalizer
+	cookieJar    *browserk.CookieJar
+	random       *browserk.Random
+	incremental  *IncrementalBaseline
+	scanErrors   *browserk.ScanErrors
+	expired      int32
+
+	authPassResults []*AuthPassResult
 
 	idMutex          *sync.RWMutex
 	leasedBrowserIDs map[int64]struct{}
 }
 
-// New engine
-func New(cfg *browserk.Config, crawl browserk.CrawlGrapher, pluginStore browserk.PluginStorer) *Browserk {
+// New engine, if scheduler is nil navigations are crawled in crawl graph order (html pages
+// first if Config.PrioritizeHTML is set)
+func New(cfg *browserk.Config, crawl browserk.CrawlGrapher, pluginStore browserk.PluginStorer, scheduler browserk.Scheduler) *Browserk {
+	if scheduler == nil && cfg.PrioritizeHTML {
+		scheduler = NewHTMLFirstScheduler(crawl)
+	} else if scheduler == nil {
+		scheduler = NewGraphScheduler(crawl)
+	}
+
 	return &Browserk{
 		cfg:              cfg,
 		pluginStore:      pluginStore,
 		crawlGraph:       crawl,
+		scheduler:        scheduler,
 		reporter:         report.New(),
+		breaker:          NewCircuitBreaker(cfg.CircuitBreaker),
+		history:          crawler.NewInteractionHistory(),
 		leasedBrowserIDs: make(map[int64]struct{}),
 		idMutex:          &sync.RWMutex{},
+		reportOut:        os.Stdout,
+		normalizer:       browserk.NewURLNormalizer(cfg.URLNormalization),
+		cookieJar:        browserk.NewCookieJar(),
+		random:           browserk.NewRandom(cfg.RandomSeed),
+		scanErrors:       browserk.NewScanErrors(),
 	}
 }
 
+// newFormHandler which generates values from the scan's seed
+func (b *Browserk) newFormHandler() *crawler.CrawlerFormHandler {
+	formHandler := crawler.NewCrawlerFormHandler(b.cfg.FormData)
+	formHandler.SetRandom(b.random)
+	return formHandler
+}
+
 // SetReporter overrides the default reporter
 func (b *Browserk) SetReporter(reporter browserk.Reporter) *Browserk {
 	b.reporter = reporter
 	return b
 }
 
+// SetIncrementalBaseline of a previous crawl, navigations whose content is unchanged are not
+// expanded or attacked. Overrides Config.IncrementalBaseline.
+func (b *Browserk) SetIncrementalBaseline(baseline *IncrementalBaseline) *Browserk {
+	b.incremental = baseline
+	return b
+}
+
+// SetURLNormalizer overrides the url normalizer shared by the scope service and crawlers
+func (b *Browserk) SetURLNormalizer(normalizer browserk.URLNormalizer) *Browserk {
+	b.normalizer = normalizer
+	return b
+}
+
+// SetEventEmitter streams navigation, finding, error and progress events to events. The
+// report is written to stderr instead of stdout so stdout can be used for the event stream.
+func (b *Browserk) SetEventEmitter(events browserk.EventEmitter) *Browserk {
+	b.events = events
+	b.reportOut = os.Stderr
+	return b
+}
+
+// emit the event if an emitter is set
+func (b *Browserk) emit(evt *browserk.ScanEvent) {
+	if b.events == nil {
+		return
+	}
+	evt.Time = time.Now()
+	b.events.Emit(evt)
+}
+
+// emitError for a failure during the scan
+func (b *Browserk) emitError(msg string, err error, correlationID string) {
+	evt := &browserk.ErrorEvent{Message: msg, CorrelationID: correlationID}
+	if err != nil {
+		evt.Error = err.Error()
+	}
+	b.emit(&browserk.ScanEvent{Type: browserk.EventError, Error: evt})
+}
+
+// AddAttackModules to be executed during the attack phase
+func (b *Browserk) AddAttackModules(modules ...browserk.AttackModule) *Browserk {
+	b.attacks = append(b.attacks, modules...)
+	return b
+}
+
 func (b *Browserk) addLeased(id int64) {
 	b.idMutex.Lock()
 	b.leasedBrowserIDs[id] = struct{}{}
@@ -94,15 +182,36 @@ func (b *Browserk) Init(ctx context.Context) error {
 
 	b.mainContext.Auth = auth.New(b.cfg)
 	b.mainContext.Scope = b.scopeService(target)
-	b.mainContext.FormHandler = crawler.NewCrawlerFormHandler(b.cfg.FormData)
+	b.formHandler = b.newFormHandler()
+	b.mainContext.FormHandler = b.formHandler
+	if b.cfg.BaselineFile != "" {
+		baseline, err := browserk.LoadBaseline(b.cfg.BaselineFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to load baseline")
+		}
+		b.reporter.SetBaseline(baseline)
+	}
+	if b.cfg.IncrementalBaseline != "" && b.incremental == nil {
+		incremental, err := LoadIncrementalBaseline(b.cfg.IncrementalBaseline)
+		if err != nil {
+			return errors.Wrap(err, "failed to load incremental baseline")
+		}
+		b.incremental = incremental
+	}
+	if b.cfg.DataPath != "" {
+		b.reporter.SetEvidenceDir(filepath.Join(b.cfg.DataPath, "evidence"))
+	}
 	b.mainContext.Reporter = b.reporter
+	if b.events != nil {
+		b.mainContext.Reporter = report.NewEventReporter(b.reporter, b.events)
+	}
 	b.mainContext.Injector = nil
 	b.mainContext.Crawl = b.crawlGraph
 	b.mainContext.PluginServicer = pluginService
 
-	log.Info().Int("num_browsers", b.cfg.NumBrowsers).Int("max_depth", b.cfg.MaxDepth).Msg("Initializing...")
-	b.navCh = make(chan []*browserk.Navigation, b.cfg.NumBrowsers)
-	b.readyCh = make(chan struct{})
+	log.Info().Int("num_browsers", b.cfg.NumBrowsers).Int("tabs_per_browser", b.cfg.TabsPerBrowser).Int("max_depth", b.cfg.MaxDepth).Int64("random_seed", b.random.Seed()).Msg("Initializing...")
+	b.navCh = make(chan []*browserk.Navigation, b.concurrency())
+	b.readyCh = make(chan struct{}, 1)
 
 	log.Logger.Info().Msg("initializing attack graph")
 	if err := b.pluginStore.Init(); err != nil {
@@ -114,7 +223,35 @@ func (b *Browserk) Init(ctx context.Context) error {
 		return err
 	}
 
-	b.formHandler = crawler.NewCrawlerFormHandler(b.cfg.FormData)
+	payloads := attack.NewFilePayloadProvider(b.cfg.PayloadDir)
+	if err := payloads.Init(); err != nil {
+		return err
+	}
+	b.payloads = payloads
+
+	params, err := attack.NewParamFilter(b.cfg.SkipParams)
+	if err != nil {
+		return err
+	}
+
+	sqliError, err := attack.NewSQLiError(b.payloads)
+	if err != nil {
+		return err
+	}
+	sqliError.SetPayloadConcurrency(b.cfg.PayloadConcurrency)
+	sqliError.SetParamFilter(params)
+	b.AddAttackModules(sqliError)
+
+	clickjacking := attack.NewClickjacking()
+	clickjacking.SetConfirm(b.cfg.ConfirmClickjacking)
+	b.AddAttackModules(clickjacking)
+
+	if b.cfg.EnableIDOR {
+		idor := attack.NewIDOR()
+		idor.SetPayloadConcurrency(b.cfg.PayloadConcurrency)
+		idor.SetParamFilter(params)
+		b.AddAttackModules(idor)
+	}
 
 	b.initNavigation()
 
@@ -122,12 +259,39 @@ func (b *Browserk) Init(ctx context.Context) error {
 
 	log.Logger.Info().Msg("starting leaser")
 	leaser := browser.NewLocalLeaser()
+	proxyFlags, err := browser.ProxyFlags(b.cfg)
+	if err != nil {
+		return err
+	}
+	leaser.AddFlags(proxyFlags...)
+	if b.cfg.ChromePath != "" {
+		leaser.SetChromePath(b.cfg.ChromePath)
+	}
+	if b.cfg.UserDataDir != "" {
+		if b.cfg.IsolateSessions {
+			log.Warn().Msg("IsolateSessions uses incognito browser contexts, cookies will not be persisted to UserDataDir")
+		}
+		leaser.SetUserDataDir(b.cfg.UserDataDir)
+	}
 	log.Logger.Info().Msg("leaser started")
 	pool := browser.NewGCDBrowserPool(b.cfg.NumBrowsers, leaser)
+	pool.SetConfig(b.cfg)
+	if b.cfg.AuthType == browserk.Script && b.cfg.AuthScript != "" {
+		pool.SetLogin(auth.ScriptLogin(b.cfg.URL, b.cfg.AuthScript), b.cfg.AuthRefresh)
+	}
 	b.browsers = pool
 	log.Logger.Info().Msg("starting browser pool")
+	if err := pool.Init(); err != nil {
+		return err
+	}
+
+	// authenticate before any navigations take a browser
+	log.Logger.Info().Msg("warming up browser pool")
+	if err := pool.Warmup(b.mainContext); err != nil {
+		return err
+	}
 	go b.processEntries()
-	return pool.Init()
+	return nil
 }
 
 func (b *Browserk) initNavigation() {
@@ -157,6 +321,7 @@ func (b *Browserk) scopeService(target *url.URL) browserk.ScopeService {
 	excluded := b.cfg.ExcludedHosts
 
 	scope := NewScopeService(target)
+	scope.SetNormalizer(b.normalizer)
 	scope.AddScope(allowed, browserk.InScope)
 	scope.AddScope(ignored, browserk.OutOfScope)
 	scope.AddScope(excluded, browserk.ExcludedFromScope)
@@ -166,27 +331,230 @@ func (b *Browserk) scopeService(target *url.URL) browserk.ScopeService {
 	return scope
 }
 
-// Start the browsers
+// how long in flight navigations have to finish once the scan's max duration is reached
+const expiredGracePeriod = 5 * time.Second
+
+// Start the scan, running each selected phase in order. If MaxDuration is set the scan is
+// stopped once it's reached, the attack phase is skipped and the report is marked incomplete.
+// Navigations which failed without stopping the scan are returned as *browserk.ScanErrors.
 func (b *Browserk) Start() error {
+	phases, err := SelectedPhases(b.cfg)
+	if err != nil {
+		return err
+	}
+
+	if b.cfg.MaxDuration > 0 {
+		timer := time.AfterFunc(b.cfg.MaxDuration, b.expire)
+		defer timer.Stop()
+	}
+
+	crawl := b.crawlPhase
+	if b.cfg.DualAuthPass {
+		crawl = func() error {
+			if err := b.crawlPhase(); err != nil {
+				return err
+			}
+			return b.dualAuthPass()
+		}
+	}
+
+	err = RunPhases(phases, map[string]PhaseFunc{
+		browserk.PhaseCrawl:  crawl,
+		browserk.PhaseAttack: b.attackPhase,
+		browserk.PhaseReport: b.reportPhase,
+	})
+	if err != nil {
+		return err
+	}
+
+	if b.scanErrors.Len() > 0 {
+		return b.scanErrors
+	}
+	return nil
+}
+
+// expire the scan, cancelling the main context so workers stop taking new navigations
+func (b *Browserk) expire() {
+	log.Warn().Dur("max_duration", b.cfg.MaxDuration).Msg("max scan duration reached, stopping scan")
+	atomic.StoreInt32(&b.expired, 1)
+	b.reporter.SetIncomplete(fmt.Sprintf("scan stopped after max duration of %s", b.cfg.MaxDuration))
+	if b.mainContext.CtxComplete != nil {
+		b.mainContext.CtxComplete()
+	}
+}
+
+// isExpired returns true once the scan's max duration was reached
+func (b *Browserk) isExpired() bool {
+	return atomic.LoadInt32(&b.expired) == 1
+}
+
+// waitInFlight gives in flight navigations a brief period to finish after the scan expired
+func (b *Browserk) waitInFlight() {
+	timer := time.NewTimer(expiredGracePeriod)
+	defer timer.Stop()
+
+	for b.browsers.Leased() > 0 {
+		select {
+		case <-b.readyCh:
+		case <-timer.C:
+			log.Warn().Int("leased_browsers", b.browsers.Leased()).Msg("in flight navigations did not finish in time")
+			return
+		}
+	}
+}
+
+// crawlPhase runs the crawler until no more unvisited navigations exist or the scan expires
+func (b *Browserk) crawlPhase() error {
 	for {
+		if b.isExpired() {
+			b.waitInFlight()
+			return nil
+		}
 
 		log.Info().Msg("searching for new navigation entries")
-		entries := b.crawlGraph.Find(b.mainContext.Ctx, browserk.NavUnvisited, browserk.NavInProcess, int64(b.cfg.NumBrowsers))
+		entries := b.nextEntries()
 		if entries == nil || len(entries) == 0 && b.browsers.Leased() == 0 {
 			log.Info().Msg("no more crawler entries or active browsers")
 			time.Sleep(time.Second * 60)
 			return nil
 		}
 		log.Info().Int("entries", len(entries)).Msg("Found entries")
+		b.emitProgress(browserk.PhaseCrawl, len(entries))
 		for _, nav := range entries {
-			b.navCh <- nav
+			select {
+			case b.navCh <- nav:
+			case <-b.mainContext.Ctx.Done():
+			}
 		}
 		log.Info().Msg("Waiting for crawler to complete")
-		<-b.readyCh
+		select {
+		case <-b.readyCh:
+		case <-b.mainContext.Ctx.Done():
+			b.waitInFlight()
+			return nil
+		}
+	}
+}
+
+// emitProgress of the phase with the number of entries it has to process
+func (b *Browserk) emitProgress(phase string, entries int) {
+	b.emit(&browserk.ScanEvent{
+		Type: browserk.EventProgress,
+		Progress: &browserk.ProgressEvent{
+			Phase:          phase,
+			Entries:        entries,
+			LeasedBrowsers: b.browsers.Leased(),
+		},
+	})
+}
+
+// nextEntries to crawl from the scheduler
+func (b *Browserk) nextEntries() [][]*browserk.Navigation {
+	return b.scheduler.Next(b.mainContext.Ctx, int64(b.concurrency()))
+}
+
+// concurrency is the number of tabs that can crawl at once
+func (b *Browserk) concurrency() int {
+	if b.cfg.TabsPerBrowser > 1 {
+		return b.cfg.NumBrowsers * b.cfg.TabsPerBrowser
+	}
+	return b.cfg.NumBrowsers
+}
+
+// attackPhase runs each attack module against the captured navigation results
+func (b *Browserk) attackPhase() error {
+	if len(b.attacks) == 0 {
+		log.Info().Msg("no attack modules loaded, skipping attack phase")
+		return nil
+	}
+
+	if b.isExpired() {
+		log.Warn().Msg("max scan duration reached, skipping attack phase")
+		return nil
+	}
+
+	results, err := b.crawlGraph.GetNavigationResults()
+	if err != nil {
+		return err
+	}
+
+	if b.incremental != nil {
+		changed := make([]*browserk.NavigationResult, 0, len(results))
+		for _, result := range results {
+			if !b.incremental.WasUnchanged(result.NavigationID) {
+				changed = append(changed, result)
+			}
+		}
+		log.Info().Int("unchanged", len(results)-len(changed)).Msg("skipping results unchanged since the incremental baseline")
+		results = changed
+	}
+
+	log.Info().Int("results", len(results)).Int("modules", len(b.attacks)).Msg("starting attack phase")
+	b.emitProgress(browserk.PhaseAttack, len(results))
+	for _, result := range results {
+		b.attack(result)
 	}
+	return nil
+}
+
+// attack a single navigation result with all attack modules, requests are replayed from a browser
+// loaded with the result's page so cookies and origin are preserved. If no browser is available
+// requests are replayed directly.
+func (b *Browserk) attack(result *browserk.NavigationResult) {
+	attackCtx := b.mainContext.Copy()
+	logger := log.With().Str("url", result.EndURL).Logger()
+	attackCtx.Log = &logger
+
+	var replayer browserk.Replayer = attack.NewHTTPReplayer(nil)
+	browser, port, err := b.browsers.Take(attackCtx)
+	if err != nil {
+		attackCtx.Log.Warn().Err(err).Msg("failed to take browser, replaying requests directly")
+	} else {
+		defer func() {
+			browser.Close()
+			b.browsers.Return(attackCtx.Ctx, port)
+		}()
+
+		navCtx, cancel := context.WithTimeout(attackCtx.Ctx, time.Second*45)
+		err = browser.Navigate(navCtx, result.EndURL)
+		cancel()
+		if err != nil {
+			attackCtx.Log.Warn().Err(err).Msg("failed to load page for attack, replaying requests directly")
+		} else {
+			replayer = browser
+		}
+	}
+
+	for _, module := range b.attacks {
+		moduleLogger := attackCtx.Log.With().Str("module", module.ID()).Logger()
+		moduleCtx := attackCtx.Copy()
+		moduleCtx.Log = &moduleLogger
+		if err := module.Attack(moduleCtx, replayer, result); err != nil {
+			moduleCtx.Log.Error().Err(err).Msg("attack module failed")
+			b.scanErrors.Add(browserk.PhaseAttack, result.EndURL, errors.Wrapf(err, "%s failed", module.ID()))
+		}
+	}
+}
+
+// Findings reported during the scan, including baselined findings
+func (b *Browserk) Findings() []*browserk.Report {
+	return b.reporter.Findings()
+}
+
+// reportPhase prints the findings and persists them to the plugin store so scans can be diffed
+func (b *Browserk) reportPhase() error {
+	b.reporter.Print(b.reportOut)
+	report.PrintFailedNavigations(b.reportOut, b.crawlGraph.Find(b.mainContext.Ctx, browserk.NavFailed, browserk.NavFailed, 1000))
+	for _, finding := range b.reporter.Findings() {
+		if err := b.pluginStore.AddReport(finding); err != nil {
+			return errors.Wrap(err, "failed to store finding")
+		}
+	}
+	return nil
 }
 
 func (b *Browserk) processEntries() {
+	b.startWorkers()
 	for {
 		select {
 		case <-b.stateMonitor.C:
@@ -195,11 +563,31 @@ func (b *Browserk) processEntries() {
 		case <-b.mainContext.Ctx.Done():
 			log.Info().Msg("scan finished due to context complete")
 			return
+		}
+	}
+}
+
+// startWorkers starts one crawl worker per tab, bounding the number of concurrent crawls
+func (b *Browserk) startWorkers() {
+	workers := b.concurrency()
+	if workers <= 0 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		go b.crawlWorker()
+	}
+}
+
+// crawlWorker crawls entries from the nav channel until the scan is complete
+func (b *Browserk) crawlWorker() {
+	for {
+		select {
+		case <-b.mainContext.Ctx.Done():
+			return
 		case nav := <-b.navCh:
 			log.Info().Int("leased_browsers", b.browsers.Leased()).Msg("processing nav")
-			go b.crawl(nav)
-			log.Info().Msg("Crawler to complete")
-			//
+			b.crawl(nav)
 		}
 	}
 }
@@ -210,13 +598,14 @@ func (b *Browserk) crawl(navs []*browserk.Navigation) {
 	browser, port, err := b.browsers.Take(navCtx)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to take browser")
+		b.emitError("failed to take browser", err, "")
 		return
 	}
 
 	b.addLeased(browser.ID())
 	defer b.removeLeased(browser.ID())
 
-	crawler := crawler.New(b.cfg)
+	crawler := crawler.New(b.cfg).SetInteractionHistory(b.history).SetURLNormalizer(b.normalizer)
 	if err := crawler.Init(); err != nil {
 		b.browsers.Return(navCtx.Ctx, port)
 		log.Error().Err(err).Msg("failed to init crawler")
@@ -224,43 +613,226 @@ func (b *Browserk) crawl(navs []*browserk.Navigation) {
 	}
 
 	isFinal := false
+	jarHost := ""
 	for i, nav := range navs {
 		// we are on the last navigation of this path so we'll want to capture some stuff
 		if i == len(navs)-1 {
 			isFinal = true
 		}
 
-		ctx, cancel := context.WithTimeout(navCtx.Ctx, time.Second*45)
-		navCtx.Ctx = ctx
+		correlationID := browserk.NewCorrelationIDFrom(b.random)
 		logger := log.With().
 			Int64("browser_id", browser.ID()).
 			Str("path", b.printActionStep(navs)).Int("step", i).
+			Str("correlation_id", correlationID).
 			Logger()
 		navCtx.Log = &logger
 
+		if b.cfg.CorrelationHeader {
+			if err := browser.SetExtraHeaders(map[string]string{browserk.CorrelationHeader: correlationID}); err != nil {
+				navCtx.Log.Warn().Err(err).Msg("failed to set correlation header")
+			}
+		}
+
+		host := b.navigationHost(browser, nav)
+		if b.cfg.HostCookieJars && host != jarHost {
+			if err := b.loadCookieJar(browser, host); err != nil {
+				navCtx.Log.Warn().Err(err).Str("host", host).Msg("failed to load cookie jar")
+			}
+			jarHost = host
+		}
+
+		if b.breaker != nil {
+			if err := b.breaker.Wait(navCtx.Ctx, host); err != nil {
+				navCtx.Log.Error().Err(err).Str("host", host).Msg("circuit breaker did not close before context completed")
+				b.crawlGraph.FailNavigation(navs[len(navs)-1].ID)
+				break
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(navCtx.Ctx, time.Second*45)
+		navCtx.Ctx = ctx
+
 		defer cancel()
 
 		result, newNavs, err := crawler.Process(navCtx, browser, nav, isFinal)
+		if b.breaker != nil {
+			b.breaker.RecordResult(result)
+			b.breaker.ProbeFinished(host)
+		}
+		if isTabCrashed(err) {
+			// the browser is replaced when returned to the pool below
+			navCtx.Log.Warn().Err(err).Msg("browser crashed, requeueing navigation")
+			b.retryOrFail(navCtx, navs[len(navs)-1], err, navigationURL(navs[len(navs)-1], result))
+			break
+		}
+
 		if err != nil {
 			navCtx.Log.Error().Err(err).Msg("failed to process action")
-			b.crawlGraph.FailNavigation(nav.ID)
+			b.emitError("failed to process action", err, correlationID)
+			// earlier navigations of the path are already visited, the path's target is retried
+			if b.mainContext.Ctx.Err() != nil {
+				// the scan is stopping, a requeued navigation would never be picked up
+				b.crawlGraph.FailNavigation(navs[len(navs)-1].ID)
+				break
+			}
+			b.retryOrFail(navCtx, navs[len(navs)-1], err, navigationURL(nav, result))
 			break
 		}
 
-		if isFinal {
-			navCtx.Log.Info().Int("nav_count", len(newNavs)).Bool("is_final", isFinal).Msg("adding new navs")
-			if err := b.crawlGraph.AddNavigations(newNavs); err != nil {
-				navCtx.Log.Error().Err(err).Msg("failed to add new navigations")
-			}
+		if b.cfg.HostCookieJars {
+			b.saveCookieJar(navCtx, browser, host)
 		}
-		if err := b.crawlGraph.AddResult(result); err != nil {
+
+		// unchanged pages are still expanded as pages below them may have changed, they're only
+		// skipped when attacking
+		if isFinal && b.incremental != nil && b.incremental.Unchanged(result) {
+			navCtx.Log.Info().Msg("content unchanged since the incremental baseline, it will not be attacked")
+		}
+
+		// the result, new navigations and visited state are written together
+		err = b.crawlGraph.Transaction(func(tx browserk.CrawlTx) error {
+			if isFinal {
+				navCtx.Log.Info().Int("nav_count", len(newNavs)).Bool("is_final", isFinal).Msg("adding new navs")
+				if err := tx.AddNavigations(newNavs); err != nil {
+					return errors.Wrap(err, "failed to add new navigations")
+				}
+			}
+			return tx.AddResult(result)
+		})
+		if err != nil {
 			navCtx.Log.Error().Err(err).Msg("failed to add result")
 		}
+
+		if observer, ok := b.scheduler.(browserk.ResultObserver); ok {
+			observer.Observe(result)
+		}
+
+		b.emit(&browserk.ScanEvent{
+			Type: browserk.EventNavigation,
+			Navigation: &browserk.NavigationEvent{
+				URL:           result.EndURL,
+				Path:          b.printActionStep(navs[:i+1]),
+				Step:          i,
+				CorrelationID: correlationID,
+				NewNavs:       len(newNavs),
+			},
+		})
 	}
 	navCtx.Log.Info().Msg("closing browser")
 	browser.Close()
 	b.browsers.Return(navCtx.Ctx, port)
-	b.readyCh <- struct{}{}
+	// wake the crawl phase without blocking this worker, completions may coalesce
+	select {
+	case b.readyCh <- struct{}{}:
+	default:
+	}
+}
+
+// number of times a failing navigation is requeued if Config.MaxNavigationRetries is not set
+const defaultNavigationRetries = 3
+
+// retryOrFail requeues the navigation which failed with a retryable error (see isRetryable) until
+// it has been retried MaxNavigationRetries times, after which it's permanently failed. Navigations
+// failing with any other error are failed immediately. The attempt count and error are recorded
+// either way.
+func (b *Browserk) retryOrFail(navCtx *browserk.Context, nav *browserk.Navigation, navErr error, pageURL string) {
+	maxRetries := defaultNavigationRetries
+	if b.cfg.MaxNavigationRetries > 0 {
+		maxRetries = b.cfg.MaxNavigationRetries
+	}
+
+	nav.Retries++
+	nav.LastError = navErr.Error()
+	if err := b.crawlGraph.RecordNavigationError(nav.ID, nav.Retries, nav.LastError); err != nil {
+		navCtx.Log.Error().Err(err).Msg("failed to record navigation error")
+	}
+
+	if !isRetryable(navErr) || nav.Retries > maxRetries {
+		navCtx.Log.Warn().Int("retries", nav.Retries-1).Bool("retryable", isRetryable(navErr)).Msg("navigation failed, marking failed")
+		if err := b.crawlGraph.FailNavigation(nav.ID); err != nil {
+			navCtx.Log.Error().Err(err).Msg("failed to mark navigation failed")
+		}
+		b.scanErrors.Add(browserk.PhaseCrawl, pageURL, navErr)
+		return
+	}
+
+	if err := b.scheduler.Requeue(nav); err != nil {
+		navCtx.Log.Error().Err(err).Msg("failed to requeue navigation")
+	}
+}
+
+// navigationURL returns the url a load url navigation loads, otherwise the page the navigation
+// was executed on
+func navigationURL(nav *browserk.Navigation, result *browserk.NavigationResult) string {
+	if nav.Action != nil && nav.Action.Type == browserk.ActLoadURL {
+		return string(nav.Action.Input)
+	}
+
+	if result == nil {
+		return ""
+	}
+	return result.StartURL
+}
+
+// isTabCrashed returns true if the error was caused by the browser tab crashing
+func isTabCrashed(err error) bool {
+	return err != nil && errors.Cause(err) == browser.ErrTabCrashed
+}
+
+// isRetryable returns true if the error was caused by the tab crashing or timing out, which a
+// later attempt may not run into
+func isRetryable(err error) bool {
+	switch errors.Cause(err) {
+	case browser.ErrTabCrashed, browser.ErrNavigationTimedOut, browser.ErrTimedOut, context.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// navigationHost returns the host a navigation will be executed against
+func (b *Browserk) navigationHost(browser browserk.Browser, nav *browserk.Navigation) string {
+	rawURL := ""
+	if nav.Action != nil && nav.Action.Type == browserk.ActLoadURL {
+		rawURL = string(nav.Action.Input)
+	} else if current, err := browser.GetURL(); err == nil {
+		rawURL = current
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// sessionPool is implemented by pools which share a login session with their browsers
+type sessionPool interface {
+	Session() *browserk.Session
+}
+
+// loadCookieJar resets the browser's cookies to the seeded and accumulated cookies of host
+func (b *Browserk) loadCookieJar(browser browserk.Browser, host string) error {
+	if pool, ok := b.browsers.(sessionPool); ok {
+		if session := pool.Session(); session != nil {
+			b.cookieJar.Seed(session.Cookies)
+		}
+	}
+
+	if err := browser.ClearCookies(); err != nil {
+		return err
+	}
+	return browser.SetCookies(b.cookieJar.Cookies(host))
+}
+
+// saveCookieJar stores the browser's cookies as those accumulated for host
+func (b *Browserk) saveCookieJar(navCtx *browserk.Context, browser browserk.Browser, host string) {
+	cookies, err := browser.GetCookies()
+	if err != nil {
+		navCtx.Log.Warn().Err(err).Str("host", host).Msg("failed to save cookie jar")
+		return
+	}
+	b.cookieJar.Update(host, cookies)
 }
 
 // Stop the browsers
@@ -0,0 +1,404 @@
+package scanner_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/browser"
+	"gitlab.com/browserker/scanner/report"
+	"gitlab.com/browserker/store"
+)
+
+func TestCrawlRequeuesOnTabCrash(t *testing.T) {
+	ctx := context.Background()
+	crashing := mock.MakeMockBrowser()
+	crashing.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		return nil, false, errors.Wrap(browser.ErrTabCrashed, "crashed")
+	}
+
+	replacement := mock.MakeMockBrowser()
+	replacement.IDFn = func() int64 {
+		return 2
+	}
+
+	pool := mock.MakeMockBrowserPool(crashing)
+	pool.TakeFn = func(ctx *browserk.Context) (browserk.Browser, string, error) {
+		if len(pool.Returned) == 0 {
+			return crashing, "9222", nil
+		}
+		return replacement, "9223", nil
+	}
+
+	graph := mock.MakeMockCrawlGraph()
+	engine := scanner.NewTestEngine(mock.MakeMockConfig(), graph, pool, mock.Context(ctx))
+
+	nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	engine.Crawl([]*browserk.Navigation{nav})
+
+	if !graph.RequeueNavigationCalled || len(graph.Requeued) != 1 || string(graph.Requeued[0]) != string(nav.ID) {
+		t.Fatalf("expected navigation to be requeued after crash")
+	}
+
+	if graph.FailNavigationCalled {
+		t.Fatalf("crashed navigation should not be marked failed")
+	}
+
+	if !crashing.CloseCalled || len(pool.Returned) != 1 {
+		t.Fatalf("expected crashed browser to be closed and returned for replacement")
+	}
+
+	// requeued navigation is processed by the replacement browser
+	engine.Crawl([]*browserk.Navigation{nav})
+	if !replacement.ExecuteActionCalled {
+		t.Fatalf("expected replacement browser to execute the requeued navigation")
+	}
+
+	if len(graph.Requeued) != 1 || !graph.AddResultCalled {
+		t.Fatalf("expected replacement browser to complete navigation")
+	}
+}
+
+func TestCrawlCorrelationID(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	orig := log.Logger
+	log.Logger = zerolog.New(buf)
+	defer func() { log.Logger = orig }()
+
+	b := mock.MakeMockBrowser()
+	var headers map[string]string
+	b.SetExtraHeadersFn = func(h map[string]string) error {
+		headers = h
+		return nil
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.CorrelationHeader = true
+	graph := mock.MakeMockCrawlGraph()
+	engine := scanner.NewTestEngine(cfg, graph, mock.MakeMockBrowserPool(b), mock.Context(ctx))
+
+	nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	engine.Crawl([]*browserk.Navigation{nav})
+
+	id := headers[browserk.CorrelationHeader]
+	if id == "" {
+		t.Fatalf("expected correlation header to be set got %v", headers)
+	}
+
+	found := 0
+	lines := bufio.NewScanner(buf)
+	for lines.Scan() {
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(lines.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry["correlation_id"] == id {
+			found++
+		}
+	}
+
+	if found == 0 {
+		t.Fatalf("expected log statements with correlation_id %s", id)
+	}
+}
+
+func TestCrawlJSONEvents(t *testing.T) {
+	ctx := context.Background()
+	out := &bytes.Buffer{}
+
+	failing := mock.MakeMockBrowser()
+	failing.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		return nil, false, errors.New("navigation failed")
+	}
+
+	graph := mock.MakeMockCrawlGraph()
+	pool := mock.MakeMockBrowserPool(mock.MakeMockBrowser())
+	engine := scanner.NewTestEngine(mock.MakeMockConfig(), graph, pool, mock.Context(ctx))
+	engine.SetEventEmitter(report.NewJSONEvents(out))
+
+	nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	engine.Crawl([]*browserk.Navigation{nav})
+
+	pool.TakeFn = func(ctx *browserk.Context) (browserk.Browser, string, error) {
+		return failing, "9222", nil
+	}
+	engine.Crawl([]*browserk.Navigation{nav})
+
+	seen := make(map[browserk.ScanEventType]int)
+	lines := bufio.NewScanner(out)
+	for lines.Scan() {
+		evt := &browserk.ScanEvent{}
+		if err := json.Unmarshal(lines.Bytes(), evt); err != nil {
+			t.Fatalf("malformed event %s: %s", lines.Text(), err)
+		}
+		if evt.Time.IsZero() {
+			t.Fatalf("expected event time to be set: %s", lines.Text())
+		}
+		seen[evt.Type]++
+
+		switch evt.Type {
+		case browserk.EventNavigation:
+			if evt.Navigation == nil || evt.Navigation.CorrelationID == "" {
+				t.Fatalf("expected navigation details: %s", lines.Text())
+			}
+		case browserk.EventError:
+			if evt.Error == nil || evt.Error.Error != "navigation failed" {
+				t.Fatalf("expected error details: %s", lines.Text())
+			}
+		}
+	}
+
+	if seen[browserk.EventNavigation] != 1 || seen[browserk.EventError] != 1 {
+		t.Fatalf("expected one navigation and one error event got %v", seen)
+	}
+}
+
+func TestStartMaxDuration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bCtx := mock.Context(ctx)
+	bCtx.CtxComplete = cancel
+
+	// navigations never finish on their own
+	blocking := mock.MakeMockBrowser()
+	blocking.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	}
+
+	nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	graph := mock.MakeMockCrawlGraph()
+	graph.FindFn = func(ctx context.Context, byState, setState browserk.NavState, limit int64) [][]*browserk.Navigation {
+		return [][]*browserk.Navigation{{nav}}
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.MaxDuration = 100 * time.Millisecond
+	module := mock.MakeMockAttackModule()
+	pool := mock.MakeMockBrowserPool(blocking)
+	engine := scanner.NewTestEngine(cfg, graph, pool, bCtx).AddAttackModules(module)
+	out := &bytes.Buffer{}
+	engine.SetReportOutput(out)
+	engine.StartWorkers()
+
+	start := time.Now()
+	if err := engine.Start(); err != nil {
+		t.Fatalf("error running scan: %s\n", err)
+	}
+
+	if time.Since(start) > 10*time.Second {
+		t.Fatalf("expected scan to stop shortly after max duration, took %s", time.Since(start))
+	}
+
+	if ctx.Err() == nil {
+		t.Fatalf("expected scan context to be cancelled")
+	}
+
+	if pool.Leased() != 0 || !graph.FailNavigationCalled {
+		t.Fatalf("expected in flight navigation to finish before the scan stopped")
+	}
+
+	if module.AttackCalled {
+		t.Fatalf("attack phase should be skipped once the scan expired")
+	}
+
+	if !strings.HasPrefix(out.String(), "INCOMPLETE:") {
+		t.Fatalf("expected report to be marked incomplete got %q", out.String())
+	}
+}
+
+func TestCrawlHostCookieJars(t *testing.T) {
+	ctx := context.Background()
+
+	// simulates the browser's cookie store, every cookie applies to both hosts
+	store := make(map[string]*browserk.Cookie)
+	sent := make(map[string][]string)
+
+	b := mock.MakeMockBrowser()
+	b.GetCookiesFn = func() ([]*browserk.Cookie, error) {
+		cookies := make([]*browserk.Cookie, 0, len(store))
+		for _, c := range store {
+			cookies = append(cookies, c)
+		}
+		return cookies, nil
+	}
+	b.SetCookiesFn = func(cookies []*browserk.Cookie) error {
+		for _, c := range cookies {
+			store[c.Name] = c
+		}
+		return nil
+	}
+	b.ClearCookiesFn = func() error {
+		store = make(map[string]*browserk.Cookie)
+		return nil
+	}
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		target := string(act.Input)
+		for name := range store {
+			sent[target] = append(sent[target], name)
+		}
+		if strings.Contains(target, "a.example.com") {
+			store["cart"] = &browserk.Cookie{Name: "cart", Value: "a", Domain: ".example.com", Path: "/"}
+		}
+		return nil, true, nil
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.HostCookieJars = true
+	engine := scanner.NewTestEngine(cfg, mock.MakeMockCrawlGraph(), mock.MakeMockBrowserPool(b), mock.Context(ctx))
+
+	hostA := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://a.example.com/")})
+	engine.Crawl([]*browserk.Navigation{hostA})
+
+	hostB := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://b.example.com/")})
+	engine.Crawl([]*browserk.Navigation{hostB})
+
+	if len(sent["http://b.example.com/"]) != 0 {
+		t.Fatalf("expected host a cookies not to be sent to host b got %v\n", sent["http://b.example.com/"])
+	}
+
+	hostA = browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://a.example.com/again")})
+	engine.Crawl([]*browserk.Navigation{hostA})
+
+	if names := sent["http://a.example.com/again"]; len(names) != 1 || names[0] != "cart" {
+		t.Fatalf("expected host a's accumulated cookies to be restored got %v\n", names)
+	}
+}
+
+func TestCrawlFailsNavigationAfterMaxRetries(t *testing.T) {
+	ctx := context.Background()
+	b := mock.MakeMockBrowser()
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		return nil, false, errors.Wrap(browser.ErrNavigationTimedOut, "always fails")
+	}
+
+	nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	graph := mock.MakeMockCrawlGraph()
+	graph.FindFn = func(ctx context.Context, byState, setState browserk.NavState, limit int64) [][]*browserk.Navigation {
+		if byState == browserk.NavFailed && graph.FailNavigationCalled {
+			return [][]*browserk.Navigation{{nav}}
+		}
+		return nil
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.MaxNavigationRetries = 2
+	cfg.Phases = []string{browserk.PhaseReport}
+	engine := scanner.NewTestEngine(cfg, graph, mock.MakeMockBrowserPool(b), mock.Context(ctx))
+
+	for i := 0; i < cfg.MaxNavigationRetries; i++ {
+		engine.Crawl([]*browserk.Navigation{nav})
+		if graph.FailNavigationCalled {
+			t.Fatalf("expected navigation to be requeued on attempt %d", i+1)
+		}
+	}
+
+	if len(graph.Requeued) != cfg.MaxNavigationRetries {
+		t.Fatalf("expected navigation to be requeued %d times got %d", cfg.MaxNavigationRetries, len(graph.Requeued))
+	}
+
+	engine.Crawl([]*browserk.Navigation{nav})
+	if !graph.FailNavigationCalled || len(graph.Requeued) != cfg.MaxNavigationRetries {
+		t.Fatalf("expected navigation to be failed once retries were exceeded")
+	}
+
+	if graph.Errors[string(nav.ID)] != "always fails: navigation timed out" || nav.Retries != 3 {
+		t.Fatalf("expected last error and attempts to be recorded got %q %d", graph.Errors[string(nav.ID)], nav.Retries)
+	}
+
+	out := &bytes.Buffer{}
+	engine.SetReportOutput(out)
+	if _, ok := engine.Start().(*browserk.ScanErrors); !ok {
+		t.Fatalf("expected the failed navigation to be returned from the scan")
+	}
+
+	if !strings.Contains(out.String(), "FAILED: ActLoadURL [http://localhost:8080/] after 3 attempts: always fails: navigation timed out") {
+		t.Fatalf("expected report to list the failed navigation got %q", out.String())
+	}
+}
+
+func TestCrawlFailsPathOnActionError(t *testing.T) {
+	ctx := context.Background()
+	b := mock.MakeMockBrowser()
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		if act.Type == browserk.ActLeftClick {
+			return nil, false, errors.New("element not found")
+		}
+		return nil, true, nil
+	}
+
+	load := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	click := browserk.NewNavigationFromElement(load, browserk.TrigCrawler, &browserk.HTMLElement{Type: browserk.BUTTON}, browserk.ActLeftClick)
+	graph := mock.MakeMockCrawlGraph()
+
+	engine := scanner.NewTestEngine(mock.MakeMockConfig(), graph, mock.MakeMockBrowserPool(b), mock.Context(ctx))
+	engine.Crawl([]*browserk.Navigation{load, click})
+
+	if len(graph.Requeued) != 0 || !graph.FailNavigationCalled {
+		t.Fatalf("expected an action error to fail the navigation without retrying it")
+	}
+
+	if _, ok := graph.Errors[string(click.ID)]; !ok || click.Retries != 1 || load.Retries != 0 {
+		t.Fatalf("expected the error to be recorded on the path's last navigation got %v", graph.Errors)
+	}
+}
+
+func TestStartReturnsScanErrors(t *testing.T) {
+	ctx := context.Background()
+	b := mock.MakeMockBrowser()
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		if strings.HasSuffix(string(act.Input), "/broken") {
+			return nil, false, errors.New("connection reset")
+		}
+		return nil, true, nil
+	}
+
+	graph := store.NewMemoryCrawlGraph()
+	if err := graph.Init(); err != nil {
+		t.Fatalf("error initializing graph: %s\n", err)
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.Phases = []string{browserk.PhaseReport}
+	engine := scanner.NewTestEngine(cfg, graph, mock.MakeMockBrowserPool(b), mock.Context(ctx))
+	engine.SetReportOutput(&bytes.Buffer{})
+
+	for _, u := range []string{"http://localhost:8080/", "http://localhost:8080/broken", "http://localhost:8080/about"} {
+		nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte(u)})
+		graph.AddNavigation(nav)
+		// failing navigations which aren't timeouts or crashes are failed without being retried
+		engine.Crawl([]*browserk.Navigation{nav})
+	}
+
+	err := engine.Start()
+	scanErrors, ok := err.(*browserk.ScanErrors)
+	if !ok {
+		t.Fatalf("expected scan errors got %v", err)
+	}
+
+	errs := scanErrors.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 failed navigation got %d: %s", len(errs), scanErrors)
+	}
+
+	if errs[0].URL != "http://localhost:8080/broken" || errs[0].Phase != browserk.PhaseCrawl || errs[0].Err.Error() != "connection reset" {
+		t.Fatalf("expected broken page's failure with context got %+v", errs[0])
+	}
+
+	// the other navigations were still crawled
+	visited := graph.Find(ctx, browserk.NavVisited, browserk.NavVisited, 10)
+	if len(visited) != 2 {
+		t.Fatalf("expected 2 visited navigations got %d", len(visited))
+	}
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// BreakerState of a host
+type BreakerState int8
+
+const (
+	// BreakerClosed navigations are allowed
+	BreakerClosed BreakerState = iota
+	// BreakerOpen navigations are paused until the cool down expires
+	BreakerOpen
+	// BreakerHalfOpen a single probe navigation is allowed to determine if we can resume
+	BreakerHalfOpen
+)
+
+type hostBreaker struct {
+	state       BreakerState
+	failures    int
+	firstFailed time.Time
+	openedAt    time.Time
+	probing     bool
+}
+
+// CircuitBreaker tracks consecutive 5xx responses per host and pauses navigations
+// to hosts that appear to be overloaded
+type CircuitBreaker struct {
+	threshold int
+	window    time.Duration
+	coolDown  time.Duration
+	lock      *sync.Mutex
+	hosts     map[string]*hostBreaker
+	now       func() time.Time
+}
+
+// NewCircuitBreaker from the config, returns nil if cfg is nil
+func NewCircuitBreaker(cfg *browserk.CircuitBreakerConfig) *CircuitBreaker {
+	if cfg == nil {
+		return nil
+	}
+	c := &CircuitBreaker{
+		threshold: cfg.Threshold,
+		window:    time.Duration(cfg.Window) * time.Second,
+		coolDown:  time.Duration(cfg.CoolDown) * time.Second,
+		lock:      &sync.Mutex{},
+		hosts:     make(map[string]*hostBreaker),
+		now:       time.Now,
+	}
+	if c.threshold <= 0 {
+		c.threshold = 5
+	}
+	if c.window <= 0 {
+		c.window = time.Second * 30
+	}
+	if c.coolDown <= 0 {
+		c.coolDown = time.Second * 60
+	}
+	return c
+}
+
+// SetClock overrides the time source (for testing)
+func (c *CircuitBreaker) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+func (c *CircuitBreaker) host(host string) *hostBreaker {
+	h, ok := c.hosts[host]
+	if !ok {
+		h = &hostBreaker{}
+		c.hosts[host] = h
+	}
+	return h
+}
+
+// State of the breaker for host
+func (c *CircuitBreaker) State(host string) BreakerState {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.host(host).state
+}
+
+// Record a response status code for host
+func (c *CircuitBreaker) Record(host string, statusCode int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	h := c.host(host)
+	now := c.now()
+	if statusCode < 500 || statusCode > 599 {
+		h.state = BreakerClosed
+		h.failures = 0
+		h.probing = false
+		return
+	}
+
+	if h.state == BreakerHalfOpen {
+		// probe failed, pause again
+		h.state = BreakerOpen
+		h.openedAt = now
+		h.probing = false
+		return
+	}
+
+	if h.failures == 0 || now.Sub(h.firstFailed) > c.window {
+		h.failures = 0
+		h.firstFailed = now
+	}
+	h.failures++
+
+	if h.failures >= c.threshold && h.state == BreakerClosed {
+		h.state = BreakerOpen
+		h.openedAt = now
+	}
+}
+
+// RecordResult records all response status codes captured in a navigation result
+func (c *CircuitBreaker) RecordResult(result *browserk.NavigationResult) {
+	if result == nil {
+		return
+	}
+	for _, m := range result.Messages {
+		if m.Response == nil || m.Response.Response == nil {
+			continue
+		}
+		u, err := url.Parse(m.Response.Response.Url)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		c.Record(u.Host, m.Response.Response.Status)
+	}
+}
+
+// ProbeFinished releases a half open probe to host that completed without recording a response
+// from the host (errored or was never sent), so another probe is allowed through
+func (c *CircuitBreaker) ProbeFinished(host string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	h := c.host(host)
+	if h.state == BreakerHalfOpen {
+		h.probing = false
+	}
+}
+
+// Allow returns true if a navigation to host may proceed. Once the cool down
+// expires a single probe is allowed through, it's result determines if the breaker closes
+func (c *CircuitBreaker) Allow(host string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	h := c.host(host)
+	switch h.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if c.now().Sub(h.openedAt) < c.coolDown {
+			return false
+		}
+		h.state = BreakerHalfOpen
+		h.probing = true
+		return true
+	case BreakerHalfOpen:
+		if h.probing {
+			return false
+		}
+		h.probing = true
+		return true
+	}
+	return true
+}
+
+// Wait until navigations to host are allowed or the context is done
+func (c *CircuitBreaker) Wait(ctx context.Context, host string) error {
+	for !c.Allow(host) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	return nil
+}
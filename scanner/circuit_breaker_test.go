@@ -0,0 +1,98 @@
+package scanner_test
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/scanner"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := scanner.NewCircuitBreaker(&browserk.CircuitBreakerConfig{Threshold: 3, Window: 10, CoolDown: 30})
+	breaker.SetClock(func() time.Time { return now })
+
+	host := "example.com"
+	for i := 0; i < 2; i++ {
+		breaker.Record(host, 503)
+	}
+	if !breaker.Allow(host) {
+		t.Fatalf("breaker should not trip before threshold")
+	}
+
+	breaker.Record(host, 500)
+	if breaker.State(host) != scanner.BreakerOpen {
+		t.Fatalf("expected breaker to be open after 3 consecutive 5xx")
+	}
+
+	if breaker.Allow(host) {
+		t.Fatalf("breaker should not allow navigations while open")
+	}
+
+	if !breaker.Allow("other.com") {
+		t.Fatalf("breaker should be per host")
+	}
+
+	now = now.Add(time.Second * 31)
+	if !breaker.Allow(host) {
+		t.Fatalf("expected probe to be allowed after cool down")
+	}
+
+	if breaker.Allow(host) {
+		t.Fatalf("only a single probe should be allowed while half open")
+	}
+
+	breaker.Record(host, 200)
+	if breaker.State(host) != scanner.BreakerClosed {
+		t.Fatalf("expected breaker to close after successful probe")
+	}
+
+	if !breaker.Allow(host) {
+		t.Fatalf("expected navigations to resume")
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	now := time.Now()
+	breaker := scanner.NewCircuitBreaker(&browserk.CircuitBreakerConfig{Threshold: 3, Window: 10, CoolDown: 30})
+	breaker.SetClock(func() time.Time { return now })
+
+	host := "example.com"
+	breaker.Record(host, 500)
+	breaker.Record(host, 500)
+	now = now.Add(time.Second * 11)
+	breaker.Record(host, 500)
+	if breaker.State(host) != scanner.BreakerClosed {
+		t.Fatalf("5xx responses outside of the window should not trip the breaker")
+	}
+
+	breaker.Record(host, 500)
+	breaker.Record(host, 200)
+	breaker.Record(host, 500)
+	if breaker.State(host) != scanner.BreakerClosed {
+		t.Fatalf("non 5xx responses should reset the count")
+	}
+}
+
+func TestCircuitBreakerProbeFinished(t *testing.T) {
+	now := time.Now()
+	breaker := scanner.NewCircuitBreaker(&browserk.CircuitBreakerConfig{Threshold: 1, Window: 10, CoolDown: 30})
+	breaker.SetClock(func() time.Time { return now })
+
+	host := "example.com"
+	breaker.Record(host, 503)
+	now = now.Add(time.Second * 31)
+	if !breaker.Allow(host) {
+		t.Fatalf("expected probe to be allowed after cool down")
+	}
+
+	// the probe navigation failed before the host responded
+	breaker.ProbeFinished(host)
+	if breaker.State(host) != scanner.BreakerHalfOpen {
+		t.Fatalf("expected breaker to remain half open without a response")
+	}
+	if !breaker.Allow(host) {
+		t.Fatalf("expected another probe once the previous one finished without a response")
+	}
+}
@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// ValidateStrategy returns an error if strategy is not a known interaction strategy, empty
+// is allowed and means forms-first
+func ValidateStrategy(strategy string) error {
+	switch strategy {
+	case "", browserk.FormsFirst, browserk.LinksFirst, browserk.Mixed:
+		return nil
+	}
+	return fmt.Errorf("unknown interaction strategy: %s", strategy)
+}
+
+// ScoreNavigation ranks how likely a navigation is to lead to new application state. The
+// interactable enumerator doesn't score elements itself, so they are ranked by kind (forms,
+// buttons, links then other interactables) and the interactive event listeners it captured.
+func ScoreNavigation(nav *browserk.Navigation) int {
+	return ScoreNavigationFor(browserk.FormsFirst, nav)
+}
+
+// ScoreNavigationFor ranks the navigation according to the interaction strategy, under mixed
+// all visible elements score the same so they keep the order they were found in
+func ScoreNavigationFor(strategy string, nav *browserk.Navigation) int {
+	if nav == nil || nav.Action == nil {
+		return 0
+	}
+
+	isForm := nav.Action.Type == browserk.ActFillForm
+	isButton := nav.Action.Element != nil && nav.Action.Element.Type == browserk.BUTTON
+	isLink := nav.Action.Element != nil && nav.Action.Element.Type == browserk.A
+
+	score := 40
+	switch strategy {
+	case browserk.Mixed:
+	case browserk.LinksFirst:
+		switch {
+		case isLink:
+			score = 100
+		case isForm:
+			score = 80
+		case isButton:
+			score = 60
+		}
+	default:
+		switch {
+		case isForm:
+			score = 100
+		case isButton:
+			score = 80
+		case isLink:
+			score = 60
+		}
+	}
+
+	if strategy != browserk.Mixed && nav.Action.Element != nil {
+		score += ListenerScore(nav.Action.Element.Listeners)
+	}
+
+	if nav.Action.Element != nil && nav.Action.Element.Hidden {
+		score -= 20
+	}
+	return score
+}
+
+// interactiveEvents are listener types which respond to a user directly interacting with an element
+var interactiveEvents = map[string]struct{}{
+	"click": {}, "dblclick": {}, "mousedown": {}, "mouseup": {}, "submit": {},
+	"keydown": {}, "keyup": {}, "keypress": {}, "input": {}, "change": {},
+}
+
+// ListenerScore of an element's event listeners, 5 for each interactive event type handled up to
+// 15 so elements are only ranked within their kind (form, button, link)
+func ListenerScore(listeners map[string]int) int {
+	score := 0
+	for eventType, count := range listeners {
+		if _, ok := interactiveEvents[eventType]; ok && count > 0 {
+			score += 5
+		}
+	}
+	if score > 15 {
+		score = 15
+	}
+	return score
+}
+
+// OrderActions sorts navs by their score for the interaction strategy, navs with the same
+// score keep the order they were found in. The score is kept as each nav's priority so they
+// are also crawled in this order.
+func OrderActions(navs []*browserk.Navigation, strategy string) []*browserk.Navigation {
+	for _, nav := range navs {
+		nav.Priority = ScoreNavigationFor(strategy, nav)
+	}
+	sort.SliceStable(navs, func(i, j int) bool {
+		return navs[i].Priority > navs[j].Priority
+	})
+	return navs
+}
+
+// LimitActions orders navs by score and marks all but the first maxActions as deferred. Deferred
+// navigations are recorded in the crawl graph but never scheduled, bounding the crawl of pages
+// with many elements. A maxActions of 0 or less disables the limit.
+func LimitActions(navs []*browserk.Navigation, maxActions int) []*browserk.Navigation {
+	return LimitActionsFor(browserk.FormsFirst, navs, maxActions)
+}
+
+// LimitActionsFor orders navs by the interaction strategy and defers all but the first maxActions
+func LimitActionsFor(strategy string, navs []*browserk.Navigation, maxActions int) []*browserk.Navigation {
+	OrderActions(navs, strategy)
+	if maxActions <= 0 {
+		return navs
+	}
+	DeferActions(navs, maxActions)
+	return navs
+}
+
+// DeferActions marks the unvisited navs after the first allowed as deferred
+func DeferActions(navs []*browserk.Navigation, allowed int) {
+	if allowed >= len(navs) {
+		return
+	}
+
+	for _, nav := range navs[allowed:] {
+		if nav.State == browserk.NavUnvisited {
+			nav.State = browserk.NavDeferred
+		}
+	}
+}
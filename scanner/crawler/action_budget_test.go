@@ -0,0 +1,289 @@
+package crawler_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/browser"
+	"gitlab.com/browserker/scanner/crawler"
+	"gitlab.com/browserker/store"
+)
+
+func TestLimitActions(t *testing.T) {
+	from := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://example.com")})
+
+	navs := make([]*browserk.Navigation, 0)
+	for i := 0; i < 20; i++ {
+		ele := &browserk.HTMLElement{Type: browserk.A, Attributes: map[string]string{"href": fmt.Sprintf("/%d", i)}}
+		navs = append(navs, browserk.NewNavigationFromElement(from, browserk.TrigCrawler, ele, browserk.ActLeftClick))
+	}
+
+	for i := 0; i < 30; i++ {
+		ele := &browserk.HTMLElement{Type: browserk.BUTTON, Attributes: map[string]string{"id": fmt.Sprintf("btn%d", i)}}
+		navs = append(navs, browserk.NewNavigationFromElement(from, browserk.TrigCrawler, ele, browserk.ActLeftClick))
+	}
+
+	limit := 5
+	navs = crawler.LimitActions(navs, limit)
+	if len(navs) != 50 {
+		t.Fatalf("expected deferred navs to still be recorded got %d", len(navs))
+	}
+
+	actioned := 0
+	deferred := 0
+	for i, nav := range navs {
+		switch nav.State {
+		case browserk.NavUnvisited:
+			actioned++
+			if nav.Action.Element.Type != browserk.BUTTON {
+				t.Fatalf("expected buttons to be prioritized over links at %d", i)
+			}
+		case browserk.NavDeferred:
+			deferred++
+		}
+	}
+
+	if actioned != limit {
+		t.Fatalf("expected %d actioned navs got %d", limit, actioned)
+	}
+
+	if deferred != 45 {
+		t.Fatalf("expected 45 deferred navs got %d", deferred)
+	}
+
+	unlimited := crawler.LimitActions(navs[:2], 0)
+	if len(unlimited) != 2 {
+		t.Fatalf("expected no limit to return all navs")
+	}
+}
+
+func TestCrawlerActionBudget(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b, port, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	defer pool.Return(ctx, port)
+
+	p, srv := testServer("/result/formResult", nil)
+	defer srv.Shutdown(ctx)
+	target := fmt.Sprintf("http://localhost:%s/budget/buttons.html", p)
+	targetURL, _ := url.Parse(target)
+	bCtx.Scope = scanner.NewScopeService(targetURL)
+
+	limit := 5
+	crawl := crawler.New(&browserk.Config{MaxActionsPerState: limit})
+	if err := crawl.Init(); err != nil {
+		t.Fatalf("error initializing crawler: %s\n", err)
+	}
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target))
+	_, newNavs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	actioned := 0
+	deferred := 0
+	for _, newNav := range newNavs {
+		switch newNav.State {
+		case browserk.NavUnvisited:
+			actioned++
+		case browserk.NavDeferred:
+			deferred++
+		}
+	}
+
+	if actioned != limit {
+		t.Fatalf("expected %d of the page's buttons to be actioned got %d", limit, actioned)
+	}
+
+	if deferred < 40-limit {
+		t.Fatalf("expected the remaining buttons to be recorded as deferred got %d", deferred)
+	}
+}
+
+func TestInteractionHistoryAllot(t *testing.T) {
+	history := crawler.NewInteractionHistory()
+	state := crawler.StateHash("http://example.com/", nil)
+
+	if allotted := history.Allot(state, 3, 5); allotted != 3 {
+		t.Fatalf("expected all 3 actions to be allotted got %d", allotted)
+	}
+
+	if allotted := history.Allot(state, 3, 5); allotted != 2 {
+		t.Fatalf("expected revisiting the state to only allot the remaining 2 actions got %d", allotted)
+	}
+
+	if allotted := history.Allot(state, 3, 5); allotted != 0 {
+		t.Fatalf("expected the state's budget to be spent got %d", allotted)
+	}
+
+	other := crawler.StateHash("http://example.com/other", nil)
+	if allotted := history.Allot(other, 3, 5); allotted != 3 {
+		t.Fatalf("expected the budget to be per state got %d", allotted)
+	}
+}
+
+func TestCrawlerInteractionStrategy(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b := mock.MakeMockBrowser()
+	// elements are only found after the action, so they are new
+	loaded := false
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		loaded = true
+		return nil, true, nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		if querySelector != "a" || !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLElement{
+			{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8080/about.html"}},
+			{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8080/contact.html"}},
+		}, nil
+	}
+	b.FindFormsFn = func() ([]*browserk.HTMLFormElement, error) {
+		if !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLFormElement{
+			{Attributes: map[string]string{"action": "http://localhost:8080/search"}, Events: map[string]browserk.HTMLEventType{}},
+		}, nil
+	}
+
+	process := func(strategy string) []*browserk.Navigation {
+		loaded = false
+		crawl := crawler.New(&browserk.Config{InteractionStrategy: strategy, MaxActionsPerState: 1})
+		if err := crawl.Init(); err != nil {
+			t.Fatalf("error initializing crawler: %s\n", err)
+		}
+		nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+		_, navs, err := crawl.Process(bCtx, b, nav, true)
+		if err != nil {
+			t.Fatalf("error processing nav: %s\n", err)
+		}
+		if len(navs) != 3 {
+			t.Fatalf("expected 3 navs got %d", len(navs))
+		}
+		return navs
+	}
+
+	navs := process(browserk.FormsFirst)
+	if navs[0].Action.Type != browserk.ActFillForm || navs[0].State != browserk.NavUnvisited {
+		t.Fatalf("expected form to be submitted first under forms-first")
+	}
+	for _, nav := range navs[1:] {
+		if nav.State != browserk.NavDeferred {
+			t.Fatalf("expected sibling links to be deferred until after the form")
+		}
+	}
+
+	navs = process(browserk.LinksFirst)
+	if navs[0].Action.Element == nil || navs[0].Action.Element.Type != browserk.A || navs[0].State != browserk.NavUnvisited {
+		t.Fatalf("expected a link to be clicked first under links-first")
+	}
+	if navs[2].Action.Type != browserk.ActFillForm || navs[2].State != browserk.NavDeferred {
+		t.Fatalf("expected form to be deferred under links-first")
+	}
+
+	if err := crawler.New(&browserk.Config{InteractionStrategy: "random"}).Init(); err == nil {
+		t.Fatalf("expected unknown strategy to fail")
+	}
+}
+
+func TestCrawlerInteractionStrategyCrawlOrder(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b := mock.MakeMockBrowser()
+	loaded := false
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		loaded = true
+		return nil, true, nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		if querySelector != "a" || !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLElement{
+			{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8080/about.html"}},
+			{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8080/contact.html"}},
+		}, nil
+	}
+	b.FindFormsFn = func() ([]*browserk.HTMLFormElement, error) {
+		if !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLFormElement{
+			{Attributes: map[string]string{"action": "http://localhost:8080/search"}, Events: map[string]browserk.HTMLEventType{}},
+		}, nil
+	}
+
+	// without an action budget the strategy still decides which navigation is crawled first
+	crawl := crawler.New(&browserk.Config{InteractionStrategy: browserk.FormsFirst})
+	if err := crawl.Init(); err != nil {
+		t.Fatalf("error initializing crawler: %s\n", err)
+	}
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+	_, navs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	graph := store.NewMemoryCrawlGraph()
+	graph.Init()
+	nav.State = browserk.NavVisited
+	graph.AddNavigation(nav)
+	if err := graph.AddNavigations(navs); err != nil {
+		t.Fatalf("error adding navs: %s\n", err)
+	}
+
+	next := graph.Find(context.Background(), browserk.NavUnvisited, browserk.NavInProcess, 1)
+	if len(next) != 1 || next[0][len(next[0])-1].Action.Type != browserk.ActFillForm {
+		t.Fatalf("expected the form to be crawled before sibling links")
+	}
+}
+
+func TestScoreNavigationListeners(t *testing.T) {
+	from := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://example.com")})
+
+	plain := &browserk.HTMLElement{Type: browserk.BUTTON, Attributes: map[string]string{"id": "plain"}}
+	handled := &browserk.HTMLElement{Type: browserk.BUTTON, Attributes: map[string]string{"id": "handled"}, Listeners: map[string]int{"click": 2, "keydown": 1, "mouseover": 1}}
+	link := &browserk.HTMLElement{Type: browserk.A, Attributes: map[string]string{"href": "/"}, Listeners: map[string]int{"click": 1, "keydown": 1, "input": 1, "change": 1}}
+
+	plainScore := crawler.ScoreNavigation(browserk.NewNavigationFromElement(from, browserk.TrigCrawler, plain, browserk.ActLeftClick))
+	handledScore := crawler.ScoreNavigation(browserk.NewNavigationFromElement(from, browserk.TrigCrawler, handled, browserk.ActLeftClick))
+	linkScore := crawler.ScoreNavigation(browserk.NewNavigationFromElement(from, browserk.TrigCrawler, link, browserk.ActLeftClick))
+
+	if handledScore != plainScore+10 {
+		t.Fatalf("expected click and keydown listeners to add 10 got %d vs %d", handledScore, plainScore)
+	}
+
+	if linkScore >= plainScore {
+		t.Fatalf("expected listeners not to rank a link above a button got %d vs %d", linkScore, plainScore)
+	}
+
+	mixed := crawler.ScoreNavigationFor(browserk.Mixed, browserk.NewNavigationFromElement(from, browserk.TrigCrawler, handled, browserk.ActLeftClick))
+	if mixed != crawler.ScoreNavigationFor(browserk.Mixed, browserk.NewNavigationFromElement(from, browserk.TrigCrawler, plain, browserk.ActLeftClick)) {
+		t.Fatalf("expected mixed strategy to ignore listeners")
+	}
+}
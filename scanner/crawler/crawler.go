@@ -2,6 +2,8 @@ package crawler
 
 import (
 	"context"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -10,17 +12,58 @@ import (
 
 // BrowserkCrawler crawls a site
 type BrowserkCrawler struct {
-	cfg *browserk.Config
+	cfg            *browserk.Config
+	skipExtensions map[string]struct{}
+	overlays       []string // selectors of overlay buttons to click
+	overlayText    []string // text of overlay buttons to click
+	history        *InteractionHistory
+	normalizer     browserk.URLNormalizer
 }
 
 // New crawler for a site
 func New(cfg *browserk.Config) *BrowserkCrawler {
-	return &BrowserkCrawler{cfg: cfg}
+	extensions := cfg.SkipExtensions
+	if extensions == nil {
+		extensions = DefaultSkipExtensions
+	}
+	overlays := cfg.OverlaySelectors
+	if overlays == nil {
+		overlays = DefaultOverlaySelectors
+	}
+	overlayText := cfg.OverlayButtonText
+	if overlayText == nil {
+		overlayText = DefaultOverlayButtonText
+	}
+	return &BrowserkCrawler{
+		cfg:            cfg,
+		skipExtensions: newExtensionSet(extensions),
+		overlays:       overlays,
+		overlayText:    overlayText,
+		history:        NewInteractionHistory(),
+		normalizer:     browserk.NewURLNormalizer(cfg.URLNormalization),
+	}
+}
+
+// SetInteractionHistory replaces the crawler's own history so elements interacted with by
+// other crawlers are not interacted with again in the same page state
+func (b *BrowserkCrawler) SetInteractionHistory(history *InteractionHistory) *BrowserkCrawler {
+	b.history = history
+	return b
+}
+
+// SetURLNormalizer replaces the crawler's normalizer so links are deduplicated the same way
+// urls are scope checked
+func (b *BrowserkCrawler) SetURLNormalizer(normalizer browserk.URLNormalizer) *BrowserkCrawler {
+	b.normalizer = normalizer
+	return b
 }
 
 // Init the crawler, if necessary
 func (b *BrowserkCrawler) Init() error {
-	return nil
+	if err := ValidateStrategy(b.cfg.InteractionStrategy); err != nil {
+		return err
+	}
+	return ValidateScheme(b.cfg.RestrictScheme)
 }
 
 // Process the next navigation entry
@@ -34,9 +77,11 @@ func (b *BrowserkCrawler) Process(bctx *browserk.Context, browser browserk.Brows
 	}
 	startCookies, err := browser.GetCookies()
 
-	//clear out storage and console events before executing our action
+	//clear out storage, console events and network timings before executing our action
 	browser.GetStorageEvents()
 	browser.GetConsoleEvents()
+	browser.NetworkSummary()
+	blockedUnloads(browser)
 
 	if isFinal {
 		diff = b.snapshot(bctx, browser)
@@ -50,6 +95,10 @@ func (b *BrowserkCrawler) Process(bctx *browserk.Context, browser browserk.Brows
 		Cookies:      startCookies,
 	}
 
+	if entry.Action.Type == browserk.ActLoadURL {
+		setReferrer(browser, startURL)
+	}
+
 	// execute the action
 	navCtx, cancel := context.WithTimeout(bctx.Ctx, time.Second*15)
 	defer cancel()
@@ -59,6 +108,28 @@ func (b *BrowserkCrawler) Process(bctx *browserk.Context, browser browserk.Brows
 		result.WasError = true
 		return result, nil, err
 	}
+	b.history.Executed(entry)
+
+	// beforeunload prompts are always accepted so the crawler can leave, optionally they're reported
+	if blocked := blockedUnloads(browser); b.cfg.ReportBeforeUnload && len(blocked) > 0 {
+		b.reportBlockedUnloads(bctx, blocked)
+	}
+
+	loaded := result.CausedLoad || entry.Action.Type == browserk.ActLoadURL
+	if b.cfg.PostNavigationDelay > 0 && loaded {
+		b.settle(bctx)
+	}
+
+	// pages too large to extract are recorded without their DOM and not expanded
+	if result.SkipReason = domTooLarge(bctx, browser, b.cfg.MaxDOMNodes); result.SkipReason != "" {
+		b.buildResult(result, beforeAction, browser)
+		return result, nil, nil
+	}
+
+	// dismiss overlays before elements are extracted, so they aren't interacted with instead of the page
+	if b.cfg.AutoDismissOverlays && loaded {
+		result.Overlays = DismissOverlays(bctx, browser, b.overlays, b.overlayText)
+	}
 
 	// capture results
 	b.buildResult(result, beforeAction, browser)
@@ -66,20 +137,51 @@ func (b *BrowserkCrawler) Process(bctx *browserk.Context, browser browserk.Brows
 	// find new potential navigation entries (if isFinal)
 	potentialNavs := make([]*browserk.Navigation, 0)
 	if isFinal {
+		if b.cfg.MaxScrolls > 0 {
+			ScrollToEnd(bctx, browser, b.cfg.MaxScrolls)
+		}
 		potentialNavs = b.FindNewNav(bctx, diff, entry, browser)
 	}
 	return result, potentialNavs, nil
 }
 
+// ReferrerSetter is implemented by browsers that can send a referrer with the next load url action
+type ReferrerSetter interface {
+	SetExtraNavigationReferrer(referrer string)
+}
+
+// setReferrer of the next load url action to the url of the page it was found on, so server side
+// referrer checks pass
+func setReferrer(browser browserk.Browser, pageURL string) {
+	setter, ok := browser.(ReferrerSetter)
+	if !ok || !strings.HasPrefix(pageURL, "http") {
+		return
+	}
+	setter.SetExtraNavigationReferrer(pageURL)
+}
+
+// settle waits for the configured post navigation delay so late rendering apps can finish
+func (b *BrowserkCrawler) settle(bctx *browserk.Context) {
+	timer := time.NewTimer(b.cfg.PostNavigationDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-bctx.Ctx.Done():
+	}
+}
+
 // buildResult captures various data points after we executed an Action
 func (b *BrowserkCrawler) buildResult(result *browserk.NavigationResult, start time.Time, browser browserk.Browser) {
 	messages, err := browser.GetMessages()
 	result.AddError(err)
 	result.Messages = browserk.MessagesAfterRequestTime(messages, start)
 	result.MessageCount = len(result.Messages)
-	dom, err := browser.GetDOM()
-	result.AddError(err)
-	result.DOM = dom
+	if result.SkipReason == "" {
+		dom, err := browser.GetDOM()
+		result.AddError(err)
+		result.DOM = dom
+	}
 	endURL, err := browser.GetURL()
 	result.AddError(err)
 	result.EndURL = endURL
@@ -88,6 +190,7 @@ func (b *BrowserkCrawler) buildResult(result *browserk.NavigationResult, start t
 	result.Cookies = browserk.DiffCookies(result.Cookies, cookies)
 	result.StorageEvents = browser.GetStorageEvents()
 	result.ConsoleEvents = browser.GetConsoleEvents()
+	result.Network = browser.NetworkSummary()
 	result.Hash()
 }
 
@@ -142,9 +245,16 @@ func (b *BrowserkCrawler) FindNewNav(bctx *browserk.Context, diff *ElementDiffer
 		bctx.Log.Info().Err(err).Msg("error while extracting forms")
 	}
 
+	currentURL, _ := browser.GetURL()
 	for _, form := range formElements {
 		scope := bctx.Scope.ResolveBaseHref(baseHref, form.GetAttribute("action"))
 		if scope == browserk.InScope && !diff.Has(browserk.FORM, form.Hash()) {
+			if !b.schemeAllowed(bctx, currentURL, b.linkURL(baseHref, currentURL, form.GetAttribute("action"))) {
+				continue
+			}
+			if b.cfg.ProbeFormsNonDestructive {
+				b.probeForm(bctx, browser, form)
+			}
 			nav := browserk.NewNavigationFromForm(entry, browserk.TrigCrawler, form)
 			bctx.FormHandler.Fill(form)
 			navs = append(navs, nav)
@@ -176,9 +286,19 @@ func (b *BrowserkCrawler) FindNewNav(bctx *browserk.Context, diff *ElementDiffer
 	for _, a := range aElements {
 		scope := bctx.Scope.ResolveBaseHref(baseHref, a.GetAttribute("href"))
 		if scope == browserk.InScope && !diff.Has(browserk.A, a.Hash()) {
+			linkURL := b.linkURL(baseHref, currentURL, a.GetAttribute("href"))
+			if !b.schemeAllowed(bctx, currentURL, linkURL) {
+				continue
+			}
 			bctx.Log.Info().Str("baseHref", baseHref).Str("href", a.Attributes["href"]).Msg("in scope, adding")
-			nav := browserk.NewNavigationFromElement(entry, browserk.TrigCrawler, a, browserk.ActLeftClick)
+			nav := browserk.NewNavigationFromLink(entry, browserk.TrigCrawler, a, linkURL)
 			nav.Scope = scope
+			if hasExtension(a.GetAttribute("href"), b.skipExtensions) {
+				nav.State = browserk.NavSkipped
+				if b.cfg.HeadSkippedLinks {
+					b.headSkipped(bctx, browser, baseHref, a.GetAttribute("href"))
+				}
+			}
 			navs = append(navs, nav)
 		} /* else {
 			bctx.Log.Debug().Str("baseHref", baseHref).Str("linkHref", a.GetAttribute("href")).Msg("a element was out of scope, not creating new nav")
@@ -226,5 +346,79 @@ func (b *BrowserkCrawler) FindNewNav(bctx *browserk.Context, diff *ElementDiffer
 		}
 	}
 	// todo pull out additional clickable/whateverable elements
+	if len(b.cfg.InteractOnly) > 0 {
+		navs = b.interactOnly(bctx, browser, navs)
+	}
+	navs, state := b.notInteracted(bctx, browser, navs, formElements, bElements, aElements, cElements)
+	navs = OrderActions(navs, b.cfg.InteractionStrategy)
+	if b.cfg.MaxActionsPerState > 0 {
+		DeferActions(navs, b.history.Allot(state, len(navs), b.cfg.MaxActionsPerState))
+	}
 	return navs
 }
+
+// probeForm fills the form without submitting it, adding fields which appeared to the form so
+// they are filled and attacked along with the rest
+func (b *BrowserkCrawler) probeForm(bctx *browserk.Context, browser browserk.Browser, form *browserk.HTMLFormElement) {
+	bctx.FormHandler.Fill(form)
+	discovered, err := browser.ProbeForm(bctx.Ctx, form)
+	if err != nil {
+		bctx.Log.Warn().Err(err).Msg("failed to probe form")
+	}
+
+	if len(discovered) == 0 {
+		return
+	}
+	bctx.Log.Info().Int("fields", len(discovered)).Str("action", form.GetAttribute("action")).Msg("probing form discovered dynamic fields")
+	form.ChildElements = append(form.ChildElements, discovered...)
+}
+
+// linkURL resolves href against the document's base href (or current url) and normalizes it,
+// returns empty if it can't be resolved to an http(s) url
+func (b *BrowserkCrawler) linkURL(baseHref, currentURL, href string) string {
+	base := currentURL
+	if strings.HasPrefix(baseHref, "http") {
+		base = baseHref
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+
+	resolved := baseURL.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return b.normalizer.Normalize(resolved.String())
+}
+
+// notInteracted removes navs whose element was already interacted with in the current page state,
+// returning the remaining navs and the state
+func (b *BrowserkCrawler) notInteracted(bctx *browserk.Context, browser browserk.Browser, navs []*browserk.Navigation, forms []*browserk.HTMLFormElement, elements ...[]*browserk.HTMLElement) ([]*browserk.Navigation, []byte) {
+	fingerprints := make([][]byte, 0)
+	for _, form := range forms {
+		fingerprints = append(fingerprints, form.Hash())
+	}
+	for _, eles := range elements {
+		for _, ele := range eles {
+			fingerprints = append(fingerprints, ele.Hash())
+		}
+	}
+	currentURL, _ := browser.GetURL()
+	state := StateHash(currentURL, fingerprints)
+
+	filtered := make([]*browserk.Navigation, 0, len(navs))
+	for _, nav := range navs {
+		if b.history.Interacted(state, nav) {
+			bctx.Log.Debug().Str("action", nav.Action.String()).Msg("already interacted with element in this state")
+			continue
+		}
+		filtered = append(filtered, nav)
+	}
+	return filtered, state
+}
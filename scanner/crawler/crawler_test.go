@@ -28,6 +28,10 @@ var leaser = browser.NewLocalLeaser()
 func testServer(path string, fn gin.HandlerFunc) (string, *http.Server) {
 	router := gin.Default()
 	router.Static("/forms", "testdata/forms")
+	router.Static("/scroll", "testdata/scroll")
+	router.Static("/overlay", "testdata/overlay")
+	router.Static("/dom", "testdata/dom")
+	router.Static("/budget", "testdata/budget")
 	if fn != nil {
 		router.Any(path, fn)
 	}
@@ -194,3 +198,88 @@ func TestCrawler(t *testing.T) {
 	}
 
 }
+
+func TestCrawlerInfiniteScroll(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b, port, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	defer pool.Return(ctx, port)
+
+	p, srv := testServer("/result/formResult", nil)
+	defer srv.Shutdown(ctx)
+	target := fmt.Sprintf("http://localhost:%s/scroll/infinite.html", p)
+	targetURL, _ := url.Parse(target)
+	bCtx.Scope = scanner.NewScopeService(targetURL)
+
+	crawl := crawler.New(&browserk.Config{MaxScrolls: 5})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target))
+	_, newNavs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	found := false
+	for _, newNav := range newNavs {
+		if newNav.Action.Element != nil && newNav.Action.Element.Attributes["href"] == "/scroll/item35.html" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected lazily loaded links to be discovered, got %d navs", len(newNavs))
+	}
+}
+
+// records the referrer set before each action
+type referrerBrowser struct {
+	*mock.Browser
+	referrer string
+}
+
+func (r *referrerBrowser) SetExtraNavigationReferrer(referrer string) {
+	r.referrer = referrer
+}
+
+func TestCrawlerSetsReferrer(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+
+	b := &referrerBrowser{Browser: mock.MakeMockBrowser()}
+	b.GetURLFn = func() (string, error) {
+		return "http://localhost:8080/index.html", nil
+	}
+
+	crawl := crawler.New(&browserk.Config{})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction("http://localhost:8080/deeplink"))
+	if _, _, err := crawl.Process(bCtx, b, nav, false); err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if b.referrer != "http://localhost:8080/index.html" {
+		t.Fatalf("expected referrer to be the originating page got %s", b.referrer)
+	}
+
+	// the first navigation has no page to refer from
+	b.referrer = ""
+	b.GetURLFn = func() (string, error) {
+		return "about:blank", nil
+	}
+	if _, _, err := crawl.Process(bCtx, b, nav, false); err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if b.referrer != "" {
+		t.Fatalf("expected no referrer from a blank page got %s", b.referrer)
+	}
+}
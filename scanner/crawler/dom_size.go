@@ -0,0 +1,33 @@
+package crawler
+
+import (
+	"fmt"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// DOMCounter is implemented by browsers that can count the nodes of the page's DOM
+type DOMCounter interface {
+	DOMNodeCount() (int, error)
+}
+
+// domTooLarge returns why the page is skipped if its DOM has more than maxNodes nodes, empty if
+// it can be extracted
+func domTooLarge(bctx *browserk.Context, browser browserk.Browser, maxNodes int) string {
+	counter, ok := browser.(DOMCounter)
+	if !ok || maxNodes <= 0 {
+		return ""
+	}
+
+	nodes, err := counter.DOMNodeCount()
+	if err != nil {
+		bctx.Log.Warn().Err(err).Msg("failed to count dom nodes")
+		return ""
+	}
+
+	if nodes <= maxNodes {
+		return ""
+	}
+	bctx.Log.Warn().Int("nodes", nodes).Int("max", maxNodes).Msg("dom too large, skipping extraction")
+	return fmt.Sprintf("dom too large: %d nodes exceeds the maximum of %d", nodes, maxNodes)
+}
@@ -0,0 +1,100 @@
+package crawler_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/browser"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+// reports a fixed number of dom nodes
+type countingBrowser struct {
+	*mock.Browser
+	nodes int
+}
+
+func (c *countingBrowser) DOMNodeCount() (int, error) {
+	return c.nodes, nil
+}
+
+func TestCrawlerSkipsLargeDOM(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b := &countingBrowser{Browser: mock.MakeMockBrowser(), nodes: 500000}
+	b.GetURLFn = func() (string, error) {
+		return "http://localhost:8080/", nil
+	}
+
+	crawl := crawler.New(&browserk.Config{MaxDOMNodes: 100000})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+	result, navs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if !strings.Contains(result.SkipReason, "500000 nodes") {
+		t.Fatalf("expected page to be recorded as too large got %s", result.SkipReason)
+	}
+
+	if b.GetDOMCalled || len(navs) != 0 {
+		t.Fatalf("expected large page to not be extracted")
+	}
+
+	b.nodes = 1000
+	result, _, err = crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if result.SkipReason != "" || !b.GetDOMCalled {
+		t.Fatalf("expected page under the limit to be extracted got %s", result.SkipReason)
+	}
+}
+
+func TestCrawlerSkipsHugeDOMFixture(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b, port, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	defer pool.Return(ctx, port)
+
+	p, srv := testServer("/result/formResult", nil)
+	defer srv.Shutdown(ctx)
+	target := fmt.Sprintf("http://localhost:%s/dom/huge.html", p)
+	targetURL, _ := url.Parse(target)
+	bCtx.Scope = scanner.NewScopeService(targetURL)
+
+	crawl := crawler.New(&browserk.Config{MaxDOMNodes: 50000})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target))
+	result, newNavs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	if !strings.HasPrefix(result.SkipReason, "dom too large") {
+		t.Fatalf("expected huge dom to be recorded as too large got %q", result.SkipReason)
+	}
+
+	if result.DOM != "" || len(newNavs) != 0 {
+		t.Fatalf("expected huge dom to not be extracted got %d navs", len(newNavs))
+	}
+}
@@ -14,12 +14,18 @@ import (
 // CrawlerFormHandler handles filling forms
 type CrawlerFormHandler struct {
 	formData *browserk.FormData
+	random   *browserk.Random
 }
 
 // NewCrawlerFormHandler will fill forms based on the provided formData and determining
 // context for each form input
 func NewCrawlerFormHandler(formData *browserk.FormData) *CrawlerFormHandler {
-	return &CrawlerFormHandler{formData: formData}
+	return &CrawlerFormHandler{formData: formData, random: browserk.NewRandom(0)}
+}
+
+// SetRandom source for generated values, so the same seed fills the same values
+func (c *CrawlerFormHandler) SetRandom(random *browserk.Random) {
+	c.random = random
 }
 
 // Init the form filler
@@ -192,9 +198,11 @@ func (c *CrawlerFormHandler) GetSuggestedInput(input *InputDetails) string {
 		return ""
 	case "tel":
 		if input.Pattern != "" {
-			result, err := regen.Generate(input.Pattern)
-			if err != nil {
-				return result
+			generator, err := regen.NewGenerator(input.Pattern, &regen.GeneratorArgs{
+				RngSource: c.random.Source(input.Name + input.ID + input.Pattern),
+			})
+			if err == nil {
+				return generator.Generate()
 			}
 		}
 		return c.formData.PhoneNumber
@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"gitlab.com/browserker/browserk"
+)
+
+// interactOnly removes navigations which act on elements not matching one of Config.InteractOnly's
+// selectors. Links are always kept so the crawl still follows them to reach the elements.
+func (b *BrowserkCrawler) interactOnly(bctx *browserk.Context, browser browserk.Browser, navs []*browserk.Navigation) []*browserk.Navigation {
+	allowed := make(map[string]struct{})
+	for _, selector := range b.cfg.InteractOnly {
+		elements, err := browser.FindElements(selector)
+		if err != nil {
+			bctx.Log.Warn().Err(err).Str("selector", selector).Msg("failed to find elements to interact with")
+			continue
+		}
+
+		for _, ele := range elements {
+			allowed[string(ele.Hash())] = struct{}{}
+			// forms are hashed without their inner text
+			if ele.Type == browserk.FORM {
+				form := &browserk.HTMLFormElement{Attributes: ele.Attributes, Events: ele.Events}
+				allowed[string(form.Hash())] = struct{}{}
+			}
+		}
+	}
+
+	filtered := make([]*browserk.Navigation, 0, len(navs))
+	for _, nav := range navs {
+		act := nav.Action
+		switch {
+		case act.Form != nil:
+			if _, ok := allowed[string(act.Form.Hash())]; !ok {
+				continue
+			}
+		case act.Element != nil && act.Element.Type == browserk.A && act.Element.GetAttribute("href") != "":
+		case act.Element != nil:
+			if _, ok := allowed[string(act.Element.Hash())]; !ok {
+				continue
+			}
+		}
+		filtered = append(filtered, nav)
+	}
+	return filtered
+}
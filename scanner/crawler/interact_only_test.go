@@ -0,0 +1,74 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+func TestCrawlerInteractOnly(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	save := &browserk.HTMLElement{Type: browserk.BUTTON, Attributes: map[string]string{"id": "save"}, InnerText: "save"}
+	remove := &browserk.HTMLElement{Type: browserk.BUTTON, Attributes: map[string]string{"id": "delete"}, InnerText: "delete"}
+
+	b := mock.MakeMockBrowser()
+	// elements are only found after the action, so they are new
+	loaded := false
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		loaded = true
+		return nil, true, nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		if !loaded {
+			return nil, nil
+		}
+		switch querySelector {
+		case "button":
+			return []*browserk.HTMLElement{save, remove}, nil
+		case "#widget button":
+			return []*browserk.HTMLElement{{Type: browserk.BUTTON, Attributes: map[string]string{"id": "save"}, InnerText: "save"}}, nil
+		case "a":
+			return []*browserk.HTMLElement{{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8080/about.html"}}}, nil
+		}
+		return nil, nil
+	}
+
+	crawl := crawler.New(&browserk.Config{InteractOnly: []string{"#widget button"}})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+	_, navs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if len(navs) != 2 {
+		t.Fatalf("expected the matching button and the link got %d navs", len(navs))
+	}
+
+	for _, n := range navs {
+		ele := n.Action.Element
+		if ele.Type == browserk.BUTTON && ele.GetAttribute("id") != "save" {
+			t.Fatalf("expected only the matching button to be clicked got %s", ele.GetAttribute("id"))
+		}
+	}
+
+	// without an allowlist every button is clicked
+	loaded = false
+	crawl = crawler.New(&browserk.Config{})
+	_, navs, err = crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if len(navs) != 3 {
+		t.Fatalf("expected both buttons and the link got %d navs", len(navs))
+	}
+}
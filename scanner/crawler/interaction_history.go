@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/md5"
+	"sort"
+	"strconv"
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// InteractionHistory records the elements interacted with in each page state, so stateful
+// apps which return to a state we've already been in don't have the same elements clicked
+// over and over. It is safe to share between crawlers.
+type InteractionHistory struct {
+	lock    *sync.Mutex
+	seen    map[string]struct{}
+	pending map[string]string // navigation id -> interaction, until the navigation is executed
+	allowed map[string]int    // state -> actions allotted by the per state action budget
+}
+
+// NewInteractionHistory for sharing between crawlers
+func NewInteractionHistory() *InteractionHistory {
+	return &InteractionHistory{
+		lock:    &sync.Mutex{},
+		seen:    make(map[string]struct{}),
+		pending: make(map[string]string),
+		allowed: make(map[string]int),
+	}
+}
+
+// Interacted returns true if the same action was already executed on the navigation's element
+// in this state. Otherwise the state is remembered so the interaction is recorded once the
+// navigation is executed (see Executed). Navigations without an element (loading urls) are
+// never interacted with.
+func (h *InteractionHistory) Interacted(state []byte, nav *browserk.Navigation) bool {
+	key := interactionKey(state, nav)
+	if key == "" {
+		return false
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if _, exist := h.seen[key]; exist {
+		return true
+	}
+	h.pending[string(nav.ID)] = key
+	return false
+}
+
+// Executed records the interaction of a navigation checked by Interacted now that its action
+// has been executed
+func (h *InteractionHistory) Executed(nav *browserk.Navigation) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	key, ok := h.pending[string(nav.ID)]
+	if !ok {
+		return
+	}
+	delete(h.pending, string(nav.ID))
+	h.seen[key] = struct{}{}
+}
+
+// interactionKey of the navigation's action on its element in the state, empty if it has no element
+func interactionKey(state []byte, nav *browserk.Navigation) string {
+	if nav == nil || nav.Action == nil {
+		return ""
+	}
+
+	var fingerprint []byte
+	switch {
+	case nav.Action.Form != nil:
+		fingerprint = nav.Action.Form.Hash()
+	case nav.Action.Element != nil:
+		fingerprint = nav.Action.Element.Hash()
+	default:
+		return ""
+	}
+	return string(state) + strconv.Itoa(int(nav.Action.Type)) + string(fingerprint)
+}
+
+// Allot up to want actions in the state without exceeding max actions across every visit to
+// the state, returning how many were allotted
+func (h *InteractionHistory) Allot(state []byte, want, max int) int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	remaining := max - h.allowed[string(state)]
+	if remaining < 0 {
+		remaining = 0
+	}
+	if want < remaining {
+		remaining = want
+	}
+	h.allowed[string(state)] += remaining
+	return remaining
+}
+
+// StateHash of a page from its url and the fingerprints of its elements, the order of the
+// elements does not matter
+func StateHash(url string, fingerprints [][]byte) []byte {
+	sorted := make([][]byte, len(fingerprints))
+	copy(sorted, fingerprints)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	hash := md5.New()
+	hash.Write([]byte(url))
+	for _, fingerprint := range sorted {
+		hash.Write(fingerprint)
+	}
+	return hash.Sum(nil)
+}
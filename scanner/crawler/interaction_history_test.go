@@ -0,0 +1,76 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+func TestCrawlerInteractionHistory(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+
+	currentURL := "http://localhost:8080/#/cart"
+	b := mock.MakeMockBrowser()
+	b.GetURLFn = func() (string, error) {
+		return currentURL, nil
+	}
+	// the button is only found after the action, so it is new to each navigation
+	loaded := false
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		loaded = true
+		return nil, false, nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		if querySelector != "button" || !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLElement{
+			{Type: browserk.BUTTON, Attributes: map[string]string{"id": "add"}, InnerText: "Add to cart"},
+		}, nil
+	}
+
+	history := crawler.NewInteractionHistory()
+	process := func(entry *browserk.Navigation) []*browserk.Navigation {
+		loaded = false
+		crawl := crawler.New(&browserk.Config{}).SetInteractionHistory(history)
+		_, navs, err := crawl.Process(bCtx, b, entry, true)
+		if err != nil {
+			t.Fatalf("error processing nav: %s\n", err)
+		}
+		return navs
+	}
+
+	first := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(currentURL))
+	navs := process(first)
+	if len(navs) != 1 || navs[0].Action.Type != browserk.ActLeftClick {
+		t.Fatalf("expected button click nav got %d navs", len(navs))
+	}
+
+	// until it's clicked, other paths ending up in the same state still find it
+	second := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(currentURL))
+	if navs := process(second); len(navs) != 1 {
+		t.Fatalf("expected button which was not clicked yet to still be found got %d navs", len(navs))
+	}
+
+	// clicking it leaves us in the same unchanged state, where it should not be clicked again
+	if navs := process(navs[0]); len(navs) != 0 {
+		t.Fatalf("expected button in the same state to only be clicked once got %d navs", len(navs))
+	}
+
+	if navs := process(second); len(navs) != 0 {
+		t.Fatalf("expected button clicked in this state to not be found again got %d navs", len(navs))
+	}
+
+	// the same button in a new state is a new interaction
+	currentURL = "http://localhost:8080/#/checkout"
+	if navs := process(second); len(navs) != 1 {
+		t.Fatalf("expected button in a new state to be clicked got %d navs", len(navs))
+	}
+}
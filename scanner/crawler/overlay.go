@@ -0,0 +1,156 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// DefaultOverlaySelectors are the accept buttons of common consent management platforms
+var DefaultOverlaySelectors = []string{
+	"#onetrust-accept-btn-handler",
+	"#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll",
+	"#truste-consent-button",
+	".cc-btn.cc-allow",
+	".cc-btn.cc-dismiss",
+	"[data-cookiefirst-action='accept']",
+	"[aria-label='Accept cookies']",
+}
+
+// DefaultOverlayButtonText is the text of buttons which dismiss consent banners and newsletter
+// modals, compared case insensitively against the button's entire text. Only buttons inside an
+// overlay are matched (see OverlayLocator).
+var DefaultOverlayButtonText = []string{
+	"accept",
+	"accept all",
+	"accept cookies",
+	"accept all cookies",
+	"allow all",
+	"allow cookies",
+	"i agree",
+	"agree",
+	"got it",
+	"ok, got it",
+	"no thanks",
+	"no, thanks",
+}
+
+// overlayButtons are the elements whose text is checked against the button text heuristics
+const overlayButtons = "button, [role='button'], input[type='button']"
+
+// OverlayLocator is implemented by browsers that can tell if an element is inside an overlay, a
+// fixed or sticky positioned container or a modal dialog. Without it buttons are only found by
+// the overlay selectors.
+type OverlayLocator interface {
+	InOverlay(selector string) (bool, error)
+}
+
+// maxOverlays dismissed after a single navigation (e.g. a consent banner then a newsletter modal)
+const maxOverlays = 3
+
+// DismissOverlays clicks the buttons of consent banners and newsletter modals that block
+// interaction with the page. Buttons are found by selectors, then by their text matching one of
+// buttonText. If a click navigates to another page the browser is returned to the page and no
+// more overlays are dismissed. Returns the selector or text each clicked button was matched by.
+func DismissOverlays(bctx *browserk.Context, browser browserk.Browser, selectors, buttonText []string) []string {
+	dismissed := make([]string, 0)
+	clicked := make(map[string]struct{})
+	for i := 0; i < maxOverlays; i++ {
+		button, matched := findOverlayButton(browser, selectors, buttonText, clicked)
+		if button == nil {
+			break
+		}
+		clicked[string(button.Hash())] = struct{}{}
+
+		pageURL, _ := browser.GetURL()
+		ctx, cancel := context.WithTimeout(bctx.Ctx, time.Second*5)
+		_, causedLoad, err := browser.ExecuteAction(ctx, &browserk.Action{Type: browserk.ActLeftClick, Element: button})
+		cancel()
+		if err != nil {
+			bctx.Log.Warn().Err(err).Str("matched", matched).Msg("failed to dismiss overlay")
+			break
+		}
+		bctx.Log.Info().Str("matched", matched).Msg("dismissed overlay")
+		dismissed = append(dismissed, matched)
+
+		if causedLoad && returnToPage(bctx, browser, pageURL) {
+			break
+		}
+	}
+	return dismissed
+}
+
+// returnToPage navigates back to pageURL if dismissing an overlay navigated somewhere else, so
+// the result is built from the page. Returns true if the browser had left the page.
+func returnToPage(bctx *browserk.Context, browser browserk.Browser, pageURL string) bool {
+	current, err := browser.GetURL()
+	if err != nil || pageURL == "" || current == pageURL {
+		return false
+	}
+
+	bctx.Log.Info().Str("url", current).Str("page_url", pageURL).Msg("dismissing overlay navigated away, returning to page")
+	ctx, cancel := context.WithTimeout(bctx.Ctx, time.Second*15)
+	defer cancel()
+	if err := browser.Navigate(ctx, pageURL); err != nil {
+		bctx.Log.Warn().Err(err).Str("page_url", pageURL).Msg("failed to return to page after dismissing overlay")
+	}
+	return true
+}
+
+// findOverlayButton that is visible and hasn't already been clicked, returning the selector or
+// text it matched
+func findOverlayButton(browser browserk.Browser, selectors, buttonText []string, clicked map[string]struct{}) (*browserk.HTMLElement, string) {
+	usable := func(ele *browserk.HTMLElement) bool {
+		_, exists := clicked[string(ele.Hash())]
+		return !ele.Hidden && !exists
+	}
+
+	for _, selector := range selectors {
+		elements, err := browser.FindElements(selector)
+		if err != nil {
+			continue
+		}
+		for _, ele := range elements {
+			if usable(ele) {
+				return ele, selector
+			}
+		}
+	}
+
+	locator, ok := browser.(OverlayLocator)
+	if len(buttonText) == 0 || !ok {
+		return nil, ""
+	}
+
+	elements, err := browser.FindElements(overlayButtons)
+	if err != nil {
+		return nil, ""
+	}
+	for _, ele := range elements {
+		text := ele.InnerText
+		if ele.Type == browserk.INPUT {
+			text = ele.Attributes["value"]
+		}
+		text = strings.TrimSpace(text)
+		if !usable(ele) || !containsFold(buttonText, text) || ele.Selector == "" {
+			continue
+		}
+
+		// page buttons with the same text (e.g. a form's "Accept") are left alone
+		if in, err := locator.InOverlay(ele.Selector); err == nil && in {
+			return ele, text
+		}
+	}
+	return nil, ""
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
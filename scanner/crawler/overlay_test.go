@@ -0,0 +1,182 @@
+package crawler_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/browser"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+// overlayBrowser reports the elements of overlays by their selector
+type overlayBrowser struct {
+	*mock.Browser
+	overlays map[string]bool
+}
+
+func (o *overlayBrowser) InOverlay(selector string) (bool, error) {
+	return o.overlays[selector], nil
+}
+
+func TestDismissOverlays(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+
+	accept := &browserk.HTMLElement{Type: browserk.BUTTON, InnerText: " Accept All ", Selector: "#consent > button", Attributes: map[string]string{"class": "consent"}}
+	subscribe := &browserk.HTMLElement{Type: browserk.BUTTON, InnerText: "Subscribe", Selector: "#newsletter > button", Attributes: map[string]string{"class": "newsletter"}}
+	terms := &browserk.HTMLElement{Type: browserk.BUTTON, InnerText: "Accept", Selector: "form > button", Attributes: map[string]string{"class": "terms"}}
+	dismissed := make(map[*browserk.HTMLElement]bool)
+
+	mb := mock.MakeMockBrowser()
+	b := &overlayBrowser{Browser: mb, overlays: map[string]bool{"#consent > button": true, "#newsletter > button": true}}
+	mb.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		elements := make([]*browserk.HTMLElement, 0)
+		for _, ele := range []*browserk.HTMLElement{terms, subscribe, accept} {
+			if !dismissed[ele] {
+				elements = append(elements, ele)
+			}
+		}
+		return elements, nil
+	}
+	mb.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		if act.Type != browserk.ActLeftClick {
+			t.Fatalf("expected overlay button to be clicked got %s", act)
+		}
+		dismissed[act.Element] = true
+		return nil, false, nil
+	}
+
+	overlays := crawler.DismissOverlays(bCtx, b, nil, crawler.DefaultOverlayButtonText)
+	if len(overlays) != 1 || overlays[0] != "Accept All" {
+		t.Fatalf("expected only the accept button to be clicked got %v", overlays)
+	}
+
+	if dismissed[subscribe] {
+		t.Fatalf("expected buttons not matching the heuristics to be left alone")
+	}
+
+	if dismissed[terms] {
+		t.Fatalf("expected buttons outside of an overlay to be left alone")
+	}
+
+	// without an overlay locator only the overlay selectors are used
+	dismissed = make(map[*browserk.HTMLElement]bool)
+	if overlays := crawler.DismissOverlays(bCtx, mb, nil, crawler.DefaultOverlayButtonText); len(overlays) != 0 {
+		t.Fatalf("expected no buttons to be matched by text without an overlay locator got %v", overlays)
+	}
+
+	// buttons which remain after being clicked are not clicked again
+	clicks := 0
+	mb.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		clicks++
+		return nil, false, nil
+	}
+	crawler.DismissOverlays(bCtx, b, nil, crawler.DefaultOverlayButtonText)
+	if clicks != 1 {
+		t.Fatalf("expected a button to only be clicked once got %d clicks", clicks)
+	}
+}
+
+func TestDismissOverlaysReturnsToPage(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+
+	accept := &browserk.HTMLElement{Type: browserk.BUTTON, InnerText: "Accept all", Selector: "#consent > a"}
+	agree := &browserk.HTMLElement{Type: browserk.BUTTON, InnerText: "I agree", Selector: "#newsletter > button"}
+	pageURL := "http://localhost:8080/page"
+	currentURL := pageURL
+
+	mb := mock.MakeMockBrowser()
+	b := &overlayBrowser{Browser: mb, overlays: map[string]bool{"#consent > a": true, "#newsletter > button": true}}
+	mb.GetURLFn = func() (string, error) {
+		return currentURL, nil
+	}
+	mb.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		return []*browserk.HTMLElement{accept, agree}, nil
+	}
+	mb.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		currentURL = "http://localhost:8080/privacy"
+		return nil, true, nil
+	}
+	navigated := ""
+	mb.NavigateFn = func(ctx context.Context, url string) error {
+		navigated = url
+		currentURL = url
+		return nil
+	}
+
+	overlays := crawler.DismissOverlays(bCtx, b, nil, crawler.DefaultOverlayButtonText)
+	if len(overlays) != 1 || overlays[0] != "Accept all" {
+		t.Fatalf("expected dismissing to stop after navigating away got %v", overlays)
+	}
+
+	if navigated != pageURL {
+		t.Fatalf("expected browser to return to %s got %s", pageURL, navigated)
+	}
+
+	// a click reloading the same page keeps dismissing overlays
+	mb.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		return nil, true, nil
+	}
+	mb.NavigateCalled = false
+	overlays = crawler.DismissOverlays(bCtx, b, nil, crawler.DefaultOverlayButtonText)
+	if len(overlays) != 2 || mb.NavigateCalled {
+		t.Fatalf("expected both overlays to be dismissed without navigating got %v", overlays)
+	}
+}
+
+func TestCrawlerDismissesOverlays(t *testing.T) {
+	pool := browser.NewGCDBrowserPool(1, leaser)
+	if err := pool.Init(); err != nil {
+		t.Fatalf("failed to init pool")
+	}
+	defer leaser.Cleanup()
+	ctx := context.Background()
+	bCtx := mock.Context(ctx)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b, port, err := pool.Take(bCtx)
+	if err != nil {
+		t.Fatalf("error taking browser: %s\n", err)
+	}
+	defer pool.Return(ctx, port)
+
+	p, srv := testServer("/result/formResult", nil)
+	defer srv.Shutdown(ctx)
+	target := fmt.Sprintf("http://localhost:%s/overlay/consent.html", p)
+	targetURL, _ := url.Parse(target)
+	bCtx.Scope = scanner.NewScopeService(targetURL)
+
+	crawl := crawler.New(&browserk.Config{AutoDismissOverlays: true})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target))
+	result, newNavs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error getting url %s\n", err)
+	}
+
+	if len(result.Overlays) != 2 || result.Overlays[0] != "Accept all" || result.Overlays[1] != "No thanks" {
+		t.Fatalf("expected consent banner and newsletter modal to be dismissed got %v", result.Overlays)
+	}
+
+	links := make(map[string]bool)
+	for _, newNav := range newNavs {
+		if newNav.Action.Element == nil {
+			continue
+		}
+		if text := newNav.Action.Element.InnerText; text == "Accept all" || text == "No thanks" {
+			t.Fatalf("expected overlay buttons to be dismissed before extraction got %s", text)
+		}
+		links[newNav.Action.Element.Attributes["href"]] = true
+	}
+
+	if !links["/overlay/products.html"] {
+		t.Fatalf("expected page links to be extracted got %v", links)
+	}
+
+	if links["/overlay/cookie-policy.html"] || links["/overlay/subscribe.html"] {
+		t.Fatalf("expected overlay links to be removed before extraction got %v", links)
+	}
+}
@@ -0,0 +1,97 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+func TestCrawlerProbeFormsNonDestructive(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+	bCtx.FormHandler = crawler.NewCrawlerFormHandler(&browserk.DefaultFormValues)
+
+	b := mock.MakeMockBrowser()
+	b.GetURLFn = func() (string, error) {
+		return "http://localhost:8080/", nil
+	}
+
+	// forms are only found after the load action, so they are new
+	loaded := false
+	actions := 0
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		actions++
+		loaded = true
+		return nil, true, nil
+	}
+
+	email := &browserk.HTMLElement{Type: browserk.INPUT, Attributes: map[string]string{"type": "email", "name": "email"}}
+	b.FindFormsFn = func() ([]*browserk.HTMLFormElement, error) {
+		if !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLFormElement{
+			{Attributes: map[string]string{"action": "/signup"}, ChildElements: []*browserk.HTMLElement{email}},
+		}, nil
+	}
+
+	confirm := &browserk.HTMLElement{Type: browserk.INPUT, Attributes: map[string]string{"type": "email", "name": "confirm_email"}}
+	b.ProbeFormFn = func(ctx context.Context, form *browserk.HTMLFormElement) ([]*browserk.HTMLElement, error) {
+		if email.Value == "" {
+			t.Fatalf("expected form to be filled before probing")
+		}
+		return []*browserk.HTMLElement{confirm}, nil
+	}
+
+	crawl := crawler.New(&browserk.Config{ProbeFormsNonDestructive: true})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+	_, navs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if !b.ProbeFormCalled {
+		t.Fatalf("expected form to be probed")
+	}
+
+	if actions != 1 {
+		t.Fatalf("expected only the load action to be executed, probing should not navigate got %d actions", actions)
+	}
+
+	var formNav *browserk.Navigation
+	for _, n := range navs {
+		if n.Action.Form != nil {
+			formNav = n
+		}
+	}
+
+	if formNav == nil {
+		t.Fatalf("expected a form nav")
+	}
+
+	if len(formNav.Action.Form.ChildElements) != 2 {
+		t.Fatalf("expected discovered field to be added to form got %d fields", len(formNav.Action.Form.ChildElements))
+	}
+
+	if confirm.Value == "" {
+		t.Fatalf("expected discovered field to be filled")
+	}
+
+	// disabled by default
+	b.ProbeFormCalled = false
+	loaded = false
+	crawl = crawler.New(&browserk.Config{})
+	if _, _, err := crawl.Process(bCtx, b, nav, true); err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if b.ProbeFormCalled {
+		t.Fatalf("expected form not to be probed when disabled")
+	}
+}
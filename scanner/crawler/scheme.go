@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// SchemeDowngradeVulnID is reported for links from https pages to http urls under https-only
+const SchemeDowngradeVulnID = "BR-C-0001"
+
+// ValidateScheme returns an error if restrict is not a known scheme restriction, empty is
+// allowed and means both http and https
+func ValidateScheme(restrict string) error {
+	switch restrict {
+	case browserk.AnyScheme, browserk.HTTPSOnly, browserk.HTTPOnly:
+		return nil
+	}
+	return fmt.Errorf("unknown scheme restriction: %s", restrict)
+}
+
+// schemeAllowed returns false if target's scheme is not allowed by Config.RestrictScheme,
+// reporting links from https pages to http when https is required. Targets which are not
+// http(s) urls (e.g. empty form actions) are allowed.
+func (b *BrowserkCrawler) schemeAllowed(bctx *browserk.Context, pageURL, target string) bool {
+	if b.cfg.RestrictScheme == browserk.AnyScheme || target == "" {
+		return true
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return true
+	}
+
+	switch b.cfg.RestrictScheme {
+	case browserk.HTTPSOnly:
+		if u.Scheme == "https" {
+			return true
+		}
+		b.reportDowngrade(bctx, pageURL, target)
+	case browserk.HTTPOnly:
+		if u.Scheme == "http" {
+			return true
+		}
+	default:
+		return true
+	}
+	bctx.Log.Debug().Str("url", target).Str("restrict", b.cfg.RestrictScheme).Msg("skipping navigation to restricted scheme")
+	return false
+}
+
+// reportDowngrade of an https page navigating to an http url
+func (b *BrowserkCrawler) reportDowngrade(bctx *browserk.Context, pageURL, target string) {
+	page, err := url.Parse(pageURL)
+	if err != nil || page.Scheme != "https" || bctx.Reporter == nil {
+		return
+	}
+
+	bctx.Reporter.Add(&browserk.Report{
+		VulnID:      SchemeDowngradeVulnID,
+		CWE:         319,
+		Severity:    browserk.Low,
+		Description: fmt.Sprintf("%s navigates to %s over unencrypted http", pageURL, target),
+		Remediation: "Link to and submit forms over https only, and send a Strict-Transport-Security header so browsers upgrade http requests",
+		Evidence: &browserk.Evidence{
+			URL:   pageURL,
+			Match: target,
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+func TestCrawlerRestrictScheme(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("https://localhost:8443/")
+	bCtx.Scope = scanner.NewScopeService(target)
+	reporter := mock.MakeMockReporter()
+	bCtx.Reporter = reporter
+
+	b := mock.MakeMockBrowser()
+	b.GetURLFn = func() (string, error) {
+		return "https://localhost:8443/", nil
+	}
+	loaded := false
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		loaded = true
+		return nil, true, nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		if querySelector != "a" || !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLElement{
+			{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8443/login"}},
+			{Type: browserk.A, Attributes: map[string]string{"href": "https://localhost:8443/about"}},
+			{Type: browserk.A, Attributes: map[string]string{"href": "/contact"}},
+		}, nil
+	}
+
+	crawl := crawler.New(&browserk.Config{RestrictScheme: browserk.HTTPSOnly})
+	if err := crawl.Init(); err != nil {
+		t.Fatalf("error initializing crawler: %s\n", err)
+	}
+
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+	_, navs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if len(navs) != 2 {
+		t.Fatalf("expected 2 https navs got %d", len(navs))
+	}
+
+	for _, n := range navs {
+		if n.Action.Element.Attributes["href"] == "http://localhost:8443/login" {
+			t.Fatalf("expected http link to be skipped")
+		}
+	}
+
+	if len(reporter.Reports) != 1 {
+		t.Fatalf("expected 1 downgrade finding got %d", len(reporter.Reports))
+	}
+
+	if reporter.Reports[0].VulnID != crawler.SchemeDowngradeVulnID || reporter.Reports[0].Evidence.Match != "http://localhost:8443/login" {
+		t.Fatalf("expected downgrade finding for http link got %+v", reporter.Reports[0])
+	}
+
+	// both schemes are allowed by default
+	crawl = crawler.New(&browserk.Config{})
+	loaded = false
+	_, navs, err = crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if len(navs) != 3 {
+		t.Fatalf("expected 3 navs got %d", len(navs))
+	}
+
+	if err := crawler.New(&browserk.Config{RestrictScheme: "ftp-only"}).Init(); err == nil {
+		t.Fatalf("expected unknown scheme restriction to fail")
+	}
+}
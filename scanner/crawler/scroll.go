@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"time"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// how long to wait for new content to load after each scroll
+const scrollSettleTime = time.Millisecond * 1500
+
+// Scroller is implemented by browsers that can scroll the page
+type Scroller interface {
+	ScrollBy(dx, dy float64) error
+	GetScrollHeight() (float64, error)
+}
+
+// ScrollToEnd repeatedly scrolls to the bottom of the page until the document height stops
+// growing or maxScrolls is reached, so lazily loaded content can be discovered. Returns the
+// number of scrolls that caused new content to load.
+func ScrollToEnd(bctx *browserk.Context, browser browserk.Browser, maxScrolls int) int {
+	scroller, ok := browser.(Scroller)
+	if !ok {
+		return 0
+	}
+
+	loaded := 0
+	for i := 0; i < maxScrolls; i++ {
+		height, err := scroller.GetScrollHeight()
+		if err != nil {
+			bctx.Log.Warn().Err(err).Msg("failed to get scroll height")
+			return loaded
+		}
+
+		if err := scroller.ScrollBy(0, height); err != nil {
+			bctx.Log.Warn().Err(err).Msg("failed to scroll")
+			return loaded
+		}
+
+		if !waitForHeightChange(bctx, scroller, height) {
+			break
+		}
+		loaded++
+	}
+	bctx.Log.Debug().Int("loaded", loaded).Msg("finished scrolling")
+	return loaded
+}
+
+// waitForHeightChange returns true if the document grew from height before the settle time expired
+func waitForHeightChange(bctx *browserk.Context, scroller Scroller, height float64) bool {
+	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
+	timer := time.NewTimer(scrollSettleTime)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-bctx.Ctx.Done():
+			return false
+		case <-timer.C:
+			return false
+		case <-ticker.C:
+			if current, err := scroller.GetScrollHeight(); err == nil && current > height {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package crawler_test
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+// grows the page by 100 pixels for each scroll until maxLoads is reached
+type scrollingBrowser struct {
+	*mock.Browser
+	height   float64
+	scrolls  int
+	maxLoads int
+}
+
+func (s *scrollingBrowser) ScrollBy(dx, dy float64) error {
+	s.scrolls++
+	if s.scrolls <= s.maxLoads {
+		s.height += 100
+	}
+	return nil
+}
+
+func (s *scrollingBrowser) GetScrollHeight() (float64, error) {
+	return s.height, nil
+}
+
+func TestScrollToEnd(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+
+	b := &scrollingBrowser{Browser: mock.MakeMockBrowser(), height: 768, maxLoads: 3}
+	if loaded := crawler.ScrollToEnd(bCtx, b, 10); loaded != 3 {
+		t.Fatalf("expected 3 loads got %d", loaded)
+	}
+
+	// stops once content is no longer loaded
+	if b.scrolls != 4 {
+		t.Fatalf("expected 4 scrolls got %d", b.scrolls)
+	}
+
+	b = &scrollingBrowser{Browser: mock.MakeMockBrowser(), height: 768, maxLoads: 10}
+	if loaded := crawler.ScrollToEnd(bCtx, b, 2); loaded != 2 || b.scrolls != 2 {
+		t.Fatalf("expected max scrolls to be respected got %d loads %d scrolls", loaded, b.scrolls)
+	}
+
+	if loaded := crawler.ScrollToEnd(bCtx, mock.MakeMockBrowser(), 10); loaded != 0 {
+		t.Fatalf("expected browsers that can't scroll to be skipped")
+	}
+}
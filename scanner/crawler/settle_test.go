@@ -0,0 +1,51 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+func TestCrawlerPostNavigationDelay(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+
+	var actionDone, extracted time.Time
+	b := mock.MakeMockBrowser()
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		actionDone = time.Now()
+		return nil, true, nil
+	}
+	b.FindFormsFn = func() ([]*browserk.HTMLFormElement, error) {
+		extracted = time.Now()
+		return nil, nil
+	}
+
+	delay := time.Millisecond * 300
+	crawl := crawler.New(&browserk.Config{PostNavigationDelay: delay})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+	if _, _, err := crawl.Process(bCtx, b, nav, true); err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if extracted.Sub(actionDone) < delay {
+		t.Fatalf("expected extraction to be deferred by %s, was %s", delay, extracted.Sub(actionDone))
+	}
+
+	// no delay configured
+	crawl = crawler.New(&browserk.Config{})
+	if _, _, err := crawl.Process(bCtx, b, nav, true); err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if extracted.Sub(actionDone) >= delay {
+		t.Fatalf("expected extraction to not be deferred, was %s", extracted.Sub(actionDone))
+	}
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// DefaultSkipExtensions are file extensions of links which are recorded but not navigated to,
+// used when Config.SkipExtensions is not set
+var DefaultSkipExtensions = []string{
+	"pdf", "zip", "gz", "tgz", "tar", "rar", "7z", "exe", "msi", "dmg", "iso", "apk", "jar",
+	"png", "jpg", "jpeg", "gif", "bmp", "ico", "svg", "webp", "tif", "tiff",
+	"mp3", "mp4", "m4a", "avi", "mov", "wav", "webm", "ogg", "flv",
+	"doc", "docx", "xls", "xlsx", "ppt", "pptx", "odt", "csv",
+	"woff", "woff2", "ttf", "eot",
+}
+
+// newExtensionSet of lower cased extensions without the leading dot
+func newExtensionSet(extensions []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(extensions))
+	for _, ext := range extensions {
+		set[strings.ToLower(strings.TrimPrefix(ext, "."))] = struct{}{}
+	}
+	return set
+}
+
+// hasExtension returns true if the path of href ends with one of the extensions
+func hasExtension(href string, extensions map[string]struct{}) bool {
+	if len(extensions) == 0 {
+		return false
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(u.Path), "."))
+	if ext == "" {
+		return false
+	}
+	_, skip := extensions[ext]
+	return skip
+}
+
+// headSkipped issues a HEAD request for a skipped link from the page so its status is still recorded
+func (b *BrowserkCrawler) headSkipped(bctx *browserk.Context, browser browserk.Browser, baseHref, href string) {
+	base := baseHref
+	if base == "" {
+		base, _ = browser.GetURL()
+	}
+
+	target := href
+	if baseURL, err := url.Parse(base); err == nil {
+		if ref, err := baseURL.Parse(href); err == nil {
+			target = ref.String()
+		}
+	}
+
+	resp, err := browser.ReplayRequest(&browserk.Request{Method: "HEAD", URL: target})
+	if err != nil {
+		bctx.Log.Debug().Err(err).Str("url", target).Msg("failed to HEAD skipped link")
+		return
+	}
+
+	status := 0
+	if resp != nil && resp.Response != nil {
+		status = resp.Response.Status
+	}
+	bctx.Log.Info().Str("url", target).Int("status", status).Msg("skipped link")
+}
@@ -0,0 +1,90 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+func TestCrawlerSkipExtensions(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+
+	b := mock.MakeMockBrowser()
+	b.GetURLFn = func() (string, error) {
+		return "http://localhost:8080/docs/", nil
+	}
+	// links are only found after the action, so they are new
+	loaded := false
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		loaded = true
+		return nil, true, nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		if querySelector != "a" || !loaded {
+			return nil, nil
+		}
+		return []*browserk.HTMLElement{
+			{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8080/docs/report.PDF?v=1"}},
+			{Type: browserk.A, Attributes: map[string]string{"href": "http://localhost:8080/about.html"}},
+		}, nil
+	}
+
+	var headURL string
+	b.ReplayRequestFn = func(req *browserk.Request) (*browserk.HTTPResponse, error) {
+		if req.Method != "HEAD" {
+			t.Fatalf("expected HEAD request got %s", req.Method)
+		}
+		headURL = req.URL
+		return nil, nil
+	}
+
+	crawl := crawler.New(&browserk.Config{HeadSkippedLinks: true})
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction(target.String()))
+	_, navs, err := crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if len(navs) != 2 {
+		t.Fatalf("expected 2 navs got %d", len(navs))
+	}
+
+	for _, n := range navs {
+		href := n.Action.Element.Attributes["href"]
+		switch href {
+		case "http://localhost:8080/docs/report.PDF?v=1":
+			if n.State != browserk.NavSkipped {
+				t.Fatalf("expected pdf link to be recorded as skipped got %v", n.State)
+			}
+		case "http://localhost:8080/about.html":
+			if n.State != browserk.NavUnvisited {
+				t.Fatalf("expected html link to be unvisited got %v", n.State)
+			}
+		}
+	}
+
+	if headURL != "http://localhost:8080/docs/report.PDF?v=1" {
+		t.Fatalf("expected HEAD request for skipped link got %s", headURL)
+	}
+
+	// an empty list disables skipping
+	crawl = crawler.New(&browserk.Config{SkipExtensions: []string{}})
+	loaded = false
+	_, navs, err = crawl.Process(bCtx, b, nav, true)
+	if err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	for _, n := range navs {
+		if n.State != browserk.NavUnvisited {
+			t.Fatalf("expected all links to be unvisited got %v for %s", n.State, n.Action.Element.Attributes["href"])
+		}
+	}
+}
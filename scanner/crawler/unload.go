@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"fmt"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// BeforeUnloadVulnID is reported for pages whose beforeunload handler prompts before leaving them
+const BeforeUnloadVulnID = "BR-C-0002"
+
+// UnloadBlocker is implemented by browsers that record pages which tried to block navigation
+// away from them with a beforeunload handler
+type UnloadBlocker interface {
+	BlockedUnloads() []string
+}
+
+// blockedUnloads returns the pages that tried to block navigation since the last call
+func blockedUnloads(browser browserk.Browser) []string {
+	blocker, ok := browser.(UnloadBlocker)
+	if !ok {
+		return nil
+	}
+	return blocker.BlockedUnloads()
+}
+
+// reportBlockedUnloads of the pages that prompted before being left, users navigating away from
+// them may lose data or be unable to leave
+func (b *BrowserkCrawler) reportBlockedUnloads(bctx *browserk.Context, pages []string) {
+	if bctx.Reporter == nil {
+		return
+	}
+
+	for _, pageURL := range pages {
+		bctx.Reporter.Add(&browserk.Report{
+			VulnID:      BeforeUnloadVulnID,
+			CWE:         451,
+			Severity:    browserk.Info,
+			Description: fmt.Sprintf("%s prompts before it can be left using a beforeunload handler", pageURL),
+			Remediation: "Only register beforeunload handlers while there are unsaved changes, and remove them once changes are saved",
+			Evidence: &browserk.Evidence{
+				URL: pageURL,
+			},
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/crawler"
+)
+
+// records a blocked unload for each action
+type unloadingBrowser struct {
+	*mock.Browser
+	blocked []string
+}
+
+func (u *unloadingBrowser) BlockedUnloads() []string {
+	blocked := u.blocked
+	u.blocked = nil
+	return blocked
+}
+
+func TestCrawlerReportBeforeUnload(t *testing.T) {
+	bCtx := mock.Context(context.Background())
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+	reporter := mock.MakeMockReporter()
+	bCtx.Reporter = reporter
+
+	b := &unloadingBrowser{Browser: mock.MakeMockBrowser()}
+	b.GetURLFn = func() (string, error) {
+		return "http://localhost:8080/editor", nil
+	}
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		b.blocked = append(b.blocked, "http://localhost:8080/editor")
+		return nil, true, nil
+	}
+
+	nav := browserk.NewNavigation(browserk.TrigCrawler, browserk.NewLoadURLAction("http://localhost:8080/next"))
+	crawl := crawler.New(&browserk.Config{})
+	if _, _, err := crawl.Process(bCtx, b, nav, false); err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if len(reporter.Reports) != 0 {
+		t.Fatalf("expected blocked unloads to only be reported when enabled got %d", len(reporter.Reports))
+	}
+
+	// pages blocked before the action aren't attributed to it
+	b.blocked = []string{"http://localhost:8080/stale"}
+	crawl = crawler.New(&browserk.Config{ReportBeforeUnload: true})
+	if _, _, err := crawl.Process(bCtx, b, nav, false); err != nil {
+		t.Fatalf("error processing nav: %s\n", err)
+	}
+
+	if len(reporter.Reports) != 1 {
+		t.Fatalf("expected 1 beforeunload finding got %d", len(reporter.Reports))
+	}
+
+	report := reporter.Reports[0]
+	if report.VulnID != "BR-C-0002" || report.CWE != 451 || report.Severity != browserk.Info || report.Evidence.URL != "http://localhost:8080/editor" {
+		t.Fatalf("expected beforeunload finding for editor got %+v", report)
+	}
+}
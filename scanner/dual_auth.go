@@ -0,0 +1,294 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// UnauthenticatedAccessVulnID is reported for pages only found while authenticated which load the
+// same without a session
+const UnauthenticatedAccessVulnID = "BR-S-0001"
+
+// maximum number of pages visited while crawling without the session
+const maxUnauthenticatedPages = 500
+
+// maxBodySizeDifference allowed between the authenticated and unauthenticated bodies of a page for
+// them to be considered the same content, as a fraction of the larger body
+const maxBodySizeDifference = 0.1
+
+// unauthenticatedPool is implemented by pools which can take browsers isolated from the login session
+type unauthenticatedPool interface {
+	TakeUnauthenticated(ctx *browserk.Context) (browserk.Browser, string, error)
+}
+
+// AuthPassResult records whether a page was reachable in the authenticated and unauthenticated passes
+type AuthPassResult struct {
+	URL             string
+	Authenticated   bool // reached by the authenticated crawl
+	Public          bool // linked from the seed without the session
+	Unauthenticated bool // loaded without the session
+}
+
+// dualAuthPass compares the authenticated crawl's pages against an unauthenticated pass over the
+// same seed. Pages only found while authenticated are requested without the session, those that
+// still load with the same status and content are reported as reachable without authentication.
+func (b *Browserk) dualAuthPass() error {
+	results, err := b.crawlGraph.GetNavigationResults()
+	if err != nil {
+		return err
+	}
+
+	authCtx := b.mainContext.Copy()
+	browser, port, err := b.takeUnauthenticated(authCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		browser.Close()
+		b.browsers.Return(authCtx.Ctx, port)
+	}()
+
+	public := b.crawlUnauthenticated(authCtx, browser)
+
+	passResults := make([]*AuthPassResult, 0)
+	checked := make(map[string]struct{})
+	for _, result := range results {
+		if result.WasError || result.EndURL == "" {
+			continue
+		}
+
+		target := b.normalizer.Normalize(result.EndURL)
+		if _, exists := checked[target]; exists {
+			continue
+		}
+		checked[target] = struct{}{}
+
+		passResult := &AuthPassResult{URL: target, Authenticated: true}
+		passResults = append(passResults, passResult)
+		if _, ok := public[target]; ok {
+			passResult.Public = true
+			passResult.Unauthenticated = true
+			continue
+		}
+
+		resp, reachable := b.loadUnauthenticated(authCtx, browser, target)
+		if !reachable {
+			continue
+		}
+		passResult.Unauthenticated = true
+
+		if !sameResponse(b.documentResponse(result.Messages, target), resp) {
+			log.Debug().Str("url", target).Msg("page loads without a session but differs from the authenticated response")
+			continue
+		}
+
+		authCtx.Reporter.Add(&browserk.Report{
+			VulnID:      UnauthenticatedAccessVulnID,
+			CWE:         306,
+			Severity:    browserk.High,
+			Description: fmt.Sprintf("%s was only found while authenticated but loads without a session", target),
+			Remediation: "Require authentication for every request to the resource, not only for the pages linking to it",
+			Response:    resp,
+			Evidence: &browserk.Evidence{
+				URL: target,
+			},
+		})
+	}
+
+	for _, passResult := range passResults {
+		log.Info().Str("url", passResult.URL).Bool("public", passResult.Public).Bool("unauthenticated", passResult.Unauthenticated).Msg("dual auth pass result")
+	}
+	b.authPassResults = passResults
+	return nil
+}
+
+// AuthPassResults of the dual auth pass, nil if DualAuthPass was not enabled
+func (b *Browserk) AuthPassResults() []*AuthPassResult {
+	return b.authPassResults
+}
+
+// takeUnauthenticated takes a browser without the login session, pools which can't isolate a tab
+// from the session have its cookies cleared instead
+func (b *Browserk) takeUnauthenticated(bctx *browserk.Context) (browserk.Browser, string, error) {
+	if pool, ok := b.browsers.(unauthenticatedPool); ok {
+		browser, port, err := pool.TakeUnauthenticated(bctx)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to take browser for unauthenticated pass")
+		}
+		return browser, port, nil
+	}
+
+	browser, port, err := b.browsers.Take(bctx)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to take browser for unauthenticated pass")
+	}
+
+	if err := browser.ClearCookies(); err != nil {
+		browser.Close()
+		b.browsers.Return(bctx.Ctx, port)
+		return nil, "", errors.Wrap(err, "failed to clear session for unauthenticated pass")
+	}
+	return browser, port, nil
+}
+
+// crawlUnauthenticated follows in scope links from the seed url up to MaxDepth, returning the
+// normalized urls of every page reached
+func (b *Browserk) crawlUnauthenticated(bctx *browserk.Context, browser browserk.Browser) map[string]struct{} {
+	public := make(map[string]struct{})
+	queued := map[string]struct{}{b.normalizer.Normalize(b.cfg.URL): {}}
+	current := []string{b.cfg.URL}
+
+	for depth := 0; depth <= b.cfg.MaxDepth && len(current) > 0; depth++ {
+		next := make([]string, 0)
+		for _, target := range current {
+			if len(public) >= maxUnauthenticatedPages {
+				return public
+			}
+
+			if !b.navigate(bctx, browser, target) {
+				continue
+			}
+
+			reached, err := browser.GetURL()
+			if err != nil {
+				continue
+			}
+			public[b.normalizer.Normalize(reached)] = struct{}{}
+
+			links, err := browser.FindElements("a")
+			if err != nil {
+				continue
+			}
+
+			for _, link := range links {
+				linked := b.resolveLink(browser.GetBaseHref(), reached, link.GetAttribute("href"))
+				if linked == "" || bctx.Scope.Check(linked) != browserk.InScope {
+					continue
+				}
+
+				if _, exists := queued[linked]; exists {
+					continue
+				}
+				queued[linked] = struct{}{}
+				next = append(next, linked)
+			}
+		}
+		current = next
+	}
+	return public
+}
+
+// loadUnauthenticated loads the target, returning its document response and true if it loaded
+// successfully without being redirected elsewhere (e.g. to a login page)
+func (b *Browserk) loadUnauthenticated(bctx *browserk.Context, browser browserk.Browser, target string) (*browserk.HTTPResponse, bool) {
+	// discard messages from previous pages
+	browser.GetMessages()
+	if !b.navigate(bctx, browser, target) {
+		return nil, false
+	}
+
+	reached, err := browser.GetURL()
+	if err != nil || b.normalizer.Normalize(reached) != target {
+		return nil, false
+	}
+
+	messages, err := browser.GetMessages()
+	if err != nil {
+		return nil, false
+	}
+
+	resp := b.documentResponse(messages, target)
+	if resp == nil {
+		return nil, false
+	}
+	return resp, resp.Response.Status >= 200 && resp.Response.Status < 300
+}
+
+// documentResponse returns the response which loaded the target document, nil if not captured
+func (b *Browserk) documentResponse(messages []*browserk.HTTPMessage, target string) *browserk.HTTPResponse {
+	for _, msg := range messages {
+		resp := msg.Response
+		if resp == nil || resp.Response == nil || resp.Type != "Document" {
+			continue
+		}
+
+		if b.normalizer.Normalize(resp.Response.Url) == target {
+			return resp
+		}
+	}
+	return nil
+}
+
+// sameResponse returns true if the unauthenticated response has the authenticated response's
+// status and a body of similar size. A login form or error rendered in place of the content
+// differs. Bodies are only compared if both were captured.
+func sameResponse(authenticated, unauthenticated *browserk.HTTPResponse) bool {
+	if authenticated == nil || authenticated.Response == nil || unauthenticated == nil || unauthenticated.Response == nil {
+		return false
+	}
+
+	if authenticated.Response.Status != unauthenticated.Response.Status {
+		return false
+	}
+
+	authBody, err := authenticated.ReadBody()
+	if err != nil || len(authBody) == 0 {
+		return true
+	}
+
+	body, err := unauthenticated.ReadBody()
+	if err != nil || len(body) == 0 {
+		return true
+	}
+
+	larger, smaller := float64(len(authBody)), float64(len(body))
+	if smaller > larger {
+		larger, smaller = smaller, larger
+	}
+	return (larger-smaller)/larger <= maxBodySizeDifference
+}
+
+// navigate to the target, returning false if it failed to load
+func (b *Browserk) navigate(bctx *browserk.Context, browser browserk.Browser, target string) bool {
+	ctx, cancel := context.WithTimeout(bctx.Ctx, time.Second*45)
+	defer cancel()
+
+	if err := browser.Navigate(ctx, target); err != nil {
+		log.Debug().Err(err).Str("url", target).Msg("failed to load page without session")
+		return false
+	}
+	return true
+}
+
+// resolveLink resolves href against the base href (or current url) and normalizes it, returns
+// empty if it isn't an http(s) url
+func (b *Browserk) resolveLink(baseHref, currentURL, href string) string {
+	base := currentURL
+	if strings.HasPrefix(baseHref, "http") {
+		base = baseHref
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+
+	resolved := baseURL.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return b.normalizer.Normalize(resolved.String())
+}
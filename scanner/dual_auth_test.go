@@ -0,0 +1,151 @@
+package scanner_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/scanner/report"
+)
+
+// authSite simulates a site where /dashboard requires a session but /secret, which is only
+// linked from the dashboard, does not. /account renders a login form in place of its content
+// without a session.
+type authSite struct {
+	session bool
+	current string
+}
+
+func (s *authSite) body() string {
+	if strings.HasSuffix(s.current, "/account") && !s.session {
+		return "<html><form><input type=password></form></html>"
+	}
+	return "<html>" + strings.Repeat("content of "+s.current, 10) + "</html>"
+}
+
+func (s *authSite) navigate(target string) {
+	s.current = target
+	if strings.HasSuffix(target, "/dashboard") && !s.session {
+		s.current = "http://localhost:8080/login"
+	}
+}
+
+func (s *authSite) links() []*browserk.HTMLElement {
+	hrefs := map[string][]string{
+		"http://localhost:8080/":          {"/about", "/login"},
+		"http://localhost:8080/dashboard": {"/secret", "/account", "/"},
+	}
+	links := make([]*browserk.HTMLElement, 0)
+	for _, href := range hrefs[s.current] {
+		links = append(links, &browserk.HTMLElement{Type: browserk.A, Attributes: map[string]string{"href": href}})
+	}
+	return links
+}
+
+func (s *authSite) messages() []*browserk.HTTPMessage {
+	return []*browserk.HTTPMessage{
+		{Response: &browserk.HTTPResponse{Type: "Document", Response: &gcdapi.NetworkResponse{Url: s.current, Status: 200}, Body: []byte(s.body())}},
+	}
+}
+
+// unauthenticatedPool takes browsers without the site's session
+type unauthenticatedPool struct {
+	*mock.BrowserPool
+	site                 *authSite
+	browser              browserk.Browser
+	unauthenticatedTakes int
+}
+
+func (p *unauthenticatedPool) TakeUnauthenticated(ctx *browserk.Context) (browserk.Browser, string, error) {
+	p.unauthenticatedTakes++
+	p.site.session = false
+	return p.browser, "9222", nil
+}
+
+func TestDualAuthPass(t *testing.T) {
+	ctx := context.Background()
+	site := &authSite{session: true}
+
+	b := mock.MakeMockBrowser()
+	b.NavigateFn = func(ctx context.Context, target string) error {
+		site.navigate(target)
+		return nil
+	}
+	b.GetURLFn = func() (string, error) {
+		return site.current, nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		return site.links(), nil
+	}
+	b.GetMessagesFn = func() ([]*browserk.HTTPMessage, error) {
+		return site.messages(), nil
+	}
+
+	// pages the authenticated crawl reached
+	graph := mock.MakeMockCrawlGraph()
+	for _, page := range []string{"/", "/about", "/dashboard", "/secret", "/account"} {
+		site.navigate("http://localhost:8080" + page)
+		graph.Results = append(graph.Results, &browserk.NavigationResult{EndURL: site.current, Messages: site.messages()})
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.DualAuthPass = true
+	target, _ := url.Parse(cfg.URL)
+	bCtx := mock.Context(ctx)
+	bCtx.Scope = scanner.NewScopeService(target)
+	reporter := report.New()
+	bCtx.Reporter = reporter
+
+	pool := &unauthenticatedPool{BrowserPool: mock.MakeMockBrowserPool(b), site: site, browser: b}
+	engine := scanner.NewTestEngine(cfg, graph, pool, bCtx)
+	if err := engine.DualAuthPass(); err != nil {
+		t.Fatalf("error running dual auth pass: %s\n", err)
+	}
+
+	if pool.unauthenticatedTakes != 1 || pool.TakeCalled != 0 {
+		t.Fatalf("expected the unauthenticated pass to take a browser without the session")
+	}
+
+	passResults := make(map[string]*scanner.AuthPassResult)
+	for _, r := range engine.AuthPassResults() {
+		passResults[r.URL] = r
+	}
+
+	if len(passResults) != 5 {
+		t.Fatalf("expected 5 pass results got %d\n", len(passResults))
+	}
+
+	about := passResults["http://localhost:8080/about"]
+	if about == nil || !about.Public || !about.Unauthenticated {
+		t.Fatalf("expected /about to be public got %#v\n", about)
+	}
+
+	dashboard := passResults["http://localhost:8080/dashboard"]
+	if dashboard == nil || dashboard.Public || dashboard.Unauthenticated {
+		t.Fatalf("expected /dashboard to require authentication got %#v\n", dashboard)
+	}
+
+	secret := passResults["http://localhost:8080/secret"]
+	if secret == nil || secret.Public || !secret.Unauthenticated {
+		t.Fatalf("expected /secret to be reachable without authentication got %#v\n", secret)
+	}
+
+	account := passResults["http://localhost:8080/account"]
+	if account == nil || account.Public || !account.Unauthenticated {
+		t.Fatalf("expected /account to load without a session got %#v\n", account)
+	}
+
+	findings := reporter.Findings()
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding got %d\n", len(findings))
+	}
+
+	if findings[0].VulnID != scanner.UnauthenticatedAccessVulnID || findings[0].Evidence.URL != "http://localhost:8080/secret" {
+		t.Fatalf("expected unauthenticated access finding for /secret got %s %s\n", findings[0].VulnID, findings[0].Evidence.URL)
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"io"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// NewTestEngine creates an engine with the browser pool and context already set, bypassing Init
+func NewTestEngine(cfg *browserk.Config, crawl browserk.CrawlGrapher, pool browserk.BrowserPool, bctx *browserk.Context) *Browserk {
+	b := New(cfg, crawl, nil, nil)
+	b.browsers = pool
+	b.mainContext = bctx
+	b.readyCh = make(chan struct{}, 1)
+	b.navCh = make(chan []*browserk.Navigation, cfg.NumBrowsers)
+	return b
+}
+
+// Crawl exposes crawl for testing
+func (b *Browserk) Crawl(navs []*browserk.Navigation) {
+	b.crawl(navs)
+	<-b.readyCh
+}
+
+// NewTestEngineWithScheduler creates a test engine which gets navigations from scheduler
+func NewTestEngineWithScheduler(cfg *browserk.Config, crawl browserk.CrawlGrapher, scheduler browserk.Scheduler, pool browserk.BrowserPool, bctx *browserk.Context) *Browserk {
+	b := NewTestEngine(cfg, crawl, pool, bctx)
+	b.scheduler = scheduler
+	return b
+}
+
+// CrawlNext crawls the next entries from the scheduler in order
+func (b *Browserk) CrawlNext() {
+	for _, navs := range b.nextEntries() {
+		b.Crawl(navs)
+	}
+}
+
+// StartWorkers exposes startWorkers for testing
+func (b *Browserk) StartWorkers() {
+	b.startWorkers()
+}
+
+// Submit navs to the crawl workers
+func (b *Browserk) Submit(navs []*browserk.Navigation) {
+	b.navCh <- navs
+}
+
+// DualAuthPass exposes dualAuthPass for testing
+func (b *Browserk) DualAuthPass() error {
+	return b.dualAuthPass()
+}
+
+// SetReportOutput overrides where the report phase writes the report
+func (b *Browserk) SetReportOutput(w io.Writer) {
+	b.reportOut = w
+}
+
+// NewFormHandler exposes newFormHandler for testing
+func (b *Browserk) NewFormHandler() browserk.FormHandler {
+	return b.newFormHandler()
+}
+
+// AttackPhase exposes attackPhase for testing
+func (b *Browserk) AttackPhase() error {
+	return b.attackPhase()
+}
@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"crypto/md5"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/store"
+)
+
+// IncrementalBaseline holds the content hashes of a previous crawl so a re-scan only attacks
+// navigations which are new or whose content changed. Unchanged pages are still expanded so
+// changes below them are found.
+type IncrementalBaseline struct {
+	hashes map[string][]byte // navigation id -> content hash of its result
+
+	lock      sync.RWMutex
+	unchanged map[string]struct{} // navigation ids of this scan found to be unchanged
+}
+
+// NewIncrementalBaseline from the results of a previous crawl graph
+func NewIncrementalBaseline(crawl browserk.CrawlGrapher) (*IncrementalBaseline, error) {
+	results, err := crawl.GetNavigationResults()
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := &IncrementalBaseline{
+		hashes:    make(map[string][]byte, len(results)),
+		unchanged: make(map[string]struct{}),
+	}
+	for _, result := range results {
+		baseline.hashes[string(result.NavigationID)] = contentHash(result)
+	}
+	return baseline, nil
+}
+
+// LoadIncrementalBaseline from the crawl graph of a previous scan's data directory
+func LoadIncrementalBaseline(dataPath string) (*IncrementalBaseline, error) {
+	crawlPath := filepath.Join(dataPath, "crawl")
+	// Init would create an empty store for a mistyped path
+	if _, err := os.Stat(crawlPath); err != nil {
+		return nil, errors.Wrapf(err, "no crawl graph found in %s", dataPath)
+	}
+
+	crawl := store.NewCrawlGraph(crawlPath)
+	if err := crawl.Init(); err != nil {
+		return nil, errors.Wrapf(err, "failed to open crawl graph in %s", dataPath)
+	}
+	defer crawl.Close()
+
+	return NewIncrementalBaseline(crawl)
+}
+
+// Unchanged returns true if the result's navigation was crawled in the baseline and its
+// content is the same, the navigation is remembered so it can be skipped when attacking
+func (i *IncrementalBaseline) Unchanged(result *browserk.NavigationResult) bool {
+	hash, ok := i.hashes[string(result.NavigationID)]
+	if !ok || string(hash) != string(contentHash(result)) {
+		return false
+	}
+
+	i.lock.Lock()
+	i.unchanged[string(result.NavigationID)] = struct{}{}
+	i.lock.Unlock()
+	return true
+}
+
+// WasUnchanged returns true if the navigation was found to be unchanged during this scan
+func (i *IncrementalBaseline) WasUnchanged(navID []byte) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	_, ok := i.unchanged[string(navID)]
+	return ok
+}
+
+// contentHash of the url and captured DOM a navigation ended on
+func contentHash(result *browserk.NavigationResult) []byte {
+	h := md5.New()
+	h.Write([]byte(result.EndURL))
+	h.Write([]byte(result.DOM))
+	return h.Sum(nil)
+}
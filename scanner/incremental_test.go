@@ -0,0 +1,128 @@
+package scanner_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/store"
+)
+
+// incrementalSite links the home page to a section, which links to a page only found from it
+type incrementalSite struct {
+	pages   map[string]string
+	links   map[string]string
+	current string
+}
+
+func (s *incrementalSite) browser() *mock.Browser {
+	b := mock.MakeMockBrowser()
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		if act.Type == browserk.ActLoadURL {
+			s.current = string(act.Input)
+		} else if act.Element != nil {
+			s.current = act.Element.Attributes["href"]
+		}
+		return nil, true, nil
+	}
+	b.GetURLFn = func() (string, error) {
+		return s.current, nil
+	}
+	b.GetDOMFn = func() (string, error) {
+		return s.pages[s.current], nil
+	}
+	b.FindElementsFn = func(querySelector string) ([]*browserk.HTMLElement, error) {
+		href, ok := s.links[s.current]
+		if querySelector != "a" || !ok {
+			return nil, nil
+		}
+		return []*browserk.HTMLElement{{Type: browserk.A, Attributes: map[string]string{"href": href}}}, nil
+	}
+	return b
+}
+
+// crawl the site from its home page until no unvisited navigations are left
+func (s *incrementalSite) crawl(t *testing.T, engine *scanner.Browserk, graph *store.MemoryCrawlGraph) {
+	ctx := context.Background()
+	seed := browserk.NewNavigation(browserk.TrigInitial, browserk.NewLoadURLAction("http://localhost:8080/"))
+	if err := graph.AddNavigation(seed); err != nil {
+		t.Fatalf("error adding navigation: %s\n", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		paths := graph.Find(ctx, browserk.NavUnvisited, browserk.NavInProcess, 10)
+		if len(paths) == 0 {
+			return
+		}
+		for _, path := range paths {
+			s.current = ""
+			engine.Crawl(path)
+		}
+	}
+	t.Fatalf("expected crawl to finish")
+}
+
+func TestIncrementalBaselineSkipsUnchanged(t *testing.T) {
+	ctx := context.Background()
+	site := &incrementalSite{
+		pages: map[string]string{
+			"http://localhost:8080/":             "<html>home</html>",
+			"http://localhost:8080/section":      "<html>section</html>",
+			"http://localhost:8080/section/page": "<html>old content</html>",
+		},
+		links: map[string]string{
+			"http://localhost:8080/":        "http://localhost:8080/section",
+			"http://localhost:8080/section": "http://localhost:8080/section/page",
+		},
+	}
+
+	bCtx := mock.Context(ctx)
+	target, _ := url.Parse("http://localhost:8080/")
+	bCtx.Scope = scanner.NewScopeService(target)
+
+	previous := store.NewMemoryCrawlGraph()
+	if err := previous.Init(); err != nil {
+		t.Fatalf("error initializing graph: %s\n", err)
+	}
+	site.crawl(t, scanner.NewTestEngine(mock.MakeMockConfig(), previous, mock.MakeMockBrowserPool(site.browser()), bCtx), previous)
+
+	baseline, err := scanner.NewIncrementalBaseline(previous)
+	if err != nil {
+		t.Fatalf("error creating baseline: %s\n", err)
+	}
+
+	// only the page two levels below the home page changed since the previous scan
+	site.pages["http://localhost:8080/section/page"] = "<html>new content</html>"
+
+	graph := store.NewMemoryCrawlGraph()
+	if err := graph.Init(); err != nil {
+		t.Fatalf("error initializing graph: %s\n", err)
+	}
+
+	attacked := make([]string, 0)
+	module := mock.MakeMockAttackModule()
+	module.AttackFn = func(bctx *browserk.Context, replayer browserk.Replayer, result *browserk.NavigationResult) error {
+		attacked = append(attacked, result.EndURL)
+		return nil
+	}
+
+	engine := scanner.NewTestEngine(mock.MakeMockConfig(), graph, mock.MakeMockBrowserPool(site.browser()), bCtx)
+	engine.SetIncrementalBaseline(baseline).AddAttackModules(module)
+	site.crawl(t, engine, graph)
+
+	visited := graph.Find(ctx, browserk.NavVisited, browserk.NavVisited, 10)
+	if len(visited) != 3 {
+		t.Fatalf("expected the unchanged pages to still be expanded got %d visited navs", len(visited))
+	}
+
+	if err := engine.AttackPhase(); err != nil {
+		t.Fatalf("error attacking: %s\n", err)
+	}
+
+	if len(attacked) != 1 || attacked[0] != "http://localhost:8080/section/page" {
+		t.Fatalf("expected only the changed page to be attacked got %v", attacked)
+	}
+}
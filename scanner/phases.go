@@ -0,0 +1,56 @@
+package scanner
+
+import (
+	"fmt"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// PhaseFunc executes a single scan phase
+type PhaseFunc func() error
+
+// SelectedPhases returns the configured phases in execution order, defaulting to all phases
+func SelectedPhases(cfg *browserk.Config) ([]string, error) {
+	if cfg == nil || len(cfg.Phases) == 0 {
+		return browserk.AllPhases, nil
+	}
+
+	selected := make(map[string]struct{}, len(cfg.Phases))
+	for _, phase := range cfg.Phases {
+		if !isPhase(phase) {
+			return nil, fmt.Errorf("unknown scan phase: %s", phase)
+		}
+		selected[phase] = struct{}{}
+	}
+
+	phases := make([]string, 0, len(selected))
+	for _, phase := range browserk.AllPhases {
+		if _, ok := selected[phase]; ok {
+			phases = append(phases, phase)
+		}
+	}
+	return phases, nil
+}
+
+func isPhase(name string) bool {
+	for _, phase := range browserk.AllPhases {
+		if phase == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunPhases executes the phase functions for the selected phases in order, stopping on the first error
+func RunPhases(selected []string, phases map[string]PhaseFunc) error {
+	for _, phase := range selected {
+		fn, ok := phases[phase]
+		if !ok {
+			return fmt.Errorf("no handler for scan phase: %s", phase)
+		}
+		if err := fn(); err != nil {
+			return fmt.Errorf("%s phase failed: %w", phase, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package scanner_test
+
+import (
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+)
+
+func TestSelectedPhases(t *testing.T) {
+	cfg := mock.MakeMockConfig()
+	phases, err := scanner.SelectedPhases(cfg)
+	if err != nil {
+		t.Fatalf("error selecting phases: %s\n", err)
+	}
+	if len(phases) != 3 {
+		t.Fatalf("expected all phases by default got %v", phases)
+	}
+
+	cfg.Phases = []string{browserk.PhaseReport, browserk.PhaseCrawl}
+	phases, err = scanner.SelectedPhases(cfg)
+	if err != nil {
+		t.Fatalf("error selecting phases: %s\n", err)
+	}
+	if len(phases) != 2 || phases[0] != browserk.PhaseCrawl || phases[1] != browserk.PhaseReport {
+		t.Fatalf("expected phases to be ordered got %v", phases)
+	}
+
+	cfg.Phases = []string{"fuzz"}
+	if _, err := scanner.SelectedPhases(cfg); err == nil {
+		t.Fatalf("expected error for unknown phase")
+	}
+}
+
+func TestRunPhasesCrawlOnly(t *testing.T) {
+	cfg := mock.MakeMockConfig()
+	cfg.Phases = []string{browserk.PhaseCrawl}
+
+	phases, err := scanner.SelectedPhases(cfg)
+	if err != nil {
+		t.Fatalf("error selecting phases: %s\n", err)
+	}
+
+	ran := make([]string, 0)
+	phaseFuncs := make(map[string]scanner.PhaseFunc)
+	for _, phase := range browserk.AllPhases {
+		phase := phase
+		phaseFuncs[phase] = func() error {
+			ran = append(ran, phase)
+			return nil
+		}
+	}
+
+	if err := scanner.RunPhases(phases, phaseFuncs); err != nil {
+		t.Fatalf("error running phases: %s\n", err)
+	}
+
+	if len(ran) != 1 || ran[0] != browserk.PhaseCrawl {
+		t.Fatalf("expected only the crawl phase to execute got %v", ran)
+	}
+}
@@ -0,0 +1,76 @@
+package scanner_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/store"
+)
+
+// seededRun fills a form and crawls a few navigations, returning the filled values, the order
+// navigations were crawled in and their correlation ids
+func seededRun(t *testing.T, seed int64) (string, []string, []string) {
+	ctx := context.Background()
+	cfg := mock.MakeMockConfig()
+	cfg.NumBrowsers = 3
+	cfg.RandomSeed = seed
+	cfg.CorrelationHeader = true
+
+	graph := store.NewMemoryCrawlGraph()
+	if err := graph.Init(); err != nil {
+		t.Fatalf("error initializing graph: %s\n", err)
+	}
+	for i := 0; i < 3; i++ {
+		nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte(fmt.Sprintf("http://localhost:8080/%d", i))})
+		if err := graph.AddNavigation(nav); err != nil {
+			t.Fatalf("error adding navigation: %s\n", err)
+		}
+	}
+
+	b := mock.MakeMockBrowser()
+	visited := make([]string, 0)
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		visited = append(visited, string(act.Input))
+		return nil, true, nil
+	}
+	correlationIDs := make([]string, 0)
+	b.SetExtraHeadersFn = func(headers map[string]string) error {
+		correlationIDs = append(correlationIDs, headers[browserk.CorrelationHeader])
+		return nil
+	}
+
+	engine := scanner.NewTestEngine(cfg, graph, mock.MakeMockBrowserPool(b), mock.Context(ctx))
+	form := mock.MakeMockAddressForm()
+	phone := mock.MakeMockInput("tel", "phone", "")
+	phone.Attributes["pattern"] = "[0-9]{3}-[0-9]{4}"
+	form.ChildElements = append(form.ChildElements, phone)
+	engine.NewFormHandler().Fill(form)
+	engine.CrawlNext()
+	return phone.Value, visited, correlationIDs
+}
+
+func TestRandomSeedReproducesScan(t *testing.T) {
+	firstValue, firstOrder, firstIDs := seededRun(t, 1234)
+	secondValue, secondOrder, secondIDs := seededRun(t, 1234)
+
+	if firstValue == "" || firstValue != secondValue {
+		t.Fatalf("expected the same seed to fill the same value got %q and %q", firstValue, secondValue)
+	}
+
+	if len(firstOrder) != 3 || fmt.Sprint(firstOrder) != fmt.Sprint(secondOrder) {
+		t.Fatalf("expected the same crawl order got %v and %v", firstOrder, secondOrder)
+	}
+
+	if len(firstIDs) != 3 || fmt.Sprint(firstIDs) != fmt.Sprint(secondIDs) {
+		t.Fatalf("expected the same correlation ids got %v and %v", firstIDs, secondIDs)
+	}
+
+	otherValue, _, otherIDs := seededRun(t, 4321)
+	if otherValue == firstValue && fmt.Sprint(otherIDs) == fmt.Sprint(firstIDs) {
+		t.Fatalf("expected a different seed to generate different values")
+	}
+}
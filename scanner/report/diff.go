@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/store"
+)
+
+// Diff the findings stored in the previous and current scan data directories
+func Diff(previous, current string) (*browserk.ScanDiff, error) {
+	before, err := LoadFindings(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := LoadFindings(current)
+	if err != nil {
+		return nil, err
+	}
+	return browserk.NewScanDiff(before, after), nil
+}
+
+// LoadFindings persisted to the attack graph of a scan's data directory
+func LoadFindings(dataPath string) ([]*browserk.Report, error) {
+	pluginPath := filepath.Join(dataPath, "plugin")
+	// Init would create an empty store for a mistyped path
+	if _, err := os.Stat(pluginPath); err != nil {
+		return nil, errors.Wrapf(err, "no attack graph found in %s", dataPath)
+	}
+
+	pluginStore := store.NewPluginStore(pluginPath)
+	if err := pluginStore.Init(); err != nil {
+		return nil, errors.Wrapf(err, "failed to open attack graph in %s", dataPath)
+	}
+	defer pluginStore.Close()
+
+	return pluginStore.GetReports()
+}
+
+// PrintDiff of the new, resolved and unchanged findings
+func PrintDiff(writer io.Writer, diff *browserk.ScanDiff) {
+	fmt.Fprintf(writer, "%d new, %d resolved, %d unchanged\n", len(diff.New), len(diff.Resolved), len(diff.Unchanged))
+	printSection(writer, "NEW", diff.New)
+	printSection(writer, "RESOLVED", diff.Resolved)
+	printSection(writer, "UNCHANGED", diff.Unchanged)
+}
+
+func printSection(writer io.Writer, title string, reports []*browserk.Report) {
+	if len(reports) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "%s:\n", title)
+	for _, report := range reports {
+		fmt.Fprintf(writer, "  %s\n", formatFinding(report))
+	}
+}
@@ -0,0 +1,64 @@
+package report_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/scanner/report"
+	"gitlab.com/browserker/store"
+)
+
+func storeScan(t *testing.T, dataPath string, reports []*browserk.Report) {
+	pluginStore := store.NewPluginStore(filepath.Join(dataPath, "plugin"))
+	if err := pluginStore.Init(); err != nil {
+		t.Fatalf("error opening plugin store: %s\n", err)
+	}
+	defer pluginStore.Close()
+
+	for _, r := range reports {
+		if err := pluginStore.AddReport(r); err != nil {
+			t.Fatalf("error adding report: %s\n", err)
+		}
+	}
+}
+
+func finding(param string) *browserk.Report {
+	return &browserk.Report{VulnID: "BR-A-0001", CWE: 89, Severity: browserk.High, Description: "sql error", Evidence: &browserk.Evidence{URL: "http://example.com/", Parameter: param}}
+}
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "browserk-diff")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	previous := filepath.Join(dir, "previous")
+	current := filepath.Join(dir, "current")
+	storeScan(t, previous, []*browserk.Report{finding("id"), finding("name")})
+	storeScan(t, current, []*browserk.Report{finding("name"), finding("email")})
+
+	diff, err := report.Diff(previous, current)
+	if err != nil {
+		t.Fatalf("error diffing scans: %s\n", err)
+	}
+
+	if len(diff.New) != 1 || diff.New[0].Evidence.Parameter != "email" {
+		t.Fatalf("expected email to be new got %#v\n", diff.New)
+	}
+
+	if len(diff.Resolved) != 1 || diff.Resolved[0].Evidence.Parameter != "id" {
+		t.Fatalf("expected id to be resolved got %#v\n", diff.Resolved)
+	}
+
+	if len(diff.Unchanged) != 1 || diff.Unchanged[0].Evidence.Parameter != "name" {
+		t.Fatalf("expected name to be unchanged got %#v\n", diff.Unchanged)
+	}
+
+	if _, err := report.Diff(filepath.Join(dir, "missing"), current); err == nil {
+		t.Fatalf("expected error diffing a missing scan\n")
+	}
+}
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// JSONEvents writes each scan event as a line of JSON
+type JSONEvents struct {
+	lock *sync.Mutex
+	enc  *json.Encoder
+}
+
+// NewJSONEvents writing newline delimited events to w
+func NewJSONEvents(w io.Writer) *JSONEvents {
+	return &JSONEvents{lock: &sync.Mutex{}, enc: json.NewEncoder(w)}
+}
+
+// Emit the event, events from concurrent crawls are never interleaved
+func (j *JSONEvents) Emit(evt *browserk.ScanEvent) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.enc.Encode(evt)
+}
+
+// EventReporter emits a finding event for every report before passing it on
+type EventReporter struct {
+	browserk.Reporter
+	events browserk.EventEmitter
+}
+
+// NewEventReporter wrapping reporter
+func NewEventReporter(reporter browserk.Reporter, events browserk.EventEmitter) *EventReporter {
+	return &EventReporter{Reporter: reporter, events: events}
+}
+
+// Add the report and emit it as a finding
+func (r *EventReporter) Add(report *browserk.Report) {
+	r.events.Emit(browserk.NewFindingEvent(report))
+	r.Reporter.Add(report)
+}
@@ -0,0 +1,53 @@
+package report_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner/report"
+)
+
+func TestEventReporter(t *testing.T) {
+	out := &bytes.Buffer{}
+	reports := mock.MakeMockReporter()
+	reporter := report.NewEventReporter(reports, report.NewJSONEvents(out))
+
+	reporter.Add(&browserk.Report{
+		VulnID:   "BR-A-0001",
+		CWE:      89,
+		Severity: browserk.High,
+		Evidence: &browserk.Evidence{URL: "http://localhost/?id=1", Parameter: "id", Screenshot: []byte("png")},
+	})
+	reporter.Add(&browserk.Report{VulnID: "BR-A-0002", Severity: browserk.Info})
+
+	if len(reports.Reports) != 2 {
+		t.Fatalf("expected reports to be passed to the wrapped reporter got %d", len(reports.Reports))
+	}
+
+	events := make([]*browserk.ScanEvent, 0)
+	lines := bufio.NewScanner(out)
+	for lines.Scan() {
+		evt := &browserk.ScanEvent{}
+		if err := json.Unmarshal(lines.Bytes(), evt); err != nil {
+			t.Fatalf("malformed event %s: %s", lines.Text(), err)
+		}
+		events = append(events, evt)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events got %d", len(events))
+	}
+
+	first := events[0]
+	if first.Type != browserk.EventFinding || first.Finding == nil {
+		t.Fatalf("expected finding event got %#v", first)
+	}
+
+	if first.Finding.Severity != "High" || first.Finding.Parameter != "id" || first.Finding.URL != "http://localhost/?id=1" {
+		t.Fatalf("unexpected finding %#v", first.Finding)
+	}
+}
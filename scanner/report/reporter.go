@@ -1,28 +1,185 @@
 package report
 
 import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"gitlab.com/browserker/browserk"
 )
 
 type Reporter struct {
-	reports map[string]map[string]*browserk.Report
+	lock       *sync.RWMutex
+	reports    map[string]map[string]*browserk.Report
+	incomplete string
+	baseline   *browserk.Baseline
+	evidence   string // directory screenshots of findings are written to
 }
 
 func New() *Reporter {
-	return &Reporter{reports: make(map[string]map[string]*browserk.Report, 0)}
+	return &Reporter{lock: &sync.RWMutex{}, reports: make(map[string]map[string]*browserk.Report, 0)}
 }
 
 func (r *Reporter) Add(report *browserk.Report) {
-	key := report.VulnID + report.Evidence.Hash()
-	if _, exist := r.reports[report.VulnID]; exist {
-		r.reports[report.VulnID][key] = report
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	report.Baselined = r.baseline.Contains(report)
+	key := report.Fingerprint()
+	if _, exist := r.reports[report.VulnID]; !exist {
+		r.reports[report.VulnID] = make(map[string]*browserk.Report)
 	}
-	r.reports[report.VulnID] = make(map[string]*browserk.Report)
 	r.reports[report.VulnID][key] = report
 }
 
+// SetIncomplete marks the report as partial with the reason the scan did not finish
+func (r *Reporter) SetIncomplete(reason string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.incomplete = reason
+}
+
+// SetBaseline of accepted findings, must be set before findings are added
+func (r *Reporter) SetBaseline(baseline *browserk.Baseline) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.baseline = baseline
+}
+
+// SetEvidenceDir screenshots of findings are written to when printed, without it they are only
+// kept with the stored findings
+func (r *Reporter) SetEvidenceDir(dir string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.evidence = dir
+}
+
+// Findings ordered by vuln id, including baselined findings
+func (r *Reporter) Findings() []*browserk.Report {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.findings()
+}
+
+// findings ordered by vuln id then fingerprint, lock must be held
+func (r *Reporter) findings() []*browserk.Report {
+	vulnIDs := make([]string, 0, len(r.reports))
+	for vulnID := range r.reports {
+		vulnIDs = append(vulnIDs, vulnID)
+	}
+	sort.Strings(vulnIDs)
+
+	findings := make([]*browserk.Report, 0)
+	for _, vulnID := range vulnIDs {
+		keys := make([]string, 0, len(r.reports[vulnID]))
+		for key := range r.reports[vulnID] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			findings = append(findings, r.reports[vulnID][key])
+		}
+	}
+	return findings
+}
+
+// Print the findings ordered by vuln id, incomplete reports are marked as such
 func (r *Reporter) Print(writer io.Writer) {
-	return
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.incomplete != "" {
+		fmt.Fprintf(writer, "INCOMPLETE: %s, results are partial\n", r.incomplete)
+	}
+
+	for _, report := range r.findings() {
+		fmt.Fprintf(writer, "%s\n", formatFinding(report))
+		r.printEvidence(writer, report)
+	}
+}
+
+// printEvidence of the element a finding was confirmed on under the finding, lock must be held
+func (r *Reporter) printEvidence(writer io.Writer, report *browserk.Report) {
+	evidence := report.Evidence
+	if evidence == nil {
+		return
+	}
+
+	if len(evidence.Ancestry) > 0 {
+		fmt.Fprintf(writer, "  element: %s\n", strings.Join(evidence.Ancestry, " > "))
+	}
+
+	if evidence.HTML != "" {
+		fmt.Fprintf(writer, "  html: %s\n", evidence.HTML)
+	}
+
+	if len(evidence.Screenshot) == 0 || r.evidence == "" {
+		return
+	}
+
+	path, err := r.writeScreenshot(report)
+	if err != nil {
+		fmt.Fprintf(writer, "  screenshot: failed to write: %s\n", err)
+		return
+	}
+	fmt.Fprintf(writer, "  screenshot: %s\n", path)
+}
+
+// writeScreenshot of the finding to the evidence directory, named by its fingerprint
+func (r *Reporter) writeScreenshot(report *browserk.Report) (string, error) {
+	if err := os.MkdirAll(r.evidence, 0700); err != nil {
+		return "", err
+	}
+
+	name := md5.Sum([]byte(report.Fingerprint()))
+	path := filepath.Join(r.evidence, report.VulnID+"-"+hex.EncodeToString(name[:])+".png")
+	if err := ioutil.WriteFile(path, report.Evidence.Screenshot, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// PrintFailedNavigations lists the navigations which were permanently failed, each path's last
+// navigation is the one which failed
+func PrintFailedNavigations(writer io.Writer, paths [][]*browserk.Navigation) {
+	for _, path := range paths {
+		if len(path) == 0 {
+			continue
+		}
+		nav := path[len(path)-1]
+		fmt.Fprintf(writer, "FAILED: %s %s after %d attempts: %s\n", browserk.ActionTypeMap[nav.Action.Type], nav.Action, nav.Retries, nav.LastError)
+	}
+}
+
+// PrintScanErrors lists the navigations and attacks which failed without stopping the scan
+func PrintScanErrors(writer io.Writer, scanErrors *browserk.ScanErrors) {
+	errs := scanErrors.Errors()
+	if len(errs) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "FAILURES (%d):\n", len(errs))
+	for _, err := range errs {
+		fmt.Fprintf(writer, "  [%s] %s: %s\n", err.Phase, err.URL, err.Err)
+	}
+}
+
+// formatFinding as a single line of severity, vuln id, cwe, url and description
+func formatFinding(report *browserk.Report) string {
+	url := ""
+	if report.Evidence != nil {
+		url = report.Evidence.URL
+	}
+	baselined := ""
+	if report.Baselined {
+		baselined = " (baselined)"
+	}
+	return fmt.Sprintf("[%s] %s CWE-%d %s: %s%s", browserk.SeverityMap[report.Severity], report.VulnID, report.CWE, url, report.Description, baselined)
 }
@@ -0,0 +1,90 @@
+package report_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/scanner/report"
+)
+
+func TestReporterPrintIncomplete(t *testing.T) {
+	reporter := report.New()
+	reporter.Add(&browserk.Report{VulnID: "BR-A-0001", CWE: 89, Severity: browserk.High, Description: "sql error", Evidence: &browserk.Evidence{URL: "http://example.com/"}})
+
+	out := &bytes.Buffer{}
+	reporter.Print(out)
+	if strings.Contains(out.String(), "INCOMPLETE") || !strings.Contains(out.String(), "[High] BR-A-0001 CWE-89 http://example.com/: sql error") {
+		t.Fatalf("unexpected report %q", out.String())
+	}
+
+	reporter.SetIncomplete("scan stopped")
+	out.Reset()
+	reporter.Print(out)
+	if !strings.HasPrefix(out.String(), "INCOMPLETE: scan stopped") || !strings.Contains(out.String(), "BR-A-0001") {
+		t.Fatalf("expected partial report to be marked incomplete got %q", out.String())
+	}
+}
+
+func TestReporterBaseline(t *testing.T) {
+	accepted := &browserk.Report{VulnID: "BR-A-0001", CWE: 89, Severity: browserk.High, Description: "sql error", Evidence: &browserk.Evidence{URL: "http://example.com/", Parameter: "id"}}
+	reporter := report.New()
+	reporter.SetBaseline(browserk.NewBaseline([]*browserk.Report{accepted}))
+
+	reporter.Add(&browserk.Report{VulnID: "BR-A-0001", CWE: 89, Severity: browserk.High, Description: "sql error", Evidence: &browserk.Evidence{URL: "http://example.com/", Parameter: "id"}})
+	reporter.Add(&browserk.Report{VulnID: "BR-A-0001", CWE: 89, Severity: browserk.High, Description: "sql error", Evidence: &browserk.Evidence{URL: "http://example.com/", Parameter: "name"}})
+
+	findings := reporter.Findings()
+	if len(findings) != 2 {
+		t.Fatalf("expected baselined findings to still be listed got %d", len(findings))
+	}
+
+	actionable := browserk.ActionableReports(findings)
+	if len(actionable) != 1 || actionable[0].Evidence.Parameter != "name" {
+		t.Fatalf("expected only the new finding to be actionable got %d", len(actionable))
+	}
+
+	out := &bytes.Buffer{}
+	reporter.Print(out)
+	if strings.Count(out.String(), "(baselined)") != 1 {
+		t.Fatalf("expected the accepted finding to be listed as baselined got %q", out.String())
+	}
+}
+
+func TestReporterPrintEvidence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "browserk_evidence")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %s\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	reporter := report.New()
+	reporter.SetEvidenceDir(dir)
+	reporter.Add(&browserk.Report{VulnID: "BR-A-0001", CWE: 89, Severity: browserk.High, Description: "sql error", Evidence: &browserk.Evidence{
+		URL:        "http://example.com/",
+		Parameter:  "id",
+		HTML:       `<input name="id">`,
+		Ancestry:   []string{"html", "body", "form#search", "input"},
+		Screenshot: []byte("png"),
+	}})
+
+	out := &bytes.Buffer{}
+	reporter.Print(out)
+	if !strings.Contains(out.String(), "  element: html > body > form#search > input\n") || !strings.Contains(out.String(), `  html: <input name="id">`) {
+		t.Fatalf("expected element evidence under the finding got %q", out.String())
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "BR-A-0001-*.png"))
+	if len(files) != 1 || !strings.Contains(out.String(), "  screenshot: "+files[0]) {
+		t.Fatalf("expected screenshot to be written and referenced got %v %q", files, out.String())
+	}
+
+	data, err := ioutil.ReadFile(files[0])
+	if err != nil || string(data) != "png" {
+		t.Fatalf("expected screenshot contents to be written: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// GraphScheduler is the default scheduler, returning unvisited navigations in crawl graph order
+type GraphScheduler struct {
+	crawl browserk.CrawlGrapher
+}
+
+// NewGraphScheduler for the crawl graph
+func NewGraphScheduler(crawl browserk.CrawlGrapher) *GraphScheduler {
+	return &GraphScheduler{crawl: crawl}
+}
+
+// Next finds up to max unvisited navigations, marking them in process
+func (s *GraphScheduler) Next(ctx context.Context, max int64) [][]*browserk.Navigation {
+	return s.crawl.Find(ctx, browserk.NavUnvisited, browserk.NavInProcess, max)
+}
+
+// Requeue sets the navigation back to unvisited
+func (s *GraphScheduler) Requeue(nav *browserk.Navigation) error {
+	return s.crawl.RequeueNavigation(nav.ID)
+}
+
+// htmlFirstLookahead is how many times the requested number of paths the html first scheduler
+// queues, html navigations only jump ahead of others within the queue
+const htmlFirstLookahead = 10
+
+// HTMLFirstScheduler crawls navigations to html pages before those known to return other content
+// (such as json api responses), which rarely yield new navigations. Content types are learned from
+// the responses captured in each navigation result. Unvisited navigations are taken from the crawl
+// graph into an in process queue so newly found html navigations can jump ahead of queued ones.
+type HTMLFirstScheduler struct {
+	crawl browserk.CrawlGrapher
+
+	lock    sync.Mutex
+	queue   [][]*browserk.Navigation
+	nonHTML map[string]bool   // url (without query) -> response was not html
+	pages   map[string]string // navigation id -> url the browser ended on after executing it
+}
+
+// NewHTMLFirstScheduler for the crawl graph
+func NewHTMLFirstScheduler(crawl browserk.CrawlGrapher) *HTMLFirstScheduler {
+	return &HTMLFirstScheduler{crawl: crawl, nonHTML: make(map[string]bool), pages: make(map[string]string)}
+}
+
+// Next tops up the queue with unvisited navigations to htmlFirstLookahead times max, returning up
+// to max of them with html and unknown content first
+func (s *HTMLFirstScheduler) Next(ctx context.Context, max int64) [][]*browserk.Navigation {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if need := max*htmlFirstLookahead - int64(len(s.queue)); need > 0 {
+		s.queue = append(s.queue, s.crawl.Find(ctx, browserk.NavUnvisited, browserk.NavInProcess, need)...)
+	}
+	sort.SliceStable(s.queue, func(i, j int) bool {
+		return !s.isNonHTML(s.queue[i]) && s.isNonHTML(s.queue[j])
+	})
+
+	if int64(len(s.queue)) < max {
+		max = int64(len(s.queue))
+	}
+	next := make([][]*browserk.Navigation, max)
+	copy(next, s.queue)
+	s.queue = s.queue[max:]
+	return next
+}
+
+// Requeue sets the navigation back to unvisited
+func (s *HTMLFirstScheduler) Requeue(nav *browserk.Navigation) error {
+	return s.crawl.RequeueNavigation(nav.ID)
+}
+
+// Observe the content types of the responses captured in result
+func (s *HTMLFirstScheduler) Observe(result *browserk.NavigationResult) {
+	if result == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(result.NavigationID) > 0 && result.EndURL != "" {
+		s.pages[string(result.NavigationID)] = result.EndURL
+	}
+
+	for _, m := range result.Messages {
+		if m.Response == nil || m.Response.Response == nil || m.Response.Response.MimeType == "" {
+			continue
+		}
+		resp := m.Response.Response
+		s.nonHTML[contentKey(resp.Url)] = !strings.Contains(resp.MimeType, "html")
+	}
+}
+
+// isNonHTML returns true if the path's last navigation loads a url known to not return html,
+// lock must be held
+func (s *HTMLFirstScheduler) isNonHTML(path []*browserk.Navigation) bool {
+	if len(path) == 0 {
+		return false
+	}
+	target := navigationTarget(path[len(path)-1], s.pageURL(path[:len(path)-1]))
+	return target != "" && s.nonHTML[contentKey(target)]
+}
+
+// pageURL the browser is on after executing the path, the observed end url of its last navigation
+// if known, otherwise the last url the path loads. lock must be held
+func (s *HTMLFirstScheduler) pageURL(path []*browserk.Navigation) string {
+	page := ""
+	for _, nav := range path {
+		if end, ok := s.pages[string(nav.ID)]; ok {
+			page = end
+			continue
+		}
+
+		if target := navigationTarget(nav, page); target != "" {
+			page = target
+		}
+	}
+	return page
+}
+
+// navigationTarget returns the absolute url the navigation loads, relative link hrefs are resolved
+// against page, the url the link was found on. Empty if it doesn't load a url.
+func navigationTarget(nav *browserk.Navigation, page string) string {
+	if nav.Action == nil {
+		return ""
+	}
+
+	if nav.Action.Type == browserk.ActLoadURL {
+		return string(nav.Action.Input)
+	}
+
+	if nav.Action.Element == nil || nav.Action.Element.Type != browserk.A {
+		return ""
+	}
+
+	href, err := url.Parse(nav.Action.Element.GetAttribute("href"))
+	if err != nil {
+		return ""
+	}
+
+	base, err := url.Parse(page)
+	if err != nil || page == "" {
+		return href.String()
+	}
+	return base.ResolveReference(href).String()
+}
+
+// contentKey of a url, the query and fragment are dropped
+func contentKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
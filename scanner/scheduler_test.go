@@ -0,0 +1,186 @@
+package scanner_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wirepair/gcd/gcdapi"
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+	"gitlab.com/browserker/store"
+)
+
+// reverseScheduler crawls the most recently added navigations first
+type reverseScheduler struct {
+	entries [][]*browserk.Navigation
+	called  bool
+}
+
+func (s *reverseScheduler) Next(ctx context.Context, max int64) [][]*browserk.Navigation {
+	s.called = true
+	next := make([][]*browserk.Navigation, 0, len(s.entries))
+	for i := len(s.entries) - 1; i >= 0 && int64(len(next)) < max; i-- {
+		next = append(next, s.entries[i])
+	}
+	s.entries = nil
+	return next
+}
+
+func (s *reverseScheduler) Requeue(nav *browserk.Navigation) error {
+	s.entries = append(s.entries, []*browserk.Navigation{nav})
+	return nil
+}
+
+func TestCrawlHonorsScheduler(t *testing.T) {
+	ctx := context.Background()
+	urls := []string{"http://localhost:8080/1", "http://localhost:8080/2", "http://localhost:8080/3"}
+	scheduler := &reverseScheduler{}
+	for _, u := range urls {
+		nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte(u)})
+		scheduler.entries = append(scheduler.entries, []*browserk.Navigation{nav})
+	}
+
+	b := mock.MakeMockBrowser()
+	visited := make([]string, 0)
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		visited = append(visited, string(act.Input))
+		return nil, true, nil
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.NumBrowsers = len(urls)
+	graph := mock.MakeMockCrawlGraph()
+	engine := scanner.NewTestEngineWithScheduler(cfg, graph, scheduler, mock.MakeMockBrowserPool(b), mock.Context(ctx))
+	engine.CrawlNext()
+
+	if !scheduler.called {
+		t.Fatalf("expected engine to get entries from the scheduler")
+	}
+
+	if graph.FindCalled {
+		t.Fatalf("expected engine to not find entries in the crawl graph directly")
+	}
+
+	if len(visited) != len(urls) {
+		t.Fatalf("expected %d navigations got %d", len(urls), len(visited))
+	}
+
+	for i, u := range visited {
+		if u != urls[len(urls)-1-i] {
+			t.Fatalf("expected navigations in scheduler order, got %v", visited)
+		}
+	}
+}
+
+// responseMessage captured for url with the response's mime type
+func responseMessage(url, mimeType string) *browserk.HTTPMessage {
+	msg := mock.MakeMockMessage("GET", url, "")
+	msg.Response = &browserk.HTTPResponse{
+		Type:     "XHR",
+		Response: &gcdapi.NetworkResponse{Url: url, Status: 200, MimeType: mimeType},
+	}
+	return msg
+}
+
+func TestCrawlPrioritizesHTML(t *testing.T) {
+	ctx := context.Background()
+	graph := store.NewMemoryCrawlGraph()
+	if err := graph.Init(); err != nil {
+		t.Fatalf("error initializing graph: %s\n", err)
+	}
+
+	seed := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	if err := graph.AddNavigation(seed); err != nil {
+		t.Fatalf("error adding navigation: %s\n", err)
+	}
+
+	b := mock.MakeMockBrowser()
+	visited := make([]string, 0)
+	b.ExecuteActionFn = func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		visited = append(visited, string(act.Input))
+		return nil, true, nil
+	}
+	// the seed page's scripts fetch json from the api
+	messages := []*browserk.HTTPMessage{
+		responseMessage("http://localhost:8080/", "text/html"),
+		responseMessage("http://localhost:8080/api/1", "application/json"),
+		responseMessage("http://localhost:8080/api/2", "application/json"),
+		responseMessage("http://localhost:8080/api/3", "application/json"),
+	}
+	b.GetMessagesFn = func() ([]*browserk.HTTPMessage, error) {
+		return messages, nil
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.NumBrowsers = 1
+	cfg.PrioritizeHTML = true
+	engine := scanner.NewTestEngine(cfg, graph, mock.MakeMockBrowserPool(b), mock.Context(ctx))
+	engine.CrawlNext()
+
+	urls := []string{"http://localhost:8080/api/1", "http://localhost:8080/api/2", "http://localhost:8080/api/3", "http://localhost:8080/page.html"}
+	for _, u := range urls {
+		nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte(u)})
+		if err := graph.AddNavigation(nav); err != nil {
+			t.Fatalf("error adding navigation: %s\n", err)
+		}
+	}
+
+	for range urls {
+		engine.CrawlNext()
+	}
+
+	if len(visited) != len(urls)+1 {
+		t.Fatalf("expected %d navigations got %v", len(urls)+1, visited)
+	}
+
+	if visited[1] != "http://localhost:8080/page.html" {
+		t.Fatalf("expected html page to be crawled before json responses got %v", visited)
+	}
+}
+
+func TestHTMLFirstSchedulerResolvesLinksAgainstPage(t *testing.T) {
+	ctx := context.Background()
+	graph := store.NewMemoryCrawlGraph()
+	if err := graph.Init(); err != nil {
+		t.Fatalf("error initializing graph: %s\n", err)
+	}
+
+	link := func(from *browserk.Navigation, href string) *browserk.Navigation {
+		ele := &browserk.HTMLElement{Type: browserk.A, Attributes: map[string]string{"href": href}}
+		return browserk.NewNavigationFromElement(from, browserk.TrigCrawler, ele, browserk.ActLeftClick)
+	}
+
+	seed := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte("http://localhost:8080/")})
+	seed.State = browserk.NavVisited
+	dir := link(seed, "/dir/")
+	dir.State = browserk.NavVisited
+	// found on /dir/ so it loads /dir/data, not /data
+	data := link(dir, "data")
+	data.Priority = 1
+	page := link(dir, "page")
+	for _, nav := range []*browserk.Navigation{seed, dir, data, page} {
+		if err := graph.AddNavigation(nav); err != nil {
+			t.Fatalf("error adding navigation: %s\n", err)
+		}
+	}
+
+	scheduler := scanner.NewHTMLFirstScheduler(graph)
+	scheduler.Observe(&browserk.NavigationResult{
+		NavigationID: dir.ID,
+		EndURL:       "http://localhost:8080/dir/",
+		Messages: []*browserk.HTTPMessage{
+			responseMessage("http://localhost:8080/data", "text/html"),
+			responseMessage("http://localhost:8080/dir/data", "application/json"),
+		},
+	})
+
+	next := scheduler.Next(ctx, 2)
+	if len(next) != 2 {
+		t.Fatalf("expected 2 paths got %d", len(next))
+	}
+
+	if last := next[1][len(next[1])-1]; string(last.ID) != string(data.ID) {
+		t.Fatalf("expected the link to json found on /dir/ to be crawled last")
+	}
+}
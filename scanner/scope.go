@@ -17,6 +17,7 @@ type ScopeService struct {
 	ignored      []string
 	excluded     []string
 	excludedURIs []string // todo make regex
+	normalizer   browserk.URLNormalizer
 }
 
 // NewScopeService set the target url for easier matching
@@ -28,11 +29,26 @@ func NewScopeService(target *url.URL) *ScopeService {
 		ignored:      make([]string, 0),
 		excluded:     make([]string, 0),
 		excludedURIs: make([]string, 0),
+		normalizer:   browserk.NewURLNormalizer(nil),
 	}
 	s.AddScope([]string{target.Hostname()}, browserk.InScope)
 	return s
 }
 
+// SetNormalizer replaces the default url normalizer, it must be set before adding excluded URIs
+func (s *ScopeService) SetNormalizer(normalizer browserk.URLNormalizer) {
+	s.normalizer = normalizer
+}
+
+// normalizePath so excluded URIs and checked paths compare the same way
+func (s *ScopeService) normalizePath(path string) string {
+	u, err := url.Parse(s.normalizer.Normalize("http://scope.invalid" + path))
+	if err != nil {
+		return path
+	}
+	return u.Path
+}
+
 // AddScope to the scope service
 func (s *ScopeService) AddScope(inputs []string, scope browserk.Scope) {
 
@@ -61,15 +77,21 @@ func (s *ScopeService) AddExcludedURIs(inputs []string) {
 				log.Warn().Err(err).Msg("failed to add URI to exclusion list")
 				continue
 			}
-			s.excludedURIs = append(s.excludedURIs, strings.ToLower(u.Path))
+			s.excludedURIs = append(s.excludedURIs, strings.ToLower(s.normalizePath(u.Path)))
 		} else {
-			s.excludedURIs = append(s.excludedURIs, strings.ToLower(input))
+			if !strings.HasPrefix(input, "/") {
+				input = "/" + input
+			}
+			s.excludedURIs = append(s.excludedURIs, strings.ToLower(s.normalizePath(input)))
 		}
 	}
 }
 
 // Check a url to see if it's in scope
 func (s *ScopeService) Check(uri string) browserk.Scope {
+	if strings.HasPrefix(strings.ToLower(uri), "http") {
+		uri = s.normalizer.Normalize(uri)
+	}
 	lowered := strings.ToLower(uri)
 	host := s.target.Hostname()
 
@@ -92,7 +114,7 @@ func (s *ScopeService) Check(uri string) browserk.Scope {
 	} else if !strings.HasPrefix(lowered, "/") {
 		lowered = "/" + lowered
 	}
-	return s.CheckRelative(host, lowered)
+	return s.CheckRelative(host, s.normalizePath(lowered))
 }
 
 // ResolveBaseHref for html document links
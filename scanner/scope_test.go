@@ -62,6 +62,18 @@ func TestScope(t *testing.T) {
 			"statistics.php",
 			browserk.InScope,
 		},
+		{
+			"http://EXAMPLE.com:80/signout/",
+			browserk.ExcludedFromScope,
+		},
+		{
+			"http://example.com/signout#top",
+			browserk.ExcludedFromScope,
+		},
+		{
+			"/log-out/?utm_source=mail",
+			browserk.ExcludedFromScope,
+		},
 	}
 	for _, in := range inputs {
 		ret := s.Check(in.in)
@@ -0,0 +1,63 @@
+package scanner_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/scanner"
+)
+
+func TestCrawlWorkersBounded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var active, maxActive, completed int32
+	execute := func(ctx context.Context, act *browserk.Action) ([]byte, bool, error) {
+		current := atomic.AddInt32(&active, 1)
+		for {
+			seen := atomic.LoadInt32(&maxActive)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 10)
+		atomic.AddInt32(&active, -1)
+		atomic.AddInt32(&completed, 1)
+		return nil, false, nil
+	}
+
+	pool := mock.MakeMockBrowserPool(nil)
+	pool.TakeFn = func(ctx *browserk.Context) (browserk.Browser, string, error) {
+		b := mock.MakeMockBrowser()
+		b.ExecuteActionFn = execute
+		return b, "9222", nil
+	}
+
+	cfg := mock.MakeMockConfig()
+	cfg.NumBrowsers = 3
+	engine := scanner.NewTestEngine(cfg, mock.MakeMockCrawlGraph(), pool, mock.Context(ctx))
+	engine.StartWorkers()
+
+	entries := 30
+	for i := 0; i < entries; i++ {
+		nav := browserk.NewNavigation(browserk.TrigInitial, &browserk.Action{Type: browserk.ActLoadURL, Input: []byte(fmt.Sprintf("http://localhost:8080/%d", i))})
+		engine.Submit([]*browserk.Navigation{nav})
+	}
+
+	deadline := time.Now().Add(time.Second * 10)
+	for atomic.LoadInt32(&completed) < int32(entries) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d entries to complete got %d", entries, atomic.LoadInt32(&completed))
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if max := atomic.LoadInt32(&maxActive); max > int32(cfg.NumBrowsers) || max == 0 {
+		t.Fatalf("expected at most %d concurrent crawls got %d", cfg.NumBrowsers, max)
+	}
+}
@@ -3,12 +3,14 @@ package store
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"reflect"
 
 	badger "github.com/dgraph-io/badger/v2"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/msgpack/v4"
 	"gitlab.com/browserker/browserk"
 )
 
@@ -105,29 +107,33 @@ func (g *CrawlGraph) AddNavigations(navs []*browserk.Navigation) error {
 	}
 
 	return g.GraphStore.Update(func(txn *badger.Txn) error {
-		for _, nav := range navs {
+		return g.addNavigations(txn, navs)
+	})
+}
 
-			existKey := MakeKey(nav.ID, "id")
-			_, err := txn.Get(existKey)
-			if err == nil {
-				log.Debug().Bytes("nav", nav.ID).Msg("not adding nav as it already exists")
-				return nil
-			}
+func (g *CrawlGraph) addNavigations(txn *badger.Txn, navs []*browserk.Navigation) error {
+	for _, nav := range navs {
+
+		existKey := MakeKey(nav.ID, "id")
+		_, err := txn.Get(existKey)
+		if err == nil {
+			log.Debug().Bytes("nav", nav.ID).Msg("not adding nav as it already exists")
+			continue
+		}
 
-			for i := 0; i < len(g.navPredicates); i++ {
-				key := MakeKey(nav.ID, g.navPredicates[i].name)
+		for i := 0; i < len(g.navPredicates); i++ {
+			key := MakeKey(nav.ID, g.navPredicates[i].name)
 
-				rv := reflect.ValueOf(*nav)
-				bytez, err := Encode(rv, g.navPredicates[i].index)
-				if err != nil {
-					return err
-				}
-				// key = <id>:<predicate>, value = msgpack'd bytes
-				txn.Set(key, bytez)
+			rv := reflect.ValueOf(*nav)
+			bytez, err := Encode(rv, g.navPredicates[i].index)
+			if err != nil {
+				return err
 			}
+			// key = <id>:<predicate>, value = msgpack'd bytes
+			txn.Set(key, bytez)
 		}
-		return nil
-	})
+	}
+	return nil
 }
 
 // NavExists check
@@ -176,44 +182,115 @@ func (g *CrawlGraph) GetNavigation(id []byte) (*browserk.Navigation, error) {
 func (g *CrawlGraph) AddResult(result *browserk.NavigationResult) error {
 
 	return g.GraphStore.Update(func(txn *badger.Txn) error {
-		for i := 0; i < len(g.navResultPredicates); i++ {
-			key := MakeKey(result.ID, g.navResultPredicates[i].name)
-			rv := reflect.ValueOf(*result)
-			bytez, err := Encode(rv, g.navResultPredicates[i].index)
-
-			if g.navResultPredicates[i].name == "r_nav_id" {
-				navKey := MakeKey(result.NavigationID, g.navResultPredicates[i].name)
-				enc, _ := EncodeBytes(result.ID)
-				// store this separately so we can it look it up (values are always encoded)
-				txn.Set(navKey, enc)
-			}
+		return g.addResult(txn, result)
+	})
+}
 
-			if err != nil {
-				log.Error().Err(err).Msg("failed to encode nav result")
-				return err
-			}
-			// key = <id>:<predicate>, value = msgpack'd bytes
-			txn.Set(key, bytez)
+func (g *CrawlGraph) addResult(txn *badger.Txn, result *browserk.NavigationResult) error {
+	for i := 0; i < len(g.navResultPredicates); i++ {
+		key := MakeKey(result.ID, g.navResultPredicates[i].name)
+		rv := reflect.ValueOf(*result)
+		bytez, err := Encode(rv, g.navResultPredicates[i].index)
+
+		if g.navResultPredicates[i].name == "r_nav_id" {
+			navKey := MakeKey(result.NavigationID, g.navResultPredicates[i].name)
+			enc, _ := EncodeBytes(result.ID)
+			// store this separately so we can it look it up (values are always encoded)
+			txn.Set(navKey, enc)
 		}
-		// set the navigation id to visited
-		// TODO: track failures
-		navIDkey := MakeKey(result.NavigationID, "state")
-		value, _ := EncodeState(browserk.NavVisited)
-		return txn.Set(navIDkey, value)
-	})
+
+		if err != nil {
+			log.Error().Err(err).Msg("failed to encode nav result")
+			return err
+		}
+		// key = <id>:<predicate>, value = msgpack'd bytes
+		txn.Set(key, bytez)
+	}
+	// set the navigation id to visited
+	// TODO: track failures
+	return setNavState(txn, result.NavigationID, browserk.NavVisited)
 }
 
 // FailNavigation for this navID
 func (g *CrawlGraph) FailNavigation(navID []byte) error {
 	return g.GraphStore.Update(func(txn *badger.Txn) error {
-		// set the navigation id to visited
 		// TODO: track failures
-		navIDkey := MakeKey(navID, "state")
-		value, _ := EncodeState(browserk.NavFailed)
-		return txn.Set(navIDkey, value)
+		return setNavState(txn, navID, browserk.NavFailed)
+	})
+}
+
+// RequeueNavigation sets the navID back to unvisited so it will be found again
+func (g *CrawlGraph) RequeueNavigation(navID []byte) error {
+	return g.GraphStore.Update(func(txn *badger.Txn) error {
+		return setNavState(txn, navID, browserk.NavUnvisited)
+	})
+}
+
+// RecordNavigationError stores how many times the navigation failed and the most recent error
+func (g *CrawlGraph) RecordNavigationError(navID []byte, retries int, lastError string) error {
+	return g.GraphStore.Update(func(txn *badger.Txn) error {
+		retriesBytes, err := msgpack.Marshal(retries)
+		if err != nil {
+			return err
+		}
+
+		errorBytes, err := msgpack.Marshal(lastError)
+		if err != nil {
+			return err
+		}
+
+		if err := txn.Set(MakeKey(navID, "retries"), retriesBytes); err != nil {
+			return err
+		}
+		return txn.Set(MakeKey(navID, "last_error"), errorBytes)
 	})
 }
 
+func setNavState(txn *badger.Txn, navID []byte, state browserk.NavState) error {
+	navIDkey := MakeKey(navID, "state")
+	value, _ := EncodeState(state)
+	return txn.Set(navIDkey, value)
+}
+
+// Transaction commits all writes made by fn in a single badger transaction, if fn returns
+// an error or panics nothing is written
+func (g *CrawlGraph) Transaction(fn func(tx browserk.CrawlTx) error) error {
+	return g.GraphStore.Update(func(txn *badger.Txn) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("crawl graph transaction panicked: %v", r)
+			}
+		}()
+		return fn(&crawlTx{g: g, txn: txn})
+	})
+}
+
+// crawlTx writes to the crawl graph in an open badger transaction
+type crawlTx struct {
+	g   *CrawlGraph
+	txn *badger.Txn
+}
+
+// AddNavigations entries into our graph, skipping any that already exist
+func (t *crawlTx) AddNavigations(navs []*browserk.Navigation) error {
+	return t.g.addNavigations(t.txn, navs)
+}
+
+// AddResult of a navigation and set the nav state to visited
+func (t *crawlTx) AddResult(result *browserk.NavigationResult) error {
+	return t.g.addResult(t.txn, result)
+}
+
+// FailNavigation for this navID
+func (t *crawlTx) FailNavigation(navID []byte) error {
+	return setNavState(t.txn, navID, browserk.NavFailed)
+}
+
+// RequeueNavigation sets the navID back to unvisited
+func (t *crawlTx) RequeueNavigation(navID []byte) error {
+	return setNavState(t.txn, navID, browserk.NavUnvisited)
+}
+
 // GetNavigationResult from the navigation id
 func (g *CrawlGraph) GetNavigationResult(navID []byte) (*browserk.NavigationResult, error) {
 	exist := &browserk.NavigationResult{}
@@ -282,7 +359,7 @@ func (g *CrawlGraph) Find(ctx context.Context, byState, setState browserk.NavSta
 	entries := make([][]*browserk.Navigation, 0)
 	if byState == setState {
 		err := g.GraphStore.View(func(txn *badger.Txn) error {
-			nodeIDs, err := StateIterator(txn, byState, limit)
+			nodeIDs, err := PriorityStateIterator(txn, byState, limit)
 			if err != nil {
 				return err
 			}
@@ -301,7 +378,7 @@ func (g *CrawlGraph) Find(ctx context.Context, byState, setState browserk.NavSta
 		}
 	} else {
 		err := g.GraphStore.Update(func(txn *badger.Txn) error {
-			nodeIDs, err := StateIterator(txn, byState, limit)
+			nodeIDs, err := PriorityStateIterator(txn, byState, limit)
 			if err != nil {
 				return err
 			}
@@ -159,6 +159,40 @@ func TestCrawlAddNavigations(t *testing.T) {
 	testGetNavResults(t, g)
 }
 
+func TestCrawlFindPriority(t *testing.T) {
+	path := "testdata/priority/crawl"
+	os.RemoveAll(path)
+
+	g := store.NewCrawlGraph(path)
+	if err := g.Init(); err != nil {
+		t.Fatalf("error init graph: %s\n", err)
+	}
+	defer g.Close()
+
+	navs := make([]*browserk.Navigation, 0)
+	for i := 1; i < 6; i++ {
+		nav := mock.MakeMockNavi([]byte{0, byte(i), 2})
+		nav.OriginID = []byte{}
+		nav.Priority = i % 3
+		navs = append(navs, nav)
+	}
+
+	if err := g.AddNavigations(navs); err != nil {
+		t.Fatalf("error calling add navigations: %s\n", err)
+	}
+
+	entries := g.Find(nil, browserk.NavUnvisited, browserk.NavInProcess, 2)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries got %d\n", len(entries))
+	}
+
+	for _, entry := range entries {
+		if entry[len(entry)-1].Priority != 2 {
+			t.Fatalf("expected highest priority navigations first got %d\n", entry[len(entry)-1].Priority)
+		}
+	}
+}
+
 func testGetNavResults(t *testing.T, g browserk.CrawlGrapher) {
 	limit := 5
 	entries := g.Find(nil, browserk.NavUnvisited, browserk.NavUnvisited, int64(limit))
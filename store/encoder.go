@@ -58,6 +58,12 @@ func EncodeTime(t time.Time) ([]byte, error) {
 	return msgpack.Marshal(t)
 }
 
+// optionalNavPredicates may be missing from navigations stored before they were added
+var optionalNavPredicates = map[string]bool{
+	"retries":    true,
+	"last_error": true,
+}
+
 // DecodeNavigation takes a transaction and a nodeID and returns a navigation object or err
 func DecodeNavigation(txn *badger.Txn, predicates []*NavGraphField, nodeID []byte) (*browserk.Navigation, error) {
 	nav := &browserk.Navigation{}
@@ -72,6 +78,9 @@ func DecodeNavigation(txn *badger.Txn, predicates []*NavGraphField, nodeID []byt
 
 	for _, pred := range fields {
 		item, err := txn.Get(pred.key)
+		if err == badger.ErrKeyNotFound && optionalNavPredicates[pred.name] {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -184,6 +193,27 @@ func DecodeNavigationResultItem(item *badger.Item, nav *browserk.NavigationResul
 			nav.StorageEvents = v
 			return err
 		})
+	case "r_network":
+		err = item.Value(func(val []byte) error {
+			var v browserk.NetworkSummary
+			err := msgpack.Unmarshal(val, &v)
+			nav.Network = v
+			return err
+		})
+	case "r_overlays":
+		err = item.Value(func(val []byte) error {
+			v := make([]string, 0)
+			err := msgpack.Unmarshal(val, &v)
+			nav.Overlays = v
+			return err
+		})
+	case "r_skip_reason":
+		err = item.Value(func(val []byte) error {
+			var v string
+			err := msgpack.Unmarshal(val, &v)
+			nav.SkipReason = v
+			return err
+		})
 	case "r_caused_load":
 		err = item.Value(func(val []byte) error {
 			var v bool
@@ -272,6 +302,27 @@ func DecodeNavigationItem(item *badger.Item, nav *browserk.Navigation, pred stri
 			nav.Action = v
 			return err
 		})
+	case "retries":
+		err = item.Value(func(val []byte) error {
+			var v int
+			err := msgpack.Unmarshal(val, &v)
+			nav.Retries = v
+			return err
+		})
+	case "last_error":
+		err = item.Value(func(val []byte) error {
+			var v string
+			err := msgpack.Unmarshal(val, &v)
+			nav.LastError = v
+			return err
+		})
+	case "priority":
+		err = item.Value(func(val []byte) error {
+			var v int
+			err := msgpack.Unmarshal(val, &v)
+			nav.Priority = v
+			return err
+		})
 	default:
 		panic("unknown predicate for navigation")
 	}
@@ -2,8 +2,10 @@ package store
 
 import (
 	"bytes"
+	"sort"
 
 	badger "github.com/dgraph-io/badger/v2"
+	"github.com/vmihailenco/msgpack/v4"
 	"gitlab.com/browserker/browserk"
 )
 
@@ -42,6 +44,41 @@ func StateIterator(txn *badger.Txn, byState browserk.NavState, limit int64) ([][
 	return states, nil
 }
 
+// PriorityStateIterator returns up to limit ids of navigations in byState, highest priority first.
+// Navigations stored without a priority have a priority of 0.
+func PriorityStateIterator(txn *badger.Txn, byState browserk.NavState, limit int64) ([][]byte, error) {
+	ids, err := StateIterator(txn, byState, -1)
+	if err != nil || ids == nil {
+		return ids, err
+	}
+
+	priorities := make(map[string]int, len(ids))
+	for _, id := range ids {
+		item, err := txn.Get(MakeKey(id, "priority"))
+		if err == badger.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var priority int
+		if err := item.Value(func(val []byte) error {
+			return msgpack.Unmarshal(val, &priority)
+		}); err != nil {
+			return nil, err
+		}
+		priorities[string(id)] = priority
+	}
+
+	sort.SliceStable(ids, func(i, j int) bool {
+		return priorities[string(ids[i])] > priorities[string(ids[j])]
+	})
+	if int64(len(ids)) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
 func IfIterator(txn *badger.Txn, key, value []byte, limit int64) ([][]byte, error) {
 	results := make([][]byte, 0)
 	idx := int64(0)
@@ -0,0 +1,350 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/browserker/browserk"
+)
+
+// Backend types for the crawl and attack graphs
+const (
+	BackendDisk   = "disk"
+	BackendMemory = "memory"
+)
+
+// NewGraphs creates the crawl graph and attack (plugin) graph for the backend type. dataPath
+// is only used by the disk backend.
+func NewGraphs(backend, dataPath string) (browserk.CrawlGrapher, browserk.PluginStorer, error) {
+	switch backend {
+	case BackendDisk, "":
+		return NewCrawlGraph(dataPath + "/crawl"), NewPluginStore(dataPath + "/plugin"), nil
+	case BackendMemory:
+		return NewMemoryCrawlGraph(), NewMemoryAttackGraph(), nil
+	}
+	return nil, nil, fmt.Errorf("unknown store backend: %s", backend)
+}
+
+// MemoryCrawlGraph is an in-memory crawl graph for tests and throw away scans
+type MemoryCrawlGraph struct {
+	lock    *sync.RWMutex
+	navs    map[string]*browserk.Navigation
+	results map[string]*browserk.NavigationResult
+}
+
+// NewMemoryCrawlGraph creates a new in-memory crawl graph
+func NewMemoryCrawlGraph() *MemoryCrawlGraph {
+	return &MemoryCrawlGraph{lock: &sync.RWMutex{}}
+}
+
+// Init the crawl graph
+func (g *MemoryCrawlGraph) Init() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.navs == nil {
+		g.navs = make(map[string]*browserk.Navigation)
+		g.results = make(map[string]*browserk.NavigationResult)
+	}
+	return nil
+}
+
+// AddNavigation entry into our graph if it's unique
+func (g *MemoryCrawlGraph) AddNavigation(nav *browserk.Navigation) error {
+	return g.AddNavigations([]*browserk.Navigation{nav})
+}
+
+// AddNavigations entries into our graph, skipping any that already exist
+func (g *MemoryCrawlGraph) AddNavigations(navs []*browserk.Navigation) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.addNavigations(navs)
+	return nil
+}
+
+// addNavigations that don't already exist, lock must be held
+func (g *MemoryCrawlGraph) addNavigations(navs []*browserk.Navigation) {
+	for _, nav := range navs {
+		if _, exist := g.navs[string(nav.ID)]; exist {
+			continue
+		}
+		copied := *nav
+		g.navs[string(nav.ID)] = &copied
+	}
+}
+
+// NavExists check if the nav exists with the same state
+func (g *MemoryCrawlGraph) NavExists(nav *browserk.Navigation) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	exist, ok := g.navs[string(nav.ID)]
+	return ok && exist.State == nav.State
+}
+
+// GetNavigation by the provided id value
+func (g *MemoryCrawlGraph) GetNavigation(id []byte) (*browserk.Navigation, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	nav, ok := g.navs[string(id)]
+	if !ok {
+		return nil, fmt.Errorf("navigation %x not found", id)
+	}
+	copied := *nav
+	return &copied, nil
+}
+
+// AddResult of a navigation and set the nav state to visited
+func (g *MemoryCrawlGraph) AddResult(result *browserk.NavigationResult) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.addResult(result)
+	return nil
+}
+
+// addResult and set the nav state to visited, lock must be held
+func (g *MemoryCrawlGraph) addResult(result *browserk.NavigationResult) {
+	g.results[string(result.NavigationID)] = result
+	g.setState(result.NavigationID, browserk.NavVisited)
+}
+
+// FailNavigation for this navID
+func (g *MemoryCrawlGraph) FailNavigation(navID []byte) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.setState(navID, browserk.NavFailed)
+	return nil
+}
+
+// RequeueNavigation sets the navID back to unvisited so it will be found again
+func (g *MemoryCrawlGraph) RequeueNavigation(navID []byte) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.setState(navID, browserk.NavUnvisited)
+	return nil
+}
+
+// RecordNavigationError stores how many times the navigation failed and the most recent error
+func (g *MemoryCrawlGraph) RecordNavigationError(navID []byte, retries int, lastError string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if nav, ok := g.navs[string(navID)]; ok {
+		nav.Retries = retries
+		nav.LastError = lastError
+	}
+	return nil
+}
+
+// setState of the navID, lock must be held
+func (g *MemoryCrawlGraph) setState(navID []byte, state browserk.NavState) {
+	if nav, ok := g.navs[string(navID)]; ok {
+		nav.State = state
+		nav.StateUpdatedTime = time.Now()
+	}
+}
+
+// GetNavigationResult from the navigation id
+func (g *MemoryCrawlGraph) GetNavigationResult(navID []byte) (*browserk.NavigationResult, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	result, ok := g.results[string(navID)]
+	if !ok {
+		return &browserk.NavigationResult{}, nil
+	}
+	return result, nil
+}
+
+// GetNavigationResults ordered by their navigation id
+func (g *MemoryCrawlGraph) GetNavigationResults() ([]*browserk.NavigationResult, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	results := make([]*browserk.NavigationResult, 0, len(g.results))
+	for _, result := range g.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return bytes.Compare(results[i].NavigationID, results[j].NavigationID) < 0
+	})
+	return results, nil
+}
+
+// Find navigation entries by a state. iff byState == setState will we not update the
+// state (and time stamp) returns a slice of a slice of all navigations on how to get
+// to the final navigation state. Navigations are ordered by id, same as the disk store.
+func (g *MemoryCrawlGraph) Find(ctx context.Context, byState, setState browserk.NavState, limit int64) [][]*browserk.Navigation {
+	// make sure limit is sane
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	ids := make([]string, 0)
+	for id, nav := range g.navs {
+		if nav.State == byState {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	sort.SliceStable(ids, func(i, j int) bool {
+		return g.navs[ids[i]].Priority > g.navs[ids[j]].Priority
+	})
+	if int64(len(ids)) > limit {
+		ids = ids[:limit]
+	}
+
+	if byState != setState {
+		for _, id := range ids {
+			g.setState([]byte(id), setState)
+		}
+	}
+
+	entries := make([][]*browserk.Navigation, 0, len(ids))
+	for _, id := range ids {
+		path, err := g.pathTo(id)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, path)
+	}
+	return entries
+}
+
+// pathTo walks back from id to the root of the nav graph, returning the
+// navigations from start to finish. lock must be held
+func (g *MemoryCrawlGraph) pathTo(id string) ([]*browserk.Navigation, error) {
+	path := make([]*browserk.Navigation, 0)
+	for nav, ok := g.navs[id]; ok; nav, ok = g.navs[string(nav.OriginID)] {
+		if len(path) > 100 {
+			return nil, fmt.Errorf("max entries exceeded walking origin")
+		}
+		copied := *nav
+		path = append([]*browserk.Navigation{&copied}, path...)
+		if len(nav.OriginID) == 0 {
+			break
+		}
+	}
+	return path, nil
+}
+
+// Transaction applies the writes made by fn together once it returns without error, if fn
+// returns an error or panics nothing is written
+func (g *MemoryCrawlGraph) Transaction(fn func(tx browserk.CrawlTx) error) (err error) {
+	tx := &memoryTx{}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("crawl graph transaction panicked: %v", r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, op := range tx.ops {
+		op(g)
+	}
+	return nil
+}
+
+// memoryTx stages writes until the transaction is committed
+type memoryTx struct {
+	ops []func(g *MemoryCrawlGraph)
+}
+
+// AddNavigations entries into our graph, skipping any that already exist
+func (t *memoryTx) AddNavigations(navs []*browserk.Navigation) error {
+	t.ops = append(t.ops, func(g *MemoryCrawlGraph) { g.addNavigations(navs) })
+	return nil
+}
+
+// AddResult of a navigation and set the nav state to visited
+func (t *memoryTx) AddResult(result *browserk.NavigationResult) error {
+	t.ops = append(t.ops, func(g *MemoryCrawlGraph) { g.addResult(result) })
+	return nil
+}
+
+// FailNavigation for this navID
+func (t *memoryTx) FailNavigation(navID []byte) error {
+	t.ops = append(t.ops, func(g *MemoryCrawlGraph) { g.setState(navID, browserk.NavFailed) })
+	return nil
+}
+
+// RequeueNavigation sets the navID back to unvisited
+func (t *memoryTx) RequeueNavigation(navID []byte) error {
+	t.ops = append(t.ops, func(g *MemoryCrawlGraph) { g.setState(navID, browserk.NavUnvisited) })
+	return nil
+}
+
+// Close the graph
+func (g *MemoryCrawlGraph) Close() error {
+	return nil
+}
+
+// MemoryAttackGraph is an in-memory plugin/attack state store
+type MemoryAttackGraph struct {
+	lock    *sync.RWMutex
+	reports map[string]*browserk.Report
+}
+
+// NewMemoryAttackGraph creates a new in-memory attack graph
+func NewMemoryAttackGraph() *MemoryAttackGraph {
+	return &MemoryAttackGraph{lock: &sync.RWMutex{}, reports: make(map[string]*browserk.Report)}
+}
+
+// Init the plugin state storage
+func (s *MemoryAttackGraph) Init() error {
+	return nil
+}
+
+// IsUnique checks if a plugin event is unique and returns a bitmask of uniqueness
+func (s *MemoryAttackGraph) IsUnique(evt *browserk.PluginEvent) browserk.Unique {
+	return browserk.UniqueHost | browserk.UniquePath | browserk.UniqueFile | browserk.UniquePage | browserk.UniqueRequest | browserk.UniqueResponse
+}
+
+// AddEvent to the plugin store
+func (s *MemoryAttackGraph) AddEvent(evt *browserk.PluginEvent) {
+
+}
+
+// AddReport keyed by its fingerprint, replacing any previous entry
+func (s *MemoryAttackGraph) AddReport(report *browserk.Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reports[report.Fingerprint()] = report
+	return nil
+}
+
+// GetReports added by AddReport ordered by fingerprint
+func (s *MemoryAttackGraph) GetReports() ([]*browserk.Report, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	reports := make([]*browserk.Report, 0, len(s.reports))
+	for _, report := range s.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Fingerprint() < reports[j].Fingerprint()
+	})
+	return reports, nil
+}
+
+// Close the plugin store
+func (s *MemoryAttackGraph) Close() error {
+	return nil
+}
@@ -0,0 +1,255 @@
+package store_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"gitlab.com/browserker/browserk"
+	"gitlab.com/browserker/mock"
+	"gitlab.com/browserker/store"
+)
+
+// runs the same crawl graph contract against each backend
+func testBackends(t *testing.T, name string, fn func(t *testing.T, g browserk.CrawlGrapher)) {
+	path := "testdata/backends/" + name
+	os.RemoveAll(path)
+
+	backends := map[string]browserk.CrawlGrapher{
+		store.BackendDisk:   store.NewCrawlGraph(path),
+		store.BackendMemory: store.NewMemoryCrawlGraph(),
+	}
+
+	for backend, g := range backends {
+		t.Run(backend, func(t *testing.T) {
+			if err := g.Init(); err != nil {
+				t.Fatalf("error init graph: %s\n", err)
+			}
+			defer g.Close()
+			fn(t, g)
+		})
+	}
+}
+
+func makeNavPath(count int) []*browserk.Navigation {
+	navs := make([]*browserk.Navigation, 0)
+	for i := 1; i < count+1; i++ {
+		nav := mock.MakeMockNavi([]byte{0, byte(i), 2})
+		nav.OriginID = []byte{0, byte(i - 1), 2}
+		nav.Distance = i - 1
+
+		if i == 1 {
+			nav.OriginID = []byte{} // signals root
+		}
+		navs = append(navs, nav)
+	}
+	return navs
+}
+
+func TestBackendNavExists(t *testing.T) {
+	testBackends(t, "exists", func(t *testing.T, g browserk.CrawlGrapher) {
+		nav := mock.MakeMockNavi([]byte{0, 1, 2})
+		nav.OriginID = []byte{}
+		if err := g.AddNavigation(nav); err != nil {
+			t.Fatalf("error adding: %s\n", err)
+		}
+		if !g.NavExists(nav) {
+			t.Fatalf("nav should have existed")
+		}
+
+		if g.NavExists(mock.MakeMockNavi([]byte{0, 2, 2})) {
+			t.Fatalf("nav should NOT existed")
+		}
+
+		result, err := g.GetNavigation(nav.ID)
+		if err != nil {
+			t.Fatalf("error reading back navigation: %s\n", err)
+		}
+
+		if string(nav.ID) != string(result.ID) || result.Action == nil || nav.Action.Type != result.Action.Type {
+			t.Fatalf("navigation did not match %#v\n", result)
+		}
+	})
+}
+
+func TestBackendAddNavigations(t *testing.T) {
+	testBackends(t, "navis", func(t *testing.T, g browserk.CrawlGrapher) {
+		navs := makeNavPath(10)
+		if err := g.AddNavigation(navs[0]); err != nil {
+			t.Fatalf("error adding: %s\n", err)
+		}
+
+		// already existing navs must not prevent the rest from being added
+		if err := g.AddNavigations(navs); err != nil {
+			t.Fatalf("error calling add navigations: %s\n", err)
+		}
+		testGetNavResults(t, g)
+
+		entries := g.Find(nil, browserk.NavUnvisited, browserk.NavUnvisited, 100)
+		if len(entries) != 5 {
+			t.Fatalf("expected remaining 5 unvisited got %d\n", len(entries))
+		}
+	})
+}
+
+func TestBackendStateChanges(t *testing.T) {
+	testBackends(t, "states", func(t *testing.T, g browserk.CrawlGrapher) {
+		navs := makeNavPath(3)
+		if err := g.AddNavigations(navs); err != nil {
+			t.Fatalf("error calling add navigations: %s\n", err)
+		}
+
+		entries := g.Find(nil, browserk.NavUnvisited, browserk.NavInProcess, 3)
+		if len(entries) != 3 {
+			t.Fatalf("expected 3 entries got %d\n", len(entries))
+		}
+
+		result := mock.MakeMockResult(navs[0].ID)
+		if err := g.AddResult(result); err != nil {
+			t.Fatalf("error adding result: %s\n", err)
+		}
+
+		if err := g.FailNavigation(navs[1].ID); err != nil {
+			t.Fatalf("error failing nav: %s\n", err)
+		}
+
+		if err := g.RequeueNavigation(navs[2].ID); err != nil {
+			t.Fatalf("error requeueing nav: %s\n", err)
+		}
+
+		expected := []browserk.NavState{browserk.NavVisited, browserk.NavFailed, browserk.NavUnvisited}
+		for i, state := range expected {
+			nav, err := g.GetNavigation(navs[i].ID)
+			if err != nil {
+				t.Fatalf("error getting nav: %s\n", err)
+			}
+			if nav.State != state {
+				t.Fatalf("nav %d expected state %d got %d\n", i, state, nav.State)
+			}
+		}
+
+		entries = g.Find(nil, browserk.NavUnvisited, browserk.NavInProcess, 3)
+		if len(entries) != 1 || len(entries[0]) != 3 {
+			t.Fatalf("expected requeued nav with full path got %d\n", len(entries))
+		}
+
+		results, err := g.GetNavigationResults()
+		if err != nil {
+			t.Fatalf("error getting results: %s\n", err)
+		}
+
+		if len(results) != 1 || results[0].DOM != result.DOM {
+			t.Fatalf("expected 1 result got %d\n", len(results))
+		}
+	})
+}
+
+func TestBackendRecordNavigationError(t *testing.T) {
+	testBackends(t, "errors", func(t *testing.T, g browserk.CrawlGrapher) {
+		navs := makeNavPath(2)
+		if err := g.AddNavigations(navs); err != nil {
+			t.Fatalf("error calling add navigations: %s\n", err)
+		}
+
+		if err := g.RecordNavigationError(navs[1].ID, 2, "timed out"); err != nil {
+			t.Fatalf("error recording navigation error: %s\n", err)
+		}
+
+		if err := g.FailNavigation(navs[1].ID); err != nil {
+			t.Fatalf("error failing nav: %s\n", err)
+		}
+
+		entries := g.Find(nil, browserk.NavFailed, browserk.NavFailed, 10)
+		if len(entries) != 1 || len(entries[0]) != 2 {
+			t.Fatalf("expected failed nav with full path got %d\n", len(entries))
+		}
+
+		failed := entries[0][1]
+		if failed.Retries != 2 || failed.LastError != "timed out" {
+			t.Fatalf("expected retries and last error to be stored got %d %q\n", failed.Retries, failed.LastError)
+		}
+	})
+}
+
+func TestBackendTransaction(t *testing.T) {
+	testBackends(t, "transaction", func(t *testing.T, g browserk.CrawlGrapher) {
+		navs := makeNavPath(3)
+		if err := g.AddNavigation(navs[0]); err != nil {
+			t.Fatalf("error adding: %s\n", err)
+		}
+
+		write := func(tx browserk.CrawlTx) {
+			if err := tx.AddNavigations(navs[1:]); err != nil {
+				t.Fatalf("error adding navs in transaction: %s\n", err)
+			}
+			if err := tx.AddResult(mock.MakeMockResult(navs[0].ID)); err != nil {
+				t.Fatalf("error adding result in transaction: %s\n", err)
+			}
+		}
+
+		unchanged := func() {
+			nav, err := g.GetNavigation(navs[0].ID)
+			if err != nil || nav.State != browserk.NavUnvisited {
+				t.Fatalf("expected nav to still be unvisited")
+			}
+			if g.NavExists(navs[1]) || g.NavExists(navs[2]) {
+				t.Fatalf("expected new navs to be rolled back")
+			}
+			if results, _ := g.GetNavigationResults(); len(results) != 0 {
+				t.Fatalf("expected result to be rolled back got %d", len(results))
+			}
+		}
+
+		err := g.Transaction(func(tx browserk.CrawlTx) error {
+			write(tx)
+			return errors.New("failed")
+		})
+		if err == nil || err.Error() != "failed" {
+			t.Fatalf("expected callback error to be returned got %v", err)
+		}
+		unchanged()
+
+		err = g.Transaction(func(tx browserk.CrawlTx) error {
+			write(tx)
+			panic("oops")
+		})
+		if err == nil {
+			t.Fatalf("expected panic to be returned as an error")
+		}
+		unchanged()
+
+		if err := g.Transaction(func(tx browserk.CrawlTx) error {
+			write(tx)
+			return nil
+		}); err != nil {
+			t.Fatalf("error committing transaction: %s\n", err)
+		}
+
+		nav, err := g.GetNavigation(navs[0].ID)
+		if err != nil || nav.State != browserk.NavVisited {
+			t.Fatalf("expected committed result to set nav visited")
+		}
+		if !g.NavExists(navs[1]) || !g.NavExists(navs[2]) {
+			t.Fatalf("expected new navs to be committed")
+		}
+	})
+}
+
+func TestNewGraphs(t *testing.T) {
+	crawl, attack, err := store.NewGraphs(store.BackendMemory, "")
+	if err != nil {
+		t.Fatalf("error creating memory graphs: %s\n", err)
+	}
+
+	if _, ok := crawl.(*store.MemoryCrawlGraph); !ok {
+		t.Fatalf("expected memory crawl graph")
+	}
+
+	if _, ok := attack.(*store.MemoryAttackGraph); !ok {
+		t.Fatalf("expected memory attack graph")
+	}
+
+	if _, _, err := store.NewGraphs("tape", ""); err == nil {
+		t.Fatalf("expected error for unknown backend")
+	}
+}
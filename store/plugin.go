@@ -2,10 +2,12 @@ package store
 
 import (
 	"os"
+	"sort"
 
 	badger "github.com/dgraph-io/badger/v2"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/msgpack/v4"
 	"gitlab.com/browserker/browserk"
 )
 
@@ -53,6 +55,52 @@ func (s *PluginStore) AddEvent(evt *browserk.PluginEvent) {
 
 }
 
+// AddReport persists the finding keyed by its fingerprint, replacing any previous entry
+func (s *PluginStore) AddReport(report *browserk.Report) error {
+	data, err := msgpack.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	return s.Store.Update(func(txn *badger.Txn) error {
+		// key = report:<fingerprint>, value = msgpack'd report
+		return txn.Set(MakeKey([]byte(report.Fingerprint()), "report"), data)
+	})
+}
+
+// GetReports persisted by AddReport ordered by fingerprint
+func (s *PluginStore) GetReports() ([]*browserk.Report, error) {
+	reports := make([]*browserk.Report, 0)
+	err := s.Store.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte("report:")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				report := &browserk.Report{}
+				if err := msgpack.Unmarshal(val, report); err != nil {
+					return err
+				}
+				reports = append(reports, report)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Fingerprint() < reports[j].Fingerprint()
+	})
+	return reports, nil
+}
+
 // Close the plugin store
 func (s *PluginStore) Close() error {
 	return s.Store.Close()